@@ -18,9 +18,13 @@ package main
 
 import (
 	goflag "flag"
+	"fmt"
+	"net/http"
 	"sync"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	flag "github.com/spf13/pflag"
 	k8sinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
@@ -36,21 +40,43 @@ import (
 	"github.com/yunify/hostnic-cni/pkg/conf"
 	"github.com/yunify/hostnic-cni/pkg/constants"
 	"github.com/yunify/hostnic-cni/pkg/controller"
+	"github.com/yunify/hostnic-cni/pkg/metrics"
 	"github.com/yunify/hostnic-cni/pkg/qcclient"
 	"github.com/yunify/hostnic-cni/pkg/signals"
 	"github.com/yunify/hostnic-cni/pkg/simple/client/network/ippool"
+	"github.com/yunify/hostnic-cni/pkg/simple/client/network/ippool/ipam"
 )
 
-var qps, burst int
+var qps, burst, metricsPort int
+var apiQPS float64
+var apiBurst, apiMaxRetries int
+var provider, staticProviderVxNets string
+var metricsRefresh time.Duration
 
 func main() {
 	klog.InitFlags(goflag.CommandLine)
 	flag.IntVar(&qps, "k8s-api-qps", 80, "maximum QPS to k8s apiserver from this client.")
 	flag.IntVar(&burst, "k8s-api-burst", 100, "maximum burst for throttle from this client.")
+	flag.IntVar(&metricsPort, "metrics-port", 9192, "metrics port")
+	flag.Float64Var(&apiQPS, "qingcloud-api-qps", qcclient.DefaultAPIQPS, "maximum QPS to qingcloud api from this client.")
+	flag.IntVar(&apiBurst, "qingcloud-api-burst", qcclient.DefaultAPIBurst, "maximum burst for throttle of qingcloud api calls.")
+	flag.IntVar(&apiMaxRetries, "qingcloud-api-max-retries", qcclient.DefaultAPIMaxRetries, "maximum retries of failed qingcloud api calls, -1 to disable.")
+	flag.StringVar(&provider, "provider", qcclient.ProviderQingCloud, "provider of vxnets, qingcloud or static.")
+	flag.StringVar(&staticProviderVxNets, "static-provider-vxnets", qcclient.DefaultStaticVxNets, "vxnets of the static provider, the file shared by the cluster.")
+	flag.DurationVar(&metricsRefresh, "metrics-refresh-interval", time.Minute, "interval to refresh ipam metrics besides ippool and block changes")
 	flag.CommandLine.AddGoFlagSet(goflag.CommandLine)
 	flag.Parse()
 
-	qcclient.SetupQingCloudClient(qcclient.Options{})
+	qcclient.SetupProvider(qcclient.Options{
+		Provider:     provider,
+		StaticVxNets: staticProviderVxNets,
+		NoStaticNics: true,
+		QPS:          apiQPS,
+		Burst:        apiBurst,
+		MaxRetries:   apiMaxRetries,
+
+		ReloadCredentials: true,
+	})
 
 	// set up signals so we handle the first shutdown signal gracefully
 	stopCh := signals.SetupSignalHandler()
@@ -85,11 +111,28 @@ func main() {
 	c2 := controller.NewIPPoolController(k8sClient, client,
 		k8sInformerFactory, informerFactory, ippool.NewProvider(client, networkv1alpha1.IPPoolTypeLocal, informerFactory, k8sInformerFactory))
 
+	ipamMetrics := metrics.NewIPAMMetricsManager(ipam.NewIPAMClient(client, networkv1alpha1.IPPoolTypeLocal, informerFactory, k8sInformerFactory),
+		informerFactory, k8sInformerFactory, metricsRefresh)
+	prometheus.MustRegister(ipamMetrics)
+	http.Handle("/metrics", promhttp.Handler())
+	qcclient.InstallHandlers(http.DefaultServeMux)
+	go func() {
+		if err := http.ListenAndServe(fmt.Sprintf(":%d", metricsPort), nil); err != nil {
+			klog.Fatalf("Failed to serve metrics on port %d: %v", metricsPort, err)
+		}
+	}()
+
 	// notice that there is no need to run Start methods in a separate goroutine. (i.e. go kubeInformerFactory.Start(stopCh)
 	// Start method is non-blocking and runs all registered informers in a dedicated goroutine.
 	k8sInformerFactory.Start(stopCh)
 	informerFactory.Start(stopCh)
 
+	go func() {
+		if err := ipamMetrics.Run(stopCh); err != nil {
+			klog.Errorf("Error running ipam metrics: %v", err)
+		}
+	}()
+
 	wg := sync.WaitGroup{}
 	wg.Add(2)
 	go func() {
@@ -19,6 +19,7 @@
 package main
 
 import (
+	"context"
 	"crypto/sha1"
 	"encoding/hex"
 	"encoding/json"
@@ -50,6 +51,7 @@ import (
 	"github.com/yunify/hostnic-cni/pkg/log"
 	"github.com/yunify/hostnic-cni/pkg/networkutils"
 	"github.com/yunify/hostnic-cni/pkg/rpc"
+	"github.com/yunify/hostnic-cni/pkg/tracing"
 	"golang.org/x/sys/unix"
 )
 
@@ -156,7 +158,7 @@ func setupContainerVeth(netns ns.NetNS, hostIfName, contIfName string, conf cons
 	return hostInterface, containerInterface, err
 }
 
-func setupHostVeth(conf constants.NetConf, vethName string, msg *rpc.IPAMMessage, result *current.Result) error {
+func setupHostVeth(ctx context.Context, conf constants.NetConf, vethName string, msg *rpc.IPAMMessage, result *current.Result) error {
 	// hostVeth moved namespaces and may have a new ifindex
 	hostVeth, err := netlink.LinkByName(vethName)
 	if err != nil {
@@ -182,10 +184,10 @@ func setupHostVeth(conf constants.NetConf, vethName string, msg *rpc.IPAMMessage
 		return fmt.Errorf("failed to add route %s to pod, err=%+v", spew.Sdump(route), err)
 	}
 
-	return networkutils.NetworkHelper.SetupPodNetwork(msg.Nic, msg.IP)
+	return networkutils.WithContext(ctx).SetupPodNetwork(msg.Nic, msg.IP)
 }
 
-func cmdAddVeth(conf constants.NetConf, hostIfName, contIfName string, msg *rpc.IPAMMessage, result *current.Result, netns ns.NetNS) error {
+func cmdAddVeth(ctx context.Context, conf constants.NetConf, hostIfName, contIfName string, msg *rpc.IPAMMessage, result *current.Result, netns ns.NetNS) error {
 	link, err := netlink.LinkByName(hostIfName)
 	if link != nil {
 		logrus.Infof("cmdAddVeth LinkByName found link %s, link type=%s, link attr=%s,err=%v; going to delete this link", hostIfName, link.Type(), spew.Sdump(link.Attrs()), err)
@@ -210,7 +212,7 @@ func cmdAddVeth(conf constants.NetConf, hostIfName, contIfName string, msg *rpc.
 	}
 	logrus.Infof("setupContainerVeth(hostIfName:%s,contIfName:%s) success, hostInterface=%s", hostIfName, contIfName, spew.Sdump(hostInterface))
 
-	if err = setupHostVeth(conf, hostInterface.Name, msg, result); err != nil {
+	if err = setupHostVeth(ctx, conf, hostInterface.Name, msg, result); err != nil {
 		logrus.Errorf("setupHostVeth %s error:%v", hostInterface.Name, err)
 		return err
 	}
@@ -269,7 +271,7 @@ func moveLinkIn(hostDev netlink.Link, containerNs ns.NetNS, ifName string, pr *c
 	return contDev, nil
 }
 
-func cmdAddPassThrough(conf constants.NetConf, hostIfName, contIfName string, msg *rpc.IPAMMessage, result *current.Result, netns ns.NetNS) error {
+func cmdAddPassThrough(ctx context.Context, conf constants.NetConf, hostIfName, contIfName string, msg *rpc.IPAMMessage, result *current.Result, netns ns.NetNS) error {
 	if len(result.Interfaces) == 0 {
 		logrus.Errorf("IPAM plugin returned missing Interface config")
 		return errors.New("IPAM plugin returned missing Interface config")
@@ -284,7 +286,7 @@ func cmdAddPassThrough(conf constants.NetConf, hostIfName, contIfName string, ms
 		logrus.Infof("mac is empty, return nil")
 		return nil
 	}
-	hostDev, err := networkutils.NetworkHelper.LinkByMacAddr(result.Interfaces[0].Mac)
+	hostDev, err := networkutils.WithContext(ctx).LinkByMacAddr(result.Interfaces[0].Mac)
 	if err != nil {
 		logrus.Errorf("failed to find host device: %v", err)
 		return fmt.Errorf("failed to find host device: %v", err)
@@ -303,7 +305,7 @@ func cmdAddPassThrough(conf constants.NetConf, hostIfName, contIfName string, ms
 	}
 	logrus.Infof("setupContainerVeth(hostIfName:%s,defaultIfName:%s) success, hostInterface=%s", hostIfName, defaultIfName, spew.Sdump(hostInterface))
 
-	if err = setupHostVeth(conf, hostInterface.Name, msg, result); err != nil {
+	if err = setupHostVeth(ctx, conf, hostInterface.Name, msg, result); err != nil {
 		logrus.Errorf("setupHostVeth %s error:%v", hostInterface.Name, err)
 		return err
 	}
@@ -426,12 +428,18 @@ func checkIptables(conf *constants.NetConf) error {
 	return nil
 }
 
-func cmdAdd(args *skel.CmdArgs) error {
-	var err error
+func cmdAdd(args *skel.CmdArgs) (err error) {
 
 	klog.Infof("cmdAdd args %+v", args)
+	timer := ipam2.NewStageTimer("add")
+	daemon, err := ipam2.Dial()
+	if err != nil {
+		return err
+	}
 	defer func() {
 		klog.Infof("cmdAdd for %s rst: %v", args.ContainerID, err)
+		timer.Report(daemon, err != nil)
+		daemon.Close()
 	}()
 
 	conf := constants.NetConf{}
@@ -442,6 +450,14 @@ func cmdAdd(args *skel.CmdArgs) error {
 		return fmt.Errorf("failed to checkConf: %v", err)
 	}
 	klog.Infof("cmdAdd for %s load and check netconf success, conf=%+v", args.ContainerID, conf)
+	timer.Mark("check_conf")
+
+	stopTracing := tracing.Setup("hostnic-cni", conf.Tracing)
+	defer stopTracing()
+	ctx, span := tracing.Start(context.Background(), "cni.ADD", tracing.String("container.id", args.ContainerID))
+	defer func() {
+		span.End(err)
+	}()
 
 	//get nodename
 	nodeName, err := os.Hostname()
@@ -450,11 +466,12 @@ func cmdAdd(args *skel.CmdArgs) error {
 	}
 
 	// run the IPAM plugin and get back the config to apply
-	ipamMsg, result, err := ipam2.AddrAlloc(args, nodeName)
+	ipamMsg, result, err := ipam2.AddrAlloc(ctx, daemon, args, nodeName)
 	if err != nil {
 		return fmt.Errorf("failed to alloc addr: %v", err)
 	}
 	klog.Infof("cmdAdd for %s AddrAlloc success, ipamMsg=%s", args.ContainerID, spew.Sdump(ipamMsg))
+	timer.Mark("addr_alloc")
 
 	podInfo := ipamMsg.Args
 	// podInfo.NicType is from annotation
@@ -477,11 +494,12 @@ func cmdAdd(args *skel.CmdArgs) error {
 	switch conf.HostNicType {
 	case constants.HostNicPassThrough:
 		klog.Infof("go to excute cmdAddPassThrough")
-		err = cmdAddPassThrough(conf, hostIfName, contIfName, ipamMsg, result, netns)
+		err = cmdAddPassThrough(ctx, conf, hostIfName, contIfName, ipamMsg, result, netns)
 	default:
 		klog.Infof("go to excute cmdAddVeth")
-		err = cmdAddVeth(conf, hostIfName, contIfName, ipamMsg, result, netns)
+		err = cmdAddVeth(ctx, conf, hostIfName, contIfName, ipamMsg, result, netns)
 	}
+	timer.Mark("setup_netns")
 
 	if err != nil {
 		klog.Errorf("add veth error:%v", err)
@@ -561,12 +579,18 @@ func cmdDelPassThrough(svcIfName, contIfName string, netns ns.NetNS) error {
 	return err
 }
 
-func cmdDel(args *skel.CmdArgs) error {
-	var err error
+func cmdDel(args *skel.CmdArgs) (err error) {
 
 	klog.Infof("cmdDel args %+v", args)
+	timer := ipam2.NewStageTimer("del")
+	daemon, err := ipam2.Dial()
+	if err != nil {
+		return err
+	}
 	defer func() {
 		klog.Infof("cmdDel for %s rst: %v", args.ContainerID, err)
+		timer.Report(daemon, err != nil)
+		daemon.Close()
 	}()
 
 	conf := constants.NetConf{}
@@ -577,8 +601,16 @@ func cmdDel(args *skel.CmdArgs) error {
 		return fmt.Errorf("failed to checkConf: %v", err)
 	}
 
+	stopTracing := tracing.Setup("hostnic-cni", conf.Tracing)
+	defer stopTracing()
+	ctx, span := tracing.Start(context.Background(), "cni.DEL", tracing.String("container.id", args.ContainerID))
+	defer func() {
+		span.End(err)
+	}()
+
 	//only get pod ip info here to delete ip rule and ebtable rule
-	ipamMsg, err := ipam2.AddrUnalloc(args, true)
+	ipamMsg, err := ipam2.AddrUnalloc(ctx, daemon, args, true)
+	timer.Mark("addr_unalloc_peek")
 	podInfo := ipamMsg.Args
 	conf.HostNicType = podInfo.NicType
 	contIfName := args.IfName
@@ -596,14 +628,14 @@ func cmdDel(args *skel.CmdArgs) error {
 	if ipamMsg.Nic == nil {
 		// not found db record and ip record, skip cleanup
 		klog.Infof("not found nic record for pod %s, try to clean up rules and release ip", podKey)
-		return cleanupRulesAndIP(args, ipamMsg)
+		return cleanupRulesAndIP(ctx, daemon, args, ipamMsg, timer)
 	}
 
 	netns, err := ns.GetNS(args.Netns)
 	if err != nil {
 		klog.Errorf("getns %s for pod %s error: %v", args.Netns, podKey, err)
 		if strings.Contains(err.Error(), "no such file or directory") {
-			return cleanupRulesAndIP(args, ipamMsg)
+			return cleanupRulesAndIP(ctx, daemon, args, ipamMsg, timer)
 		}
 		return fmt.Errorf("getns %s for pod %s error: %v", args.Netns, podKey, err)
 	}
@@ -619,16 +651,17 @@ func cmdDel(args *skel.CmdArgs) error {
 	if err != nil {
 		klog.Errorf("del nic for pod %s error: %v", podKey, err)
 		if strings.Contains(err.Error(), "no such file or directory") {
-			return cleanupRulesAndIP(args, ipamMsg)
+			return cleanupRulesAndIP(ctx, daemon, args, ipamMsg, timer)
 		}
 		return fmt.Errorf("del nic for pod %s error: %v", podKey, err)
 	}
 	klog.Infof("del nic for pod %s success", podKey)
+	timer.Mark("teardown_netns")
 
-	return cleanupRulesAndIP(args, ipamMsg)
+	return cleanupRulesAndIP(ctx, daemon, args, ipamMsg, timer)
 }
 
-func cleanupRulesAndIP(args *skel.CmdArgs, ipamMsg *rpc.IPAMMessage) error {
+func cleanupRulesAndIP(ctx context.Context, daemon *ipam2.Daemon, args *skel.CmdArgs, ipamMsg *rpc.IPAMMessage, timer *ipam2.StageTimer) error {
 	// clean up ip rule and ebtables
 	// delete rule and ebtables rule before delete db record, after delete db record, can not get pod ip and nic;
 	podInfo := ipamMsg.Args
@@ -636,19 +669,21 @@ func cleanupRulesAndIP(args *skel.CmdArgs, ipamMsg *rpc.IPAMMessage) error {
 	klog.Infof("going to clean network rules and ip for pod %s", podKey)
 
 	if ipamMsg.Nic != nil {
-		err := networkutils.NetworkHelper.CleanupPodNetwork(ipamMsg.Nic, ipamMsg.IP)
+		err := networkutils.WithContext(ctx).CleanupPodNetwork(ipamMsg.Nic, ipamMsg.IP)
 		if err != nil {
 			return fmt.Errorf("clean network rule for pod %s error: %v", podKey, err)
 		}
 		klog.Infof("clean network rule for pod %s success", podKey)
 	}
+	timer.Mark("cleanup_rules")
 
 	// release ip and delet db record
-	_, err := ipam2.AddrUnalloc(args, false)
+	_, err := ipam2.AddrUnalloc(ctx, daemon, args, false)
 	if err != nil {
 		return fmt.Errorf("ipam addr unalloc for pod %s error: %v", podKey, err)
 	}
 	klog.Infof("ipam addr unalloc for pod %s success", podKey)
+	timer.Mark("addr_unalloc")
 	return nil
 }
 
@@ -22,6 +22,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/containernetworking/cni/pkg/skel"
@@ -34,9 +35,63 @@ import (
 	. "github.com/yunify/hostnic-cni/pkg/constants"
 	"github.com/yunify/hostnic-cni/pkg/networkutils"
 	"github.com/yunify/hostnic-cni/pkg/rpc"
+	"github.com/yunify/hostnic-cni/pkg/tracing"
 )
 
-func AddrAlloc(args *skel.CmdArgs, nodeName string) (*rpc.IPAMMessage, *current.Result, error) {
+// Daemon is the connection of a cni command to the ipamD server, shared by all calls of the command
+type Daemon struct {
+	conn   *grpc.ClientConn
+	client rpc.CNIBackendClient
+
+	lock    sync.Mutex
+	version *rpc.VersionInfo
+}
+
+// Dial sets up a connection to the ipamD server, the connection is made on the first call
+func Dial() (*Daemon, error) {
+	conn, err := grpc.Dial(DefaultUnixSocketPath, grpc.WithInsecure(), grpc.WithConnectParams(grpc.ConnectParams{
+		Backoff:           backoff.DefaultConfig,
+		MinConnectTimeout: 30 * time.Second,
+	}), grpc.WithUnaryInterceptor(tracing.UnaryClientInterceptor()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect server, err=%v", err)
+	}
+	return &Daemon{
+		conn:   conn,
+		client: rpc.NewCNIBackendClient(conn),
+	}, nil
+}
+
+// Version negotiates the version with the server once, later calls return the same version
+func (d *Daemon) Version(ctx context.Context) (*rpc.VersionInfo, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.version != nil {
+		return d.version, nil
+	}
+	version, err := rpc.NegotiateWithDaemon(ctx, d.client, rpc.NewVersionInfo(rpc.PluginFeatures))
+	if err != nil {
+		return nil, err
+	}
+	d.version = version
+	return version, nil
+}
+
+// negotiatedVersion returns the version negotiated by a call of the command, or nil
+func (d *Daemon) negotiatedVersion() *rpc.VersionInfo {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	return d.version
+}
+
+// Close closes the connection to the ipamD server
+func (d *Daemon) Close() {
+	d.conn.Close()
+}
+
+func AddrAlloc(ctx context.Context, d *Daemon, args *skel.CmdArgs, nodeName string) (*rpc.IPAMMessage, *current.Result, error) {
 	// conf := NetConf{}
 	// if err := json.Unmarshal(args.StdinData, &conf); err != nil {
 	// 	return nil, nil, fmt.Errorf("failed to unmarshal netconf %s", spew.Sdump(args))
@@ -47,15 +102,12 @@ func AddrAlloc(args *skel.CmdArgs, nodeName string) (*rpc.IPAMMessage, *current.
 		return nil, nil, fmt.Errorf("failed to load k8s args  %s", spew.Sdump(args))
 	}
 
-	// Set up a connection to the ipamD server.
-	conn, err := grpc.Dial(DefaultUnixSocketPath, grpc.WithInsecure())
+	version, err := d.Version(ctx)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to connect server, err=%v", err)
+		return nil, nil, fmt.Errorf("failed to negotiate version with server, err=%v", err)
 	}
-	defer conn.Close()
 
-	c := rpc.NewCNIBackendClient(conn)
-	r, err := c.AddNetwork(context.Background(),
+	r, err := d.client.AddNetwork(ctx,
 		&rpc.IPAMMessage{
 			Args: &rpc.PodInfo{
 				Name:       string(k8sArgs.K8S_POD_NAME),
@@ -65,6 +117,8 @@ func AddrAlloc(args *skel.CmdArgs, nodeName string) (*rpc.IPAMMessage, *current.
 				IfName:     args.IfName,
 				NodeName:   nodeName,
 			},
+			Protocol: rpc.ProtocolCurrent,
+			Features: rpc.PluginFeatures,
 		})
 	if err != nil {
 		return nil, nil, err
@@ -72,8 +126,8 @@ func AddrAlloc(args *skel.CmdArgs, nodeName string) (*rpc.IPAMMessage, *current.
 
 	nic := r.Nic
 
-	//wait for nic attach
-	for {
+	//wait for nic attach, legacy daemons may reply before the link shows up
+	for !version.HasFeature(rpc.FeatureNicLinkReady) {
 		link, err := networkutils.NetworkHelper.LinkByMacAddr(nic.HardwareAddr)
 		if err != nil && err != ErrNicNotFound {
 			return nil, nil, err
@@ -102,7 +156,7 @@ func AddrAlloc(args *skel.CmdArgs, nodeName string) (*rpc.IPAMMessage, *current.
 	return r, result, nil
 }
 
-func AddrUnalloc(args *skel.CmdArgs, peek bool) (*rpc.IPAMMessage, error) {
+func AddrUnalloc(ctx context.Context, d *Daemon, args *skel.CmdArgs, peek bool) (*rpc.IPAMMessage, error) {
 	// conf := NetConf{}
 	// if err := json.Unmarshal(args.StdinData, &conf); err != nil {
 	// 	return nil, fmt.Errorf("failed to unmarshal netconf %s", spew.Sdump(args))
@@ -121,22 +175,13 @@ func AddrUnalloc(args *skel.CmdArgs, peek bool) (*rpc.IPAMMessage, error) {
 			Netns:      args.Netns,
 			IfName:     args.IfName,
 		},
-		Peek: peek,
+		Peek:     peek,
+		Protocol: rpc.ProtocolCurrent,
+		Features: rpc.PluginFeatures,
 	}
 
 	// notify local PodIP address manager to free secondary PodIP
-	// Set up a connection to the server.
-	conn, err := grpc.Dial(DefaultUnixSocketPath, grpc.WithInsecure(), grpc.WithConnectParams(grpc.ConnectParams{
-		Backoff:           backoff.DefaultConfig,
-		MinConnectTimeout: 30 * time.Second,
-	}))
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect server")
-	}
-	defer conn.Close()
-
-	c := rpc.NewCNIBackendClient(conn)
-	reply, err := c.DelNetwork(context.Background(), info)
+	reply, err := d.client.DelNetwork(ctx, info)
 	if err != nil {
 		return nil, fmt.Errorf("failed to call DelNetwork: %v", err)
 	}
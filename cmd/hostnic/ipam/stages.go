@@ -0,0 +1,65 @@
+package ipam
+
+import (
+	"context"
+	"time"
+
+	klog "k8s.io/klog/v2"
+
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+)
+
+// the report delays the result of the cni command by at most this
+const reportStagesTimeout = 200 * time.Millisecond
+
+// StageTimer records the duration of each stage of a cni command
+type StageTimer struct {
+	op     string
+	start  time.Time
+	last   time.Time
+	stages []*rpc.Stage
+}
+
+func NewStageTimer(op string) *StageTimer {
+	now := time.Now()
+	return &StageTimer{
+		op:    op,
+		start: now,
+		last:  now,
+	}
+}
+
+// Mark ends stage, the next stage starts now
+func (t *StageTimer) Mark(stage string) {
+	now := time.Now()
+	t.stages = append(t.stages, &rpc.Stage{
+		Name:    stage,
+		Seconds: now.Sub(t.last).Seconds(),
+	})
+	t.last = now
+}
+
+// Report sends the timings to the daemon on the connection of the command, waiting at most
+// reportStagesTimeout. It is skipped unless a call of the command negotiated a version which
+// supports it, e.g. DEL does not negotiate. Errors are only logged because the result of the
+// cni command should not depend on it.
+func (t *StageTimer) Report(d *Daemon, failed bool) {
+	version := d.negotiatedVersion()
+	if version == nil || !version.HasFeature(rpc.FeatureStageReport) {
+		return
+	}
+	report := &rpc.StageReport{
+		Op: t.op,
+		Stages: append(t.stages, &rpc.Stage{
+			Name:    "total",
+			Seconds: time.Since(t.start).Seconds(),
+		}),
+		Failed: failed,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), reportStagesTimeout)
+	defer cancel()
+	if _, err := d.client.ReportStages(ctx, report); err != nil {
+		klog.Errorf("failed to report stages: %v", err)
+	}
+}
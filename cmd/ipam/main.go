@@ -20,10 +20,12 @@ package main
 
 import (
 	"flag"
+	"os"
 	"time"
 
 	k8sinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
 	log "k8s.io/klog/v2"
 
@@ -35,14 +37,20 @@ import (
 	"github.com/yunify/hostnic-cni/pkg/config"
 	"github.com/yunify/hostnic-cni/pkg/constants"
 	"github.com/yunify/hostnic-cni/pkg/db"
+	"github.com/yunify/hostnic-cni/pkg/events"
+	"github.com/yunify/hostnic-cni/pkg/health"
+	"github.com/yunify/hostnic-cni/pkg/metrics"
 	"github.com/yunify/hostnic-cni/pkg/networkutils"
 	"github.com/yunify/hostnic-cni/pkg/qcclient"
 	"github.com/yunify/hostnic-cni/pkg/server"
 	"github.com/yunify/hostnic-cni/pkg/signals"
 	"github.com/yunify/hostnic-cni/pkg/simple/client/network/ippool/ipam"
+	"github.com/yunify/hostnic-cni/pkg/tracing"
 )
 
 var qps, burst, metricsPort int
+var metricsRefresh time.Duration
+var reconcileOpts server.ReconcilerOptions
 
 func main() {
 	//parse flag and setup klog
@@ -50,10 +58,16 @@ func main() {
 	flag.IntVar(&qps, "k8s-api-qps", 80, "maximum QPS to k8s apiserver from this client.")
 	flag.IntVar(&burst, "k8s-api-burst", 100, "maximum burst for throttle from this client.")
 	flag.IntVar(&metricsPort, "metrics-port", 9191, "metrics port")
-	dbOpts := db.NewLevelDBOptions()
+	flag.DurationVar(&metricsRefresh, "metrics-refresh-interval", time.Minute, "interval to refresh metrics besides nic and pod changes")
+	flag.DurationVar(&reconcileOpts.Interval, "reconcile-interval", 10*time.Minute, "interval to release pod records whose DEL was missed and nics missing from db, 0 to disable")
+	flag.BoolVar(&reconcileOpts.DryRun, "reconcile-dry-run", false, "only log orphaned pod records and nics, without releasing them")
+	flag.StringVar(&reconcileOpts.CRIEndpoint, "cri-endpoint", "", "cri runtime endpoint to check pod sandboxes in reconcile, e.g. unix:///run/containerd/containerd.sock")
+	dbOpts := db.NewOptions()
 	dbOpts.AddFlags()
 	flag.Parse()
-	db.SetupLevelDB(dbOpts)
+	if err := db.SetupStore(dbOpts); err != nil {
+		log.Fatalf("failed to setup db: %v", err)
+	}
 	defer func() {
 		db.CloseDB()
 	}()
@@ -67,10 +81,25 @@ func main() {
 		log.Fatalf("failed to load config: %v", err)
 	}
 	log.Infof("hostnic config is %v", conf)
+	stopTracing := tracing.Setup("hostnic-node", conf.Tracing)
+	defer stopTracing()
+
+	health.Register(health.CheckClusterConfig, "waiting for cluster config to sync")
+	health.Register(health.CheckIPAMClient, "waiting for ipam client to sync")
+	health.Register(health.CheckAllocator, "waiting for allocator to restore from db")
+	server.StartHTTPServer(metricsPort)
 
 	// setup qcclient, k8s
-	qcclient.SetupQingCloudClient(qcclient.Options{
-		Tag: conf.Pool.Tag,
+	qcclient.SetupProvider(qcclient.Options{
+		Tag:          conf.Pool.Tag,
+		Provider:     conf.Pool.Provider,
+		StaticConfig: conf.Pool.StaticProviderConfig,
+		StaticVxNets: conf.Pool.StaticProviderVxNets,
+		QPS:          conf.Pool.APIQPS,
+		Burst:        conf.Pool.APIBurst,
+		MaxRetries:   conf.Pool.APIMaxRetries,
+
+		ReloadCredentials: true,
 	})
 
 	cfg, err := clientcmd.BuildConfigFromFlags("", "")
@@ -90,11 +119,19 @@ func main() {
 		log.Fatalf("Error building example clientset: %v", err)
 	}
 
+	events.SetupEventRecorder(k8sClient, "hostnic-node", os.Getenv("MY_NODE_NAME"))
+	defer events.Shutdown()
+
 	k8sInformerFactory := k8sinformers.NewSharedInformerFactory(k8sClient, time.Second*30)
 	informerFactory := informers.NewSharedInformerFactory(client, time.Second*30)
 
 	clusterConfig := config.NewClusterConfig(k8sInformerFactory.Core().V1().ConfigMaps())
 	ipamClient := ipam.NewIPAMClient(client, networkv1alpha1.IPPoolTypeLocal, informerFactory, k8sInformerFactory)
+	reconciler := server.NewReconciler(reconcileOpts, os.Getenv("MY_NODE_NAME"), k8sInformerFactory.Core().V1().Pods().Lister(), ipamClient)
+
+	// the allocator reads the vxnets published by the controller
+	vxnetPools := informerFactory.Network().V1alpha1().VxNetPools()
+	vxnetPools.Informer()
 
 	k8sInformerFactory.Start(stopCh)
 	informerFactory.Start(stopCh)
@@ -102,17 +139,31 @@ func main() {
 	if err = clusterConfig.Sync(stopCh); err != nil {
 		log.Fatalf("clusterConfig sync error: %v", err)
 	}
+	health.SetHealthy(health.CheckClusterConfig, "synced")
 
 	if err = ipamClient.Sync(stopCh); err != nil {
 		log.Fatalf("ipamclient sync error: %v", err)
 	}
+	health.SetHealthy(health.CheckIPAMClient, "synced")
 
 	networkutils.SetupNetworkHelper()
-	allocator.SetupAllocator(conf.Pool)
+	networkutils.SetupIPv6VxNets(conf.Pool.IPv6VxNets)
+	if !cache.WaitForCacheSync(stopCh, vxnetPools.Informer().HasSynced) {
+		log.Fatalf("vxnetpool informer sync error")
+	}
+	allocator.SetupAllocator(conf.Pool, db.DefaultStore, vxnetPools.Lister())
+	// orphaned nics are adopted before the allocator repairs its nics, which needs the pods of this node
+	k8sInformerFactory.WaitForCacheSync(stopCh)
+	if err = reconciler.ReconcileNics(); err != nil {
+		log.Errorf("reconcile nics failed: %v", err)
+	}
 
 	log.Info("all setup done, startup daemon")
 	allocator.Alloc.Start(stopCh)
-	server.NewIPAMServer(conf.Server, clusterConfig, k8sClient, ipamClient, metricsPort).Start(stopCh)
+	nodeMetrics := metrics.NewHostnicMetricsManager(metricsRefresh)
+	go nodeMetrics.Run(stopCh)
+	server.NewIPAMServer(conf.Server, clusterConfig, k8sClient, ipamClient, nodeMetrics).Start(stopCh)
+	reconciler.Start(stopCh)
 
 	<-stopCh
 	log.Info("daemon exited")
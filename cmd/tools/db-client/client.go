@@ -5,12 +5,12 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/yunify/hostnic-cni/pkg/db"
 )
 
-func get(db *leveldb.DB, key string) {
+func get(store db.Store, key string) {
 	if key != "" {
-		if data, err := db.Get([]byte(key), nil); err != nil {
+		if data, err := store.Get(key); err != nil {
 			fmt.Printf("Get %s from DB failed: %v\n", key, err)
 		} else {
 			fmt.Printf("Get %s from DB:\n", key)
@@ -20,78 +20,112 @@ func get(db *leveldb.DB, key string) {
 	}
 
 	fmt.Printf("Get all from DB:\n")
-	iter := db.NewIterator(nil, nil)
-	for iter.Next() {
-		k := iter.Key()
-		v := iter.Value()
-		fmt.Printf("\t%s: %s\n", string(k), string(v))
-	}
-
-	iter.Release()
-	if err := iter.Error(); err != nil {
+	err := store.Iterate("", func(k string, v []byte) error {
+		fmt.Printf("\t%s: %s\n", k, string(v))
+		return nil
+	})
+	if err != nil {
 		fmt.Printf("iter err: %v\n", err)
 	}
 }
 
-func set(db *leveldb.DB, key, value string) {
+func set(store db.Store, key, value string) {
 	if key == "" {
 		fmt.Printf("Plesse set iter's key\n")
 		return
 	}
 
-	if err := db.Put([]byte(key), []byte(value), nil); err != nil {
+	if err := store.Put(key, []byte(value)); err != nil {
 		fmt.Printf("Set key(%s) value(%s) failed: %v\n", key, value, err)
 	} else {
 		fmt.Printf("Set key(%s) value(%s) OK\n", key, value)
 	}
 }
 
-func del(db *leveldb.DB, key string) {
+func del(store db.Store, key string) {
 	if key == "" {
 		fmt.Printf("Plesse set iter's key\n")
 		return
 	}
 
-	if err := db.Delete([]byte(key), nil); err != nil {
+	if err := store.Delete(key); err != nil {
 		fmt.Printf("Del key(%s) failed: %v\n", key, err)
 	} else {
 		fmt.Printf("Del key(%s) OK\n", key)
 	}
 }
 
-var path, op, key, value string
+var op, key, value string
 
 func usage() {
-	fmt.Fprintf(flag.CommandLine.Output(), "Usage of %s:\n", os.Args[0])
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage of %s: [flags] [get|set|del|export|import|verify] [command flags]\n", os.Args[0])
 	flag.PrintDefaults()
+	fmt.Println("\nThe node daemon should be stopped, it keeps the db locked.")
 	fmt.Println("\nExamples:")
 	fmt.Println("\t./client")
 	fmt.Println("\t./client -op get -key t1")
+	fmt.Println("\t./client -op get -key nic/vxnet-xxx")
 	fmt.Println("\t./client -op set -key t2 -value 789")
 	fmt.Println("\t./client -op del -key t2")
+	fmt.Println("\t./client export -format yaml -o nics.yaml")
+	fmt.Println("\t./client import -replace nics.yaml")
+	fmt.Println("\t./client verify -kubeconfig /root/.kube/config -fix")
 }
 
 func main() {
-	flag.StringVar(&path, "dbpath", "/var/lib/hostnic", "set leveldb path")
-	flag.StringVar(&op, "op", "get", "operator to db")
+	dbOpts := db.NewOptions()
+	dbOpts.AddFlags()
+	flag.StringVar(&op, "op", "get", "operator to db, one of get, set and del, if no command is given")
 	flag.StringVar(&key, "key", "", "iter's key")
 	flag.StringVar(&value, "value", "", "iter's value")
 	flag.Usage = usage
 	flag.Parse()
 
-	db, err := leveldb.OpenFile(path, nil)
+	store, err := dbOpts.Open()
 	if err != nil {
-		fmt.Printf("cannot open leveldb file %s : %v\n", path, err)
-		return
+		fmt.Printf("cannot open db: %v\n", err)
+		os.Exit(1)
 	}
-	defer db.Close()
+	defer store.Close()
 
-	switch op {
+	command, args := op, []string(nil)
+	if flag.NArg() > 0 {
+		command, args = flag.Arg(0), flag.Args()[1:]
+	}
+
+	switch command {
 	case "get":
-		get(db, key)
+		get(store, argOr(args, 0, key))
 	case "set":
-		set(db, key, value)
+		set(store, argOr(args, 0, key), argOr(args, 1, value))
 	case "del":
-		del(db, key)
+		del(store, argOr(args, 0, key))
+	case "export", "import", "verify":
+		// records are decoded by the latest schema
+		if err = db.Migrate(store); err != nil {
+			break
+		}
+		switch command {
+		case "export":
+			err = exportNics(store, args)
+		case "import":
+			err = importNics(store, args)
+		case "verify":
+			err = verify(store, args)
+		}
+	default:
+		err = fmt.Errorf("unknown command %q", command)
+	}
+	if err != nil {
+		fmt.Printf("%s failed: %v\n", command, err)
+		store.Close()
+		os.Exit(1)
+	}
+}
+
+func argOr(args []string, i int, def string) string {
+	if i < len(args) {
+		return args[i]
 	}
+	return def
 }
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/yunify/hostnic-cni/pkg/allocator"
+	"github.com/yunify/hostnic-cni/pkg/db"
+)
+
+func exportNics(store db.Store, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", "json", "output format, json or yaml")
+	output := fs.String("o", "", "output file, stdout if empty")
+	fs.Parse(args)
+
+	records, err := allocator.ExportNics(store)
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	switch *format {
+	case "json":
+		data, err = json.MarshalIndent(records, "", "  ")
+		data = append(data, '\n')
+	case "yaml":
+		data, err = yaml.Marshal(records)
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(*output, data, 0600)
+}
+
+func importNics(store db.Store, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	replace := fs.Bool("replace", false, "replace all nics and pods in db, otherwise the vxnets imported must not be recorded")
+	dryRun := fs.Bool("dry-run", false, "only validate the records")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of import: [flags] file, - for stdin, json or yaml as written by export\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("no file to import")
+	}
+
+	var data []byte
+	var err error
+	if fs.Arg(0) == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(fs.Arg(0))
+	}
+	if err != nil {
+		return err
+	}
+
+	// json is also yaml
+	var records []*allocator.NicRecord
+	if err := yaml.UnmarshalStrict(data, &records); err != nil {
+		return fmt.Errorf("decode %s error: %v", fs.Arg(0), err)
+	}
+
+	if *dryRun {
+		if err := allocator.ValidateNics(records); err != nil {
+			return err
+		}
+		fmt.Printf("%d nics are valid\n", len(records))
+		return nil
+	}
+	if err := allocator.ImportNics(store, records, *replace); err != nil {
+		return err
+	}
+	fmt.Printf("imported %d nics\n", len(records))
+	return nil
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	k8sinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/yunify/hostnic-cni/pkg/allocator"
+	networkv1alpha1 "github.com/yunify/hostnic-cni/pkg/apis/network/v1alpha1"
+	clientset "github.com/yunify/hostnic-cni/pkg/client/clientset/versioned"
+	informers "github.com/yunify/hostnic-cni/pkg/client/informers/externalversions"
+	"github.com/yunify/hostnic-cni/pkg/db"
+	"github.com/yunify/hostnic-cni/pkg/networkutils"
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+	"github.com/yunify/hostnic-cni/pkg/server"
+	"github.com/yunify/hostnic-cni/pkg/signals"
+	"github.com/yunify/hostnic-cni/pkg/simple/client/network/ippool/ipam"
+)
+
+// verify prints the differences between the nic records and the kernel, and the ipam handles
+// of the node if kubeconfig is set. Lines starting with - are recorded but missing, lines
+// starting with + are there but not recorded.
+func verify(store db.Store, args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	fix := fs.Bool("fix", false, "fix the differences which do not need the node daemon")
+	kubeconfig := fs.String("kubeconfig", "", "path to a kubeconfig to check the ipam handles of pods, they are not checked if empty")
+	node := fs.String("node", os.Getenv("MY_NODE_NAME"), "name of the node whose ipam handles are checked, defaults to the hostname")
+	fs.Parse(args)
+	if *node == "" {
+		*node, _ = os.Hostname()
+	}
+
+	records, err := allocator.ExportNics(store)
+	if err != nil {
+		return err
+	}
+
+	checkHandles := *kubeconfig != ""
+	var ipamClient ipam.IPAMClient
+	if checkHandles {
+		if ipamClient, err = newIPAMClient(*kubeconfig); err != nil {
+			return err
+		}
+	}
+
+	total, fixed := 0, 0
+	report := func(title string, diffs []networkutils.Diff) {
+		fmt.Printf("%s:\n", title)
+		for _, diff := range diffs {
+			total++
+			if !*fix || diff.Fix == nil {
+				fmt.Printf("\t%s\n", diff)
+				continue
+			}
+			if err := diff.Fix(); err != nil {
+				fmt.Printf("\t%s, fix failed: %v\n", diff, err)
+				continue
+			}
+			fixed++
+			fmt.Printf("\t%s, fixed\n", diff)
+		}
+	}
+
+	recordedHandles := make(map[string]bool)
+	for _, record := range records {
+		nicKey := allocator.GetNicKey(record.Nic)
+		var containers, podIPs []string
+		for container, pod := range record.Pods {
+			containers = append(containers, container)
+			if pod.PodIP != "" {
+				podIPs = append(podIPs, pod.PodIP)
+			}
+		}
+		sort.Strings(containers)
+		sort.Strings(podIPs)
+
+		diffs, err := networkutils.VerifyNetwork(record.Nic, podIPs)
+		if err != nil {
+			return fmt.Errorf("verify nic %s error: %v", nicKey, err)
+		}
+		if checkHandles {
+			for _, container := range containers {
+				recordedHandles[server.PodHandleKey(record.Pods[container])] = true
+				diff, err := verifyHandle(ipamClient, record.Pods[container])
+				if err != nil {
+					return fmt.Errorf("verify nic %s error: %v", nicKey, err)
+				}
+				if diff != nil {
+					diffs = append(diffs, *diff)
+				}
+			}
+		}
+
+		if len(diffs) == 0 {
+			fmt.Printf("nic %s (%s, %d pods): ok\n", nicKey, record.Nic.Phase.String(), len(record.Pods))
+			continue
+		}
+		report(fmt.Sprintf("nic %s (%s, %d pods)", nicKey, record.Nic.Phase.String(), len(record.Pods)), diffs)
+	}
+
+	if checkHandles {
+		diffs, err := verifyNodeHandles(ipamClient, *node, recordedHandles)
+		if err != nil {
+			return err
+		}
+		if len(diffs) == 0 {
+			fmt.Printf("ipam handles of node %s: ok\n", *node)
+		} else {
+			report(fmt.Sprintf("ipam handles of node %s", *node), diffs)
+		}
+	}
+
+	if total > fixed {
+		return fmt.Errorf("%d differences found, %d fixed", total, fixed)
+	}
+	return nil
+}
+
+// verifyHandle checks that the ipam handle of pod holds its ip
+func verifyHandle(ipamClient ipam.IPAMClient, pod *rpc.PodInfo) (*networkutils.Diff, error) {
+	handleID := server.PodHandleKey(pod)
+	ips, err := ipamClient.GetIPByHandleID(handleID)
+	if err != nil {
+		return nil, err
+	}
+	for _, ip := range ips {
+		if ip == pod.PodIP {
+			return nil, nil
+		}
+	}
+	return &networkutils.Diff{
+		Missing: true,
+		What:    fmt.Sprintf("ipam handle %s with ip %s, got %v", handleID, pod.PodIP, ips),
+	}, nil
+}
+
+// verifyNodeHandles reports the handles holding ips assigned to node which have no pod record. They
+// are not fixed, the ip may belong to a pod being set up, or to one whose record is lost.
+func verifyNodeHandles(ipamClient ipam.IPAMClient, node string, recorded map[string]bool) ([]networkutils.Diff, error) {
+	handles, err := ipamClient.GetNodeHandles(node)
+	if err != nil {
+		return nil, err
+	}
+	var handleIDs []string
+	for handleID := range handles {
+		if !recorded[handleID] {
+			handleIDs = append(handleIDs, handleID)
+		}
+	}
+	sort.Strings(handleIDs)
+
+	var diffs []networkutils.Diff
+	for _, handleID := range handleIDs {
+		diffs = append(diffs, networkutils.Diff{
+			What: fmt.Sprintf("ipam handle %s with ip %v, no pod record", handleID, handles[handleID]),
+		})
+	}
+	return diffs, nil
+}
+
+func newIPAMClient(kubeconfig string) (ipam.IPAMClient, error) {
+	cfg, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		return ipam.IPAMClient{}, fmt.Errorf("build kubeconfig error: %v", err)
+	}
+	k8sClient, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return ipam.IPAMClient{}, fmt.Errorf("build kubernetes clientset error: %v", err)
+	}
+	client, err := clientset.NewForConfig(cfg)
+	if err != nil {
+		return ipam.IPAMClient{}, fmt.Errorf("build network clientset error: %v", err)
+	}
+
+	stopCh := signals.SetupSignalHandler()
+	k8sInformerFactory := k8sinformers.NewSharedInformerFactory(k8sClient, time.Second*10)
+	informerFactory := informers.NewSharedInformerFactory(client, time.Second*30)
+	ipamClient := ipam.NewIPAMClient(client, networkv1alpha1.IPPoolTypeLocal, informerFactory, k8sInformerFactory)
+	k8sInformerFactory.Start(stopCh)
+	informerFactory.Start(stopCh)
+	if err := ipamClient.Sync(stopCh); err != nil {
+		return ipam.IPAMClient{}, fmt.Errorf("ipam client sync error: %v", err)
+	}
+	return ipamClient, nil
+}
@@ -4,16 +4,14 @@ import (
 	"flag"
 	"fmt"
 	"log"
-	"net"
 	"os"
 	"time"
 
-	"github.com/insomniacslk/dhcp/dhcpv4"
-	"github.com/insomniacslk/dhcp/dhcpv4/client4"
 	"github.com/insomniacslk/dhcp/dhcpv6"
-	"github.com/insomniacslk/dhcp/dhcpv6/client6"
 	"github.com/insomniacslk/dhcp/netboot"
 	"github.com/vishvananda/netlink"
+
+	"github.com/yunify/hostnic-cni/pkg/dhcp"
 )
 
 var (
@@ -80,36 +78,7 @@ func main() {
 }
 
 func dhclient6(ifname string, attempts int, verbose bool) (*netboot.BootConf, error) {
-	if attempts < 1 {
-		attempts = 1
-	}
-	llAddr, err := dhcpv6.GetLinkLocalAddr(ifname)
-	if err != nil {
-		return nil, err
-	}
-	laddr := net.UDPAddr{
-		IP:   llAddr,
-		Port: dhcpv6.DefaultClientPort,
-		Zone: ifname,
-	}
-	raddr := net.UDPAddr{
-		IP:   dhcpv6.AllDHCPRelayAgentsAndServers,
-		Port: dhcpv6.DefaultServerPort,
-		Zone: ifname,
-	}
-	c := client6.NewClient()
-	c.LocalAddr = &laddr
-	c.RemoteAddr = &raddr
-	var conv []dhcpv6.DHCPv6
-	for attempt := 0; attempt < attempts; attempt++ {
-		log.Printf("Attempt %d of %d", attempt+1, attempts)
-		conv, err = c.Exchange(ifname, dhcpv6.WithNetboot)
-		if err != nil && attempt < attempts {
-			log.Printf("Error: %v", err)
-			continue
-		}
-		break
-	}
+	conv, err := dhcp.Exchange6(ifname, attempts, dhcpv6.WithNetboot)
 	if verbose {
 		for _, m := range conv {
 			log.Print(m.Summary())
@@ -124,23 +93,7 @@ func dhclient6(ifname string, attempts int, verbose bool) (*netboot.BootConf, er
 }
 
 func dhclient4(ifname string, attempts int, verbose bool) (*netboot.BootConf, error) {
-	if attempts < 1 {
-		attempts = 1
-	}
-	client := client4.NewClient()
-	var (
-		conv []*dhcpv4.DHCPv4
-		err  error
-	)
-	for attempt := 0; attempt < attempts; attempt++ {
-		log.Printf("Attempt %d of %d", attempt+1, attempts)
-		conv, err = client.Exchange(ifname)
-		if err != nil && attempt < attempts {
-			log.Printf("Error: %v", err)
-			continue
-		}
-		break
-	}
+	conv, err := dhcp.Exchange4(ifname, attempts)
 	if verbose {
 		for _, m := range conv {
 			log.Print(m.Summary())
@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 
 	"google.golang.org/grpc"
 
@@ -17,11 +18,29 @@ func usage() {
 	fmt.Fprintf(flag.CommandLine.Output(), "Usage of %s:\n", os.Args[0])
 	fmt.Println("\t./hostnic-client")
 	fmt.Println("\t./hostnic-client -clear true")
+	fmt.Println("\t./hostnic-client -pods")
+	fmt.Println("\t./hostnic-client -pod <namespace>/<name>|<container id>")
+	fmt.Println("\t./hostnic-client -routes [-vxnet <vxnet>] [-nic <nic>]")
+	fmt.Println("\t./hostnic-client -repair [-resetup] -vxnet <vxnet>|-nic <nic>")
+	fmt.Println("\t./hostnic-client -watch nics|pods [-epoch <epoch> -seq <sequence>]")
+	flag.PrintDefaults()
 }
 
 func main() {
-	var clear bool
+	var clear, pods, routes, repair, resetup bool
+	var pod, vxnet, nic, watch, epoch string
+	var seq uint64
 	flag.BoolVar(&clear, "clear", false, "clear free hostnics")
+	flag.BoolVar(&pods, "pods", false, "list pods of the current node")
+	flag.StringVar(&pod, "pod", "", "show network record of the pod, specified by namespace/name or container id")
+	flag.BoolVar(&routes, "routes", false, "dump route tables and rules of hostnics")
+	flag.BoolVar(&repair, "repair", false, "check and repair a hostnic")
+	flag.BoolVar(&resetup, "resetup", false, "setup hostnic network again even if it is healthy, used with -repair")
+	flag.StringVar(&vxnet, "vxnet", "", "select hostnic by vxnet")
+	flag.StringVar(&nic, "nic", "", "select hostnic by id")
+	flag.StringVar(&watch, "watch", "", "watch nic or pod events, nics or pods")
+	flag.Uint64Var(&seq, "seq", 0, "resume watching after the sequence, used with -watch")
+	flag.StringVar(&epoch, "epoch", "", "the epoch of the sequence, used with -seq")
 	flag.Usage = usage
 	flag.Parse()
 
@@ -33,6 +52,23 @@ func main() {
 	defer conn.Close()
 
 	client := rpc.NewCNIBackendClient(conn)
+	switch {
+	case pods:
+		listPods(client)
+	case pod != "":
+		getPod(client, pod)
+	case routes:
+		dumpRoutes(client, &rpc.NicRequest{Vxnet: vxnet, Nic: nic})
+	case repair:
+		repairNic(client, &rpc.NicRequest{Vxnet: vxnet, Nic: nic, Resetup: resetup})
+	case watch != "":
+		watchEvents(client, watch, epoch, seq)
+	default:
+		showNics(client, clear)
+	}
+}
+
+func showNics(client rpc.CNIBackendClient, clear bool) {
 	result, err := client.ShowNics(context.Background(), &rpc.Nothing{})
 	if err != nil {
 		fmt.Printf("failed to get nics: %v\n", err)
@@ -52,3 +88,110 @@ func main() {
 		}
 	}
 }
+
+func listPods(client rpc.CNIBackendClient) {
+	result, err := client.ListPods(context.Background(), &rpc.Nothing{})
+	if err != nil {
+		fmt.Printf("failed to list pods: %v\n", err)
+		return
+	}
+
+	fmt.Println("********************* current node pods *********************")
+	for _, pod := range result.Items {
+		fmt.Printf("%s/%s %s %s %s %d %s\n", pod.Namespace, pod.Name, pod.PodIP, pod.Vxnet, pod.HostNic, pod.RouteTableNum, pod.Containter)
+	}
+}
+
+func getPod(client rpc.CNIBackendClient, pod string) {
+	args := &rpc.PodInfo{}
+	if parts := strings.SplitN(pod, "/", 2); len(parts) == 2 {
+		args.Namespace, args.Name = parts[0], parts[1]
+	} else {
+		args.Containter = pod
+	}
+
+	result, err := client.GetPod(context.Background(), args)
+	if err != nil {
+		fmt.Printf("failed to get pod %s: %v\n", pod, err)
+		return
+	}
+
+	fmt.Printf("pod:\n%s\n", result.Pod)
+	fmt.Printf("nic:\n%s\n", result.Nic)
+	fmt.Println("rules:")
+	for _, rule := range result.Rules {
+		fmt.Printf("\t%s\n", rule)
+	}
+	fmt.Println("arp replies:")
+	for _, reply := range result.ArpReplies {
+		fmt.Printf("\t%s\n", reply)
+	}
+}
+
+func dumpRoutes(client rpc.CNIBackendClient, req *rpc.NicRequest) {
+	result, err := client.DumpNicRoutes(context.Background(), req)
+	if err != nil {
+		fmt.Printf("failed to dump routes: %v\n", err)
+		return
+	}
+
+	for _, nic := range result.Items {
+		fmt.Printf("********************* %s %s table %d %s *********************\n", nic.Vxnet, nic.Id, nic.RouteTableNum, nic.Bridge)
+		fmt.Println("routes:")
+		for _, route := range nic.Routes {
+			fmt.Printf("\t%s\n", route)
+		}
+		fmt.Println("rules:")
+		for _, rule := range nic.Rules {
+			fmt.Printf("\t%s\n", rule)
+		}
+	}
+}
+
+func repairNic(client rpc.CNIBackendClient, req *rpc.NicRequest) {
+	result, err := client.RepairNic(context.Background(), req)
+	if err != nil {
+		fmt.Printf("RepairNic failed: %v\n", err)
+		return
+	}
+	fmt.Printf("%s %s %s %s\n", result.Vxnet, result.Id, result.Phase, result.Status)
+	if result.Error != "" {
+		fmt.Printf("RepairNic failed: %s\n", result.Error)
+	}
+}
+
+func watchEvents(client rpc.CNIBackendClient, watch, epoch string, seq uint64) {
+	req := &rpc.WatchRequest{Sequence: seq, Epoch: epoch}
+	switch watch {
+	case "nics":
+		stream, err := client.WatchNics(context.Background(), req)
+		if err != nil {
+			fmt.Printf("failed to watch nics: %v\n", err)
+			return
+		}
+		for {
+			e, err := stream.Recv()
+			if err != nil {
+				fmt.Printf("watch nics stopped: %v\n", err)
+				return
+			}
+			fmt.Printf("%s %d %s %s %s %s -> %s %s\n", e.Epoch, e.Sequence, e.Type, e.Nic.VxNet.ID, e.Nic.ID, e.PrevPhase, e.Nic.Phase, e.Message)
+		}
+	case "pods":
+		stream, err := client.WatchPods(context.Background(), req)
+		if err != nil {
+			fmt.Printf("failed to watch pods: %v\n", err)
+			return
+		}
+		for {
+			e, err := stream.Recv()
+			if err != nil {
+				fmt.Printf("watch pods stopped: %v\n", err)
+				return
+			}
+			fmt.Printf("%s %d %s %s/%s %s %s %s\n", e.Epoch, e.Sequence, e.Type, e.Pod.Namespace, e.Pod.Name, e.Pod.PodIP, e.Nic.VxNet.ID, e.Nic.ID)
+		}
+	default:
+		fmt.Printf("unknown watch type %s, should be nics or pods\n", watch)
+	}
+}
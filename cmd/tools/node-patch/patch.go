@@ -95,7 +95,7 @@ func main() {
 	}
 
 	// setup qcclient, k8s
-	qcclient.SetupQingCloudClient(qcclient.Options{})
+	qcclient.SetupProvider(qcclient.Options{})
 
 	cfg, err := clientcmd.BuildConfigFromFlags("", "")
 	if err != nil {
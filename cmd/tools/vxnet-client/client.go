@@ -61,7 +61,7 @@ func main() {
 		return
 	}
 
-	qcclient.SetupQingCloudClient(qcclient.Options{})
+	qcclient.SetupProvider(qcclient.Options{})
 	if v := showVxNetInfo(vxnet); v != nil && clear == true {
 		deleteVIPs(v)
 	}
@@ -1,64 +1,93 @@
 package allocator
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
 	"sync"
 	"time"
 
+	"google.golang.org/protobuf/proto"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/klog/v2"
 	log "k8s.io/klog/v2"
 
+	listers "github.com/yunify/hostnic-cni/pkg/client/listers/network/v1alpha1"
 	"github.com/yunify/hostnic-cni/pkg/conf"
 	"github.com/yunify/hostnic-cni/pkg/constants"
 	"github.com/yunify/hostnic-cni/pkg/db"
+	"github.com/yunify/hostnic-cni/pkg/dhcp"
+	"github.com/yunify/hostnic-cni/pkg/events"
+	"github.com/yunify/hostnic-cni/pkg/health"
 	"github.com/yunify/hostnic-cni/pkg/networkutils"
 	"github.com/yunify/hostnic-cni/pkg/qcclient"
 	"github.com/yunify/hostnic-cni/pkg/rpc"
+	"github.com/yunify/hostnic-cni/pkg/tracing"
 )
 
 type nicStatus struct {
-	Nic  *rpc.HostNic
-	Pods map[string]*rpc.PodInfo
+	Nic *rpc.HostNic
+	// pods are kept in records of their own, see db.PodKey
+	Pods map[string]*rpc.PodInfo `json:"-"`
+
+	// netlink changes received before are made by the daemon itself, it is set
+	// ownChangeLag after the phase of the nic was set last
+	settled time.Time
 }
 
-func (n *nicStatus) setNicPhase(pahse rpc.Phase) error {
+// save puts the record of the nic in batch
+func (n *nicStatus) save(batch *db.Batch) error {
+	value, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	batch.Put(db.NicKey(n.Nic.VxNet.ID), value)
+	return nil
+}
+
+func (n *nicStatus) setNicPhase(store db.Store, pahse rpc.Phase) error {
 	save := n.Nic.Phase
 	n.Nic.Phase = pahse
-	if err := db.SetNetworkInfo(n.Nic.VxNet.ID, n); err != nil {
+	batch := new(db.Batch)
+	err := n.save(batch)
+	if err == nil {
+		err = store.Write(batch)
+	}
+	if err != nil {
 		n.Nic.Phase = save
 		return err
 	}
 	return nil
 }
 
-// always set status to Phase_Succeeded when add NicPod
-func (n *nicStatus) addNicPod(pod *rpc.PodInfo) error {
-	savePod := n.Pods[getContainterKey(pod)]
+// always set status to Phase_Succeeded when add NicPod, the phase and the pod are written in one batch
+func (n *nicStatus) addNicPod(store db.Store, pod *rpc.PodInfo) error {
 	saveStatus := n.Nic.Phase
-	n.Pods[getContainterKey(pod)] = pod
 	n.Nic.Phase = rpc.Phase_Succeeded
-	if err := db.SetNetworkInfo(n.Nic.VxNet.ID, n); err != nil {
-		if savePod == nil {
-			delete(n.Pods, getContainterKey(pod))
-			n.Nic.Phase = saveStatus
-		} else {
-			n.Pods[getContainterKey(pod)] = savePod
-			n.Nic.Phase = saveStatus
-		}
+	batch := new(db.Batch)
+	err := n.save(batch)
+	if err == nil {
+		var value []byte
+		value, err = json.Marshal(pod)
+		batch.Put(db.PodKey(n.Nic.VxNet.ID, getContainterKey(pod)), value)
+	}
+	if err == nil {
+		err = store.Write(batch)
+	}
+	if err != nil {
+		n.Nic.Phase = saveStatus
 		return err
 	}
+	n.Pods[getContainterKey(pod)] = pod
 	return nil
 }
 
-func (n *nicStatus) delNicPod(pod *rpc.PodInfo) error {
-	save := n.Pods[getContainterKey(pod)]
-	delete(n.Pods, getContainterKey(pod))
-	if err := db.SetNetworkInfo(n.Nic.VxNet.ID, n); err != nil {
-		n.Pods[getContainterKey(pod)] = save
+func (n *nicStatus) delNicPod(store db.Store, pod *rpc.PodInfo) error {
+	if err := store.Delete(db.PodKey(n.Nic.VxNet.ID, getContainterKey(pod))); err != nil {
 		return err
 	}
+	delete(n.Pods, getContainterKey(pod))
 	return nil
 }
 
@@ -70,28 +99,49 @@ func (n *nicStatus) getPhase() string {
 	return n.Nic.Phase.String()
 }
 
+const (
+	allocatorLivenessInterval = 30 * time.Second
+	// longer than a sync DeattachNics with retries
+	allocatorLivenessTimeout = 15 * time.Minute
+)
+
 type Allocator struct {
-	lock sync.RWMutex
-	nics map[string]*nicStatus
-	conf conf.PoolConf
+	lock   sync.RWMutex
+	nics   map[string]*nicStatus
+	conf   conf.PoolConf
+	store  db.Store
+	events *eventLog
+	vxnets *vxnetCache
+
+	// until when the changes of a bridge are made by its dhcp lease, see settledAt
+	bridgesLock    sync.Mutex
+	bridgesChanged map[string]time.Time
 }
 
 func (a *Allocator) setNicStatus(nic *rpc.HostNic, pahse rpc.Phase) error {
 	log.Infof("setNicStatus: %s %s", getNicKey(nic), pahse.String())
 	if status, ok := a.nics[nic.VxNet.ID]; ok {
-		if err := status.setNicPhase(pahse); err != nil {
+		prev := status.Nic.Phase
+		if err := status.setNicPhase(a.store, pahse); err != nil {
 			return err
 		}
+		status.settled = time.Now().Add(ownChangeLag)
+		if prev != pahse {
+			a.emitNicEvent(rpc.NicEventType_NicPhase, status.Nic, prev, "")
+		}
 	} else {
+		prev := nic.Phase
 		nicStatus := nicStatus{
-			Nic:  nic,
-			Pods: make(map[string]*rpc.PodInfo),
+			Nic:     nic,
+			Pods:    make(map[string]*rpc.PodInfo),
+			settled: time.Now().Add(ownChangeLag),
 		}
-		if err := nicStatus.setNicPhase(pahse); err != nil {
+		if err := nicStatus.setNicPhase(a.store, pahse); err != nil {
 			return err
 		} else {
 			a.nics[nic.VxNet.ID] = &nicStatus
 		}
+		a.emitNicEvent(rpc.NicEventType_NicPhase, nic, prev, "")
 	}
 
 	return nil
@@ -100,20 +150,28 @@ func (a *Allocator) setNicStatus(nic *rpc.HostNic, pahse rpc.Phase) error {
 func (a *Allocator) addNicPod(nic *rpc.HostNic, info *rpc.PodInfo) error {
 	log.Infof("addNicPod: %s %s", getNicKey(nic), getPodKey(info))
 	if status, ok := a.nics[nic.VxNet.ID]; ok {
-		if err := status.addNicPod(info); err != nil {
+		prev := status.Nic.Phase
+		if err := status.addNicPod(a.store, info); err != nil {
 			return err
 		}
+		if prev != status.Nic.Phase {
+			a.emitNicEvent(rpc.NicEventType_NicPhase, status.Nic, prev, "")
+		}
 	} else {
+		prev := nic.Phase
 		nicStatus := nicStatus{
-			Nic:  nic,
-			Pods: make(map[string]*rpc.PodInfo),
+			Nic:     nic,
+			Pods:    make(map[string]*rpc.PodInfo),
+			settled: time.Now().Add(ownChangeLag),
 		}
-		if err := nicStatus.addNicPod(info); err != nil {
+		if err := nicStatus.addNicPod(a.store, info); err != nil {
 			return err
 		} else {
 			a.nics[nic.VxNet.ID] = &nicStatus
 		}
+		a.emitNicEvent(rpc.NicEventType_NicPhase, nic, prev, "")
 	}
+	a.emitPodEvent(rpc.PodEventType_PodAttach, nic, info)
 
 	return nil
 }
@@ -121,9 +179,10 @@ func (a *Allocator) addNicPod(nic *rpc.HostNic, info *rpc.PodInfo) error {
 func (a *Allocator) delNicPod(nic *rpc.HostNic, info *rpc.PodInfo) error {
 	log.Infof("delNicPod: %s %s", getNicKey(nic), getPodKey(info))
 	if status, ok := a.nics[nic.VxNet.ID]; ok {
-		if err := status.delNicPod(info); err != nil {
+		if err := status.delNicPod(a.store, info); err != nil {
 			return err
 		}
+		a.emitPodEvent(rpc.PodEventType_PodDetach, status.Nic, info)
 	}
 
 	return nil
@@ -131,9 +190,22 @@ func (a *Allocator) delNicPod(nic *rpc.HostNic, info *rpc.PodInfo) error {
 
 func (a *Allocator) delNic(vxnet string) error {
 	log.Infof("delNic: %s", vxnet)
-	if err := db.DeleteNetworkInfo(vxnet); err != nil {
+	// the nic and the records of its pods are deleted in one batch
+	batch := new(db.Batch)
+	batch.Delete(db.NicKey(vxnet))
+	err := a.store.Iterate(db.PodKey(vxnet, ""), func(key string, value []byte) error {
+		batch.Delete(key)
+		return nil
+	})
+	if err != nil {
 		return err
 	}
+	if err := a.store.Write(batch); err != nil {
+		return err
+	}
+	if status, ok := a.nics[vxnet]; ok {
+		a.emitNicEvent(rpc.NicEventType_NicFree, status.Nic, status.Nic.Phase, "")
+	}
 	delete(a.nics, vxnet)
 
 	return nil
@@ -156,32 +228,36 @@ func (a *Allocator) getNicRouteTableNum(nic *rpc.HostNic) int32 {
 	}
 }
 
-func (a *Allocator) getVxnets(vxnet string) (*rpc.VxNet, error) {
+func (a *Allocator) getVxnets(ctx context.Context, vxnet string) (*rpc.VxNet, error) {
 	for _, nic := range a.nics {
 		if nic.Nic.VxNet.ID == vxnet {
+			vxnetLookups.WithLabelValues(vxnetSourceNic).Inc()
 			return nic.Nic.VxNet, nil
 		}
 	}
 
-	result, err := qcclient.QClient.GetVxNets([]string{vxnet}, 0)
-	if err != nil {
-		return nil, err
-	}
-
-	if v, ok := result[vxnet]; !ok {
-		return nil, fmt.Errorf("get vxnet %s from qingcloud: not found", vxnet)
-	} else {
-		return v, nil
-	}
+	v, source, err := a.vxnets.get(ctx, vxnet)
+	vxnetLookups.WithLabelValues(source).Inc()
+	return v, err
 }
 
 func (a *Allocator) canAlloc() int {
 	return a.conf.MaxNic - len(a.nics)
 }
 
-func (a *Allocator) AllocHostNic(args *rpc.PodInfo) (*rpc.HostNic, error) {
+func (a *Allocator) AllocHostNic(ctx context.Context, args *rpc.PodInfo) (result *rpc.HostNic, err error) {
+	start := time.Now()
+	defer observeStage("total", start)
+
+	ctx, span := tracing.Start(ctx, "AllocHostNic", tracing.String("hostnic.vxnet", args.VxNet))
+	defer func() {
+		span.SetAttributes(tracing.String("hostnic.nic", result.GetID()))
+		span.End(err)
+	}()
+
 	a.lock.Lock()
 	defer a.lock.Unlock()
+	stageStart := observeStage("lock", start)
 
 	vxnetName := args.VxNet
 	if nic, ok := a.nics[vxnetName]; ok {
@@ -194,7 +270,8 @@ func (a *Allocator) AllocHostNic(args *rpc.PodInfo) (*rpc.HostNic, error) {
 			return nic.Nic, nil
 		} else {
 			// create bridge and rule here
-			phase, err := networkutils.NetworkHelper.SetupNetwork(nic.Nic)
+			phase, err := networkutils.WithContext(ctx).SetupNetwork(nic.Nic)
+			observeStage("setup_network", stageStart)
 			if err != nil {
 				if err := a.setNicStatus(nic.Nic, phase); err != nil {
 					log.Errorf("setNicStatus failed: %s %s %v", getNicKey(nic.Nic), phase.String(), err)
@@ -209,23 +286,29 @@ func (a *Allocator) AllocHostNic(args *rpc.PodInfo) (*rpc.HostNic, error) {
 	}
 
 	if a.canAlloc() <= 0 {
+		events.NodeEventf(corev1.EventTypeWarning, events.ReasonMaxNicReached,
+			"Cannot attach nic of vxnet %s, node already has %d of max %d nics", vxnetName, len(a.nics), a.conf.MaxNic)
 		return nil, constants.ErrNoAvailableNIC
 	}
 
-	vxnet, err := a.getVxnets(vxnetName)
+	vxnet, err := a.getVxnets(ctx, vxnetName)
 	if err != nil {
 		return nil, err
 	}
-	nics, _, err := qcclient.QClient.CreateNicsAndAttach(vxnet, 1, nil, 1)
+	stageStart = observeStage("get_vxnet", stageStart)
+	nics, _, err := qcclient.WithContext(ctx).CreateNicsAndAttach(vxnet, 1, nil, 1)
+	stageStart = observeStage("create_and_attach", stageStart)
 	if err != nil {
-		return nil, fmt.Errorf("create and attach nic failed: %v", err)
+		return nil, fmt.Errorf("create and attach nic failed: %w", err)
 	}
 	log.Infof("create and attach nic %s", getNicKey(nics[0]))
 
 	//wait for nic attach
+	_, waitSpan := tracing.Start(ctx, "WaitNicAttach", tracing.String("hostnic.mac", nics[0].HardwareAddr))
 	for {
 		link, err := networkutils.NetworkHelper.LinkByMacAddr(nics[0].HardwareAddr)
 		if err != nil && err != constants.ErrNicNotFound {
+			waitSpan.End(err)
 			return nil, err
 		}
 		if link != nil {
@@ -233,14 +316,17 @@ func (a *Allocator) AllocHostNic(args *rpc.PodInfo) (*rpc.HostNic, error) {
 		}
 		time.Sleep(1 * time.Second)
 	}
+	waitSpan.End(nil)
 
 	log.Infof("attach nic %s success", getNicKey(nics[0]))
+	stageStart = observeStage("wait_attach", stageStart)
 
 	nics[0].Reserved = true
 	nics[0].RouteTableNum = a.getNicRouteTableNum(nics[0])
 
 	// create bridge and rule here
-	phase, err := networkutils.NetworkHelper.SetupNetwork(nics[0])
+	phase, err := networkutils.WithContext(ctx).SetupNetwork(nics[0])
+	observeStage("setup_network", stageStart)
 	if err != nil {
 		if err := a.setNicStatus(nics[0], phase); err != nil {
 			log.Errorf("setNicStatus failed: %s %s %v", getNicKey(nics[0]), phase.String(), err)
@@ -296,55 +382,174 @@ func (a *Allocator) HostNicCheck() {
 	defer a.lock.Unlock()
 
 	for _, nic := range a.nics {
-		nicKey := getNicKey(nic.Nic)
+		a.checkHostNic(nic)
+	}
+}
 
-		exists := true
-		_, err := networkutils.NetworkHelper.LinkByMacAddr(nic.Nic.ID)
-		if err == constants.ErrNicNotFound {
-			exists = false
-		}
+func (a *Allocator) checkHostNic(nic *nicStatus) error {
+	nicKey := getNicKey(nic.Nic)
 
-		if !nic.isOK() || !exists {
-			log.Infof("hostNic %s status: %s , exists: %t, try to repair it", nicKey, nic.getPhase(), exists)
-			phase, err := networkutils.NetworkHelper.CheckAndRepairNetwork(nic.Nic)
-			if err := a.setNicStatus(nic.Nic, phase); err != nil {
-				log.Errorf("setNicStatus failed: %s %s %v", nicKey, phase.String(), err)
-			}
-			log.Infof("Repair hostNic %s: %s, %v", nicKey, nic.getPhase(), err)
-		}
+	exists := true
+	_, err := networkutils.NetworkHelper.LinkByMacAddr(nic.Nic.ID)
+	if err == constants.ErrNicNotFound {
+		exists = false
+	}
+
+	if !nic.isOK() || !exists {
+		log.Infof("hostNic %s status: %s , exists: %t, try to repair it", nicKey, nic.getPhase(), exists)
+		return a.repairHostNic(nic)
 	}
+
+	return nil
+}
+
+// repairHostNic repairs the network of nic, and restores the rules of its pods on success
+func (a *Allocator) repairHostNic(nic *nicStatus) error {
+	nicKey := getNicKey(nic.Nic)
+	prev := nic.Nic.Phase
+	phase, err := networkutils.NetworkHelper.CheckAndRepairNetwork(nic.Nic)
+	if err := a.setNicStatus(nic.Nic, phase); err != nil {
+		log.Errorf("setNicStatus failed: %s %s %v", nicKey, phase.String(), err)
+	}
+	log.Infof("Repair hostNic %s: %s, %v", nicKey, nic.getPhase(), err)
+	a.emitNicEvent(rpc.NicEventType_NicRepair, nic.Nic, prev, errorMessage(err))
+	recordRepairEvent(nic.Nic, err)
+	if err == nil && nic.isOK() {
+		a.restorePodNetwork(nic)
+	}
+	return err
 }
 
-func (a *Allocator) IPAddrReNew() {
+// RepairHostNic runs the HostNicCheck for a single nic, selected by vxnet or nic id.
+// With resetup the nic network is setup again even if the nic looks healthy.
+func (a *Allocator) RepairHostNic(vxnet, nicID string, resetup bool) (*rpc.HostNic, error) {
 	a.lock.Lock()
 	defer a.lock.Unlock()
 
-	for _, nic := range a.nics {
-		if nic != nil {
-			nicKey := getNicKey(nic.Nic)
-			if nic.isOK() && nic.Nic.VxNet.TunnelType == constants.TunnelTypeVlan {
-				brName := constants.GetHostNicBridgeName(int(nic.Nic.RouteTableNum))
-				// renew ip lease
-				err := networkutils.UpdateLinkIPAddrAndLease(nic.Nic)
-				if err != nil {
-					log.Errorf("renew hostNic %s bridge %s ip addr lease error: %v", nicKey, brName, err)
-				} else {
-					log.Infof("renew hostNic %s bridge %s ip addr lease success!", nicKey, brName)
-				}
-			}
-		}
+	nic := a.findNic(vxnet, nicID)
+	if nic == nil {
+		return nil, constants.ErrNicNotFound
 	}
+
+	if !resetup {
+		err := a.checkHostNic(nic)
+		return proto.Clone(nic.Nic).(*rpc.HostNic), err
+	}
+
+	nicKey := getNicKey(nic.Nic)
+	log.Infof("hostNic %s status: %s, resetup it on demand", nicKey, nic.getPhase())
+	prev := nic.Nic.Phase
+	phase, err := networkutils.NetworkHelper.SetupNetwork(nic.Nic)
+	if err := a.setNicStatus(nic.Nic, phase); err != nil {
+		log.Errorf("setNicStatus failed: %s %s %v", nicKey, phase.String(), err)
+	}
+	log.Infof("Resetup hostNic %s: %s, %v", nicKey, nic.getPhase(), err)
+	a.emitNicEvent(rpc.NicEventType_NicRepair, nic.Nic, prev, errorMessage(err))
+	recordRepairEvent(nic.Nic, err)
+	if err == nil && nic.isOK() {
+		a.restorePodNetwork(nic)
+	}
+
+	return proto.Clone(nic.Nic).(*rpc.HostNic), err
 }
 
 func (a *Allocator) Start(stopCh <-chan struct{}) error {
 	go a.run(stopCh)
+	go a.watchNetwork(stopCh)
+	go a.probeLock(stopCh)
 	return nil
 }
 
+// probeLock reports the allocator alive whenever it takes the lock, the lock is held for
+// long only while waiting for the api, a timeout of minutes means it is stuck
+func (a *Allocator) probeLock(stopCh <-chan struct{}) {
+	health.RegisterLiveness(health.LivenessAllocator, allocatorLivenessTimeout)
+	ticker := time.NewTicker(allocatorLivenessInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			a.lock.Lock()
+			a.lock.Unlock()
+			health.Heartbeat(health.LivenessAllocator)
+		}
+	}
+}
+
 func (a *Allocator) GetNics() map[string]*nicStatus {
 	return a.nics
 }
 
+// GetHostNics returns a copy of the nics selected by vxnet or nic id, all nics if both are empty.
+func (a *Allocator) GetHostNics(vxnet, nicID string) []*rpc.HostNic {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	var result []*rpc.HostNic
+	for _, nic := range a.nics {
+		if (vxnet == "" || nic.Nic.VxNet.ID == vxnet) && (nicID == "" || nic.Nic.ID == nicID) {
+			result = append(result, proto.Clone(nic.Nic).(*rpc.HostNic))
+		}
+	}
+	return result
+}
+
+// ListPods returns all pods recorded on this node.
+func (a *Allocator) ListPods() []*rpc.PodNetInfo {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	var result []*rpc.PodNetInfo
+	for _, nic := range a.nics {
+		for _, pod := range nic.Pods {
+			result = append(result, &rpc.PodNetInfo{
+				Namespace:     pod.Namespace,
+				Name:          pod.Name,
+				Containter:    pod.Containter,
+				PodIP:         pod.PodIP,
+				HostNic:       nic.Nic.ID,
+				Vxnet:         nic.Nic.VxNet.ID,
+				RouteTableNum: nic.Nic.RouteTableNum,
+			})
+		}
+	}
+	return result
+}
+
+// GetPod returns the record of a pod and its nic, the pod is matched by container id,
+// or by namespace and name if container id is empty.
+func (a *Allocator) GetPod(args *rpc.PodInfo) (*rpc.HostNic, *rpc.PodInfo) {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	for _, nic := range a.nics {
+		for _, pod := range nic.Pods {
+			if args.Containter != "" && pod.Containter != args.Containter {
+				continue
+			}
+			if args.Containter == "" && (pod.Namespace != args.Namespace || pod.Name != args.Name) {
+				continue
+			}
+			return proto.Clone(nic.Nic).(*rpc.HostNic), proto.Clone(pod).(*rpc.PodInfo)
+		}
+	}
+	return nil, nil
+}
+
+func (a *Allocator) findNic(vxnet, nicID string) *nicStatus {
+	if vxnet != "" {
+		return a.nics[vxnet]
+	}
+	for _, nic := range a.nics {
+		if nic.Nic.ID == nicID {
+			return nic
+		}
+	}
+	return nil
+}
+
 func (a *Allocator) freeHostnic(nic *rpc.HostNic) error {
 	if err := networkutils.NetworkHelper.CleanupNetwork(nic); err != nil {
 		log.Errorf("CleanupNetwork for vxnet %s failed: nic %s %v", nic.VxNet.ID, nic.ID, err)
@@ -419,6 +624,8 @@ func (a *Allocator) run(stopCh <-chan struct{}) {
 	jobTimer := time.NewTicker(time.Duration(a.conf.Sync) * time.Second).C
 	freeTimer := time.NewTicker(time.Duration(a.conf.FreePeriod) * time.Minute).C
 
+	// nics restored from db are repaired and their pods restored at once, e.g. after node reboot
+	a.HostNicCheck()
 	for {
 		select {
 		case <-stopCh:
@@ -430,9 +637,6 @@ func (a *Allocator) run(stopCh <-chan struct{}) {
 		case <-freeTimer:
 			log.Infof("period free sync")
 			a.ClearFreeHostnic(false)
-		case <-constants.IpAddrReNewTicker.C:
-			log.Infof("ip addr renew sync")
-			a.IPAddrReNew()
 		}
 	}
 }
@@ -441,29 +645,51 @@ var (
 	Alloc *Allocator
 )
 
-func SetupAllocator(conf conf.PoolConf) {
+// SetupAllocator sets up Alloc, the vxnets are looked up in the status of the vxnetpool in pools
+// before the api
+func SetupAllocator(conf conf.PoolConf, store db.Store, pools listers.VxNetPoolLister) {
 	Alloc = &Allocator{
-		nics: make(map[string]*nicStatus),
-		conf: conf,
+		nics:   make(map[string]*nicStatus),
+		conf:   conf,
+		store:  store,
+		events: newEventLog(),
+		vxnets: newVxNetCache(pools, time.Duration(conf.VxNetCacheTTL)*time.Minute),
 	}
 
-	err := db.Iterator(func(value interface{}) error {
-		var nic nicStatus
-		json.Unmarshal(value.([]byte), &nic)
-		Alloc.nics[nic.Nic.VxNet.ID] = &nic
-		return nil
-	})
+	nics, err := restoreNics(store)
 	if err != nil {
-		log.Fatalf("Failed to restore allocator from leveldb: %v", err)
+		log.Fatalf("Failed to restore allocator from db: %v", err)
+	}
+	Alloc.nics = nics
+
+	// a provider without state of its own, i.e. static, learns the nics in use from db
+	var recorded []string
+	for _, status := range Alloc.nics {
+		recorded = append(recorded, status.Nic.ID)
+	}
+	qcclient.RestoreNicsInUse(recorded)
+
+	// dhcp leases are kept for the bridges of vlan nics
+	var bridges []string
+	for _, status := range Alloc.nics {
+		if status.Nic.VxNet.TunnelType == constants.TunnelTypeVlan {
+			bridges = append(bridges, constants.GetHostNicBridgeName(int(status.Nic.RouteTableNum)))
+		}
+	}
+	if err := dhcp.SetupLeaseManager(bridges, dhcp.Hooks{
+		OnFailure: recordLeaseFailure,
+		OnChange:  Alloc.recordLeaseChange,
+	}); err != nil {
+		log.Fatalf("Failed to setup dhcp lease manager: %v", err)
 	}
 
 	// restore create nics
-	nics, err := qcclient.QClient.GetCreatedNicsByName(constants.NicPrefix + qcclient.QClient.GetInstanceID())
+	created, err := qcclient.QClient.GetCreatedNicsByName(constants.NicPrefix + qcclient.QClient.GetInstanceID())
 	if err != nil {
 		log.Fatalf("Failed to get created nics from qingcloud: %v", err)
 	}
 
-	for _, nic := range nics {
+	for _, nic := range created {
 		if status, ok := Alloc.nics[nic.VxNet.ID]; !ok {
 			// nic not attached at this node
 		} else {
@@ -471,6 +697,79 @@ func SetupAllocator(conf conf.PoolConf) {
 			log.Infof("Restore create nic %s %s routetable num %d", nic.ID, getNicKey(status.Nic), status.Nic.RouteTableNum)
 		}
 	}
+	health.SetHealthy(health.CheckAllocator, fmt.Sprintf("restored %d nics from db", len(Alloc.nics)))
+}
+
+// restoreNics returns the nics in store by vxnet along with their pods, records which cannot
+// be restored are quarantined
+func restoreNics(store db.Store) (map[string]*nicStatus, error) {
+	nics := make(map[string]*nicStatus)
+	err := db.Restore(store, db.NamespaceNic, func(key string, value []byte) error {
+		return decodeNic(nics, key, value)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Restore(store, db.NamespacePod, func(key string, value []byte) error {
+		return decodePod(nics, key, value)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nics, nil
+}
+
+// decodeNic adds the nic record of vxnet key to nics
+func decodeNic(nics map[string]*nicStatus, key string, value []byte) error {
+	var nic nicStatus
+	if err := db.Decode(value, &nic); err != nil {
+		return err
+	}
+	if nic.Nic == nil || nic.Nic.VxNet == nil || nic.Nic.VxNet.ID != key {
+		return fmt.Errorf("nic record of vxnet %s has no nic of the vxnet", key)
+	}
+	nic.Pods = make(map[string]*rpc.PodInfo)
+	nics[key] = &nic
+	return nil
+}
+
+// decodePod adds the pod record of key, which is <vxnet>/<container>, to its nic in nics
+func decodePod(nics map[string]*nicStatus, key string, value []byte) error {
+	parts := strings.SplitN(key, "/", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid pod record key %s", key)
+	}
+	nic, ok := nics[parts[0]]
+	if !ok {
+		return fmt.Errorf("pod record %s of unknown nic", key)
+	}
+	var pod rpc.PodInfo
+	if err := db.Decode(value, &pod); err != nil {
+		return err
+	}
+	nic.Pods[parts[1]] = &pod
+	return nil
+}
+
+func recordRepairEvent(nic *rpc.HostNic, err error) {
+	if err != nil {
+		events.NodeEventf(corev1.EventTypeWarning, events.ReasonNicRepairFailed, "Failed to repair nic %s: %v", getNicKey(nic), err)
+	} else {
+		events.NodeEventf(corev1.EventTypeNormal, events.ReasonNicRepaired, "Repaired nic %s: %s", getNicKey(nic), nic.Phase.String())
+	}
+}
+
+func recordLeaseFailure(bridge string, err error) {
+	events.NodeEventf(corev1.EventTypeWarning, events.ReasonDHCPLeaseRenewFailed,
+		"Failed to renew dhcp lease of bridge %s: %v", bridge, err)
+}
+
+func errorMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
 }
 
 func getContainterKey(info *rpc.PodInfo) string {
@@ -0,0 +1,56 @@
+package allocator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yunify/hostnic-cni/pkg/db"
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+)
+
+func TestNicRecords(t *testing.T) {
+	store := db.NewMemoryStore()
+	a := &Allocator{
+		nics:   make(map[string]*nicStatus),
+		store:  store,
+		events: newEventLog(),
+	}
+	nic := &rpc.HostNic{ID: "hostnic-a", VxNet: &rpc.VxNet{ID: "vxnet-a"}}
+	a.setNicStatus(nic, rpc.Phase_Init)
+	a.addNicPod(nic, &rpc.PodInfo{Name: "pod-1", Containter: "c1"})
+	a.addNicPod(nic, &rpc.PodInfo{Name: "pod-2", Containter: "c2"})
+	a.delNicPod(nic, &rpc.PodInfo{Name: "pod-2", Containter: "c2"})
+	// a pod of a nic which is not recorded is quarantined
+	store.Put(db.PodKey("vxnet-b", "c3"), []byte(`{"Name":"pod-3"}`))
+
+	nics, err := restoreNics(store)
+	if err != nil {
+		t.Fatalf("failed to restore nics: %v", err)
+	}
+	status, ok := nics["vxnet-a"]
+	if !ok || status.Nic.ID != "hostnic-a" || status.Nic.Phase != rpc.Phase_Succeeded {
+		t.Fatalf("expect nic hostnic-a of phase succeeded restored, got %v", nics)
+	}
+	var pods []string
+	for key, pod := range status.Pods {
+		pods = append(pods, key+"="+pod.Name)
+	}
+	if !reflect.DeepEqual(pods, []string{"c1=pod-1"}) {
+		t.Fatalf("expect pod-1 restored, got %v", pods)
+	}
+	if quarantined, _ := db.Quarantined(store); !reflect.DeepEqual(quarantined, []string{db.PodKey("vxnet-b", "c3")}) {
+		t.Fatalf("expect pod of vxnet-b quarantined, got %v", quarantined)
+	}
+
+	if err := a.delNic("vxnet-a"); err != nil {
+		t.Fatalf("failed to delete nic: %v", err)
+	}
+	var left []string
+	store.Iterate("", func(key string, value []byte) error {
+		left = append(left, key)
+		return nil
+	})
+	if expect := []string{db.NamespaceQuarantine + db.PodKey("vxnet-b", "c3")}; !reflect.DeepEqual(left, expect) {
+		t.Fatalf("expect only %v left after deleting nic, got %v", expect, left)
+	}
+}
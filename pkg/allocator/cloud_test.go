@@ -0,0 +1,231 @@
+package allocator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/yunify/hostnic-cni/pkg/conf"
+	"github.com/yunify/hostnic-cni/pkg/constants"
+	"github.com/yunify/hostnic-cni/pkg/db"
+	"github.com/yunify/hostnic-cni/pkg/networkutils"
+	"github.com/yunify/hostnic-cni/pkg/qcclient/fake"
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+)
+
+const testInstance = "i-test"
+
+// cloudNetwork shows the nics attached in cloud as links, the network setup always succeeds
+type cloudNetwork struct {
+	cloud   *fake.Cloud
+	cleaned []string
+}
+
+func (n *cloudNetwork) SetupNetwork(nic *rpc.HostNic) (rpc.Phase, error) {
+	return rpc.Phase_Succeeded, nil
+}
+
+func (n *cloudNetwork) CleanupNetwork(nic *rpc.HostNic) error {
+	n.cleaned = append(n.cleaned, nic.ID)
+	return nil
+}
+
+func (n *cloudNetwork) CheckAndRepairNetwork(nic *rpc.HostNic) (rpc.Phase, error) {
+	return rpc.Phase_Succeeded, nil
+}
+
+func (n *cloudNetwork) SetupPodNetwork(nic *rpc.HostNic, ip string) error {
+	return nil
+}
+
+func (n *cloudNetwork) CleanupPodNetwork(nic *rpc.HostNic, ip string) error {
+	return nil
+}
+
+func (n *cloudNetwork) LinkByMacAddr(macAddr string) (netlink.Link, error) {
+	nic, ok := n.cloud.Nics(testInstance)[macAddr]
+	if !ok || !nic.Using {
+		return nil, constants.ErrNicNotFound
+	}
+	return &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: constants.GetHostNicName(nic.VxNet.ID)}}, nil
+}
+
+func (n *cloudNetwork) IsNSorErr(nspath string) error {
+	return nil
+}
+
+func setupTestCloud(t *testing.T) (*fake.Cloud, *cloudNetwork, *Allocator) {
+	cloud := fake.NewCloud(testInstance)
+	for _, vxnet := range []*rpc.VxNet{
+		{ID: "vxnet-a", Gateway: "192.168.0.1", Network: "192.168.0.0/24", IPStart: "192.168.0.2", IPEnd: "192.168.0.250"},
+		{ID: "vxnet-b", Gateway: "192.168.1.1", Network: "192.168.1.0/24", IPStart: "192.168.1.2", IPEnd: "192.168.1.250"},
+	} {
+		cloud.AddVxNet(vxnet)
+	}
+	cloud.AddPrimaryNic(testInstance, "vxnet-a", "192.168.0.250")
+	fake.Setup(cloud)
+
+	network := &cloudNetwork{cloud: cloud}
+	helper := networkutils.NetworkHelper
+	networkutils.NetworkHelper = network
+	t.Cleanup(func() { networkutils.NetworkHelper = helper })
+
+	a := &Allocator{
+		nics:   make(map[string]*nicStatus),
+		conf:   conf.PoolConf{MaxNic: 2, RouteTableBase: constants.DefaultRouteTableBase},
+		store:  db.NewMemoryStore(),
+		events: newEventLog(),
+		vxnets: newVxNetCache(nil, 0),
+	}
+	return cloud, network, a
+}
+
+func TestAllocFreeHostNic(t *testing.T) {
+	cloud, network, a := setupTestCloud(t)
+	ctx := context.Background()
+
+	pod1 := &rpc.PodInfo{Name: "pod-1", Namespace: "default", Containter: "c1", VxNet: "vxnet-a"}
+	pod2 := &rpc.PodInfo{Name: "pod-2", Namespace: "default", Containter: "c2", VxNet: "vxnet-a"}
+	nic, err := a.AllocHostNic(ctx, pod1)
+	if err != nil {
+		t.Fatalf("AllocHostNic error: %v", err)
+	}
+	if nic.RouteTableNum != int32(constants.DefaultRouteTableBase) || !nic.Reserved {
+		t.Fatalf("unexpected nic %v", nic)
+	}
+	again, err := a.AllocHostNic(ctx, pod2)
+	if err != nil || again.ID != nic.ID {
+		t.Fatalf("expect nic %s shared by pods of vxnet-a, got %v %v", nic.ID, again, err)
+	}
+	if calls := cloud.Calls("CreateNicsAndAttach"); calls != 1 {
+		t.Fatalf("expect 1 nic created, got %d", calls)
+	}
+
+	cloud.Inject("CreateNicsAndAttach", fake.Fault{Err: errors.New("quota exceeded"), Times: 1})
+	pod3 := &rpc.PodInfo{Name: "pod-3", Namespace: "default", Containter: "c3", VxNet: "vxnet-b"}
+	if _, err := a.AllocHostNic(ctx, pod3); err == nil || !strings.Contains(err.Error(), "quota exceeded") {
+		t.Fatalf("expect injected error, got %v", err)
+	}
+	if _, ok := a.nics["vxnet-b"]; ok {
+		t.Fatalf("nic of vxnet-b recorded after a failed create")
+	}
+	if _, err := a.AllocHostNic(ctx, pod3); err != nil {
+		t.Fatalf("AllocHostNic error after the fault: %v", err)
+	}
+	if _, err := a.AllocHostNic(ctx, &rpc.PodInfo{Containter: "c4", VxNet: "vxnet-c"}); err != constants.ErrNoAvailableNIC {
+		t.Fatalf("expect %v, got %v", constants.ErrNoAvailableNIC, err)
+	}
+
+	for _, pod := range []*rpc.PodInfo{pod1, pod2} {
+		if _, _, err := a.FreeHostNic(pod, false); err != nil {
+			t.Fatalf("FreeHostNic error: %v", err)
+		}
+	}
+	if err := a.ClearFreeHostnic(false); err != nil {
+		t.Fatalf("ClearFreeHostnic error: %v", err)
+	}
+	if _, ok := a.nics["vxnet-a"]; ok {
+		t.Fatalf("nic of vxnet-a recorded after it was freed")
+	}
+	if _, ok := cloud.Nics(testInstance)[nic.ID]; ok {
+		t.Fatalf("nic %s of vxnet-a left in cloud", nic.ID)
+	}
+	if len(network.cleaned) != 1 || network.cleaned[0] != nic.ID {
+		t.Fatalf("expect network of %s cleaned up, got %v", nic.ID, network.cleaned)
+	}
+	if len(cloud.Nics(testInstance)) != 1 {
+		t.Fatalf("expect only the nic of vxnet-b left, got %v", cloud.Nics(testInstance))
+	}
+}
+
+func TestReconcileNicsWithCloud(t *testing.T) {
+	cloud, _, a := setupTestCloud(t)
+
+	// a nic created before a crash, which was never recorded
+	orphans, _, err := cloud.CreateNicsAndAttach(&rpc.VxNet{ID: "vxnet-b"}, 1, nil, 1)
+	if err != nil {
+		t.Fatalf("CreateNicsAndAttach error: %v", err)
+	}
+	cloud.CompleteJobs()
+	if _, err := a.AllocHostNic(context.Background(), &rpc.PodInfo{Name: "pod-1", Containter: "c1", VxNet: "vxnet-a"}); err != nil {
+		t.Fatalf("AllocHostNic error: %v", err)
+	}
+
+	if err := a.ReconcileNics(func(*rpc.VxNet) bool { return false }, true); err != nil {
+		t.Fatalf("ReconcileNics error: %v", err)
+	}
+	if _, ok := cloud.Nics(testInstance)[orphans[0].ID]; !ok {
+		t.Fatalf("orphaned nic %s deleted in dry run", orphans[0].ID)
+	}
+
+	if err := a.ReconcileNics(func(*rpc.VxNet) bool { return false }, false); err != nil {
+		t.Fatalf("ReconcileNics error: %v", err)
+	}
+	nics := cloud.Nics(testInstance)
+	if _, ok := nics[orphans[0].ID]; ok {
+		t.Fatalf("orphaned nic %s left in cloud", orphans[0].ID)
+	}
+	if _, ok := nics[a.nics["vxnet-a"].Nic.ID]; len(nics) != 1 || !ok {
+		t.Fatalf("expect only the recorded nic left, got %v", nics)
+	}
+}
+
+func TestReconcileNicsAdopt(t *testing.T) {
+	cloud, _, a := setupTestCloud(t)
+
+	if _, err := a.AllocHostNic(context.Background(), &rpc.PodInfo{Name: "pod-1", Containter: "c1", VxNet: "vxnet-a"}); err != nil {
+		t.Fatalf("AllocHostNic error: %v", err)
+	}
+	// nics created before a crash, vxnet-a already has a recorded nic
+	var orphans []*rpc.HostNic
+	for _, vxnet := range []string{"vxnet-a", "vxnet-b"} {
+		nics, _, err := cloud.CreateNicsAndAttach(&rpc.VxNet{ID: vxnet}, 1, nil, 1)
+		if err != nil {
+			t.Fatalf("CreateNicsAndAttach error: %v", err)
+		}
+		orphans = append(orphans, nics[0])
+	}
+	cloud.CompleteJobs()
+
+	inUse := func(vxnet *rpc.VxNet) bool {
+		// pods are looked up without the allocator lock
+		if !a.lock.TryLock() {
+			t.Errorf("allocator locked while checking vxnet %s", vxnet.ID)
+		} else {
+			a.lock.Unlock()
+		}
+		return true
+	}
+	if err := a.ReconcileNics(inUse, false); err != nil {
+		t.Fatalf("ReconcileNics error: %v", err)
+	}
+
+	status, ok := a.nics["vxnet-b"]
+	if !ok || status.Nic.ID != orphans[1].ID || !status.Nic.Reserved || status.Nic.Phase != rpc.Phase_Succeeded {
+		t.Fatalf("expect nic %s of vxnet-b adopted, got %v", orphans[1].ID, status)
+	}
+	if status.Nic.RouteTableNum == a.nics["vxnet-a"].Nic.RouteTableNum {
+		t.Fatalf("adopted nic takes route table num %d of vxnet-a", status.Nic.RouteTableNum)
+	}
+	if _, err := a.store.Get(db.NicKey("vxnet-b")); err != nil {
+		t.Fatalf("adopted nic is not recorded in db: %v", err)
+	}
+	nics := cloud.Nics(testInstance)
+	if _, ok := nics[orphans[0].ID]; ok {
+		t.Fatalf("orphaned nic %s of vxnet-a left in cloud", orphans[0].ID)
+	}
+	if len(nics) != 2 {
+		t.Fatalf("expect only the two recorded nics left, got %v", nics)
+	}
+
+	// adopted nics are recorded, nothing left to reconcile
+	if err := a.ReconcileNics(inUse, false); err != nil {
+		t.Fatalf("ReconcileNics error: %v", err)
+	}
+	if len(cloud.Nics(testInstance)) != 2 || a.nics["vxnet-b"].Nic.ID != orphans[1].ID {
+		t.Fatalf("expect the adopted nic kept")
+	}
+}
@@ -0,0 +1,77 @@
+package allocator
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// actions of restoring pod network
+const (
+	restoreActionRestored = "restored"
+	restoreActionPruned   = "pruned"
+	restoreActionFailed   = "failed"
+)
+
+// actions of reconciling nics missing from db
+const (
+	orphanActionAdopted = "adopted"
+	orphanActionDeleted = "deleted"
+	orphanActionFailed  = "failed"
+)
+
+var (
+	allocStageDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "hostnic_allocator_stage_duration_seconds",
+			Help:    "latency of each stage of hostnic allocation with hostnic cni",
+			Buckets: prometheus.ExponentialBuckets(0.005, 2, 16),
+		},
+		[]string{"stage"},
+	)
+	podRestores = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hostnic_pod_network_restore_total",
+			Help: "pod rules restored for running sandboxes or pruned for gone sandboxes after nic repair with hostnic cni",
+		},
+		[]string{"action"},
+	)
+	nicChanges = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hostnic_nic_network_changes_total",
+			Help: "netlink changes which break a nic and trigger its repair with hostnic cni",
+		},
+		[]string{"reason"},
+	)
+	netlinkSubscribeErrors = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hostnic_netlink_subscribe_errors_total",
+			Help: "failures of the netlink subscription, nics are only checked periodically until it is resubscribed with hostnic cni",
+		},
+	)
+	orphanNics = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hostnic_orphan_nics_total",
+			Help: "nics of the instance missing from db which are adopted or deleted with hostnic cni",
+		},
+		[]string{"action"},
+	)
+	vxnetLookups = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hostnic_vxnet_lookups_total",
+			Help: "lookups of vxnet metadata by the source which served them, nic, pool, cache or api with hostnic cni",
+		},
+		[]string{"source"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(allocStageDuration, podRestores, nicChanges, netlinkSubscribeErrors, orphanNics, vxnetLookups)
+}
+
+// observeStage records the duration of stage since start, and returns the start of the next stage
+func observeStage(stage string, start time.Time) time.Time {
+	now := time.Now()
+	allocStageDuration.WithLabelValues(stage).Observe(now.Sub(start).Seconds())
+	return now
+}
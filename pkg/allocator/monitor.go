@@ -0,0 +1,255 @@
+package allocator
+
+import (
+	"time"
+
+	log "k8s.io/klog/v2"
+
+	"github.com/yunify/hostnic-cni/pkg/constants"
+	"github.com/yunify/hostnic-cni/pkg/dhcp"
+	"github.com/yunify/hostnic-cni/pkg/networkutils"
+)
+
+const (
+	// a nic is repaired once it has no change for nicRepairDebounce, or at the
+	// latest nicRepairMaxDelay after its first change
+	nicRepairDebounce = 2 * time.Second
+	nicRepairMaxDelay = 10 * time.Second
+	// changes are stamped when the watcher reads them, so changes of the daemon
+	// itself are stamped a bit after it made them
+	ownChangeLag = time.Second
+
+	netlinkResubscribeBackoff    = time.Second
+	netlinkResubscribeBackoffMax = time.Minute
+)
+
+// reasons a nic is repaired on a netlink change
+const (
+	changeLinkDeleted  = "link_deleted"
+	changeLinkRenamed  = "link_renamed"
+	changeLinkDown     = "link_down"
+	changeAddrDeleted  = "addr_deleted"
+	changeRouteDeleted = "route_deleted"
+	changeRuleDeleted  = "rule_deleted"
+)
+
+// watchNetwork repairs the nics as soon as their link, bridge, routes or rule
+// are broken, instead of waiting for the next HostNicCheck. The periodic
+// HostNicCheck is kept as a fallback, e.g. while the subscription is broken.
+func (a *Allocator) watchNetwork(stopCh <-chan struct{}) {
+	changes := make(chan networkutils.NetChange, 256)
+	go a.subscribeNetwork(stopCh, changes)
+
+	queue := newRepairQueue()
+	timer := time.NewTimer(nicRepairDebounce)
+	timer.Stop()
+	var due <-chan time.Time
+	for {
+		select {
+		case <-stopCh:
+			timer.Stop()
+			return
+		case change := <-changes:
+			vxnet, reason := a.matchNetChange(change)
+			if vxnet == "" {
+				continue
+			}
+			log.V(2).Infof("hostNic of vxnet %s changed: %s", vxnet, reason)
+			nicChanges.WithLabelValues(reason).Inc()
+			queue.add(vxnet, change.Received)
+		case <-due:
+			a.repairChangedNics(queue.due(time.Now()))
+		}
+
+		// wait for the first due repair
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		due = nil
+		if next, ok := queue.next(); ok {
+			timer.Reset(time.Until(next))
+			due = timer.C
+		}
+	}
+}
+
+// repairQueue debounces the changes of the nics by vxnet
+type repairQueue struct {
+	pending map[string]*pendingRepair
+}
+
+type pendingRepair struct {
+	first time.Time
+	// received time of the latest change
+	last time.Time
+}
+
+func newRepairQueue() *repairQueue {
+	return &repairQueue{pending: make(map[string]*pendingRepair)}
+}
+
+func (q *repairQueue) add(vxnet string, received time.Time) {
+	p, ok := q.pending[vxnet]
+	if !ok {
+		q.pending[vxnet] = &pendingRepair{first: received, last: received}
+		return
+	}
+	if received.After(p.last) {
+		p.last = received
+	}
+}
+
+func (p *pendingRepair) dueAt() time.Time {
+	at := p.last.Add(nicRepairDebounce)
+	if max := p.first.Add(nicRepairMaxDelay); max.Before(at) {
+		return max
+	}
+	return at
+}
+
+// next returns when the first repair is due, false if nothing is pending
+func (q *repairQueue) next() (time.Time, bool) {
+	var next time.Time
+	for _, p := range q.pending {
+		if at := p.dueAt(); next.IsZero() || at.Before(next) {
+			next = at
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// due removes the repairs which are due at now, and returns the received time of their latest change by vxnet
+func (q *repairQueue) due(now time.Time) map[string]time.Time {
+	result := make(map[string]time.Time)
+	for vxnet, p := range q.pending {
+		if !now.Before(p.dueAt()) {
+			result[vxnet] = p.last
+			delete(q.pending, vxnet)
+		}
+	}
+	return result
+}
+
+// subscribeNetwork keeps the netlink subscription, until stopCh is closed
+func (a *Allocator) subscribeNetwork(stopCh <-chan struct{}, changes chan<- networkutils.NetChange) {
+	backoff := netlinkResubscribeBackoff
+	for {
+		start := time.Now()
+		err := networkutils.WatchNetChanges(stopCh, changes)
+		if err == nil {
+			return
+		}
+		netlinkSubscribeErrors.Inc()
+		if time.Since(start) > netlinkResubscribeBackoffMax {
+			backoff = netlinkResubscribeBackoff
+		}
+		log.Errorf("watch netlink changes failed, resubscribe in %v: %v", backoff, err)
+
+		select {
+		case <-stopCh:
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > netlinkResubscribeBackoffMax {
+			backoff = netlinkResubscribeBackoffMax
+		}
+		// changes may be lost meanwhile
+		a.HostNicCheck()
+	}
+}
+
+// matchNetChange returns the vxnet of the nic which is broken by change, and why.
+// Nics which are not ready yet are left to HostNicCheck.
+func (a *Allocator) matchNetChange(change networkutils.NetChange) (string, string) {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	for vxnet, nic := range a.nics {
+		brName := constants.GetHostNicBridgeName(int(nic.Nic.RouteTableNum))
+		if !nic.isOK() || change.Received.Before(a.settledAt(nic, brName)) {
+			continue
+		}
+		devName := constants.GetHostNicName(vxnet)
+		vlan := nic.Nic.VxNet.TunnelType == constants.TunnelTypeVlan
+
+		switch change.Kind {
+		case networkutils.NetChangeLink:
+			// the bridge has the hardware address of the nic
+			if change.HardwareAddr != nic.Nic.HardwareAddr && change.LinkName != devName && change.LinkName != brName {
+				continue
+			}
+			if change.Deleted {
+				return vxnet, changeLinkDeleted
+			}
+			if change.LinkName != devName && change.LinkName != brName {
+				return vxnet, changeLinkRenamed
+			}
+			if !change.Up {
+				return vxnet, changeLinkDown
+			}
+		case networkutils.NetChangeAddr:
+			// the bridge of a vlan nic gets its address by dhcp
+			if vlan && change.Deleted && change.LinkName == brName {
+				return vxnet, changeAddrDeleted
+			}
+		case networkutils.NetChangeRoute:
+			// routes of a vlan nic are removed by the repair itself
+			if !vlan && change.Deleted && change.Table == int(nic.Nic.RouteTableNum) {
+				return vxnet, changeRouteDeleted
+			}
+		case networkutils.NetChangeRule:
+			// pod rules are removed on DEL, the rule of the vxnet only with the nic
+			if change.Deleted && change.Table == int(nic.Nic.RouteTableNum) && change.Priority == constants.FromContainerRulePriority {
+				return vxnet, changeRuleDeleted
+			}
+		}
+	}
+	return "", ""
+}
+
+// repairChangedNics repairs the nics of pending, unless they were set up again since their last change
+func (a *Allocator) repairChangedNics(pending map[string]time.Time) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	for vxnet, received := range pending {
+		nic, ok := a.nics[vxnet]
+		if !ok || !nic.isOK() || received.Before(a.settledAt(nic, constants.GetHostNicBridgeName(int(nic.Nic.RouteTableNum)))) {
+			continue
+		}
+		log.Infof("hostNic %s network changed, try to repair it", getNicKey(nic.Nic))
+		a.repairHostNic(nic)
+	}
+}
+
+// settledAt returns until when the changes of nic are made by the daemon itself,
+// either by setting it up or by the dhcp lease of its bridge
+func (a *Allocator) settledAt(nic *nicStatus, bridge string) time.Time {
+	a.bridgesLock.Lock()
+	defer a.bridgesLock.Unlock()
+
+	if changed := a.bridgesChanged[bridge]; changed.After(nic.settled) {
+		return changed
+	}
+	return nic.settled
+}
+
+// recordLeaseChange is called by the lease manager after it replaced or removed the address
+// of a bridge, the ipv6 rule and routes of the nic follow the address. It is called without
+// the allocator lock, which may be held by the caller of dhcp Acquire.
+func (a *Allocator) recordLeaseChange(lease dhcp.Lease) {
+	a.bridgesLock.Lock()
+	if a.bridgesChanged == nil {
+		a.bridgesChanged = make(map[string]time.Time)
+	}
+	a.bridgesChanged[lease.Bridge] = time.Now().Add(ownChangeLag)
+	a.bridgesLock.Unlock()
+
+	if err := networkutils.UpdateIPv6RouteTable(lease); err != nil {
+		log.Errorf("failed to update ipv6 route table of link %s: %v", lease.Bridge, err)
+	}
+}
@@ -0,0 +1,114 @@
+package allocator
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/yunify/hostnic-cni/pkg/constants"
+	"github.com/yunify/hostnic-cni/pkg/dhcp"
+	"github.com/yunify/hostnic-cni/pkg/networkutils"
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+)
+
+func TestMatchNetChange(t *testing.T) {
+	settled := time.Now()
+	after := settled.Add(time.Second)
+	a := &Allocator{nics: map[string]*nicStatus{
+		"vxnet-a": {
+			Nic:     &rpc.HostNic{ID: "52:54:00:00:01:00", HardwareAddr: "52:54:00:00:01:00", VxNet: &rpc.VxNet{ID: "vxnet-a"}, RouteTableNum: 260, Phase: rpc.Phase_Succeeded},
+			settled: settled,
+		},
+		"vxnet-v": {
+			Nic:     &rpc.HostNic{ID: "52:54:00:00:02:00", HardwareAddr: "52:54:00:00:02:00", VxNet: &rpc.VxNet{ID: "vxnet-v", TunnelType: constants.TunnelTypeVlan}, RouteTableNum: 261, Phase: rpc.Phase_Succeeded},
+			settled: settled,
+		},
+		"vxnet-i": {
+			Nic:     &rpc.HostNic{ID: "52:54:00:00:03:00", HardwareAddr: "52:54:00:00:03:00", VxNet: &rpc.VxNet{ID: "vxnet-i"}, RouteTableNum: 262, Phase: rpc.Phase_Init},
+			settled: settled,
+		},
+	}}
+	devA := constants.GetHostNicName("vxnet-a")
+	brA := constants.GetHostNicBridgeName(260)
+	brV := constants.GetHostNicBridgeName(261)
+
+	for _, c := range []struct {
+		name   string
+		change networkutils.NetChange
+		vxnet  string
+		reason string
+	}{
+		{"nic deleted", networkutils.NetChange{Kind: networkutils.NetChangeLink, Received: after, Deleted: true, LinkName: devA, HardwareAddr: "52:54:00:00:01:00"}, "vxnet-a", changeLinkDeleted},
+		{"bridge deleted", networkutils.NetChange{Kind: networkutils.NetChangeLink, Received: after, Deleted: true, LinkName: brA}, "vxnet-a", changeLinkDeleted},
+		{"nic renamed", networkutils.NetChange{Kind: networkutils.NetChangeLink, Received: after, LinkName: "eth9", HardwareAddr: "52:54:00:00:01:00", Up: true}, "vxnet-a", changeLinkRenamed},
+		{"nic down", networkutils.NetChange{Kind: networkutils.NetChangeLink, Received: after, LinkName: devA, HardwareAddr: "52:54:00:00:01:00"}, "vxnet-a", changeLinkDown},
+		{"nic up", networkutils.NetChange{Kind: networkutils.NetChangeLink, Received: after, LinkName: devA, HardwareAddr: "52:54:00:00:01:00", Up: true}, "", ""},
+		{"other link", networkutils.NetChange{Kind: networkutils.NetChangeLink, Received: after, Deleted: true, LinkName: "eth9", HardwareAddr: "52:54:00:00:09:00"}, "", ""},
+		{"nic not ready", networkutils.NetChange{Kind: networkutils.NetChangeLink, Received: after, Deleted: true, HardwareAddr: "52:54:00:00:03:00"}, "", ""},
+		{"own change", networkutils.NetChange{Kind: networkutils.NetChangeLink, Received: settled.Add(-time.Millisecond), Deleted: true, LinkName: devA}, "", ""},
+		{"vlan bridge address deleted", networkutils.NetChange{Kind: networkutils.NetChangeAddr, Received: after, Deleted: true, LinkName: brV}, "vxnet-v", changeAddrDeleted},
+		{"vlan bridge address added", networkutils.NetChange{Kind: networkutils.NetChangeAddr, Received: after, LinkName: brV}, "", ""},
+		{"vxlan bridge address deleted", networkutils.NetChange{Kind: networkutils.NetChangeAddr, Received: after, Deleted: true, LinkName: brA}, "", ""},
+		{"route deleted", networkutils.NetChange{Kind: networkutils.NetChangeRoute, Received: after, Deleted: true, Table: 260}, "vxnet-a", changeRouteDeleted},
+		{"route added", networkutils.NetChange{Kind: networkutils.NetChangeRoute, Received: after, Table: 260}, "", ""},
+		{"vlan route deleted", networkutils.NetChange{Kind: networkutils.NetChangeRoute, Received: after, Deleted: true, Table: 261}, "", ""},
+		{"rule deleted", networkutils.NetChange{Kind: networkutils.NetChangeRule, Received: after, Deleted: true, Table: 260, Priority: constants.FromContainerRulePriority}, "vxnet-a", changeRuleDeleted},
+		{"pod rule deleted", networkutils.NetChange{Kind: networkutils.NetChangeRule, Received: after, Deleted: true, Table: 260, Priority: constants.FromContainerRulePriority + 1}, "", ""},
+	} {
+		vxnet, reason := a.matchNetChange(c.change)
+		if vxnet != c.vxnet || reason != c.reason {
+			t.Errorf("%s: expect %q %q, got %q %q", c.name, c.vxnet, c.reason, vxnet, reason)
+		}
+	}
+
+	// the address replaced by the dhcp lease of the bridge
+	a.recordLeaseChange(dhcp.Lease{Bridge: brV})
+	change := networkutils.NetChange{Kind: networkutils.NetChangeAddr, Received: time.Now(), Deleted: true, LinkName: brV}
+	if vxnet, reason := a.matchNetChange(change); vxnet != "" {
+		t.Errorf("expect the change of the dhcp lease ignored, got %q %q", vxnet, reason)
+	}
+	change.Received = time.Now().Add(2 * ownChangeLag)
+	if vxnet, _ := a.matchNetChange(change); vxnet != "vxnet-v" {
+		t.Errorf("expect the change after the dhcp lease matched, got %q", vxnet)
+	}
+}
+
+func TestRepairQueue(t *testing.T) {
+	start := time.Now()
+	q := newRepairQueue()
+	if _, ok := q.next(); ok {
+		t.Fatalf("expect nothing pending")
+	}
+
+	q.add("vxnet-a", start)
+	q.add("vxnet-b", start.Add(time.Second))
+	// each change of vxnet-a puts off its repair
+	q.add("vxnet-a", start.Add(1500*time.Millisecond))
+	if next, _ := q.next(); !next.Equal(start.Add(time.Second + nicRepairDebounce)) {
+		t.Fatalf("expect vxnet-b due first, got %v", next.Sub(start))
+	}
+	if due := q.due(start.Add(nicRepairDebounce)); len(due) != 0 {
+		t.Fatalf("expect nothing due while changing, got %v", due)
+	}
+	due := q.due(start.Add(time.Second + nicRepairDebounce))
+	if expect := map[string]time.Time{"vxnet-b": start.Add(time.Second)}; !reflect.DeepEqual(due, expect) {
+		t.Fatalf("expect %v due, got %v", expect, due)
+	}
+
+	// vxnet-a keeps changing, it is repaired nicRepairMaxDelay after its first change
+	for at := start.Add(2500 * time.Millisecond); at.Before(start.Add(nicRepairMaxDelay)); at = at.Add(time.Second) {
+		if due := q.due(at); len(due) != 0 {
+			t.Fatalf("expect nothing due at %v, got %v", at.Sub(start), due)
+		}
+		q.add("vxnet-a", at)
+	}
+	if next, _ := q.next(); !next.Equal(start.Add(nicRepairMaxDelay)) {
+		t.Fatalf("expect vxnet-a due at %v, got %v", nicRepairMaxDelay, next.Sub(start))
+	}
+	if due := q.due(start.Add(nicRepairMaxDelay)); len(due) != 1 || due["vxnet-a"].IsZero() {
+		t.Fatalf("expect vxnet-a due, got %v", due)
+	}
+	if _, ok := q.next(); ok {
+		t.Fatalf("expect nothing pending after the repairs")
+	}
+}
@@ -0,0 +1,154 @@
+package allocator
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	log "k8s.io/klog/v2"
+
+	"github.com/yunify/hostnic-cni/pkg/constants"
+	"github.com/yunify/hostnic-cni/pkg/events"
+	"github.com/yunify/hostnic-cni/pkg/qcclient"
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+)
+
+// ReconcileNics looks for the nics created for this instance which are not
+// recorded in db, e.g. after a crash between CreateNicsAndAttach and
+// setNicStatus. An attached nic is adopted if no nic is recorded for its vxnet
+// and inUse reports live pods in the vxnet, other nics are detached and deleted.
+// With dryRun the nics are only reported.
+//
+// The cloud is queried without the allocator lock, each nic missing from db
+// is checked again under the lock before it is adopted or deleted. A nic
+// which is being created by AllocHostNic is recorded by then, since
+// AllocHostNic holds the lock until it records the nic.
+func (a *Allocator) ReconcileNics(inUse func(vxnet *rpc.VxNet) bool, dryRun bool) error {
+	recorded := a.recordedNicIDs()
+
+	created, err := qcclient.QClient.GetCreatedNicsByName(constants.NicPrefix + qcclient.QClient.GetInstanceID())
+	if err != nil {
+		return fmt.Errorf("get created nics error: %v", err)
+	}
+	attached, err := qcclient.QClient.GetAttachedNics()
+	if err != nil {
+		return fmt.Errorf("get attached nics error: %v", err)
+	}
+	attachedIDs := make(map[string]bool)
+	for _, nic := range attached {
+		attachedIDs[nic.ID] = true
+	}
+
+	for _, nic := range created {
+		if recorded[nic.ID] {
+			continue
+		}
+		orphan, adoptable := a.checkOrphanNic(nic)
+		if !orphan {
+			continue
+		}
+
+		nicKey := getNicKey(nic)
+		adopt := attachedIDs[nic.ID] && adoptable && inUse(nic.VxNet)
+		if dryRun {
+			log.Infof("dry run, skip orphaned nic %s, attached %t, adopt %t", nicKey, attachedIDs[nic.ID], adopt)
+			continue
+		}
+		if adopt {
+			adopted, err := a.adoptOrphanNic(nic)
+			if err != nil {
+				log.Errorf("adopt orphaned nic %s failed: %v", nicKey, err)
+				orphanNics.WithLabelValues(orphanActionFailed).Inc()
+				continue
+			}
+			if adopted {
+				log.Infof("adopted orphaned nic %s with routetable num %d", nicKey, nic.RouteTableNum)
+				orphanNics.WithLabelValues(orphanActionAdopted).Inc()
+				events.NodeEventf(corev1.EventTypeNormal, events.ReasonOrphanNicAdopted,
+					"Adopted nic %s of vxnet %s which has live pods", nic.ID, nic.VxNet.ID)
+				continue
+			}
+			// recorded or its vxnet taken meanwhile
+			if orphan, _ := a.checkOrphanNic(nic); !orphan {
+				continue
+			}
+		}
+
+		if err := deleteOrphanNic(nic, attachedIDs[nic.ID]); err != nil {
+			log.Errorf("delete orphaned nic %s failed: %v", nicKey, err)
+			orphanNics.WithLabelValues(orphanActionFailed).Inc()
+			continue
+		}
+		log.Infof("deleted orphaned nic %s, attached %t", nicKey, attachedIDs[nic.ID])
+		orphanNics.WithLabelValues(orphanActionDeleted).Inc()
+		events.NodeEventf(corev1.EventTypeNormal, events.ReasonOrphanNicDeleted,
+			"Deleted nic %s of vxnet %s which is not used by hostnic", nic.ID, nic.VxNet.ID)
+	}
+
+	return nil
+}
+
+func (a *Allocator) recordedNicIDs() map[string]bool {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	ids := make(map[string]bool)
+	for _, status := range a.nics {
+		ids[status.Nic.ID] = true
+	}
+	return ids
+}
+
+// checkOrphanNic returns whether nic is still missing from db, and whether it
+// could be adopted, i.e. no nic is recorded for its vxnet and one more nic is allowed
+func (a *Allocator) checkOrphanNic(nic *rpc.HostNic) (orphan, adoptable bool) {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	return a.isOrphanNic(nic), a.nics[nic.VxNet.ID] == nil && a.canAlloc() > 0
+}
+
+func (a *Allocator) isOrphanNic(nic *rpc.HostNic) bool {
+	for _, status := range a.nics {
+		if status.Nic.ID == nic.ID {
+			return false
+		}
+	}
+	return true
+}
+
+// adoptOrphanNic records nic and sets up its network, if it is still adoptable
+func (a *Allocator) adoptOrphanNic(nic *rpc.HostNic) (bool, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if !a.isOrphanNic(nic) || a.nics[nic.VxNet.ID] != nil || a.canAlloc() <= 0 {
+		return false, nil
+	}
+	nic.Reserved = true
+	nic.RouteTableNum = a.getNicRouteTableNum(nic)
+	if err := a.setNicStatus(nic, rpc.Phase_Init); err != nil {
+		return false, err
+	}
+	// setup its network now, instead of on the next HostNicCheck
+	a.checkHostNic(a.nics[nic.VxNet.ID])
+	return true, nil
+}
+
+// deleteOrphanNic is freeHostnic without cleaning up the network, which was never setup
+// for an orphaned nic. Its route table num is unknown and may be taken by another nic.
+func deleteOrphanNic(nic *rpc.HostNic, attached bool) error {
+	if attached {
+		if _, err := qcclient.QClient.DeattachNics([]string{nic.ID}, true); err != nil {
+			if strings.Contains(err.Error(), constants.ResourceNotFound) {
+				return nil
+			}
+			return fmt.Errorf("DeattachNics error: %v", err)
+		}
+	}
+
+	if err := qcclient.QClient.DeleteNics([]string{nic.ID}); err != nil && !strings.Contains(err.Error(), constants.ResourceNotFound) {
+		return fmt.Errorf("DeleteNics error: %v", err)
+	}
+	return nil
+}
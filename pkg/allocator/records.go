@@ -0,0 +1,131 @@
+package allocator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/yunify/hostnic-cni/pkg/db"
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+)
+
+// NicRecord is a nic in db along with its pods by container id, as exported by db-client
+type NicRecord struct {
+	Nic  *rpc.HostNic            `json:"nic"`
+	Pods map[string]*rpc.PodInfo `json:"pods,omitempty"`
+}
+
+// ExportNics returns the nic records in store ordered by vxnet. Unlike restoreNics it does not
+// quarantine records, a record which cannot be decoded is an error.
+func ExportNics(store db.Store) ([]*NicRecord, error) {
+	nics := make(map[string]*nicStatus)
+	err := store.Iterate(db.NamespaceNic, func(key string, value []byte) error {
+		if err := decodeNic(nics, strings.TrimPrefix(key, db.NamespaceNic), value); err != nil {
+			return fmt.Errorf("decode record %s error: %v", key, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = store.Iterate(db.NamespacePod, func(key string, value []byte) error {
+		if err := decodePod(nics, strings.TrimPrefix(key, db.NamespacePod), value); err != nil {
+			return fmt.Errorf("decode record %s error: %v", key, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]*NicRecord, 0, len(nics))
+	for _, nic := range nics {
+		records = append(records, &NicRecord{Nic: nic.Nic, Pods: nic.Pods})
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Nic.VxNet.ID < records[j].Nic.VxNet.ID
+	})
+	return records, nil
+}
+
+// ValidateNics checks that records could have been written by the allocator
+func ValidateNics(records []*NicRecord) error {
+	vxnets := make(map[string]bool)
+	tables := make(map[int32]string)
+	for i, record := range records {
+		nic := record.Nic
+		if nic == nil || nic.VxNet == nil || nic.VxNet.ID == "" || nic.ID == "" {
+			return fmt.Errorf("record %d has no nic with id and vxnet", i)
+		}
+		nicKey := getNicKey(nic)
+		if vxnets[nic.VxNet.ID] {
+			return fmt.Errorf("nic %s: more than one nic of vxnet %s", nicKey, nic.VxNet.ID)
+		}
+		vxnets[nic.VxNet.ID] = true
+		if _, err := net.ParseMAC(nic.HardwareAddr); err != nil {
+			return fmt.Errorf("nic %s: invalid hardware addr %q", nicKey, nic.HardwareAddr)
+		}
+		if nic.RouteTableNum <= 0 {
+			return fmt.Errorf("nic %s: invalid route table num %d", nicKey, nic.RouteTableNum)
+		}
+		if other, ok := tables[nic.RouteTableNum]; ok {
+			return fmt.Errorf("nic %s: route table num %d is also used by nic %s", nicKey, nic.RouteTableNum, other)
+		}
+		tables[nic.RouteTableNum] = nicKey
+
+		for key, pod := range record.Pods {
+			if pod == nil || getContainterKey(pod) != key {
+				return fmt.Errorf("nic %s: pod of container %s has another container id", nicKey, key)
+			}
+			if pod.PodIP != "" && net.ParseIP(pod.PodIP) == nil {
+				return fmt.Errorf("nic %s: pod %s has invalid ip %q", nicKey, getPodKey(pod), pod.PodIP)
+			}
+		}
+	}
+	return nil
+}
+
+// ImportNics validates records and writes them to store in one batch. The nics and pods already
+// in store are replaced if replace is set, otherwise a nic of a vxnet already recorded is an error.
+func ImportNics(store db.Store, records []*NicRecord, replace bool) error {
+	if err := ValidateNics(records); err != nil {
+		return err
+	}
+
+	batch := new(db.Batch)
+	for _, ns := range []string{db.NamespaceNic, db.NamespacePod} {
+		err := store.Iterate(ns, func(key string, value []byte) error {
+			if replace {
+				batch.Delete(key)
+				return nil
+			}
+			for _, record := range records {
+				vxnet := record.Nic.VxNet.ID
+				if key == db.NicKey(vxnet) || strings.HasPrefix(key, db.PodKey(vxnet, "")) {
+					return fmt.Errorf("vxnet %s is already recorded by %s", vxnet, key)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, record := range records {
+		nic := nicStatus{Nic: record.Nic}
+		if err := nic.save(batch); err != nil {
+			return err
+		}
+		for key, pod := range record.Pods {
+			value, err := json.Marshal(pod)
+			if err != nil {
+				return err
+			}
+			batch.Put(db.PodKey(record.Nic.VxNet.ID, key), value)
+		}
+	}
+	return store.Write(batch)
+}
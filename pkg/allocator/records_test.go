@@ -0,0 +1,86 @@
+package allocator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yunify/hostnic-cni/pkg/db"
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+)
+
+func testNicRecord(vxnet string, table int32, containers ...string) *NicRecord {
+	record := &NicRecord{
+		Nic: &rpc.HostNic{
+			ID:            "hostnic-" + vxnet,
+			VxNet:         &rpc.VxNet{ID: vxnet},
+			HardwareAddr:  "52:54:00:00:00:01",
+			RouteTableNum: table,
+		},
+		Pods: make(map[string]*rpc.PodInfo),
+	}
+	for _, container := range containers {
+		record.Pods[container] = &rpc.PodInfo{Name: "pod-" + container, Containter: container, PodIP: "10.0.0.1"}
+	}
+	return record
+}
+
+func TestImportExportNics(t *testing.T) {
+	store := db.NewMemoryStore()
+	records := []*NicRecord{testNicRecord("vxnet-a", 260, "c1", "c2"), testNicRecord("vxnet-b", 261)}
+	if err := ImportNics(store, records, false); err != nil {
+		t.Fatalf("failed to import nics: %v", err)
+	}
+
+	exported, err := ExportNics(store)
+	if err != nil {
+		t.Fatalf("failed to export nics: %v", err)
+	}
+	if len(exported) != 2 || exported[0].Nic.ID != "hostnic-vxnet-a" || exported[1].Nic.ID != "hostnic-vxnet-b" {
+		t.Fatalf("expect nics of vxnet-a and vxnet-b exported, got %v", exported)
+	}
+	var pods []string
+	for container := range exported[0].Pods {
+		pods = append(pods, container)
+	}
+	if len(pods) != 2 || exported[0].Pods["c1"].Name != "pod-c1" {
+		t.Fatalf("expect pods c1 and c2 exported, got %v", exported[0].Pods)
+	}
+
+	if err := ImportNics(store, []*NicRecord{testNicRecord("vxnet-a", 262)}, false); err == nil {
+		t.Fatalf("expect error to import a nic of a recorded vxnet")
+	}
+	if err := ImportNics(store, []*NicRecord{testNicRecord("vxnet-c", 262)}, true); err != nil {
+		t.Fatalf("failed to replace nics: %v", err)
+	}
+	nics, err := restoreNics(store)
+	if err != nil {
+		t.Fatalf("failed to restore nics: %v", err)
+	}
+	var vxnets []string
+	for vxnet := range nics {
+		vxnets = append(vxnets, vxnet)
+	}
+	if !reflect.DeepEqual(vxnets, []string{"vxnet-c"}) {
+		t.Fatalf("expect only vxnet-c after replace, got %v", vxnets)
+	}
+}
+
+func TestValidateNics(t *testing.T) {
+	badMAC := testNicRecord("vxnet-a", 260)
+	badMAC.Nic.HardwareAddr = "hostnic"
+	badPod := testNicRecord("vxnet-a", 260, "c1")
+	badPod.Pods["c1"].Containter = "c2"
+
+	for name, records := range map[string][]*NicRecord{
+		"no nic":          {{}},
+		"bad mac":         {badMAC},
+		"no route table":  {testNicRecord("vxnet-a", 0)},
+		"same vxnet":      {testNicRecord("vxnet-a", 260), testNicRecord("vxnet-a", 261)},
+		"same routetable": {testNicRecord("vxnet-a", 260), testNicRecord("vxnet-b", 260)},
+		"bad pod":         {badPod},
+	} {
+		if err := ValidateNics(records); err == nil {
+			t.Errorf("expect %s invalid", name)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package allocator
+
+import (
+	"errors"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"golang.org/x/sys/unix"
+	log "k8s.io/klog/v2"
+
+	"github.com/yunify/hostnic-cni/pkg/networkutils"
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+)
+
+type sandboxState int
+
+const (
+	sandboxUnknown sandboxState = iota
+	sandboxExists
+	sandboxGone
+)
+
+// procNetnsPath matches the netns path of a sandbox process, e.g. /proc/1234/ns/net of dockershim
+var procNetnsPath = regexp.MustCompile(`^/proc/[0-9]+/ns/net$`)
+
+// inode of the initial pid namespace, PROC_PID_INIT_INO of the kernel
+const hostPIDNamespaceIno = 0xEFFFFFFC
+
+// inHostPIDNamespace returns whether pids under /proc are those of the host,
+// hostnic-node runs with hostPID for that
+var inHostPIDNamespace = func() bool {
+	var stat unix.Stat_t
+	if err := unix.Stat("/proc/self/ns/pid", &stat); err != nil {
+		return false
+	}
+	return stat.Ino == hostPIDNamespaceIno
+}
+
+// isHostNetns returns whether netns is the netns of hostnic-node, which runs with hostNetwork
+func isHostNetns(netns string) bool {
+	host, err := os.Stat("/proc/self/ns/net")
+	if err != nil {
+		return false
+	}
+	info, err := os.Stat(netns)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(host, info)
+}
+
+// getSandboxState checks the netns of a pod recorded by AddNetwork. It is
+// either a bind mount under /var/run/netns or the /proc path of a sandbox
+// process, which can only be checked in the host pid namespace.
+func getSandboxState(netns string) sandboxState {
+	if netns == "" {
+		return sandboxUnknown
+	}
+	isProc := strings.HasPrefix(netns, "/proc/")
+	if isProc && (!procNetnsPath.MatchString(netns) || !inHostPIDNamespace()) {
+		return sandboxUnknown
+	}
+
+	err := networkutils.NetworkHelper.IsNSorErr(netns)
+	if err == nil {
+		// after a reboot the pid may be taken by a process of the host
+		if isProc && isHostNetns(netns) {
+			return sandboxGone
+		}
+		return sandboxExists
+	}
+	var notExist ns.NSPathNotExistErr
+	var notNS ns.NSPathNotNSErr
+	if errors.As(err, &notExist) || errors.As(err, &notNS) {
+		return sandboxGone
+	}
+	log.Warningf("failed to check netns %s: %v", netns, err)
+	return sandboxUnknown
+}
+
+// restorePodNetwork sets up the rules of the pods on nic again. They are lost
+// on node reboot, while the bridge and route table of the nic are rebuilt by
+// CheckAndRepairNetwork. Rules of the pods whose sandbox is gone are removed,
+// their records are kept for DEL to release the ip.
+func (a *Allocator) restorePodNetwork(nic *nicStatus) {
+	nicKey := getNicKey(nic.Nic)
+	var gone []*rpc.PodInfo
+	for _, pod := range nic.Pods {
+		if pod.PodIP == "" {
+			continue
+		}
+		if getSandboxState(pod.Netns) == sandboxGone {
+			gone = append(gone, pod)
+			continue
+		}
+
+		if err := networkutils.NetworkHelper.SetupPodNetwork(nic.Nic, pod.PodIP); err != nil {
+			log.Errorf("restore pod network %s %s ip %s failed: %v", nicKey, getPodKey(pod), pod.PodIP, err)
+			podRestores.WithLabelValues(restoreActionFailed).Inc()
+			continue
+		}
+		log.V(4).Infof("restore pod network %s %s ip %s", nicKey, getPodKey(pod), pod.PodIP)
+		podRestores.WithLabelValues(restoreActionRestored).Inc()
+	}
+
+	for _, pod := range gone {
+		// the ip may have been assigned again to a running sandbox
+		if a.ipInUse(pod.PodIP) {
+			continue
+		}
+		if err := networkutils.NetworkHelper.CleanupPodNetwork(nic.Nic, pod.PodIP); err != nil {
+			log.Errorf("prune pod network %s %s ip %s failed: %v", nicKey, getPodKey(pod), pod.PodIP, err)
+			podRestores.WithLabelValues(restoreActionFailed).Inc()
+			continue
+		}
+		log.Infof("prune pod network %s %s ip %s, sandbox %s is gone", nicKey, getPodKey(pod), pod.PodIP, pod.Netns)
+		podRestores.WithLabelValues(restoreActionPruned).Inc()
+	}
+}
+
+// ipInUse returns whether a pod with a sandbox which is not gone has ip
+func (a *Allocator) ipInUse(ip string) bool {
+	for _, status := range a.nics {
+		for _, pod := range status.Pods {
+			if pod.PodIP == ip && getSandboxState(pod.Netns) != sandboxGone {
+				return true
+			}
+		}
+	}
+	return false
+}
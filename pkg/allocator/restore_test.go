@@ -0,0 +1,130 @@
+package allocator
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+
+	"github.com/yunify/hostnic-cni/pkg/networkutils"
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+)
+
+// sandboxNetwork knows the netns of the sandboxes and records the pod rules set up and cleaned up
+type sandboxNetwork struct {
+	networkutils.NetworkUtilsFake
+	netns   map[string]error
+	setup   *[]string
+	cleanup *[]string
+}
+
+func (n sandboxNetwork) IsNSorErr(nspath string) error {
+	if err, ok := n.netns[nspath]; ok {
+		return err
+	}
+	return ns.NSPathNotExistErr{}
+}
+
+func (n sandboxNetwork) SetupPodNetwork(nic *rpc.HostNic, ip string) error {
+	*n.setup = append(*n.setup, ip)
+	return nil
+}
+
+func (n sandboxNetwork) CleanupPodNetwork(nic *rpc.HostNic, ip string) error {
+	*n.cleanup = append(*n.cleanup, ip)
+	return nil
+}
+
+func TestGetSandboxState(t *testing.T) {
+	helper := networkutils.NetworkHelper
+	hostPID := inHostPIDNamespace
+	defer func() {
+		networkutils.NetworkHelper = helper
+		inHostPIDNamespace = hostPID
+	}()
+	self := fmt.Sprintf("/proc/%d/ns/net", os.Getpid())
+	networkutils.NetworkHelper = sandboxNetwork{netns: map[string]error{
+		"/var/run/netns/cni-a": nil,
+		"/proc/100/ns/net":     nil,
+		"/var/run/netns/file":  ns.NSPathNotNSErr{},
+		"/var/run/netns/err":   fmt.Errorf("permission denied"),
+		self:                   nil,
+	}}
+
+	for _, c := range []struct {
+		netns   string
+		hostPID bool
+		state   sandboxState
+	}{
+		{"", true, sandboxUnknown},
+		{"/var/run/netns/cni-a", false, sandboxExists},
+		{"/var/run/netns/cni-b", false, sandboxGone},
+		{"/var/run/netns/file", false, sandboxGone},
+		{"/var/run/netns/err", false, sandboxUnknown},
+		{"/proc/100/ns/net", true, sandboxExists},
+		{"/proc/101/ns/net", true, sandboxGone},
+		{"/proc/101/ns/net", false, sandboxUnknown},
+		{"/proc/101/ns/ipc", true, sandboxUnknown},
+		// the pid is reused by a process of the host netns
+		{self, true, sandboxGone},
+	} {
+		inHostPIDNamespace = func() bool { return c.hostPID }
+		if state := getSandboxState(c.netns); state != c.state {
+			t.Errorf("netns %q host pid %v: expect state %d, got %d", c.netns, c.hostPID, c.state, state)
+		}
+	}
+}
+
+func TestRestorePodNetwork(t *testing.T) {
+	helper := networkutils.NetworkHelper
+	hostPID := inHostPIDNamespace
+	defer func() {
+		networkutils.NetworkHelper = helper
+		inHostPIDNamespace = hostPID
+	}()
+	inHostPIDNamespace = func() bool { return true }
+	var setup, cleanup []string
+	networkutils.NetworkHelper = sandboxNetwork{
+		netns: map[string]error{
+			"/var/run/netns/cni-a": nil,
+			"/proc/100/ns/net":     nil,
+			"/proc/102/ns/net":     nil,
+		},
+		setup:   &setup,
+		cleanup: &cleanup,
+	}
+
+	nic := &rpc.HostNic{ID: "hostnic-a", VxNet: &rpc.VxNet{ID: "vxnet-a"}}
+	status := &nicStatus{Nic: nic, Pods: map[string]*rpc.PodInfo{
+		"c1": {Containter: "c1", PodIP: "192.168.0.11", Netns: "/var/run/netns/cni-a"},
+		"c2": {Containter: "c2", PodIP: "192.168.0.12", Netns: "/proc/100/ns/net"},
+		"c3": {Containter: "c3", PodIP: "192.168.0.13", Netns: "/var/run/netns/cni-gone"},
+		"c4": {Containter: "c4", PodIP: "192.168.0.14", Netns: "/proc/101/ns/net"},
+		// the ip of a gone sandbox taken by a running one
+		"c5": {Containter: "c5", PodIP: "192.168.0.15", Netns: "/proc/103/ns/net"},
+		"c6": {Containter: "c6", PodIP: "192.168.0.15", Netns: "/proc/102/ns/net"},
+		// not set up yet
+		"c7": {Containter: "c7", Netns: "/var/run/netns/cni-a"},
+	}}
+	a := &Allocator{
+		nics:   map[string]*nicStatus{"vxnet-a": status},
+		events: newEventLog(),
+	}
+	a.restorePodNetwork(status)
+
+	sort.Strings(setup)
+	sort.Strings(cleanup)
+	if expect := []string{"192.168.0.11", "192.168.0.12", "192.168.0.15"}; !reflect.DeepEqual(setup, expect) {
+		t.Errorf("expect pod network of %v restored, got %v", expect, setup)
+	}
+	if expect := []string{"192.168.0.13", "192.168.0.14"}; !reflect.DeepEqual(cleanup, expect) {
+		t.Errorf("expect pod network of %v pruned, got %v", expect, cleanup)
+	}
+	// records are kept for DEL to release the ip
+	if len(status.Pods) != 7 {
+		t.Errorf("expect pod records kept, got %d", len(status.Pods))
+	}
+}
@@ -0,0 +1,118 @@
+package allocator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	listers "github.com/yunify/hostnic-cni/pkg/client/listers/network/v1alpha1"
+	"github.com/yunify/hostnic-cni/pkg/constants"
+	"github.com/yunify/hostnic-cni/pkg/qcclient"
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+)
+
+// sources of the vxnet metadata looked up by the allocator
+const (
+	vxnetSourceNic   = "nic"
+	vxnetSourcePool  = "pool"
+	vxnetSourceCache = "cache"
+	vxnetSourceAPI   = "api"
+)
+
+type vxnetEntry struct {
+	vxnet   *rpc.VxNet
+	expires time.Time
+}
+
+// vxnetCache looks up the metadata of a vxnet in the status of the vxnetpool, where it is
+// published by the controller, then in the vxnets got from the api within ttl. The api is
+// called only if both miss, e.g. before the controller synced a new vxnet.
+type vxnetCache struct {
+	pools listers.VxNetPoolLister
+	ttl   time.Duration
+	now   func() time.Time
+
+	lock    sync.Mutex
+	entries map[string]vxnetEntry
+}
+
+// newVxNetCache returns a cache of the vxnets in the status of pools, pools may be nil if no
+// informer is running. The vxnets got from the api are not cached if ttl is not positive.
+func newVxNetCache(pools listers.VxNetPoolLister, ttl time.Duration) *vxnetCache {
+	return &vxnetCache{
+		pools:   pools,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]vxnetEntry),
+	}
+}
+
+func (c *vxnetCache) fromPool(id string) *rpc.VxNet {
+	if c.pools == nil {
+		return nil
+	}
+	pool, err := c.pools.Get(constants.IPAMVxnetPoolName)
+	if err != nil {
+		return nil
+	}
+	for _, info := range pool.Status.Pools {
+		if info.Name == id && info.VxNet != nil {
+			return &rpc.VxNet{
+				ID:         id,
+				Gateway:    info.VxNet.Gateway,
+				Network:    info.VxNet.Network,
+				IPStart:    info.VxNet.IPStart,
+				IPEnd:      info.VxNet.IPEnd,
+				TunnelType: info.VxNet.TunnelType,
+			}
+		}
+	}
+	return nil
+}
+
+func (c *vxnetCache) fromCache(id string) *rpc.VxNet {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entry, ok := c.entries[id]
+	if !ok {
+		return nil
+	}
+	if !c.now().Before(entry.expires) {
+		delete(c.entries, id)
+		return nil
+	}
+	return proto.Clone(entry.vxnet).(*rpc.VxNet)
+}
+
+// get returns the vxnet of id along with the source which served it
+func (c *vxnetCache) get(ctx context.Context, id string) (*rpc.VxNet, string, error) {
+	if v := c.fromPool(id); v != nil {
+		return v, vxnetSourcePool, nil
+	}
+	if v := c.fromCache(id); v != nil {
+		return v, vxnetSourceCache, nil
+	}
+
+	result, err := qcclient.WithContext(ctx).GetVxNets([]string{id}, 0)
+	if err != nil {
+		return nil, vxnetSourceAPI, err
+	}
+	v, ok := result[id]
+	if !ok {
+		return nil, vxnetSourceAPI, fmt.Errorf("get vxnet %s from qingcloud: not found", id)
+	}
+
+	if c.ttl > 0 {
+		c.lock.Lock()
+		c.entries[id] = vxnetEntry{
+			vxnet:   proto.Clone(v).(*rpc.VxNet),
+			expires: c.now().Add(c.ttl),
+		}
+		c.lock.Unlock()
+	}
+	return v, vxnetSourceAPI, nil
+}
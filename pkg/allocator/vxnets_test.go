@@ -0,0 +1,80 @@
+package allocator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1alpha1 "github.com/yunify/hostnic-cni/pkg/apis/network/v1alpha1"
+	clientfake "github.com/yunify/hostnic-cni/pkg/client/clientset/versioned/fake"
+	informers "github.com/yunify/hostnic-cni/pkg/client/informers/externalversions"
+	"github.com/yunify/hostnic-cni/pkg/constants"
+	"github.com/yunify/hostnic-cni/pkg/qcclient/fake"
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+)
+
+func TestVxNetCache(t *testing.T) {
+	cloud := fake.NewCloud(testInstance)
+	cloud.AddVxNet(&rpc.VxNet{ID: "vxnet-b", Gateway: "192.168.1.1", Network: "192.168.1.0/24", IPStart: "192.168.1.2", IPEnd: "192.168.1.250"})
+	fake.Setup(cloud)
+
+	pool := &networkv1alpha1.VxNetPool{
+		ObjectMeta: metav1.ObjectMeta{Name: constants.IPAMVxnetPoolName},
+		Status: networkv1alpha1.VxNetPoolStatus{
+			Pools: []networkv1alpha1.PoolInfo{
+				{Name: "vxnet-a", IPPool: "vxnet-a", VxNet: &networkv1alpha1.VxNetStatus{
+					Network:    "192.168.0.0/24",
+					Gateway:    "192.168.0.1",
+					IPStart:    "192.168.0.2",
+					IPEnd:      "192.168.0.200",
+					TunnelType: constants.TunnelTypeVlan,
+				}},
+				// not synced by the controller yet
+				{Name: "vxnet-b", IPPool: "vxnet-b"},
+			},
+		},
+	}
+	factory := informers.NewSharedInformerFactory(clientfake.NewSimpleClientset(), 0)
+	pools := factory.Network().V1alpha1().VxNetPools()
+	if err := pools.Informer().GetIndexer().Add(pool); err != nil {
+		t.Fatalf("failed to add vxnetpool: %v", err)
+	}
+
+	c := newVxNetCache(pools.Lister(), time.Minute)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	v, source, err := c.get(ctx, "vxnet-a")
+	if err != nil || source != vxnetSourcePool || v.Gateway != "192.168.0.1" || v.TunnelType != constants.TunnelTypeVlan {
+		t.Fatalf("expect vxnet-a from the pool, got %v %s %v", v, source, err)
+	}
+
+	for _, expect := range []string{vxnetSourceAPI, vxnetSourceCache} {
+		if v, source, err := c.get(ctx, "vxnet-b"); err != nil || source != expect || v.Network != "192.168.1.0/24" {
+			t.Fatalf("expect vxnet-b from %s, got %v %s %v", expect, v, source, err)
+		}
+	}
+	if calls := cloud.Calls("GetVxNets"); calls != 1 {
+		t.Fatalf("expect GetVxNets called once, got %d", calls)
+	}
+
+	now = now.Add(time.Minute)
+	if _, source, _ := c.get(ctx, "vxnet-b"); source != vxnetSourceAPI {
+		t.Fatalf("expect vxnet-b from the api after ttl, got %s", source)
+	}
+
+	if _, _, err := c.get(ctx, "vxnet-c"); err == nil {
+		t.Fatalf("expect error of an unknown vxnet")
+	}
+
+	// without informer and ttl every lookup calls the api
+	c = newVxNetCache(nil, 0)
+	c.get(ctx, "vxnet-b")
+	c.get(ctx, "vxnet-b")
+	if calls := cloud.Calls("GetVxNets"); calls != 5 {
+		t.Fatalf("expect GetVxNets called for each lookup, got %d", calls)
+	}
+}
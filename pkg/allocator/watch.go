@@ -0,0 +1,134 @@
+package allocator
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+)
+
+// number of events kept for watchers to resume from
+const eventHistorySize = 1024
+
+var (
+	ErrEventsCompacted = errors.New("events after the sequence have been compacted, list and watch again")
+	ErrSequenceAhead   = errors.New("sequence is ahead of the daemon, list and watch again")
+	ErrEpochChanged    = errors.New("epoch of the sequence is gone, the daemon has restarted, list and watch again")
+)
+
+// Event is a state change of the allocator, only one of Nic and Pod is set
+type Event struct {
+	Sequence uint64
+	Nic      *rpc.NicEvent
+	Pod      *rpc.PodEvent
+}
+
+type eventLog struct {
+	// sequences restart from 0 with the daemon, the epoch tells them apart
+	epoch   string
+	lock    sync.Mutex
+	seq     uint64
+	history []*Event
+	// closed and replaced when an event is added
+	notify chan struct{}
+}
+
+func newEventLog() *eventLog {
+	return &eventLog{
+		epoch:  strconv.FormatInt(time.Now().UnixNano(), 36),
+		notify: make(chan struct{}),
+	}
+}
+
+func (l *eventLog) add(e *Event) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.seq++
+	e.Sequence = l.seq
+	if e.Nic != nil {
+		e.Nic.Sequence = l.seq
+		e.Nic.Epoch = l.epoch
+	}
+	if e.Pod != nil {
+		e.Pod.Sequence = l.seq
+		e.Pod.Epoch = l.epoch
+	}
+
+	l.history = append(l.history, e)
+	if len(l.history) > eventHistorySize {
+		l.history = l.history[len(l.history)-eventHistorySize:]
+	}
+	close(l.notify)
+	l.notify = make(chan struct{})
+}
+
+func (l *eventLog) latest() uint64 {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.seq
+}
+
+// since returns the events after seq of epoch, and a channel which is closed when the next event is added
+func (l *eventLog) since(epoch string, seq uint64) ([]*Event, <-chan struct{}, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if seq > 0 && epoch != l.epoch {
+		return nil, nil, ErrEpochChanged
+	}
+	if seq > l.seq {
+		return nil, nil, ErrSequenceAhead
+	}
+	oldest := l.seq - uint64(len(l.history))
+	if seq < oldest {
+		return nil, nil, ErrEventsCompacted
+	}
+
+	events := make([]*Event, l.seq-seq)
+	copy(events, l.history[uint64(len(l.history))-(l.seq-seq):])
+	return events, l.notify, nil
+}
+
+func (a *Allocator) emitNicEvent(t rpc.NicEventType, nic *rpc.HostNic, prev rpc.Phase, msg string) {
+	a.events.add(&Event{
+		Nic: &rpc.NicEvent{
+			Type:      t,
+			Nic:       proto.Clone(nic).(*rpc.HostNic),
+			PrevPhase: prev,
+			Message:   msg,
+		},
+	})
+}
+
+func (a *Allocator) emitPodEvent(t rpc.PodEventType, nic *rpc.HostNic, pod *rpc.PodInfo) {
+	a.events.add(&Event{
+		Pod: &rpc.PodEvent{
+			Type: t,
+			Pod:  proto.Clone(pod).(*rpc.PodInfo),
+			Nic:  proto.Clone(nic).(*rpc.HostNic),
+		},
+	})
+}
+
+// LatestSequence returns the sequence of the last event
+func (a *Allocator) LatestSequence() uint64 {
+	return a.events.latest()
+}
+
+// Epoch returns the epoch of the event sequences, which changes when the daemon restarts
+func (a *Allocator) Epoch() string {
+	return a.events.epoch
+}
+
+// EventsSince returns the events after seq of epoch, and a channel which is closed when more events
+// are available. Watchers should list and watch again on ErrEventsCompacted, ErrSequenceAhead or
+// ErrEpochChanged.
+func (a *Allocator) EventsSince(epoch string, seq uint64) ([]*Event, <-chan struct{}, error) {
+	return a.events.since(epoch, seq)
+}
@@ -0,0 +1,64 @@
+package allocator
+
+import (
+	"testing"
+
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+)
+
+func TestEventLogSince(t *testing.T) {
+	l := newEventLog()
+	for i := 0; i < eventHistorySize+10; i++ {
+		l.add(&Event{Nic: &rpc.NicEvent{Type: rpc.NicEventType_NicPhase}})
+	}
+	latest := l.latest()
+	if latest != eventHistorySize+10 {
+		t.Fatalf("expect sequence %d, got %d", eventHistorySize+10, latest)
+	}
+
+	// resume
+	events, next, err := l.since(l.epoch, latest-2)
+	if err != nil || len(events) != 2 || events[0].Sequence != latest-1 || events[1].Sequence != latest {
+		t.Fatalf("expect the last 2 events, got %v %v", events, err)
+	}
+	if e := events[1].Nic; e.Sequence != latest || e.Epoch != l.epoch {
+		t.Fatalf("unexpected nic event %v", e)
+	}
+	if events, _, err := l.since(l.epoch, latest); err != nil || len(events) != 0 {
+		t.Fatalf("expect no events after the latest, got %v %v", events, err)
+	}
+	// the oldest event kept
+	if events, _, err := l.since(l.epoch, 10); err != nil || len(events) != eventHistorySize {
+		t.Fatalf("expect all events kept, got %d %v", len(events), err)
+	}
+
+	l.add(&Event{Pod: &rpc.PodEvent{}})
+	select {
+	case <-next:
+	default:
+		t.Fatalf("expect next closed after an event is added")
+	}
+
+	for _, c := range []struct {
+		name  string
+		epoch string
+		seq   uint64
+		err   error
+	}{
+		{"compacted", l.epoch, 10, ErrEventsCompacted},
+		{"ahead", l.epoch, latest + 2, ErrSequenceAhead},
+		{"restarted", "other", 1, ErrEpochChanged},
+		{"no epoch", "", 1, ErrEpochChanged},
+	} {
+		if _, _, err := l.since(c.epoch, c.seq); err != c.err {
+			t.Errorf("%s: expect %v, got %v", c.name, c.err, err)
+		}
+	}
+
+	// the daemon restarted, sequences start over in a new epoch
+	restarted := newEventLog()
+	restarted.add(&Event{Pod: &rpc.PodEvent{}})
+	if _, _, err := restarted.since(l.epoch, 1); err != ErrEpochChanged {
+		t.Fatalf("expect %v after restart, got %v", ErrEpochChanged, err)
+	}
+}
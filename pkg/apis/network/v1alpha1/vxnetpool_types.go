@@ -58,6 +58,18 @@ type PoolInfo struct {
 	Name    string   `json:"name"`
 	IPPool  string   `json:"ippool"`
 	Subnets []string `json:"subnets,omitempty"`
+	// metadata of the vxnet, nodes read it instead of the cloud api
+	// +optional
+	VxNet *VxNetStatus `json:"vxnet,omitempty"`
+}
+
+// VxNetStatus is the metadata of a vxnet got by the controller from the cloud api
+type VxNetStatus struct {
+	Network    string `json:"network"`
+	Gateway    string `json:"gateway"`
+	IPStart    string `json:"ipStart"`
+	IPEnd      string `json:"ipEnd"`
+	TunnelType string `json:"tunnelType,omitempty"`
 }
 
 // VxNetPoolStatus is the status for a VxNetPool resource
@@ -364,6 +364,11 @@ func (in *PoolInfo) DeepCopyInto(out *PoolInfo) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.VxNet != nil {
+		in, out := &in.VxNet, &out.VxNet
+		*out = new(VxNetStatus)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolInfo.
@@ -532,6 +537,21 @@ func (in *VxNetPoolStatus) DeepCopy() *VxNetPoolStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VxNetStatus) DeepCopyInto(out *VxNetStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VxNetStatus.
+func (in *VxNetStatus) DeepCopy() *VxNetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VxNetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VxnetInfo) DeepCopyInto(out *VxnetInfo) {
 	*out = *in
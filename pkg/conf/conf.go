@@ -8,11 +8,13 @@ import (
 	"k8s.io/apimachinery/pkg/util/yaml"
 
 	"github.com/yunify/hostnic-cni/pkg/constants"
+	"github.com/yunify/hostnic-cni/pkg/tracing"
 )
 
 type IpamConf struct {
-	Pool   PoolConf   `json:"pool,omitempty" yaml:"pool,omitempty"`
-	Server ServerConf `json:"server,omitempty" yaml:"server,omitempty"`
+	Pool    PoolConf       `json:"pool,omitempty" yaml:"pool,omitempty"`
+	Server  ServerConf     `json:"server,omitempty" yaml:"server,omitempty"`
+	Tracing tracing.Config `json:"tracing,omitempty" yaml:"tracing,omitempty"`
 }
 
 type PoolConf struct {
@@ -23,16 +25,35 @@ type PoolConf struct {
 	RouteTableBase int      `json:"routeTableBase,omitempty" yaml:"routeTableBase,omitempty"`
 	Tag            string   `json:"tag,omitempty" yaml:"tag,omitempty"`
 	VxNets         []string `json:"vxNets,omitempty" yaml:"vxNets,omitempty"`
+	// vlan vxnets with ipv6, their bridges get ipv6 addresses by dhcpv6. Other vlan vxnets are ipv4 only.
+	IPv6VxNets []string `json:"ipv6VxNets,omitempty" yaml:"ipv6VxNets,omitempty"`
 
 	//free hostnic opts
 	NodeThreshold  int `json:"nodeThreshold,omitempty" yaml:"nodeThreshold,omitempty"`
 	VxnetThreshold int `json:"vxnetThreshold,omitempty" yaml:"vxnetThreshold,omitempty"`
 	FreePeriod     int `json:"freePeriod,omitempty" yaml:"freePeriod,omitempty"`
+
+	//minutes to cache the vxnets got from the api, 0 to disable
+	VxNetCacheTTL int `json:"vxnetCacheTTL,omitempty" yaml:"vxnetCacheTTL,omitempty"`
+
+	//qingcloud api rate limit and retries, see qcclient.Options
+	APIQPS        float64 `json:"apiQPS,omitempty" yaml:"apiQPS,omitempty"`
+	APIBurst      int     `json:"apiBurst,omitempty" yaml:"apiBurst,omitempty"`
+	APIMaxRetries int     `json:"apiMaxRetries,omitempty" yaml:"apiMaxRetries,omitempty"`
+
+	//provider of nics and vxnets, qingcloud or static, see qcclient.Options
+	Provider             string `json:"provider,omitempty" yaml:"provider,omitempty"`
+	StaticProviderConfig string `json:"staticProviderConfig,omitempty" yaml:"staticProviderConfig,omitempty"`
+	StaticProviderVxNets string `json:"staticProviderVxNets,omitempty" yaml:"staticProviderVxNets,omitempty"`
 }
 
 type ServerConf struct {
 	ServerPath    string `json:"serverPath,omitempty" yaml:"serverPath,omitempty"`
 	NetworkPolicy string `json:"networkPolicy,omitempty" yaml:"networkPolicy,omitempty"`
+
+	//socket authorization, root is always allowed to call all methods
+	AllowedUIDs   []uint32 `json:"allowedUIDs,omitempty" yaml:"allowedUIDs,omitempty"`
+	ReadOnlyGroup string   `json:"readOnlyGroup,omitempty" yaml:"readOnlyGroup,omitempty"`
 }
 
 // TryLoadFromDisk loads configuration from default location after server startup
@@ -52,6 +73,7 @@ func TryLoadIpamConfFromDisk(name, path string) (*IpamConf, error) {
 			NodeThreshold:  constants.DefaultNodeThreshold,
 			VxnetThreshold: constants.DefaultVxnetThreshold,
 			FreePeriod:     constants.DefaultFreePeriod,
+			VxNetCacheTTL:  constants.DefaultVxNetCacheTTL,
 		},
 		Server: ServerConf{
 			ServerPath: constants.DefaultSocketPath,
@@ -22,10 +22,10 @@ import (
 	"errors"
 	"fmt"
 	"strings"
-	"time"
 
 	"github.com/containernetworking/cni/pkg/types"
 	"github.com/yunify/hostnic-cni/pkg/rpc"
+	"github.com/yunify/hostnic-cni/pkg/tracing"
 )
 
 const (
@@ -43,6 +43,8 @@ const (
 	DefaultVxnetThreshold = 128
 	// Minute
 	DefaultFreePeriod = 12 * 60
+	// Minute
+	DefaultVxNetCacheTTL = 10
 
 	VIPNumLimit           = 253
 	NicNumLimit           = 63
@@ -142,6 +144,8 @@ type NetConf struct {
 	NatMark  string `json:"natMark,omitempty"`
 	LogLevel int    `json:"logLevel,omitempty"`
 	LogFile  string `json:"logFile,omitempty"`
+	// trace exporter of the plugin, disabled by default
+	Tracing tracing.Config `json:"tracing,omitempty"`
 }
 
 // K8sArgs is the valid CNI_ARGS used for Kubernetes
@@ -158,7 +162,4 @@ type K8sArgs struct {
 var (
 	ErrNoAvailableNIC = errors.New("no free nic")
 	ErrNicNotFound    = errors.New("hostnic not found")
-
-	LastIPAddrRenewPeriod = 60 * 60 * time.Second //s, default 1h
-	IpAddrReNewTicker     = time.NewTicker(LastIPAddrRenewPeriod)
 )
@@ -315,11 +315,22 @@ func (c *VxNetPoolController) getPools(pool *networkv1alpha1.VxNetPool) ([]netwo
 					subnets = append(subnets, item.Name)
 				}
 			}
-			pools = append(pools, networkv1alpha1.PoolInfo{
+			info := networkv1alpha1.PoolInfo{
 				Name:    vxnet.Name,
 				IPPool:  vxnet.Name,
 				Subnets: subnets,
-			})
+			}
+			// published for the nodes, which read it instead of the cloud api
+			if v, ok := c.getVxNetInfo(vxnet.Name); ok {
+				info.VxNet = &networkv1alpha1.VxNetStatus{
+					Network:    v.Network,
+					Gateway:    v.Gateway,
+					IPStart:    v.IPStart,
+					IPEnd:      v.IPEnd,
+					TunnelType: v.TunnelType,
+				}
+			}
+			pools = append(pools, info)
 		}
 	}
 
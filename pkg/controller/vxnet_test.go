@@ -0,0 +1,156 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sinformers "k8s.io/client-go/informers"
+
+	networkv1alpha1 "github.com/yunify/hostnic-cni/pkg/apis/network/v1alpha1"
+	"github.com/yunify/hostnic-cni/pkg/client/clientset/versioned/fake"
+	informers "github.com/yunify/hostnic-cni/pkg/client/informers/externalversions"
+	"github.com/yunify/hostnic-cni/pkg/conf"
+	"github.com/yunify/hostnic-cni/pkg/constants"
+	qcfake "github.com/yunify/hostnic-cni/pkg/qcclient/fake"
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+)
+
+func newTestController(t *testing.T, clusterConf *conf.ClusterConfig) (*VxNetPoolController, *networkv1alpha1.VxNetPool) {
+	pool := &networkv1alpha1.VxNetPool{
+		ObjectMeta: metav1.ObjectMeta{Name: constants.IPAMVxnetPoolName},
+		Spec: networkv1alpha1.VxNetPoolSpec{
+			Vxnets:    []networkv1alpha1.VxnetInfo{{Name: "vxnet-a"}},
+			BlockSize: 26,
+		},
+	}
+	client := fake.NewSimpleClientset(pool)
+	factory := informers.NewSharedInformerFactory(client, 0)
+	c := NewVxNetPoolController(clusterConf, nil, client, factory, k8sinformers.NewSharedInformerFactory(nil, 0))
+	if err := factory.Network().V1alpha1().VxNetPools().Informer().GetIndexer().Add(pool); err != nil {
+		t.Fatalf("failed to add vxnetpool: %v", err)
+	}
+	return c, pool
+}
+
+func TestPrepareQcloudResource(t *testing.T) {
+	cloud := qcfake.NewCloud("i-test")
+	cloud.AddVxNet(&rpc.VxNet{
+		ID:      "vxnet-a",
+		Gateway: "192.168.0.1",
+		Network: "192.168.0.0/24",
+		IPStart: "192.168.0.2",
+		IPEnd:   "192.168.0.30",
+	})
+	cloud.AddCluster("cl-test", "sg-test")
+	qcfake.Setup(cloud)
+
+	c, pool := newTestController(t, &conf.ClusterConfig{ClusterID: "cl-test"})
+	if ready, err := c.prepareQcloudResource(pool); err != nil || ready {
+		t.Fatalf("expect not ready before the vxnet is synced, got %t %v", ready, err)
+	}
+
+	c.qingCloudSync()
+	if v, ok := c.getVxNetInfo("vxnet-a"); !ok || v.IPEnd != "192.168.0.18" {
+		t.Fatalf("expect vxnet-a synced with its vip range, got %v", v)
+	}
+	if c.conf.SecurityGroup != "sg-test" {
+		t.Fatalf("expect security group of the cluster, got %q", c.conf.SecurityGroup)
+	}
+
+	// the vips are created by a job
+	cloud.SetJobLatency(time.Hour)
+	if ready, err := c.prepareQcloudResource(pool); err != nil || ready {
+		t.Fatalf("expect not ready while creating vips, got %t %v", ready, err)
+	}
+	if _, ok := c.getJob(keyForVxNetVIP("vxnet-a")); !ok {
+		t.Fatalf("expect job to create vips of vxnet-a")
+	}
+	c.prepareQcloudResource(pool)
+	if calls := cloud.Calls("CreateVIPs"); calls != 1 {
+		t.Fatalf("expect vips created once while the job is working, got %d", calls)
+	}
+	c.qingCloudSync()
+	if _, ok := c.getVxNetVIPInfo("vxnet-a"); ok {
+		t.Fatalf("vips synced before the job is done")
+	}
+
+	cloud.CompleteJobs()
+	c.qingCloudSync()
+	vips, ok := c.getVxNetVIPInfo("vxnet-a")
+	if !ok || len(vips) != 17 {
+		t.Fatalf("expect 17 vips of vxnet-a, got %v", vips)
+	}
+	if _, ok := c.getJob(keyForVxNetVIP("vxnet-a")); ok {
+		t.Fatalf("job to create vips left after they are synced")
+	}
+
+	// then the security group rule
+	if ready, err := c.prepareQcloudResource(pool); err != nil || ready {
+		t.Fatalf("expect not ready while creating the security group rule, got %t %v", ready, err)
+	}
+	c.qingCloudSync()
+	if _, ok := c.getSecurityGroupRule(keyForVxNetSG("sg-test", "vxnet-a")); !ok {
+		t.Fatalf("expect security group rule of vxnet-a synced")
+	}
+	if ready, err := c.prepareQcloudResource(pool); err != nil || !ready {
+		t.Fatalf("expect ready, got %t %v", ready, err)
+	}
+	if calls := cloud.Calls("CreateSecurityGroupRuleForVxNet"); calls != 1 {
+		t.Fatalf("expect security group rule created once, got %d", calls)
+	}
+}
+
+func TestQingCloudSyncFailure(t *testing.T) {
+	cloud := qcfake.NewCloud("i-test")
+	cloud.Inject("GetVxNets", qcfake.Fault{Err: qcfake.NotFound("vxnet-a"), Times: 1})
+	qcfake.Setup(cloud)
+
+	c, pool := newTestController(t, &conf.ClusterConfig{})
+	c.qingCloudSync()
+	if _, ok := c.getVxNetInfo("vxnet-a"); ok {
+		t.Fatalf("vxnet-a synced after a failed call")
+	}
+
+	cloud.AddVxNet(&rpc.VxNet{ID: "vxnet-a", Network: "192.168.0.0/24", IPStart: "192.168.0.2", IPEnd: "192.168.0.30"})
+	c.qingCloudSync()
+	if _, ok := c.getVxNetInfo("vxnet-a"); !ok {
+		t.Fatalf("expect vxnet-a synced after the fault")
+	}
+	if ready, err := c.prepareQcloudResource(pool); err != nil || ready {
+		t.Fatalf("expect not ready before the vips are created, got %t %v", ready, err)
+	}
+}
+
+func TestPublishVxNetStatus(t *testing.T) {
+	cloud := qcfake.NewCloud("i-test")
+	cloud.AddVxNet(&rpc.VxNet{
+		ID:         "vxnet-a",
+		Gateway:    "192.168.0.1",
+		Network:    "192.168.0.0/24",
+		IPStart:    "192.168.0.2",
+		IPEnd:      "192.168.0.30",
+		TunnelType: constants.TunnelTypeVlan,
+	})
+	qcfake.Setup(cloud)
+
+	c, pool := newTestController(t, &conf.ClusterConfig{})
+	if err := c.updatePoolStatus(pool, false); err != nil {
+		t.Fatalf("updatePoolStatus error: %v", err)
+	}
+	updated, _ := c.clientset.NetworkV1alpha1().VxNetPools().Get(context.TODO(), pool.Name, metav1.GetOptions{})
+	if len(updated.Status.Pools) != 1 || updated.Status.Pools[0].VxNet != nil {
+		t.Fatalf("expect no vxnet metadata before it is synced, got %+v", updated.Status.Pools)
+	}
+
+	c.qingCloudSync()
+	if err := c.updatePoolStatus(updated, false); err != nil {
+		t.Fatalf("updatePoolStatus error: %v", err)
+	}
+	updated, _ = c.clientset.NetworkV1alpha1().VxNetPools().Get(context.TODO(), pool.Name, metav1.GetOptions{})
+	v := updated.Status.Pools[0].VxNet
+	if v == nil || v.Gateway != "192.168.0.1" || v.Network != "192.168.0.0/24" || v.IPEnd != "192.168.0.26" || v.TunnelType != constants.TunnelTypeVlan {
+		t.Fatalf("expect vxnet metadata of vxnet-a published, got %+v", v)
+	}
+}
@@ -0,0 +1,143 @@
+package db
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	defaultDBPath = "/var/lib/hostnic"
+
+	boltFile = "hostnic.db"
+	// records the backend which owns the db directory
+	backendFile = "backend"
+	// every leveldb directory has it
+	levelDBCurrentFile = "CURRENT"
+)
+
+var (
+	// the store set up for the node daemon, the allocator is given it explicitly
+	DefaultStore Store
+)
+
+type Options struct {
+	backend string
+	dbpath  string
+}
+
+func NewOptions() *Options {
+	return &Options{
+		backend: BackendLevelDB,
+		dbpath:  defaultDBPath,
+	}
+}
+
+func (opt *Options) AddFlags() {
+	flag.StringVar(&opt.backend, "db-backend", BackendLevelDB, "store of the node daemon, one of leveldb and bbolt. It must not be changed once the node has records in dbpath")
+	flag.StringVar(&opt.dbpath, "dbpath", defaultDBPath, "set db directory")
+}
+
+// OpenStore opens the store of backend in the directory path. It refuses a directory written by
+// another backend, whose records would be silently lost otherwise.
+func OpenStore(backend, path string) (Store, error) {
+	if backend != BackendLevelDB && backend != BackendBolt {
+		return nil, fmt.Errorf("unknown db backend %q, should be one of %s and %s", backend, BackendLevelDB, BackendBolt)
+	}
+	if err := os.MkdirAll(path, 0700); err != nil {
+		return nil, fmt.Errorf("cannot create db directory %s : %v", path, err)
+	}
+	if err := checkBackend(backend, path); err != nil {
+		return nil, err
+	}
+
+	var store Store
+	var err error
+	if backend == BackendBolt {
+		store, err = OpenBoltStore(filepath.Join(path, boltFile))
+	} else {
+		store, err = OpenLevelDBStore(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(path, backendFile), []byte(backend), 0600); err != nil {
+		store.Close()
+		return nil, fmt.Errorf("cannot record db backend in %s : %v", path, err)
+	}
+	return store, nil
+}
+
+// checkBackend returns an error if the db directory path belongs to a backend other than backend.
+// Directories written before the backend was recorded are told by their files.
+func checkBackend(backend, path string) error {
+	var recorded string
+	value, err := os.ReadFile(filepath.Join(path, backendFile))
+	switch {
+	case err == nil:
+		recorded = strings.TrimSpace(string(value))
+	case !os.IsNotExist(err):
+		return fmt.Errorf("cannot read db backend in %s : %v", path, err)
+	case exists(filepath.Join(path, boltFile)):
+		recorded = BackendBolt
+	case exists(filepath.Join(path, levelDBCurrentFile)):
+		recorded = BackendLevelDB
+	}
+
+	if recorded != "" && recorded != backend {
+		return fmt.Errorf("db directory %s was written by the %s backend, refuse to open it with %s: "+
+			"run with -db-backend=%s, or export the records with db-client and import them into a new directory", path, recorded, backend, recorded)
+	}
+	return nil
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// Open opens the store selected by the flags of opt
+func (opt *Options) Open() (Store, error) {
+	return OpenStore(opt.backend, opt.dbpath)
+}
+
+// SetupStore opens DefaultStore and migrates it to the latest schema version
+func SetupStore(opt *Options) error {
+	store, err := opt.Open()
+	if err != nil {
+		return err
+	}
+	if err := Migrate(store); err != nil {
+		store.Close()
+		return err
+	}
+	klog.Infof("%s store at %s set up", opt.backend, opt.dbpath)
+
+	DefaultStore = store
+
+	return nil
+}
+
+func CloseDB() {
+	err := DefaultStore.Close()
+	if err != nil {
+		klog.Errorf("failed to close db: %v", err)
+	} else {
+		klog.Info("db closed")
+	}
+}
+
+// dhcp leases are keyed by the bridge, with a suffix of the family for ipv6
+func SetLease(key string, lease interface{}) error {
+	value, _ := json.Marshal(lease)
+	return DefaultStore.Put(NamespaceLease+key, value)
+}
+
+func DeleteLease(key string) error {
+	return DefaultStore.Delete(NamespaceLease + key)
+}
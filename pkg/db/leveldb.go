@@ -1,80 +1,66 @@
 package db
 
 import (
-	"encoding/json"
-	"flag"
 	"fmt"
 
 	"github.com/syndtr/goleveldb/leveldb"
-	"k8s.io/klog/v2"
-
-	"github.com/yunify/hostnic-cni/pkg/constants"
-)
-
-const (
-	defaultDBPath = "/var/lib/hostnic"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
-var (
-	LevelDB *leveldb.DB
-)
-
-type LevelDBOptions struct {
-	dbpath string
-}
-
-func NewLevelDBOptions() *LevelDBOptions {
-	return &LevelDBOptions{
-		dbpath: defaultDBPath,
-	}
-}
-
-func (opt *LevelDBOptions) AddFlags() {
-	flag.StringVar(&opt.dbpath, "dbpath", defaultDBPath, "set leveldb path")
+type levelDBStore struct {
+	db *leveldb.DB
 }
 
-func SetupLevelDB(opt *LevelDBOptions) error {
-	db, err := leveldb.OpenFile(opt.dbpath, nil)
+func OpenLevelDBStore(path string) (Store, error) {
+	db, err := leveldb.OpenFile(path, nil)
 	if err != nil {
-		return fmt.Errorf("cannot open leveldb file %s : %v", opt.dbpath, err)
+		return nil, fmt.Errorf("cannot open leveldb file %s : %v", path, err)
 	}
-
-	LevelDB = db
-
-	return nil
+	return &levelDBStore{db: db}, nil
 }
 
-func CloseDB() {
-	err := LevelDB.Close()
-	if err != nil {
-		klog.Error("failed to close leveldb: %v", err)
-	} else {
-		klog.Info("leveldb closed")
+func (s *levelDBStore) Get(key string) ([]byte, error) {
+	value, err := s.db.Get([]byte(key), nil)
+	if err == leveldb.ErrNotFound {
+		return nil, ErrNotFound
 	}
+	return value, err
 }
 
-func SetNetworkInfo(key string, info interface{}) error {
-	value, _ := json.Marshal(info)
-	return LevelDB.Put([]byte(key), value, nil)
+func (s *levelDBStore) Put(key string, value []byte) error {
+	return s.db.Put([]byte(key), value, nil)
 }
 
-func DeleteNetworkInfo(key string) error {
-	err := LevelDB.Delete([]byte(key), nil)
-	if err == leveldb.ErrNotFound {
-		return constants.ErrNicNotFound
-	}
-
-	return err
+func (s *levelDBStore) Delete(key string) error {
+	return s.db.Delete([]byte(key), nil)
 }
 
-func Iterator(fn func(info interface{}) error) error {
-	iter := LevelDB.NewIterator(nil, nil)
+func (s *levelDBStore) Iterate(prefix string, fn func(key string, value []byte) error) error {
+	// the iterator reads an implicit snapshot, writes of fn are not seen
+	iter := s.db.NewIterator(util.BytesPrefix([]byte(prefix)), nil)
+	defer iter.Release()
 	for iter.Next() {
 		// Remember that the contents of the returned slice should not be modified, and
 		// only valid until the next call to Next.
-		fn(iter.Value())
+		if err := fn(string(iter.Key()), iter.Value()); err != nil {
+			return err
+		}
 	}
-	iter.Release()
-
 	return iter.Error()
 }
+
+func (s *levelDBStore) Write(batch *Batch) error {
+	b := new(leveldb.Batch)
+	for _, op := range batch.ops {
+		if op.delete {
+			b.Delete([]byte(op.key))
+		} else {
+			b.Put([]byte(op.key), op.value)
+		}
+	}
+	return s.db.Write(b, nil)
+}
+
+func (s *levelDBStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,190 @@
+package db
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/klog/v2"
+)
+
+// every record type has its own key prefix
+const (
+	NamespaceNic   = "nic/"
+	NamespacePod   = "pod/"
+	NamespaceLease = "dhcp-lease/"
+	// records which fail to decode are kept here with their original keys
+	NamespaceQuarantine = "quarantine/"
+
+	schemaVersionKey = "schema-version"
+)
+
+// NicKey is the key of the record of the nic in vxnet
+func NicKey(vxnet string) string {
+	return NamespaceNic + vxnet
+}
+
+// PodKey is the key of the record of a pod on the nic in vxnet
+func PodKey(vxnet, pod string) string {
+	return NamespacePod + vxnet + "/" + pod
+}
+
+// Migration upgrades the records of schema version Version-1 to Version. Its writes are
+// committed in one batch along with the new schema version.
+type Migration struct {
+	Version     int
+	Description string
+	Migrate     func(s Store, batch *Batch) error
+}
+
+var (
+	migrations []Migration
+)
+
+// RegisterMigration adds m to the migrations run by SetupStore, versions must be registered in order.
+func RegisterMigration(m Migration) {
+	if m.Version != len(migrations)+1 {
+		panic(fmt.Sprintf("db migration %d registered after version %d", m.Version, len(migrations)))
+	}
+	migrations = append(migrations, m)
+}
+
+// SchemaVersion returns the schema version which the registered migrations upgrade to
+func SchemaVersion() int {
+	return len(migrations)
+}
+
+func init() {
+	RegisterMigration(Migration{
+		Version:     1,
+		Description: "move nic records keyed by bare vxnet ids to " + NamespaceNic,
+		Migrate: func(s Store, batch *Batch) error {
+			return s.Iterate("", func(key string, value []byte) error {
+				if key == schemaVersionKey || strings.Contains(key, "/") {
+					return nil
+				}
+				batch.Put(NicKey(key), append([]byte(nil), value...))
+				batch.Delete(key)
+				return nil
+			})
+		},
+	})
+	RegisterMigration(Migration{
+		Version:     2,
+		Description: "move the pods of nic records to " + NamespacePod,
+		Migrate: func(s Store, batch *Batch) error {
+			return s.Iterate(NamespaceNic, func(key string, value []byte) error {
+				// records which cannot be parsed are left to be quarantined on restore
+				var record map[string]json.RawMessage
+				if err := json.Unmarshal(value, &record); err != nil {
+					return nil
+				}
+				var pods map[string]json.RawMessage
+				if err := json.Unmarshal(record["Pods"], &pods); err != nil && record["Pods"] != nil {
+					return nil
+				}
+				delete(record, "Pods")
+
+				vxnet := strings.TrimPrefix(key, NamespaceNic)
+				for pod, info := range pods {
+					if string(info) != "null" {
+						batch.Put(PodKey(vxnet, pod), info)
+					}
+				}
+				nic, err := json.Marshal(record)
+				if err != nil {
+					return err
+				}
+				batch.Put(key, nic)
+				return nil
+			})
+		},
+	})
+}
+
+// storedVersion returns the schema version of s, which is 0 if it has no version record
+func storedVersion(s Store) (int, error) {
+	value, err := s.Get(schemaVersionKey)
+	if err == ErrNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	version, err := strconv.Atoi(string(value))
+	if err != nil {
+		return 0, fmt.Errorf("invalid schema version %q: %v", value, err)
+	}
+	return version, nil
+}
+
+// Migrate runs the migrations from the schema version of s to SchemaVersion
+func Migrate(s Store) error {
+	version, err := storedVersion(s)
+	if err != nil {
+		return err
+	}
+	if version > SchemaVersion() {
+		return fmt.Errorf("schema version %d of db is newer than %d supported", version, SchemaVersion())
+	}
+
+	for _, m := range migrations[version:] {
+		batch := new(Batch)
+		if err := m.Migrate(s, batch); err != nil {
+			return fmt.Errorf("migrate db to schema version %d error: %v", m.Version, err)
+		}
+		batch.Put(schemaVersionKey, []byte(strconv.Itoa(m.Version)))
+		if err := s.Write(batch); err != nil {
+			return fmt.Errorf("migrate db to schema version %d error: %v", m.Version, err)
+		}
+		klog.Infof("migrate db to schema version %d: %s", m.Version, m.Description)
+	}
+	return nil
+}
+
+// Decode unmarshals a record strictly, fields unknown to v are errors
+func Decode(value []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// Restore calls fn with the key, without the namespace, and the value of each record in ns of s.
+// A record for which fn returns an error is moved to NamespaceQuarantine, so it is neither
+// restored nor lost.
+func Restore(s Store, ns string, fn func(key string, value []byte) error) error {
+	bad := new(Batch)
+	var quarantined []string
+
+	err := s.Iterate(ns, func(key string, value []byte) error {
+		if err := fn(strings.TrimPrefix(key, ns), value); err != nil {
+			klog.Errorf("quarantine db record %s which failed to decode: %v", key, err)
+			bad.Put(NamespaceQuarantine+key, append([]byte(nil), value...))
+			bad.Delete(key)
+			quarantined = append(quarantined, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if bad.Len() > 0 {
+		if err := s.Write(bad); err != nil {
+			return fmt.Errorf("quarantine db records %v error: %v", quarantined, err)
+		}
+	}
+	return nil
+}
+
+// Quarantined returns the keys of the records in NamespaceQuarantine of s, in order
+func Quarantined(s Store) ([]string, error) {
+	var keys []string
+	err := s.Iterate(NamespaceQuarantine, func(key string, value []byte) error {
+		keys = append(keys, strings.TrimPrefix(key, NamespaceQuarantine))
+		return nil
+	})
+	return keys, err
+}
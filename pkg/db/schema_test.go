@@ -0,0 +1,90 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func records(t *testing.T, s Store) map[string]string {
+	result := make(map[string]string)
+	err := s.Iterate("", func(key string, value []byte) error {
+		result[key] = string(value)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to iterate store: %v", err)
+	}
+	return result
+}
+
+func TestMigrate(t *testing.T) {
+	s := NewMemoryStore()
+	// records of schema version 0
+	s.Put("vxnet-a", []byte(`{"Nic":{"ID":"hostnic-a"},"Pods":{"c1":{"Name":"pod-1"},"c2":null}}`))
+	s.Put("vxnet-b", []byte(`{"Nic":{"ID":"hostnic-b"},"Pods":null}`))
+	s.Put("dhcp-lease/br_260", []byte(`{"bridge":"br_260"}`))
+
+	for i := 0; i < 2; i++ {
+		if err := Migrate(s); err != nil {
+			t.Fatalf("failed to migrate: %v", err)
+		}
+		expect := map[string]string{
+			"nic/vxnet-a":       `{"Nic":{"ID":"hostnic-a"}}`,
+			"pod/vxnet-a/c1":    `{"Name":"pod-1"}`,
+			"nic/vxnet-b":       `{"Nic":{"ID":"hostnic-b"}}`,
+			"dhcp-lease/br_260": `{"bridge":"br_260"}`,
+			schemaVersionKey:    "2",
+		}
+		if got := records(t, s); !reflect.DeepEqual(got, expect) {
+			t.Fatalf("expect records %v after migration %d, got %v", expect, i, got)
+		}
+	}
+
+	s.Put(schemaVersionKey, []byte("99"))
+	if err := Migrate(s); err == nil {
+		t.Fatalf("expect error to migrate from a newer schema version")
+	}
+}
+
+func TestRestoreQuarantine(t *testing.T) {
+	DefaultStore = NewMemoryStore()
+	s := DefaultStore
+	s.Put(NicKey("vxnet-a"), []byte(`{"ID":"a"}`))
+	s.Put(NicKey("vxnet-b"), []byte(`{"ID":"b","Phase":1}`))
+	s.Put(NicKey("vxnet-c"), []byte(`{"ID":`))
+	SetLease("br_260", map[string]string{"ID": "lease"})
+
+	var restored []string
+	err := Restore(s, NamespaceNic, func(key string, value []byte) error {
+		var record struct{ ID string }
+		if err := Decode(value, &record); err != nil {
+			return err
+		}
+		restored = append(restored, key)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to restore: %v", err)
+	}
+	if !reflect.DeepEqual(restored, []string{"vxnet-a"}) {
+		t.Fatalf("expect only vxnet-a restored, got %v", restored)
+	}
+
+	quarantined, err := Quarantined(s)
+	if err != nil {
+		t.Fatalf("failed to list quarantined records: %v", err)
+	}
+	if expect := []string{NicKey("vxnet-b"), NicKey("vxnet-c")}; !reflect.DeepEqual(quarantined, expect) {
+		t.Fatalf("expect %v quarantined, got %v", expect, quarantined)
+	}
+	got := records(t, s)
+	if _, ok := got[NicKey("vxnet-b")]; ok {
+		t.Fatalf("expect vxnet-b moved out of %s, got %v", NamespaceNic, got)
+	}
+	if got[NamespaceQuarantine+NicKey("vxnet-b")] != `{"ID":"b","Phase":1}` {
+		t.Fatalf("expect vxnet-b kept in quarantine, got %v", got)
+	}
+	if _, ok := got[NamespaceLease+"br_260"]; !ok {
+		t.Fatalf("expect lease untouched, got %v", got)
+	}
+}
@@ -0,0 +1,128 @@
+package db
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var ErrNotFound = errors.New("record not found")
+
+// Store keeps the records of the node daemon in one ordered key space, the key of a record
+// starts with the namespace of its type.
+type Store interface {
+	// Get returns ErrNotFound if there is no record of key
+	Get(key string) ([]byte, error)
+	Put(key string, value []byte) error
+	Delete(key string) error
+	// Iterate calls fn with each record whose key has prefix, in key order. It iterates over the
+	// records as they were when it started, so fn may write to the store without seeing its writes.
+	// The value is only valid in fn, and iteration stops at the first error of fn.
+	Iterate(prefix string, fn func(key string, value []byte) error) error
+	// Write applies the puts and deletes of batch atomically, in order
+	Write(batch *Batch) error
+	Close() error
+}
+
+// Batch is a list of puts and deletes which are written atomically by Store.Write
+type Batch struct {
+	ops []batchOp
+}
+
+type batchOp struct {
+	key    string
+	value  []byte
+	delete bool
+}
+
+func (b *Batch) Put(key string, value []byte) {
+	b.ops = append(b.ops, batchOp{key: key, value: value})
+}
+
+func (b *Batch) Delete(key string) {
+	b.ops = append(b.ops, batchOp{key: key, delete: true})
+}
+
+func (b *Batch) Len() int {
+	return len(b.ops)
+}
+
+// memoryStore keeps the records in memory only, they are lost on restart
+type memoryStore struct {
+	lock    sync.RWMutex
+	records map[string][]byte
+}
+
+func NewMemoryStore() Store {
+	return &memoryStore{
+		records: make(map[string][]byte),
+	}
+}
+
+func (s *memoryStore) Get(key string) ([]byte, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	value, ok := s.records[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), value...), nil
+}
+
+func (s *memoryStore) Put(key string, value []byte) error {
+	batch := new(Batch)
+	batch.Put(key, value)
+	return s.Write(batch)
+}
+
+func (s *memoryStore) Delete(key string) error {
+	batch := new(Batch)
+	batch.Delete(key)
+	return s.Write(batch)
+}
+
+func (s *memoryStore) Iterate(prefix string, fn func(key string, value []byte) error) error {
+	s.lock.RLock()
+	var keys []string
+	snapshot := make(map[string][]byte)
+	for key, value := range s.records {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+			snapshot[key] = value
+		}
+	}
+	s.lock.RUnlock()
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := fn(key, snapshot[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) Write(batch *Batch) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, op := range batch.ops {
+		if op.delete {
+			delete(s.records, op.key)
+		} else {
+			s.records[op.key] = append([]byte(nil), op.value...)
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) Close() error {
+	return nil
+}
+
+// backends of Store selected by Options, records in memory are lost on restart so
+// the memory store is for tests only
+const (
+	BackendLevelDB = "leveldb"
+	BackendBolt    = "bbolt"
+)
@@ -0,0 +1,136 @@
+package db
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const backendMemory = "memory"
+
+func openTestStore(t *testing.T, backend string) Store {
+	if backend == backendMemory {
+		return NewMemoryStore()
+	}
+	s, err := OpenStore(backend, t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	return s
+}
+
+func TestStores(t *testing.T) {
+	for _, backend := range []string{backendMemory, BackendLevelDB, BackendBolt} {
+		t.Run(backend, func(t *testing.T) {
+			s := openTestStore(t, backend)
+			defer s.Close()
+
+			if _, err := s.Get("nic/vxnet-a"); err != ErrNotFound {
+				t.Fatalf("expect ErrNotFound of missing record, got %v", err)
+			}
+			s.Put("nic/vxnet-b", []byte("b"))
+			s.Put("nic/vxnet-a", []byte("a"))
+			s.Put("pod/vxnet-a/c1", []byte("c1"))
+			if value, err := s.Get("nic/vxnet-a"); err != nil || string(value) != "a" {
+				t.Fatalf("expect record a, got %q %v", value, err)
+			}
+
+			batch := new(Batch)
+			batch.Put("nic/vxnet-a", []byte("a2"))
+			batch.Put("pod/vxnet-a/c2", []byte("c2"))
+			batch.Delete("nic/vxnet-b")
+			if err := s.Write(batch); err != nil {
+				t.Fatalf("failed to write batch: %v", err)
+			}
+			expect := map[string]string{
+				"nic/vxnet-a":    "a2",
+				"pod/vxnet-a/c1": "c1",
+				"pod/vxnet-a/c2": "c2",
+			}
+			if got := records(t, s); !reflect.DeepEqual(got, expect) {
+				t.Fatalf("expect records %v, got %v", expect, got)
+			}
+
+			var keys []string
+			stop := fmt.Errorf("stop")
+			err := s.Iterate("pod/", func(key string, value []byte) error {
+				keys = append(keys, key)
+				return stop
+			})
+			if err != stop || !reflect.DeepEqual(keys, []string{"pod/vxnet-a/c1"}) {
+				t.Fatalf("expect iteration stopped at pod/vxnet-a/c1, got %v %v", keys, err)
+			}
+
+			// writes of fn are applied but not seen by the iteration
+			keys = nil
+			err = s.Iterate("pod/", func(key string, value []byte) error {
+				keys = append(keys, key)
+				if err := s.Put("pod/vxnet-a/c3", []byte("c3")); err != nil {
+					return err
+				}
+				return s.Delete("pod/vxnet-a/c2")
+			})
+			if err != nil || !reflect.DeepEqual(keys, []string{"pod/vxnet-a/c1", "pod/vxnet-a/c2"}) {
+				t.Fatalf("expect iteration over pod/vxnet-a/c1 and c2, got %v %v", keys, err)
+			}
+			expect = map[string]string{
+				"nic/vxnet-a":    "a2",
+				"pod/vxnet-a/c1": "c1",
+				"pod/vxnet-a/c3": "c3",
+			}
+			if got := records(t, s); !reflect.DeepEqual(got, expect) {
+				t.Fatalf("expect records %v after writes in iteration, got %v", expect, got)
+			}
+
+			if err := s.Delete("pod/vxnet-a/c1"); err != nil {
+				t.Fatalf("failed to delete record: %v", err)
+			}
+			if _, err := s.Get("pod/vxnet-a/c1"); err != ErrNotFound {
+				t.Fatalf("expect deleted record not found, got %v", err)
+			}
+		})
+	}
+}
+
+func TestBackendChange(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		setup   func(dir string)
+		backend string
+		ok      bool
+	}{
+		{"new directory", func(dir string) {}, BackendBolt, true},
+		{"recorded backend", func(dir string) { os.WriteFile(filepath.Join(dir, backendFile), []byte(BackendBolt), 0600) }, BackendBolt, true},
+		{"other recorded backend", func(dir string) { os.WriteFile(filepath.Join(dir, backendFile), []byte(BackendBolt), 0600) }, BackendLevelDB, false},
+		// written before the backend was recorded
+		{"leveldb files", func(dir string) { os.WriteFile(filepath.Join(dir, levelDBCurrentFile), nil, 0600) }, BackendBolt, false},
+		{"bbolt file", func(dir string) { os.WriteFile(filepath.Join(dir, boltFile), nil, 0600) }, BackendLevelDB, false},
+	} {
+		dir := t.TempDir()
+		c.setup(dir)
+		if err := checkBackend(c.backend, dir); (err == nil) != c.ok {
+			t.Errorf("%s: expect ok %v, got %v", c.name, c.ok, err)
+		}
+	}
+
+	dir := t.TempDir()
+	s, err := OpenStore(BackendLevelDB, dir)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	s.Put("nic/vxnet-a", []byte("a"))
+	s.Close()
+	if _, err := OpenStore(BackendBolt, dir); err == nil {
+		t.Fatalf("expect the leveldb directory refused by bbolt")
+	}
+	s, err = OpenStore(BackendLevelDB, dir)
+	if err != nil {
+		t.Fatalf("failed to open store again: %v", err)
+	}
+	defer s.Close()
+	if value, err := s.Get("nic/vxnet-a"); err != nil || string(value) != "a" {
+		t.Fatalf("expect record a kept, got %q %v", value, err)
+	}
+}
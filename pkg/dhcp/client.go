@@ -0,0 +1,215 @@
+package dhcp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+	"github.com/insomniacslk/dhcp/dhcpv4/client4"
+	"golang.org/x/sys/unix"
+	"k8s.io/klog/v2"
+)
+
+var (
+	errNAK = errors.New("dhcp server replied nak")
+
+	// the bridge may have no address yet, so all messages are sent and received on raw sockets
+	exchangeTimeout = client4.DefaultReadTimeout * 5
+)
+
+// protocol exchanges the messages of one family with the dhcp servers on a bridge
+type protocol interface {
+	// discover gets a new lease
+	discover(bridge string) (*Lease, error)
+	// renew extends lease from the server which granted it, or from any server with rebind.
+	// It returns errNAK if the lease is refused.
+	renew(lease *Lease, rebind bool) (*Lease, error)
+	// reboot verifies lease with any server after restart, it returns errNAK if the lease is no longer valid
+	reboot(lease *Lease) (*Lease, error)
+	// release gives lease back to the server, no reply is expected
+	release(lease *Lease) error
+}
+
+func protocolOf(family Family) protocol {
+	if family == FamilyIPv6 {
+		return dhcp6{}
+	}
+	return dhcp4{}
+}
+
+type dhcp4 struct{}
+
+func (dhcp4) discover(bridge string) (*Lease, error) {
+	ack, err := discover(bridge)
+	if err != nil {
+		return nil, err
+	}
+	return newLease(bridge, ack, time.Now()), nil
+}
+
+func (dhcp4) renew(lease *Lease, rebind bool) (*Lease, error) {
+	ack, err := lease.ack()
+	if err != nil {
+		return nil, err
+	}
+	ack, err = renew(lease.Bridge, ack, rebind)
+	if err != nil {
+		return nil, err
+	}
+	return newLease(lease.Bridge, ack, time.Now()), nil
+}
+
+func (dhcp4) reboot(lease *Lease) (*Lease, error) {
+	ack, err := lease.ack()
+	if err != nil {
+		return nil, err
+	}
+	ack, err = reboot(lease.Bridge, ack)
+	if err != nil {
+		return nil, err
+	}
+	return newLease(lease.Bridge, ack, time.Now()), nil
+}
+
+func (dhcp4) release(lease *Lease) error {
+	ack, err := lease.ack()
+	if err != nil {
+		return err
+	}
+	return release(lease.Bridge, ack)
+}
+
+// Exchange4 gets a lease on ifname by a full DORA exchange, which is tried up to attempts times,
+// and returns the messages of the last exchange.
+func Exchange4(ifname string, attempts int) ([]*dhcpv4.DHCPv4, error) {
+	client := &client4.Client{
+		ReadTimeout:  exchangeTimeout,
+		WriteTimeout: exchangeTimeout,
+	}
+	var (
+		conv []*dhcpv4.DHCPv4
+		err  error
+	)
+	for attempt := 1; ; attempt++ {
+		conv, err = client.Exchange(ifname)
+		if err == nil || attempt >= attempts {
+			break
+		}
+		klog.Warningf("dhcp client exchange on link %s error, attempt %d of %d: %v", ifname, attempt, attempts, err)
+	}
+	if err != nil {
+		return conv, fmt.Errorf("dhcp client exchange error: %v", err)
+	}
+	return conv, nil
+}
+
+// discover gets a new lease by a full DORA exchange, and returns the ack
+func discover(ifname string) (*dhcpv4.DHCPv4, error) {
+	conv, err := Exchange4(ifname, 1)
+	if err != nil {
+		return nil, err
+	}
+	return conv[len(conv)-1], nil
+}
+
+// renew requests to extend the lease of ack, unicast to the server which granted it in
+// RENEWING, broadcast to any server in REBINDING. It returns errNAK if the server refused.
+func renew(ifname string, ack *dhcpv4.DHCPv4, broadcast bool) (*dhcpv4.DHCPv4, error) {
+	xid, err := dhcpv4.GenerateTransactionID()
+	if err != nil {
+		return nil, err
+	}
+	server := ack.ServerIdentifier()
+	if broadcast || server == nil {
+		server = net.IPv4bcast
+	}
+	req, err := dhcpv4.NewRenewFromAck(ack, dhcpv4.WithTransactionID(xid))
+	if err != nil {
+		return nil, err
+	}
+	return request(ifname, req, ack.YourIPAddr, server)
+}
+
+// reboot verifies the lease of ack with any server after restart, see INIT-REBOOT in RFC 2131.
+// It returns errNAK if the lease is no longer valid.
+func reboot(ifname string, ack *dhcpv4.DHCPv4) (*dhcpv4.DHCPv4, error) {
+	req, err := dhcpv4.New(
+		dhcpv4.WithHwAddr(ack.ClientHWAddr),
+		dhcpv4.WithMessageType(dhcpv4.MessageTypeRequest),
+		dhcpv4.WithOption(dhcpv4.OptRequestedIPAddress(ack.YourIPAddr)),
+		dhcpv4.WithRequestedOptions(
+			dhcpv4.OptionSubnetMask,
+			dhcpv4.OptionRouter,
+			dhcpv4.OptionDomainName,
+			dhcpv4.OptionDomainNameServer,
+		),
+		dhcpv4.WithBroadcast(true),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return request(ifname, req, net.IPv4zero, net.IPv4bcast)
+}
+
+// release gives the lease of ack back to the server, no reply is expected
+func release(ifname string, ack *dhcpv4.DHCPv4) error {
+	req, err := dhcpv4.NewReleaseFromACK(ack)
+	if err != nil {
+		return err
+	}
+	server := ack.ServerIdentifier()
+	if server == nil {
+		server = net.IPv4bcast
+	}
+	packet, err := client4.MakeRawUDPPacket(req.ToBytes(),
+		net.UDPAddr{IP: server, Port: dhcpv4.ServerPort},
+		net.UDPAddr{IP: ack.YourIPAddr, Port: dhcpv4.ClientPort})
+	if err != nil {
+		return err
+	}
+
+	fd, err := client4.MakeBroadcastSocket(ifname)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+
+	var dst [net.IPv4len]byte
+	copy(dst[:], server.To4())
+	return unix.Sendto(fd, packet, 0, &unix.SockaddrInet4{Port: dhcpv4.ServerPort, Addr: dst})
+}
+
+func request(ifname string, req *dhcpv4.DHCPv4, local, server net.IP) (*dhcpv4.DHCPv4, error) {
+	client := &client4.Client{
+		ReadTimeout:  exchangeTimeout,
+		WriteTimeout: exchangeTimeout,
+		LocalAddr:    &net.UDPAddr{IP: local, Port: dhcpv4.ClientPort},
+		RemoteAddr:   &net.UDPAddr{IP: server, Port: dhcpv4.ServerPort},
+	}
+	sfd, err := client4.MakeBroadcastSocket(ifname)
+	if err != nil {
+		return nil, err
+	}
+	defer unix.Close(sfd)
+	rfd, err := client4.MakeListeningSocket(ifname)
+	if err != nil {
+		return nil, err
+	}
+	defer unix.Close(rfd)
+
+	// the reply is either an ack or a nak
+	reply, err := client.SendReceive(sfd, rfd, req, dhcpv4.MessageTypeNone)
+	if err != nil {
+		return nil, fmt.Errorf("dhcp client request error: %v", err)
+	}
+	switch reply.MessageType() {
+	case dhcpv4.MessageTypeAck:
+		return reply, nil
+	case dhcpv4.MessageTypeNak:
+		return nil, errNAK
+	default:
+		return nil, fmt.Errorf("dhcp client request error: unexpected reply %s", reply.MessageType())
+	}
+}
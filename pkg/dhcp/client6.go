@@ -0,0 +1,228 @@
+package dhcp
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv6"
+	"github.com/insomniacslk/dhcp/dhcpv6/client6"
+	"github.com/insomniacslk/dhcp/iana"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+	"k8s.io/klog/v2"
+)
+
+// dhcpv6 messages are sent from the link-local address of the bridge to all servers, see RFC 8415 section 18.2
+type dhcp6 struct{}
+
+func (dhcp6) discover(bridge string) (*Lease, error) {
+	reply, err := discover6(bridge)
+	if err != nil {
+		return nil, err
+	}
+	return newLease6(bridge, reply, time.Now()), nil
+}
+
+func (dhcp6) renew(lease *Lease, rebind bool) (*Lease, error) {
+	reply, err := lease.reply()
+	if err != nil {
+		return nil, err
+	}
+	msgType := dhcpv6.MessageTypeRenew
+	if rebind {
+		msgType = dhcpv6.MessageTypeRebind
+	}
+	reply, err = request6(lease.Bridge, reply, msgType)
+	if err != nil {
+		return nil, err
+	}
+	return newLease6(lease.Bridge, reply, time.Now()), nil
+}
+
+// reboot rebinds the lease, the reply carries new lifetimes or refuses it with NoBinding
+func (p dhcp6) reboot(lease *Lease) (*Lease, error) {
+	return p.renew(lease, true)
+}
+
+func (dhcp6) release(lease *Lease) error {
+	reply, err := lease.reply()
+	if err != nil {
+		return err
+	}
+	msg, err := newMessage6(reply, dhcpv6.MessageTypeRelease)
+	if err != nil {
+		return err
+	}
+	conn, err := listen6(lease.Bridge)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	conn.SetWriteDeadline(time.Now().Add(exchangeTimeout))
+	_, err = conn.WriteTo(msg.ToBytes(), serverAddr6(lease.Bridge))
+	return err
+}
+
+// Exchange6 gets a lease on ifname by solicit, advertise, request and reply, which is tried up to attempts
+// times, and returns the messages of the last exchange. The modifiers are applied to solicit and request.
+func Exchange6(ifname string, attempts int, modifiers ...dhcpv6.Modifier) ([]dhcpv6.DHCPv6, error) {
+	laddr, err := linkLocalAddr(ifname)
+	if err != nil {
+		return nil, err
+	}
+	client := &client6.Client{
+		ReadTimeout:  exchangeTimeout,
+		WriteTimeout: exchangeTimeout,
+		LocalAddr:    laddr,
+		RemoteAddr:   serverAddr6(ifname),
+	}
+	var conv []dhcpv6.DHCPv6
+	for attempt := 1; ; attempt++ {
+		conv, err = client.Exchange(ifname, modifiers...)
+		if err == nil || attempt >= attempts {
+			break
+		}
+		klog.Warningf("dhcpv6 client exchange on link %s error, attempt %d of %d: %v", ifname, attempt, attempts, err)
+	}
+	if err != nil {
+		return conv, fmt.Errorf("dhcpv6 client exchange error: %v", err)
+	}
+	return conv, nil
+}
+
+// discover6 gets a new lease, and returns the reply which has an address
+func discover6(ifname string) (*dhcpv6.Message, error) {
+	conv, err := Exchange6(ifname, 1)
+	if err != nil {
+		return nil, err
+	}
+	reply, ok := conv[len(conv)-1].(*dhcpv6.Message)
+	if !ok {
+		return nil, fmt.Errorf("dhcpv6 client exchange error: unexpected reply %s", conv[len(conv)-1].Type())
+	}
+	if err := checkReply(reply); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
+// request6 sends a renew or rebind for the lease of reply, and returns the reply of the server
+func request6(ifname string, reply *dhcpv6.Message, msgType dhcpv6.MessageType) (*dhcpv6.Message, error) {
+	msg, err := newMessage6(reply, msgType)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := listen6(ifname)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(exchangeTimeout))
+	if _, err := conn.WriteTo(msg.ToBytes(), serverAddr6(ifname)); err != nil {
+		return nil, fmt.Errorf("dhcpv6 client send %s error: %v", msgType, err)
+	}
+	buf := make([]byte, client6.MaxUDPReceivedPacketSize)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return nil, fmt.Errorf("dhcpv6 client receive reply of %s error: %v", msgType, err)
+		}
+		reply, err := dhcpv6.MessageFromBytes(buf[:n])
+		if err != nil || reply.TransactionID != msg.TransactionID || reply.MessageType != dhcpv6.MessageTypeReply {
+			continue
+		}
+		if err := checkReply(reply); err != nil {
+			return nil, err
+		}
+		return reply, nil
+	}
+}
+
+// newMessage6 builds a message of msgType for the lease of reply, only renew and release are sent to its server
+func newMessage6(reply *dhcpv6.Message, msgType dhcpv6.MessageType) (*dhcpv6.Message, error) {
+	clientID, serverID, ia := reply.Options.ClientID(), reply.Options.ServerID(), reply.Options.OneIANA()
+	if clientID == nil || serverID == nil || ia == nil {
+		return nil, fmt.Errorf("reply of dhcpv6 lease has no client id, server id or IA_NA")
+	}
+	msg, err := dhcpv6.NewMessage()
+	if err != nil {
+		return nil, err
+	}
+	msg.MessageType = msgType
+	msg.AddOption(dhcpv6.OptClientID(clientID))
+	if msgType != dhcpv6.MessageTypeRebind {
+		msg.AddOption(dhcpv6.OptServerID(serverID))
+	}
+	msg.AddOption(dhcpv6.OptElapsedTime(0))
+	msg.AddOption(ia)
+	return msg, nil
+}
+
+// checkReply returns errNAK if the server has no binding or address for the client, see RFC 8415 section 18.2.10
+func checkReply(reply *dhcpv6.Message) error {
+	if status := reply.Options.Status(); status != nil && status.StatusCode != iana.StatusSuccess {
+		return fmt.Errorf("dhcpv6 server replied %s", status)
+	}
+	ia := reply.Options.OneIANA()
+	if ia == nil {
+		return errNAK
+	}
+	if status := ia.Options.Status(); status != nil && status.StatusCode != iana.StatusSuccess {
+		switch status.StatusCode {
+		case iana.StatusNoBinding, iana.StatusNotOnLink, iana.StatusNoAddrsAvail:
+			return errNAK
+		}
+		return fmt.Errorf("dhcpv6 server replied %s", status)
+	}
+	if addr := ia.Options.OneAddress(); addr == nil || addr.ValidLifetime == 0 {
+		return errNAK
+	}
+	return nil
+}
+
+func listen6(ifname string) (*net.UDPConn, error) {
+	laddr, err := linkLocalAddr(ifname)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp6", laddr)
+	if err != nil {
+		return nil, fmt.Errorf("dhcpv6 client listen on %s error: %v", laddr, err)
+	}
+	return conn, nil
+}
+
+func serverAddr6(ifname string) *net.UDPAddr {
+	return &net.UDPAddr{
+		IP:   dhcpv6.AllDHCPRelayAgentsAndServers,
+		Port: dhcpv6.DefaultServerPort,
+		Zone: ifname,
+	}
+}
+
+// linkLocalAddr returns the link-local address of ifname, it waits for the duplicate address detection of a new link
+func linkLocalAddr(ifname string) (*net.UDPAddr, error) {
+	link, err := netlink.LinkByName(ifname)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lookup link %s: %v", ifname, err)
+	}
+	deadline := time.Now().Add(exchangeTimeout)
+	for {
+		addrs, err := netlink.AddrList(link, netlink.FAMILY_V6)
+		if err != nil {
+			return nil, fmt.Errorf("list addrs of link %s error: %v", ifname, err)
+		}
+		for _, addr := range addrs {
+			if addr.IP.IsLinkLocalUnicast() && addr.Flags&unix.IFA_F_TENTATIVE == 0 {
+				return &net.UDPAddr{IP: addr.IP, Port: dhcpv6.DefaultClientPort, Zone: ifname}, nil
+			}
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("link %s has no link-local address", ifname)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
@@ -0,0 +1,239 @@
+package dhcp
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+	"github.com/insomniacslk/dhcp/dhcpv6"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"github.com/yunify/hostnic-cni/pkg/db"
+)
+
+type LeaseState string
+
+// states of a lease, see RFC 2131 section 4.4 and RFC 8415 section 18.2
+const (
+	StateBound     LeaseState = "bound"
+	StateRenewing  LeaseState = "renewing"
+	StateRebinding LeaseState = "rebinding"
+	// the lease was refused or has expired, a new one is being discovered
+	StateInit LeaseState = "init"
+)
+
+type Family string
+
+const (
+	FamilyIPv4 Family = "ipv4"
+	FamilyIPv6 Family = "ipv6"
+)
+
+// used if the server does not send the lease time
+const defaultLeaseTime = time.Hour
+
+// Lease is the dhcpv4 or dhcpv6 lease of a bridge, it is persisted in db
+type Lease struct {
+	Bridge  string     `json:"bridge"`
+	Family  Family     `json:"family,omitempty"`
+	State   LeaseState `json:"state"`
+	Address string     `json:"address"`
+	Server  string     `json:"server"`
+
+	// when the ack was received, the times of the lease start from it
+	Bound  time.Time `json:"bound"`
+	Renew  time.Time `json:"renew"`
+	Rebind time.Time `json:"rebind"`
+	Expiry time.Time `json:"expiry"`
+	// end of the preferred lifetime of a dhcpv6 address
+	Preferred time.Time `json:"preferred,omitempty"`
+
+	// the ack, or the reply of dhcpv6, is kept to build the requests of renew, rebind and release, also after restart
+	ACK []byte `json:"ack"`
+}
+
+func newLease(bridge string, ack *dhcpv4.DHCPv4, now time.Time) *Lease {
+	leaseTime := ack.IPAddressLeaseTime(defaultLeaseTime)
+	// T1 and T2 default to 0.5 and 0.875 of the lease time
+	renewalTime := ack.IPAddressRenewalTime(leaseTime / 2)
+	rebindingTime := ack.IPAddressRebindingTime(leaseTime * 7 / 8)
+	if rebindingTime > leaseTime {
+		rebindingTime = leaseTime * 7 / 8
+	}
+	if renewalTime > rebindingTime {
+		renewalTime = rebindingTime / 2
+	}
+
+	mask := ack.SubnetMask()
+	if mask == nil {
+		mask = ack.YourIPAddr.DefaultMask()
+	}
+	addr := net.IPNet{IP: ack.YourIPAddr.To4(), Mask: mask}
+
+	return &Lease{
+		Bridge:  bridge,
+		Family:  FamilyIPv4,
+		State:   StateBound,
+		Address: addr.String(),
+		Server:  ack.ServerIdentifier().String(),
+		Bound:   now,
+		Renew:   now.Add(renewalTime),
+		Rebind:  now.Add(rebindingTime),
+		Expiry:  now.Add(leaseTime),
+		ACK:     ack.ToBytes(),
+	}
+}
+
+// newLease6 takes the first address of the IA_NA in reply, which has been checked by checkReply
+func newLease6(bridge string, reply *dhcpv6.Message, now time.Time) *Lease {
+	iana := reply.Options.OneIANA()
+	iaAddr := iana.Options.OneAddress()
+	validTime, preferredTime := iaAddr.ValidLifetime, iaAddr.PreferredLifetime
+	if preferredTime > validTime {
+		preferredTime = validTime
+	}
+	// T1 and T2 default to 0.5 and 0.8 of the preferred lifetime, see RFC 8415 section 21.4
+	renewalTime, rebindingTime := iana.T1, iana.T2
+	if rebindingTime == 0 || rebindingTime > validTime {
+		rebindingTime = preferredTime * 4 / 5
+	}
+	if renewalTime == 0 || renewalTime > rebindingTime {
+		renewalTime = rebindingTime * 5 / 8
+	}
+
+	addr := net.IPNet{IP: iaAddr.IPv6Addr, Mask: net.CIDRMask(128, 128)}
+
+	return &Lease{
+		Bridge:    bridge,
+		Family:    FamilyIPv6,
+		State:     StateBound,
+		Address:   addr.String(),
+		Server:    reply.Options.ServerID().String(),
+		Bound:     now,
+		Renew:     now.Add(renewalTime),
+		Rebind:    now.Add(rebindingTime),
+		Expiry:    now.Add(validTime),
+		Preferred: now.Add(preferredTime),
+		ACK:       reply.ToBytes(),
+	}
+}
+
+func (l *Lease) ack() (*dhcpv4.DHCPv4, error) {
+	ack, err := dhcpv4.FromBytes(l.ACK)
+	if err != nil {
+		return nil, fmt.Errorf("decode ack of lease %s error: %v", l.Bridge, err)
+	}
+	return ack, nil
+}
+
+func (l *Lease) reply() (*dhcpv6.Message, error) {
+	reply, err := dhcpv6.MessageFromBytes(l.ACK)
+	if err != nil {
+		return nil, fmt.Errorf("decode reply of lease %s error: %v", l.Bridge, err)
+	}
+	return reply, nil
+}
+
+func (l *Lease) ipNet() (*net.IPNet, error) {
+	ip, ipNet, err := net.ParseCIDR(l.Address)
+	if err != nil {
+		return nil, fmt.Errorf("parse address of lease %s error: %v", l.Bridge, err)
+	}
+	ipNet.IP = ip
+	return ipNet, nil
+}
+
+// IP returns the address of the lease without its mask
+func (l *Lease) IP() net.IP {
+	ip, _, _ := net.ParseCIDR(l.Address)
+	return ip
+}
+
+// key of the lease in db, the leases of ipv4 are keyed by the bridge alone
+func (l *Lease) key() string {
+	return leaseKey(l.Bridge, l.Family)
+}
+
+func leaseKey(bridge string, family Family) string {
+	if family == FamilyIPv6 {
+		return bridge + "/" + string(family)
+	}
+	return bridge
+}
+
+func (l *Lease) save() error {
+	return db.SetLease(l.key(), l)
+}
+
+// install replaces the address of the lease to the bridge, the kernel removes it on expiry
+func (l *Lease) install(now time.Time) error {
+	ipNet, err := l.ipNet()
+	if err != nil {
+		return err
+	}
+	link, err := netlink.LinkByName(l.Bridge)
+	if err != nil {
+		return fmt.Errorf("failed to lookup link %s: %v", l.Bridge, err)
+	}
+
+	lifetime := int(l.Expiry.Sub(now).Seconds())
+	if lifetime <= 0 {
+		return fmt.Errorf("lease %s of link %s has expired", l.Address, l.Bridge)
+	}
+	preferred := lifetime
+	if !l.Preferred.IsZero() {
+		preferred = int(l.Preferred.Sub(now).Seconds())
+		if preferred < 0 {
+			preferred = 0
+		}
+	}
+	addr := &netlink.Addr{
+		IPNet:       ipNet,
+		ValidLft:    lifetime,
+		PreferedLft: preferred,
+	}
+	if err := netlink.AddrReplace(link, addr); err != nil {
+		return fmt.Errorf("replace addr %+v to link %s error: %v", addr, l.Bridge, err)
+	}
+	return nil
+}
+
+// uninstall removes the address of the lease from the bridge, if the bridge is still there
+func (l *Lease) uninstall() error {
+	ipNet, err := l.ipNet()
+	if err != nil {
+		return err
+	}
+	link, err := netlink.LinkByName(l.Bridge)
+	if err != nil {
+		if _, ok := err.(netlink.LinkNotFoundError); ok {
+			return nil
+		}
+		return fmt.Errorf("failed to lookup link %s: %v", l.Bridge, err)
+	}
+
+	err = netlink.AddrDel(link, &netlink.Addr{IPNet: ipNet})
+	if err != nil && err != unix.EADDRNOTAVAIL {
+		return fmt.Errorf("del addr %s from link %s error: %v", l.Address, l.Bridge, err)
+	}
+	return nil
+}
+
+// loadLeases returns the leases in db by their keys, leases saved without a family are of ipv4
+func loadLeases() (map[string]*Lease, error) {
+	leases := make(map[string]*Lease)
+	err := db.Restore(db.DefaultStore, db.NamespaceLease, func(key string, value []byte) error {
+		var lease Lease
+		if err := db.Decode(value, &lease); err != nil {
+			return err
+		}
+		if lease.Family == "" {
+			lease.Family = FamilyIPv4
+		}
+		leases[key] = &lease
+		return nil
+	})
+	return leases, err
+}
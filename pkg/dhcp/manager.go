@@ -0,0 +1,432 @@
+package dhcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/yunify/hostnic-cni/pkg/db"
+)
+
+var (
+	// retries of renew and rebind wait half of the time left, but at least minRetryInterval
+	minRetryInterval = 60 * time.Second
+	// retries of discover after the lease is lost
+	discoverRetryInterval = 10 * time.Second
+)
+
+// bridgeLease is the lease of a bridge in one family, and the loop which keeps it renewed
+type bridgeLease struct {
+	name   string
+	family Family
+	proto  protocol
+
+	lock  sync.Mutex
+	lease *Lease
+	// a lease restored from db is verified with the server before it is used again
+	restored bool
+	running  bool
+	stop     chan struct{}
+
+	// copy of lease for Leases, guarded by the lock of the manager
+	snapshot *Lease
+}
+
+// LeaseManager keeps a dhcpv4 lease for each vlan bridge, and a dhcpv6 lease for the bridges of
+// ipv6 vxnets, see RFC 2131 section 4.4.5 and RFC 8415 section 18.2.4.
+// A lease is renewed from the server which granted it at T1, rebound from any server at T2,
+// and a new one is discovered when it is refused or expires.
+type LeaseManager struct {
+	lock sync.Mutex
+	// keyed by the bridge, with a suffix of the family for ipv6
+	bridges map[string]*bridgeLease
+
+	hooks Hooks
+}
+
+// Hooks are called on the goroutine which holds the lease, they must not wait for Acquire
+// or Release, which may be the caller.
+type Hooks struct {
+	// a lease cannot be renewed or is lost
+	OnFailure func(bridge string, err error)
+	// the address of a lease was installed to or removed from its bridge,
+	// a removed address has the lease in StateInit
+	OnChange func(lease Lease)
+}
+
+var (
+	Leases *LeaseManager
+)
+
+// SetupLeaseManager restores the leases of bridges from db, the leases of other bridges are deleted.
+func SetupLeaseManager(bridges []string, hooks Hooks) error {
+	m, err := NewLeaseManager(bridges, hooks)
+	if err != nil {
+		return err
+	}
+	Leases = m
+	return nil
+}
+
+func NewLeaseManager(bridges []string, hooks Hooks) (*LeaseManager, error) {
+	leases, err := loadLeases()
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore dhcp leases from db: %v", err)
+	}
+
+	keep := make(map[string]bool)
+	for _, bridge := range bridges {
+		keep[bridge] = true
+	}
+	m := &LeaseManager{
+		bridges: make(map[string]*bridgeLease),
+		hooks:   hooks,
+	}
+	for key, lease := range leases {
+		if !keep[lease.Bridge] {
+			klog.Infof("delete dhcp lease %s of link %s which has no nic", lease.Address, lease.Bridge)
+			if err := db.DeleteLease(key); err != nil {
+				klog.Errorf("delete dhcp lease of link %s error: %v", lease.Bridge, err)
+			}
+			continue
+		}
+		b := newBridgeLease(lease.Bridge, lease.Family)
+		b.lease = lease
+		b.restored = true
+		m.bridges[key] = b
+		m.publish(b)
+		klog.Infof("restore dhcp lease %s of link %s, expires at %v", lease.Address, lease.Bridge, lease.Expiry)
+	}
+	return m, nil
+}
+
+func newBridgeLease(bridge string, family Family) *bridgeLease {
+	return &bridgeLease{
+		name:   bridge,
+		family: family,
+		proto:  protocolOf(family),
+	}
+}
+
+// Acquire gets a dhcpv4 lease for bridge and installs its address, then keeps the lease renewed until
+// Release. A valid lease is reused, so it is called again whenever the bridge is set up.
+func (m *LeaseManager) Acquire(bridge string) (*Lease, error) {
+	return m.acquire(bridge, FamilyIPv4)
+}
+
+// AcquireIPv6 is Acquire with a dhcpv6 lease, which is kept along with the dhcpv4 lease of bridge
+func (m *LeaseManager) AcquireIPv6(bridge string) (*Lease, error) {
+	return m.acquire(bridge, FamilyIPv6)
+}
+
+func (m *LeaseManager) acquire(bridge string, family Family) (*Lease, error) {
+	key := leaseKey(bridge, family)
+	m.lock.Lock()
+	b, ok := m.bridges[key]
+	if !ok {
+		b = newBridgeLease(bridge, family)
+		m.bridges[key] = b
+	}
+	m.lock.Unlock()
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.restored && b.valid(time.Now()) {
+		m.reboot(b)
+	}
+	b.restored = false
+
+	if b.valid(time.Now()) {
+		if err := b.lease.install(time.Now()); err != nil {
+			return nil, fmt.Errorf("failed to replace ip address for link %s: %v", bridge, err)
+		}
+	} else if err := m.discover(b); err != nil {
+		return nil, err
+	}
+
+	if !b.running {
+		b.running = true
+		b.stop = make(chan struct{})
+		go m.run(b)
+	}
+
+	lease := *b.lease
+	return &lease, nil
+}
+
+// Release stops renewing the leases of bridge, gives them back to the servers and deletes them.
+// It should be called before the bridge is deleted.
+func (m *LeaseManager) Release(bridge string) error {
+	for _, family := range []Family{FamilyIPv4, FamilyIPv6} {
+		if err := m.release(bridge, family); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *LeaseManager) release(bridge string, family Family) error {
+	key := leaseKey(bridge, family)
+	m.lock.Lock()
+	b, ok := m.bridges[key]
+	delete(m.bridges, key)
+	m.lock.Unlock()
+	if !ok {
+		return nil
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.running {
+		close(b.stop)
+		b.running = false
+	}
+	if b.valid(time.Now()) {
+		if err := b.proto.release(b.lease); err != nil {
+			klog.Warningf("release dhcp lease %s of link %s error: %v", b.lease.Address, bridge, err)
+		} else {
+			klog.Infof("release dhcp lease %s of link %s success", b.lease.Address, bridge)
+		}
+	}
+	leaseExpiry.DeleteLabelValues(bridge, string(family))
+
+	if err := db.DeleteLease(key); err != nil {
+		return fmt.Errorf("delete dhcp lease of link %s error: %v", bridge, err)
+	}
+	return nil
+}
+
+// Lease returns a copy of the lease of bridge in family, if it has one
+func (m *LeaseManager) Lease(bridge string, family Family) (Lease, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	b, ok := m.bridges[leaseKey(bridge, family)]
+	if !ok || b.snapshot == nil {
+		return Lease{}, false
+	}
+	return *b.snapshot, true
+}
+
+// Leases returns a copy of the leases of all bridges, ordered by bridge and family
+func (m *LeaseManager) Leases() []Lease {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	result := make([]Lease, 0, len(m.bridges))
+	for _, b := range m.bridges {
+		if b.snapshot != nil {
+			result = append(result, *b.snapshot)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Bridge != result[j].Bridge {
+			return result[i].Bridge < result[j].Bridge
+		}
+		return result[i].Family < result[j].Family
+	})
+	return result
+}
+
+// LeasesHandler serves the leases of all bridges as json
+func (m *LeaseManager) LeasesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(m.Leases())
+}
+
+func (m *LeaseManager) run(b *bridgeLease) {
+	for {
+		b.lock.Lock()
+		select {
+		case <-b.stop:
+			b.lock.Unlock()
+			return
+		default:
+		}
+		wait := m.maintain(b)
+		b.lock.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-b.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// maintain renews, rebinds or discovers the lease of b as due, and returns when it is due next
+func (m *LeaseManager) maintain(b *bridgeLease) time.Duration {
+	now := time.Now()
+	if b.valid(now) {
+		if now.Before(b.lease.Renew) {
+			return b.lease.Renew.Sub(now)
+		}
+
+		// ask the server of the lease until T2, then any server until expiry
+		state, next := StateRenewing, b.lease.Rebind
+		if !now.Before(b.lease.Rebind) {
+			state, next = StateRebinding, b.lease.Expiry
+		}
+		if b.lease.State != state {
+			b.lease.State = state
+			m.publish(b)
+		}
+
+		err := m.renew(b, state == StateRebinding)
+		if err == nil {
+			return time.Until(b.lease.Renew)
+		}
+		if err != errNAK {
+			m.fail(b, fmt.Errorf("%s error: %v", state, err))
+			return retryInterval(time.Until(next))
+		}
+		m.lose(b, leaseEventNAK)
+	} else if b.lease != nil && b.lease.State != StateInit {
+		m.lose(b, leaseEventExpired)
+	}
+
+	if err := m.discover(b); err != nil {
+		m.fail(b, err)
+		return discoverRetryInterval
+	}
+	return time.Until(b.lease.Renew)
+}
+
+func (m *LeaseManager) renew(b *bridgeLease, rebind bool) error {
+	lease, err := b.proto.renew(b.lease, rebind)
+	if err != nil {
+		return err
+	}
+
+	event := leaseEventRenewed
+	if rebind {
+		event = leaseEventRebound
+	}
+	leaseEvents.WithLabelValues(event).Inc()
+	klog.Infof("%s dhcp lease %s of link %s success", event, lease.Address, b.name)
+	return m.bind(b, lease)
+}
+
+// reboot verifies the lease restored from db, it is dropped if refused and kept
+// until expiry if no server replies.
+func (m *LeaseManager) reboot(b *bridgeLease) {
+	lease, err := b.proto.reboot(b.lease)
+	switch {
+	case err == nil:
+		klog.Infof("verify dhcp lease %s of link %s success", lease.Address, b.name)
+		if err := m.bind(b, lease); err != nil {
+			klog.Errorf("bind dhcp lease of link %s error: %v", b.name, err)
+		}
+	case err == errNAK:
+		m.lose(b, leaseEventNAK)
+	default:
+		klog.Warningf("verify dhcp lease %s of link %s error, keep using it until expiry: %v", b.lease.Address, b.name, err)
+	}
+}
+
+func (m *LeaseManager) discover(b *bridgeLease) error {
+	lease, err := b.proto.discover(b.name)
+	if err != nil {
+		return fmt.Errorf("failed to get %s address for link %s: %v", b.family, b.name, err)
+	}
+
+	leaseEvents.WithLabelValues(leaseEventAcquired).Inc()
+	klog.Infof("get ip addr %s success from dhcp server for link %s", lease.Address, b.name)
+	return m.bind(b, lease)
+}
+
+// bind takes lease as the lease of b, replaces its address to the bridge and saves it
+func (m *LeaseManager) bind(b *bridgeLease, lease *Lease) error {
+	if b.lease != nil && b.lease.Address != lease.Address {
+		if err := b.lease.uninstall(); err != nil {
+			klog.Errorf("remove dhcp lease %s of link %s error: %v", b.lease.Address, b.name, err)
+		}
+	}
+	b.lease = lease
+	m.publish(b)
+
+	err := lease.install(time.Now())
+	m.changed(b)
+	if err != nil {
+		return fmt.Errorf("failed to replace ip address for link %s: %v", b.name, err)
+	}
+	return nil
+}
+
+// lose removes the address of a lease which was refused or has expired, a new one is discovered then
+func (m *LeaseManager) lose(b *bridgeLease, event string) {
+	leaseEvents.WithLabelValues(event).Inc()
+	m.fail(b, fmt.Errorf("lost lease %s: %s", b.lease.Address, event))
+
+	if err := b.lease.uninstall(); err != nil {
+		klog.Errorf("remove dhcp lease %s of link %s error: %v", b.lease.Address, b.name, err)
+	}
+	b.lease.State = StateInit
+	m.publish(b)
+	m.changed(b)
+}
+
+func (m *LeaseManager) fail(b *bridgeLease, err error) {
+	leaseEvents.WithLabelValues(leaseEventFailed).Inc()
+	klog.Errorf("dhcp %s lease of link %s error: %v", b.family, b.name, err)
+	if m.hooks.OnFailure != nil {
+		m.hooks.OnFailure(b.name, err)
+	}
+}
+
+func (m *LeaseManager) changed(b *bridgeLease) {
+	if m.hooks.OnChange != nil {
+		m.hooks.OnChange(*b.lease)
+	}
+}
+
+// publish saves the lease of b to db, and updates the copy for Leases and the metrics
+func (m *LeaseManager) publish(b *bridgeLease) {
+	lease := *b.lease
+	m.lock.Lock()
+	b.snapshot = &lease
+	m.lock.Unlock()
+
+	leaseExpiry.WithLabelValues(b.name, string(b.family)).Set(float64(lease.Expiry.Unix()))
+	if err := lease.save(); err != nil {
+		klog.Errorf("save dhcp lease of link %s error: %v", b.name, err)
+	}
+}
+
+// valid returns true if b has a lease which is neither lost nor expired at now
+func (b *bridgeLease) valid(now time.Time) bool {
+	return b.lease != nil && b.lease.State != StateInit && now.Before(b.lease.Expiry)
+}
+
+func retryInterval(left time.Duration) time.Duration {
+	if left <= 0 {
+		return 0
+	}
+	if left/2 > minRetryInterval {
+		return left / 2
+	}
+	if left > minRetryInterval {
+		return minRetryInterval
+	}
+	return left
+}
+
+// InstallHandlers registers /debug/dhcp/leases on mux, it serves the leases of Leases once it is set up.
+func InstallHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/dhcp/leases", func(w http.ResponseWriter, r *http.Request) {
+		if Leases == nil {
+			http.Error(w, "dhcp lease manager is not set up", http.StatusServiceUnavailable)
+			return
+		}
+		Leases.LeasesHandler(w, r)
+	})
+}
@@ -0,0 +1,526 @@
+package dhcp
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/containernetworking/plugins/pkg/utils/sysctl"
+	"github.com/insomniacslk/dhcp/dhcpv4"
+	"github.com/insomniacslk/dhcp/dhcpv4/server4"
+	"github.com/insomniacslk/dhcp/dhcpv6"
+	"github.com/insomniacslk/dhcp/iana"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+
+	"github.com/yunify/hostnic-cni/pkg/db"
+)
+
+const (
+	testClientLink = "hndhcp0"
+	testServerLink = "hndhcp1"
+)
+
+var testServerIP = net.IPv4(198, 18, 0, 1).To4()
+
+// testServer grants short leases on 198.18.0.0/24, and counts the messages it receives by kind.
+// It refuses renew and reboot with nak, and does not reply them with ignore.
+type testServer struct {
+	lock     sync.Mutex
+	next     byte
+	leases   map[string]net.IP
+	nak      bool
+	ignore   bool
+	received map[string]int
+}
+
+func (s *testServer) handle(conn net.PacketConn, peer net.Addr, m *dhcpv4.DHCPv4) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	mac := m.ClientHWAddr.String()
+	var kind string
+	msgType := dhcpv4.MessageTypeAck
+	switch m.MessageType() {
+	case dhcpv4.MessageTypeDiscover:
+		kind, msgType = "discover", dhcpv4.MessageTypeOffer
+		if s.leases[mac] == nil {
+			s.next++
+			s.leases[mac] = net.IPv4(198, 18, 0, 10+s.next).To4()
+		}
+	case dhcpv4.MessageTypeRequest:
+		switch {
+		case !m.ClientIPAddr.IsUnspecified():
+			kind = "renew"
+		case m.ServerIdentifier() == nil:
+			kind = "reboot"
+		default:
+			kind = "select"
+		}
+		if s.nak && kind != "select" {
+			msgType = dhcpv4.MessageTypeNak
+			delete(s.leases, mac)
+		}
+	case dhcpv4.MessageTypeRelease:
+		kind = "release"
+		delete(s.leases, mac)
+	}
+	s.received[kind]++
+	if kind == "release" || (s.ignore && kind == "renew") {
+		return
+	}
+
+	reply, err := dhcpv4.NewReplyFromRequest(m,
+		dhcpv4.WithMessageType(msgType),
+		dhcpv4.WithOption(dhcpv4.OptServerIdentifier(testServerIP)),
+	)
+	if err != nil {
+		return
+	}
+	if msgType != dhcpv4.MessageTypeNak {
+		reply.YourIPAddr = s.leases[mac]
+		reply.UpdateOption(dhcpv4.OptSubnetMask(net.CIDRMask(24, 32)))
+		reply.UpdateOption(dhcpv4.OptIPAddressLeaseTime(6 * time.Second))
+		reply.UpdateOption(dhcpv4.Option{Code: dhcpv4.OptionRenewTimeValue, Value: dhcpv4.Duration(2 * time.Second)})
+		reply.UpdateOption(dhcpv4.Option{Code: dhcpv4.OptionRebindingTimeValue, Value: dhcpv4.Duration(4 * time.Second)})
+	}
+	conn.WriteTo(reply.ToBytes(), peer)
+}
+
+// testServer6 grants short dhcpv6 leases on 2001:db8::/64, it refuses renew and rebind with nak
+type testServer6 struct {
+	lock     sync.Mutex
+	conn     *net.UDPConn
+	next     byte
+	leases   map[string]net.IP
+	nak      bool
+	received map[dhcpv6.MessageType]int
+}
+
+var testServerDUID = &dhcpv6.DUIDLL{HWType: iana.HWTypeEthernet, LinkLayerAddr: net.HardwareAddr{2, 0, 0, 0, 0, 1}}
+
+func (s *testServer6) serve() {
+	buf := make([]byte, 1500)
+	for {
+		n, peer, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		m, err := dhcpv6.MessageFromBytes(buf[:n])
+		if err != nil {
+			continue
+		}
+		if reply := s.handle(m); reply != nil {
+			s.conn.WriteTo(reply.ToBytes(), peer)
+		}
+	}
+}
+
+func (s *testServer6) handle(m *dhcpv6.Message) *dhcpv6.Message {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.received[m.MessageType]++
+
+	clientID, ia := m.Options.ClientID(), m.Options.OneIANA()
+	if clientID == nil || ia == nil {
+		return nil
+	}
+	client := clientID.String()
+	msgType := dhcpv6.MessageTypeReply
+	switch m.MessageType {
+	case dhcpv6.MessageTypeSolicit:
+		msgType = dhcpv6.MessageTypeAdvertise
+		if s.leases[client] == nil {
+			s.next++
+			s.leases[client] = net.ParseIP(fmt.Sprintf("2001:db8::%d", 10+s.next))
+		}
+	case dhcpv6.MessageTypeRenew, dhcpv6.MessageTypeRebind:
+		if s.nak {
+			delete(s.leases, client)
+		}
+	case dhcpv6.MessageTypeRelease:
+		delete(s.leases, client)
+		return nil
+	}
+
+	reply := &dhcpv6.Message{MessageType: msgType, TransactionID: m.TransactionID}
+	reply.AddOption(dhcpv6.OptClientID(clientID))
+	reply.AddOption(dhcpv6.OptServerID(testServerDUID))
+	replyIA := &dhcpv6.OptIANA{IaId: ia.IaId, T1: 2 * time.Second, T2: 4 * time.Second}
+	if addr := s.leases[client]; addr != nil {
+		replyIA.Options.Add(&dhcpv6.OptIAAddress{IPv6Addr: addr, PreferredLifetime: 5 * time.Second, ValidLifetime: 6 * time.Second})
+	} else {
+		replyIA.Options.Add(&dhcpv6.OptStatusCode{StatusCode: iana.StatusNoBinding})
+	}
+	reply.AddOption(replyIA)
+	return reply
+}
+
+func (s *testServer6) count(msgType dhcpv6.MessageType) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.received[msgType]
+}
+
+func (s *testServer6) set(nak bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.nak = nak
+}
+
+func (s *testServer) count(kind string) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.received[kind]
+}
+
+func (s *testServer) set(nak, ignore bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.nak, s.ignore = nak, ignore
+}
+
+// setupTestServer runs a testServer and a testServer6 in a new netns, behind a veth pair with testClientLink in this netns
+func setupTestServer(t *testing.T) (*testServer, *testServer6) {
+	veth := &netlink.Veth{
+		LinkAttrs: netlink.LinkAttrs{Name: testClientLink},
+		PeerName:  testServerLink,
+	}
+	if err := netlink.LinkAdd(veth); err != nil {
+		t.Fatalf("failed to add veth: %v", err)
+	}
+	t.Cleanup(func() { netlink.LinkDel(veth) })
+
+	peer, err := netlink.LinkByName(testServerLink)
+	if err != nil {
+		t.Fatalf("failed to get %s: %v", testServerLink, err)
+	}
+
+	// the netns of the thread is switched to setup the server
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	origNS, err := netns.Get()
+	if err != nil {
+		t.Fatalf("failed to get netns: %v", err)
+	}
+	defer origNS.Close()
+	serverNS, err := netns.New()
+	if err != nil {
+		t.Fatalf("failed to create netns: %v", err)
+	}
+	defer netns.Set(origNS)
+	t.Cleanup(func() { serverNS.Close() })
+
+	if err := netns.Set(origNS); err != nil {
+		t.Fatalf("failed to set netns back: %v", err)
+	}
+	if err := netlink.LinkSetNsFd(peer, int(serverNS)); err != nil {
+		t.Fatalf("failed to move %s to netns: %v", testServerLink, err)
+	}
+	if err := netns.Set(serverNS); err != nil {
+		t.Fatalf("failed to set netns: %v", err)
+	}
+	peer, err = netlink.LinkByName(testServerLink)
+	if err != nil {
+		t.Fatalf("failed to get %s: %v", testServerLink, err)
+	}
+	// link-local addresses are used at once without duplicate address detection
+	if _, err := sysctl.Sysctl(fmt.Sprintf("net/ipv6/conf/%s/accept_dad", testServerLink), "0"); err != nil {
+		t.Fatalf("failed to disable dad of %s: %v", testServerLink, err)
+	}
+	addr := &netlink.Addr{IPNet: &net.IPNet{IP: testServerIP, Mask: net.CIDRMask(24, 32)}}
+	if err := netlink.AddrAdd(peer, addr); err != nil {
+		t.Fatalf("failed to add addr to %s: %v", testServerLink, err)
+	}
+	if err := netlink.LinkSetUp(peer); err != nil {
+		t.Fatalf("failed to set %s up: %v", testServerLink, err)
+	}
+
+	s := &testServer{
+		leases:   make(map[string]net.IP),
+		received: make(map[string]int),
+	}
+	server, err := server4.NewServer(testServerLink, nil, s.handle)
+	if err != nil {
+		t.Fatalf("failed to start dhcp server: %v", err)
+	}
+	go server.Serve()
+	t.Cleanup(func() { server.Close() })
+
+	serverIface, err := net.InterfaceByName(testServerLink)
+	if err != nil {
+		t.Fatalf("failed to get %s: %v", testServerLink, err)
+	}
+	conn, err := net.ListenMulticastUDP("udp6", serverIface, &net.UDPAddr{
+		IP:   dhcpv6.AllDHCPRelayAgentsAndServers,
+		Port: dhcpv6.DefaultServerPort,
+	})
+	if err != nil {
+		t.Fatalf("failed to start dhcpv6 server: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	s6 := &testServer6{
+		conn:     conn,
+		leases:   make(map[string]net.IP),
+		received: make(map[dhcpv6.MessageType]int),
+	}
+	go s6.serve()
+
+	if err := netns.Set(origNS); err != nil {
+		t.Fatalf("failed to set netns back: %v", err)
+	}
+	if _, err := sysctl.Sysctl(fmt.Sprintf("net/ipv6/conf/%s/accept_dad", testClientLink), "0"); err != nil {
+		t.Fatalf("failed to disable dad of %s: %v", testClientLink, err)
+	}
+	link, err := netlink.LinkByName(testClientLink)
+	if err == nil {
+		err = netlink.LinkSetUp(link)
+	}
+	if err != nil {
+		t.Fatalf("failed to set %s up: %v", testClientLink, err)
+	}
+	return s, s6
+}
+
+func setupTestDB(t *testing.T) {
+	db.DefaultStore = db.NewMemoryStore()
+}
+
+func waitFor(t *testing.T, what string, fn func() bool) {
+	deadline := time.Now().Add(10 * time.Second)
+	for !fn() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func linkHasAddr(t *testing.T, address string) bool {
+	link, err := netlink.LinkByName(testClientLink)
+	if err != nil {
+		t.Fatalf("failed to get %s: %v", testClientLink, err)
+	}
+	addrs, err := netlink.AddrList(link, netlink.FAMILY_ALL)
+	if err != nil {
+		t.Fatalf("failed to list addrs of %s: %v", testClientLink, err)
+	}
+	for _, addr := range addrs {
+		if addr.IPNet.String() == address {
+			return true
+		}
+	}
+	return false
+}
+
+func onlyLease(t *testing.T, m *LeaseManager) Lease {
+	leases := m.Leases()
+	if len(leases) != 1 {
+		t.Fatalf("expect 1 lease, got %v", leases)
+	}
+	return leases[0]
+}
+
+func TestLeaseManager(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("requires root to setup veth and netns")
+	}
+	exchangeTimeout, minRetryInterval, discoverRetryInterval = time.Second, time.Second, time.Second
+
+	setupTestDB(t)
+	server, _ := setupTestServer(t)
+	var lock sync.Mutex
+	var failures []string
+	var changes []Lease
+	hooks := Hooks{
+		OnFailure: func(bridge string, err error) {
+			lock.Lock()
+			defer lock.Unlock()
+			failures = append(failures, err.Error())
+		},
+		OnChange: func(lease Lease) {
+			lock.Lock()
+			defer lock.Unlock()
+			changes = append(changes, lease)
+		},
+	}
+	changed := func(address string, state LeaseState) bool {
+		lock.Lock()
+		defer lock.Unlock()
+		for _, lease := range changes {
+			if lease.Address == address && lease.State == state {
+				return true
+			}
+		}
+		return false
+	}
+	failed := func(substr string) bool {
+		lock.Lock()
+		defer lock.Unlock()
+		for _, failure := range failures {
+			if strings.Contains(failure, substr) {
+				return true
+			}
+		}
+		return false
+	}
+	m, err := NewLeaseManager(nil, hooks)
+	if err != nil {
+		t.Fatalf("failed to create lease manager: %v", err)
+	}
+
+	lease, err := m.Acquire(testClientLink)
+	if err != nil {
+		t.Fatalf("failed to acquire lease: %v", err)
+	}
+	if lease.State != StateBound || !linkHasAddr(t, lease.Address) {
+		t.Fatalf("expect bound lease installed, got %+v", lease)
+	}
+	if leases, _ := loadLeases(); leases[testClientLink] == nil || leases[testClientLink].Address != lease.Address {
+		t.Fatalf("expect lease %s saved, got %v", lease.Address, leases)
+	}
+
+	// renewed at T1 with the same address
+	waitFor(t, "renew", func() bool { return onlyLease(t, m).Bound.After(lease.Bound) })
+	if renewed := onlyLease(t, m); renewed.Address != lease.Address || server.count("renew") == 0 {
+		t.Fatalf("expect lease %s renewed, got %+v", lease.Address, renewed)
+	}
+
+	// a refused lease is dropped, and a new one is discovered
+	server.set(true, false)
+	waitFor(t, "new lease", func() bool {
+		current := onlyLease(t, m)
+		return current.State == StateBound && current.Address != lease.Address
+	})
+	server.set(false, false)
+	current := onlyLease(t, m)
+	if !failed(leaseEventNAK) || linkHasAddr(t, lease.Address) || !linkHasAddr(t, current.Address) {
+		t.Fatalf("expect address replaced from %s to %s on nak", lease.Address, current.Address)
+	}
+	if !changed(lease.Address, StateInit) || !changed(current.Address, StateBound) {
+		t.Fatalf("expect changes of %s removed and %s installed, got %+v", lease.Address, current.Address, changes)
+	}
+
+	// without replies the lease is renewed and rebound in vain until it expires, then discovered again
+	server.set(false, true)
+	waitFor(t, "expiry", func() bool { return failed(leaseEventExpired) })
+	server.set(false, false)
+	if !failed(string(StateRebinding)) {
+		t.Fatalf("expect lease rebound before expiry, got failures %v", failures)
+	}
+	waitFor(t, "lease after expiry", func() bool {
+		lease := onlyLease(t, m)
+		return lease.State == StateBound && lease.Bound.After(current.Expiry)
+	})
+	current = onlyLease(t, m)
+
+	// after restart the lease restored from db is verified with the server
+	m.lock.Lock()
+	for _, b := range m.bridges {
+		b.lock.Lock()
+		close(b.stop)
+		b.running = false
+		b.lock.Unlock()
+	}
+	m.lock.Unlock()
+	restarted, err := NewLeaseManager([]string{testClientLink}, Hooks{})
+	if err != nil {
+		t.Fatalf("failed to restore lease manager: %v", err)
+	}
+	if restored := onlyLease(t, restarted); restored.Address != current.Address {
+		t.Fatalf("expect lease %s restored, got %+v", current.Address, restored)
+	}
+	lease, err = restarted.Acquire(testClientLink)
+	if err != nil {
+		t.Fatalf("failed to acquire lease after restart: %v", err)
+	}
+	if lease.Address != current.Address || server.count("reboot") != 1 {
+		t.Fatalf("expect lease %s verified, got %+v", current.Address, lease)
+	}
+
+	if err := restarted.Release(testClientLink); err != nil {
+		t.Fatalf("failed to release lease: %v", err)
+	}
+	waitFor(t, "release", func() bool { return server.count("release") == 1 })
+	if leases, _ := loadLeases(); len(leases) != 0 || len(restarted.Leases()) != 0 {
+		t.Fatalf("expect no lease after release, got %v", leases)
+	}
+}
+
+func TestLeaseManagerIPv6(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("requires root to setup veth and netns")
+	}
+	exchangeTimeout, minRetryInterval, discoverRetryInterval = time.Second, time.Second, time.Second
+
+	setupTestDB(t)
+	_, server := setupTestServer(t)
+	m, err := NewLeaseManager(nil, Hooks{})
+	if err != nil {
+		t.Fatalf("failed to create lease manager: %v", err)
+	}
+	onlyLease6 := func() Lease {
+		lease, ok := m.Lease(testClientLink, FamilyIPv6)
+		if !ok {
+			t.Fatalf("expect dhcpv6 lease of %s, got %v", testClientLink, m.Leases())
+		}
+		return lease
+	}
+
+	lease, err := m.AcquireIPv6(testClientLink)
+	if err != nil {
+		t.Fatalf("failed to acquire lease: %v", err)
+	}
+	if lease.Family != FamilyIPv6 || !strings.HasSuffix(lease.Address, "/128") || !linkHasAddr(t, lease.Address) {
+		t.Fatalf("expect bound dhcpv6 lease installed, got %+v", lease)
+	}
+	if leases, _ := loadLeases(); leases[testClientLink+"/ipv6"] == nil {
+		t.Fatalf("expect lease %s saved, got %v", lease.Address, leases)
+	}
+
+	// renewed at T1 from the server of the lease
+	waitFor(t, "renew", func() bool { return onlyLease6().Bound.After(lease.Bound) })
+	if renewed := onlyLease6(); renewed.Address != lease.Address || server.count(dhcpv6.MessageTypeRenew) == 0 {
+		t.Fatalf("expect lease %s renewed, got %+v", lease.Address, renewed)
+	}
+
+	// a lease without binding is dropped, and a new one is solicited
+	server.set(true)
+	waitFor(t, "new lease", func() bool {
+		current := onlyLease6()
+		return current.State == StateBound && current.Address != lease.Address
+	})
+	server.set(false)
+	if current := onlyLease6(); linkHasAddr(t, lease.Address) || !linkHasAddr(t, current.Address) {
+		t.Fatalf("expect address replaced from %s to %s on nak", lease.Address, current.Address)
+	}
+
+	if err := m.Release(testClientLink); err != nil {
+		t.Fatalf("failed to release lease: %v", err)
+	}
+	waitFor(t, "release", func() bool { return server.count(dhcpv6.MessageTypeRelease) == 1 })
+	if leases, _ := loadLeases(); len(leases) != 0 || len(m.Leases()) != 0 {
+		t.Fatalf("expect no lease after release, got %v", leases)
+	}
+}
+
+func TestRetryInterval(t *testing.T) {
+	minRetryInterval = 60 * time.Second
+	for _, c := range []struct {
+		left, expect time.Duration
+	}{
+		{-time.Second, 0},
+		{30 * time.Second, 30 * time.Second},
+		{90 * time.Second, 60 * time.Second},
+		{10 * time.Minute, 5 * time.Minute},
+	} {
+		if got := retryInterval(c.left); got != c.expect {
+			t.Errorf("expect retry after %v with %v left, got %v", c.expect, c.left, got)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package dhcp
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// events of the leases
+const (
+	leaseEventAcquired = "acquired"
+	leaseEventRenewed  = "renewed"
+	leaseEventRebound  = "rebound"
+	leaseEventNAK      = "nak"
+	leaseEventExpired  = "expired"
+	leaseEventFailed   = "failed"
+)
+
+var (
+	leaseEvents = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hostnic_dhcp_lease_events_total",
+			Help: "dhcp leases of vlan bridges acquired, renewed, rebound, refused, expired or failed to get with hostnic cni",
+		},
+		[]string{"event"},
+	)
+	leaseExpiry = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "hostnic_dhcp_lease_expiry_timestamp_seconds",
+			Help: "expiry of the dhcp leases of each vlan bridge by family with hostnic cni",
+		},
+		[]string{"bridge", "family"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(leaseEvents, leaseExpiry)
+}
@@ -0,0 +1,135 @@
+// Package events records the pod network failures of hostnic-node as
+// Kubernetes Events on the affected Pod or Node.
+//
+// Events go through the client-go correlator, which aggregates similar events
+// and rate-limits each object with a token bucket. Identical events of an object
+// are dropped before that for dedupWindow, so a crash-looping pod produces
+// one event per window instead of one api call per retry.
+package events
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	clientcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+	log "k8s.io/klog/v2"
+)
+
+// reasons of the events on pods
+const (
+	ReasonAllocateIPFailed  = "AllocateIPFailed"
+	ReasonFixedIPConflict   = "FixedIPConflict"
+	ReasonAllocateNicFailed = "AllocateNicFailed"
+)
+
+// reasons of the events on nodes
+const (
+	ReasonMaxNicReached        = "MaxNicReached"
+	ReasonNicRepaired          = "NicRepaired"
+	ReasonNicRepairFailed      = "NicRepairFailed"
+	ReasonDHCPLeaseRenewFailed = "DHCPLeaseRenewFailed"
+	ReasonOrphanNicAdopted     = "OrphanNicAdopted"
+	ReasonOrphanNicDeleted     = "OrphanNicDeleted"
+)
+
+const (
+	// identical events of an object are recorded at most once per dedupWindow
+	dedupWindow    = 5 * time.Minute
+	dedupCacheSize = 4096
+
+	// each object may burst spamBurst events, then gets one every 1/spamQPS seconds
+	spamBurst = 10
+	spamQPS   = 1. / 60.
+)
+
+type Recorder struct {
+	broadcaster record.EventBroadcaster
+	recorder    record.EventRecorder
+	node        *corev1.ObjectReference
+	recent      *cache.LRUExpireCache
+}
+
+var (
+	// nil until SetupEventRecorder, events are only logged then
+	defaultRecorder *Recorder
+)
+
+// SetupEventRecorder starts recording events of component on node nodeName to the apiserver
+func SetupEventRecorder(k8sClient kubernetes.Interface, component, nodeName string) {
+	broadcaster := record.NewBroadcasterWithCorrelatorOptions(record.CorrelatorOptions{
+		BurstSize: spamBurst,
+		QPS:       spamQPS,
+	})
+	broadcaster.StartRecordingToSink(&clientcorev1.EventSinkImpl{Interface: k8sClient.CoreV1().Events("")})
+
+	defaultRecorder = &Recorder{
+		broadcaster: broadcaster,
+		recorder:    broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: component, Host: nodeName}),
+		node:        NodeReference(nodeName),
+		recent:      cache.NewLRUExpireCache(dedupCacheSize),
+	}
+}
+
+// Shutdown stops recording, events not yet sent are dropped
+func Shutdown() {
+	if defaultRecorder != nil {
+		defaultRecorder.broadcaster.Shutdown()
+	}
+}
+
+// PodReference refers to a pod, uid may be empty if the pod could not be read
+func PodReference(namespace, name string, uid types.UID) *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		Kind:       "Pod",
+		APIVersion: "v1",
+		Namespace:  namespace,
+		Name:       name,
+		UID:        uid,
+	}
+}
+
+// NodeReference refers to a node the way kubelet does, so that the events show up in kubectl describe node
+func NodeReference(name string) *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		Kind: "Node",
+		Name: name,
+		UID:  types.UID(name),
+	}
+}
+
+// Eventf records an event on ref
+func Eventf(ref *corev1.ObjectReference, eventtype, reason, messageFmt string, args ...interface{}) {
+	defaultRecorder.eventf(ref, eventtype, reason, messageFmt, args...)
+}
+
+// NodeEventf records an event on the node hostnic-node runs on
+func NodeEventf(eventtype, reason, messageFmt string, args ...interface{}) {
+	if defaultRecorder == nil {
+		log.V(4).Infof("drop node event %s: %s", reason, fmt.Sprintf(messageFmt, args...))
+		return
+	}
+	defaultRecorder.eventf(defaultRecorder.node, eventtype, reason, messageFmt, args...)
+}
+
+func (r *Recorder) eventf(ref *corev1.ObjectReference, eventtype, reason, messageFmt string, args ...interface{}) {
+	message := fmt.Sprintf(messageFmt, args...)
+	if r == nil {
+		log.V(4).Infof("drop event %s of %s %s/%s: %s", reason, ref.Kind, ref.Namespace, ref.Name, message)
+		return
+	}
+
+	key := fmt.Sprintf("%s/%s/%s/%s/%s/%s", ref.Kind, ref.Namespace, ref.Name, ref.UID, reason, message)
+	if _, ok := r.recent.Get(key); ok {
+		log.V(4).Infof("suppress duplicated event %s of %s %s/%s", reason, ref.Kind, ref.Namespace, ref.Name)
+		return
+	}
+	r.recent.Add(key, struct{}{}, dedupWindow)
+
+	r.recorder.Event(ref, eventtype, reason, message)
+}
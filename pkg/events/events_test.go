@@ -0,0 +1,89 @@
+package events
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/client-go/tools/record"
+)
+
+func newTestRecorder() (*Recorder, *record.FakeRecorder) {
+	fake := record.NewFakeRecorder(16)
+	return &Recorder{
+		recorder: fake,
+		node:     NodeReference("node1"),
+		recent:   cache.NewLRUExpireCache(dedupCacheSize),
+	}, fake
+}
+
+// recorded returns the number of events the fake recorder received
+func recorded(fake *record.FakeRecorder) int {
+	n := 0
+	for {
+		select {
+		case <-fake.Events:
+			n++
+		default:
+			return n
+		}
+	}
+}
+
+func TestEventfDedup(t *testing.T) {
+	r, fake := newTestRecorder()
+	pod := PodReference("default", "pod1", "uid1")
+
+	r.eventf(pod, corev1.EventTypeWarning, ReasonAllocateIPFailed, "no ip in %s", "pool1")
+	r.eventf(pod, corev1.EventTypeWarning, ReasonAllocateIPFailed, "no ip in %s", "pool1")
+	if n := recorded(fake); n != 1 {
+		t.Fatalf("expect identical events within the window to be recorded once, got %d", n)
+	}
+
+	// a different reason, message or object is another event
+	r.eventf(pod, corev1.EventTypeWarning, ReasonAllocateNicFailed, "no ip in %s", "pool1")
+	r.eventf(pod, corev1.EventTypeWarning, ReasonAllocateIPFailed, "no ip in %s", "pool2")
+	r.eventf(PodReference("default", "pod2", "uid2"), corev1.EventTypeWarning, ReasonAllocateIPFailed, "no ip in %s", "pool1")
+	r.eventf(PodReference("default", "pod1", "uid3"), corev1.EventTypeWarning, ReasonAllocateIPFailed, "no ip in %s", "pool1")
+	r.eventf(r.node, corev1.EventTypeWarning, ReasonAllocateIPFailed, "no ip in %s", "pool1")
+	if n := recorded(fake); n != 5 {
+		t.Fatalf("expect 5 distinct events to be recorded, got %d", n)
+	}
+
+	r.eventf(pod, corev1.EventTypeWarning, ReasonAllocateNicFailed, "no ip in %s", "pool1")
+	if n := recorded(fake); n != 0 {
+		t.Fatalf("expect the repeated event to be suppressed, got %d", n)
+	}
+}
+
+func TestEventfWithoutRecorder(t *testing.T) {
+	saved := defaultRecorder
+	defaultRecorder = nil
+	defer func() { defaultRecorder = saved }()
+
+	// only logged until SetupEventRecorder
+	Eventf(PodReference("default", "pod1", ""), corev1.EventTypeWarning, ReasonAllocateIPFailed, "no ip in %s", "pool1")
+	NodeEventf(corev1.EventTypeWarning, ReasonMaxNicReached, "%d nics", 64)
+	Shutdown()
+}
+
+func TestNodeEventf(t *testing.T) {
+	r, fake := newTestRecorder()
+	saved := defaultRecorder
+	defaultRecorder = r
+	defer func() { defaultRecorder = saved }()
+
+	NodeEventf(corev1.EventTypeWarning, ReasonMaxNicReached, "%d nics", 64)
+	NodeEventf(corev1.EventTypeWarning, ReasonMaxNicReached, "%d nics", 64)
+	select {
+	case event := <-fake.Events:
+		if event != "Warning MaxNicReached 64 nics" {
+			t.Errorf("unexpected event %q", event)
+		}
+	default:
+		t.Fatal("expect an event on the node")
+	}
+	if n := recorded(fake); n != 0 {
+		t.Errorf("expect the identical node event to be suppressed, got %d", n)
+	}
+}
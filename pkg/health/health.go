@@ -0,0 +1,292 @@
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// names of the checks reported by hostnic-node
+const (
+	CheckClusterConfig = "cluster-config-sync"
+	CheckIPAMClient    = "ipam-client-sync"
+	CheckAllocator     = "allocator-restore"
+	CheckQingCloud     = "qingcloud-api"
+)
+
+// names of the liveness checks of hostnic-node, /healthz fails if one of them fails
+const (
+	LivenessAllocator  = "allocator-lock"
+	LivenessGRPCServer = "grpc-server"
+)
+
+// qingcloud api is reported unhealthy after this many consecutive failures,
+// a single failed call (e.g. a resource not found) should not flip readiness.
+const qingcloudFailureThreshold = 3
+
+type CheckStatus struct {
+	Name       string    `json:"name"`
+	Healthy    bool      `json:"healthy"`
+	Message    string    `json:"message,omitempty"`
+	LastUpdate time.Time `json:"lastUpdate"`
+}
+
+// livenessCheck fails once it is dead or its last heartbeat is older than timeout, it
+// needs no heartbeat if timeout is 0
+type livenessCheck struct {
+	timeout time.Duration
+	last    time.Time
+	err     error
+}
+
+type Checker struct {
+	lock     sync.RWMutex
+	checks   map[string]*CheckStatus
+	liveness map[string]*livenessCheck
+	now      func() time.Time
+	watchers []func(ready bool)
+	// serializes notifications so watchers always see the latest readiness
+	notifyLock sync.Mutex
+
+	qcFailures int
+}
+
+var (
+	Default = NewChecker()
+)
+
+func NewChecker() *Checker {
+	return &Checker{
+		checks:   make(map[string]*CheckStatus),
+		liveness: make(map[string]*livenessCheck),
+		now:      time.Now,
+	}
+}
+
+// Register adds a check which is unhealthy until it is reported otherwise.
+func (c *Checker) Register(name, message string) {
+	c.set(name, false, message)
+}
+
+func (c *Checker) SetHealthy(name, message string) {
+	c.set(name, true, message)
+}
+
+func (c *Checker) SetUnhealthy(name string, err error) {
+	c.set(name, false, err.Error())
+}
+
+// RegisterLiveness adds a liveness check which fails if no heartbeat is seen within timeout.
+func (c *Checker) RegisterLiveness(name string, timeout time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.liveness[name] = &livenessCheck{timeout: timeout, last: c.now()}
+}
+
+// Heartbeat reports the liveness check alive.
+func (c *Checker) Heartbeat(name string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if check, ok := c.liveness[name]; ok {
+		check.last = c.now()
+		check.err = nil
+	}
+}
+
+// SetDead fails the liveness check until its next heartbeat.
+func (c *Checker) SetDead(name string, err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if check, ok := c.liveness[name]; ok {
+		check.err = err
+	}
+}
+
+// Live returns true if all liveness checks pass, along with each check's status.
+func (c *Checker) Live() (bool, []CheckStatus) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	live := true
+	now := c.now()
+	result := make([]CheckStatus, 0, len(c.liveness))
+	for name, check := range c.liveness {
+		status := CheckStatus{Name: name, Healthy: true, LastUpdate: check.last}
+		if check.err != nil {
+			status.Healthy = false
+			status.Message = check.err.Error()
+		} else if check.timeout > 0 && now.Sub(check.last) > check.timeout {
+			status.Healthy = false
+			status.Message = fmt.Sprintf("no heartbeat for %v", now.Sub(check.last).Round(time.Second))
+		}
+		if !status.Healthy {
+			live = false
+		}
+		result = append(result, status)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return live, result
+}
+
+// ReportQingCloudResult records the result of a qingcloud api call.
+func (c *Checker) ReportQingCloudResult(method string, err error) {
+	c.lock.Lock()
+	if err == nil {
+		c.qcFailures = 0
+	} else {
+		c.qcFailures++
+	}
+	failures := c.qcFailures
+	c.lock.Unlock()
+
+	if err == nil {
+		if status, ok := c.Get(CheckQingCloud); !ok || !status.Healthy {
+			c.set(CheckQingCloud, true, fmt.Sprintf("%s succeeded", method))
+		}
+		return
+	}
+
+	c.set(CheckQingCloud, failures < qingcloudFailureThreshold,
+		fmt.Sprintf("%d consecutive failures, last %s: %v", failures, method, err))
+}
+
+// Watch registers fn to be called with the readiness whenever a check changes.
+func (c *Checker) Watch(fn func(ready bool)) {
+	c.lock.Lock()
+	c.watchers = append(c.watchers, fn)
+	c.lock.Unlock()
+
+	c.notify()
+}
+
+func (c *Checker) Get(name string) (CheckStatus, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	status, ok := c.checks[name]
+	if !ok {
+		return CheckStatus{}, false
+	}
+	return *status, true
+}
+
+// Ready returns true if all registered checks are healthy, along with each check's status.
+func (c *Checker) Ready() (bool, []CheckStatus) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	ready := true
+	result := make([]CheckStatus, 0, len(c.checks))
+	for _, status := range c.checks {
+		if !status.Healthy {
+			ready = false
+		}
+		result = append(result, *status)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return ready, result
+}
+
+func (c *Checker) set(name string, healthy bool, message string) {
+	c.lock.Lock()
+	c.checks[name] = &CheckStatus{
+		Name:       name,
+		Healthy:    healthy,
+		Message:    message,
+		LastUpdate: time.Now(),
+	}
+	c.lock.Unlock()
+
+	c.notify()
+}
+
+func (c *Checker) notify() {
+	c.notifyLock.Lock()
+	defer c.notifyLock.Unlock()
+
+	c.lock.RLock()
+	watchers := c.watchers
+	c.lock.RUnlock()
+
+	ready, _ := c.Ready()
+	for _, fn := range watchers {
+		fn(ready)
+	}
+}
+
+type response struct {
+	Status string        `json:"status"`
+	Checks []CheckStatus `json:"checks,omitempty"`
+}
+
+// HealthzHandler reports liveness, it fails when a liveness check fails, e.g. the allocator is
+// stuck or the grpc server is gone, readiness checks do not fail it.
+func (c *Checker) HealthzHandler(w http.ResponseWriter, r *http.Request) {
+	live, checks := c.Live()
+	writeResponse(w, live, checks)
+}
+
+// ReadyzHandler reports readiness, it fails until every check is healthy.
+func (c *Checker) ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	ready, checks := c.Ready()
+	writeResponse(w, ready, checks)
+}
+
+func writeResponse(w http.ResponseWriter, ok bool, checks []CheckStatus) {
+	resp := response{
+		Status: "ok",
+		Checks: checks,
+	}
+	code := http.StatusOK
+	if !ok {
+		resp.Status = "failed"
+		code = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func Register(name, message string) {
+	Default.Register(name, message)
+}
+
+func SetHealthy(name, message string) {
+	Default.SetHealthy(name, message)
+}
+
+func SetUnhealthy(name string, err error) {
+	Default.SetUnhealthy(name, err)
+}
+
+func RegisterLiveness(name string, timeout time.Duration) {
+	Default.RegisterLiveness(name, timeout)
+}
+
+func Heartbeat(name string) {
+	Default.Heartbeat(name)
+}
+
+func SetDead(name string, err error) {
+	Default.SetDead(name, err)
+}
+
+func ReportQingCloudResult(method string, err error) {
+	Default.ReportQingCloudResult(method, err)
+}
+
+// InstallHandlers registers /healthz and /readyz on mux.
+func InstallHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/healthz", Default.HealthzHandler)
+	mux.HandleFunc("/readyz", Default.ReadyzHandler)
+}
@@ -0,0 +1,63 @@
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func serve(t *testing.T, handler http.HandlerFunc) (int, response) {
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	var resp response
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return w.Code, resp
+}
+
+func TestReadyzHandler(t *testing.T) {
+	c := NewChecker()
+	c.Register(CheckAllocator, "waiting for allocator to restore from db")
+	if code, resp := serve(t, c.ReadyzHandler); code != http.StatusServiceUnavailable || resp.Status != "failed" {
+		t.Fatalf("expect not ready, got %d %+v", code, resp)
+	}
+	// not ready does not fail liveness
+	if code, _ := serve(t, c.HealthzHandler); code != http.StatusOK {
+		t.Fatalf("expect live while not ready, got %d", code)
+	}
+
+	c.SetHealthy(CheckAllocator, "restored")
+	if code, resp := serve(t, c.ReadyzHandler); code != http.StatusOK || resp.Status != "ok" || len(resp.Checks) != 1 {
+		t.Fatalf("expect ready, got %d %+v", code, resp)
+	}
+}
+
+func TestHealthzHandler(t *testing.T) {
+	c := NewChecker()
+	now := time.Now()
+	c.now = func() time.Time { return now }
+	c.RegisterLiveness(LivenessAllocator, time.Minute)
+	c.RegisterLiveness(LivenessGRPCServer, 0)
+	if code, resp := serve(t, c.HealthzHandler); code != http.StatusOK || len(resp.Checks) != 2 {
+		t.Fatalf("expect live, got %d %+v", code, resp)
+	}
+
+	// the allocator is stuck
+	now = now.Add(2 * time.Minute)
+	if code, resp := serve(t, c.HealthzHandler); code != http.StatusServiceUnavailable || resp.Checks[0].Healthy {
+		t.Fatalf("expect not live without heartbeat, got %d %+v", code, resp)
+	}
+	c.Heartbeat(LivenessAllocator)
+	if code, _ := serve(t, c.HealthzHandler); code != http.StatusOK {
+		t.Fatalf("expect live after heartbeat, got %d", code)
+	}
+
+	c.SetDead(LivenessGRPCServer, errors.New("grpc server exited"))
+	if code, resp := serve(t, c.HealthzHandler); code != http.StatusServiceUnavailable || resp.Checks[1].Message != "grpc server exited" {
+		t.Fatalf("expect not live after grpc server exited, got %d %+v", code, resp)
+	}
+}
@@ -0,0 +1,286 @@
+package metrics
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	k8sinformers "k8s.io/client-go/informers"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	informers "github.com/yunify/hostnic-cni/pkg/client/informers/externalversions"
+	"github.com/yunify/hostnic-cni/pkg/constants"
+	"github.com/yunify/hostnic-cni/pkg/simple/client/network/ippool/ipam"
+)
+
+// changes of ippools and blocks within this period are collected in one update
+const ipamMetricsBatchPeriod = 5 * time.Second
+
+// IPAMMetricsManager exports the ip utilization of the cluster by vxnet, block
+// and namespace. It is run by the controller only. Metrics are rebuilt from the
+// informer caches after ippools, blocks or the ipam config change, and every
+// refresh interval, a scrape only returns the cached metrics.
+type IPAMMetricsManager struct {
+	ipamclient      ipam.IPAMClient
+	configMapLister corelisters.ConfigMapLister
+	refresh         time.Duration
+	dirty           chan struct{}
+
+	HostnicIpamVxnetAllocator       *prometheus.Desc
+	HostnicIpamVxnetUnallocator     *prometheus.Desc
+	HostnicIpamVxnetTotal           *prometheus.Desc
+	HostnicIpamSubnetAllocator      *prometheus.Desc
+	HostnicIpamSubnetUnallocator    *prometheus.Desc
+	HostnicIpamSubnetTotal          *prometheus.Desc
+	HostnicIpamNamespaceAllocator   *prometheus.Desc
+	HostnicIpamNamespaceUnallocator *prometheus.Desc
+	HostnicIpamNamespaceTotal       *prometheus.Desc
+
+	lock    sync.RWMutex
+	metrics IPAMMetrics
+}
+
+// IPAMCount is the number of ips of a vxnet, block or namespace
+type IPAMCount struct {
+	Name        string
+	Allocate    float64
+	Unallocated float64
+	Total       float64
+}
+
+type IPAMMetrics struct {
+	Vxnets     []IPAMCount
+	Subnets    []IPAMCount
+	Namespaces []IPAMCount
+}
+
+func (c *IPAMMetricsManager) GenerateMetrics() IPAMMetrics {
+	var result IPAMMetrics
+
+	var datas map[string][]string
+	if cm, err := c.configMapLister.ConfigMaps(constants.IPAMConfigNamespace).Get(constants.IPAMConfigName); err != nil {
+		klog.Errorf("get configmap %s failed: %v", constants.IPAMConfigName, err)
+	} else if err := json.Unmarshal([]byte(cm.Data[constants.IPAMConfigDate]), &datas); err != nil {
+		klog.Errorf("unmarshal ipam data failed: %v", err)
+	}
+
+	utils, err := c.ipamclient.GetPoolBlocksUtilization(ipam.GetUtilizationArgs{})
+	if err != nil {
+		klog.Errorf("GetPoolBlocksUtilization failed: %v", err)
+		return result
+	}
+
+	namespaces := make(map[string]*IPAMCount)
+	var order []string
+	for _, util := range utils {
+		result.Vxnets = append(result.Vxnets, IPAMCount{
+			Name:        util.Name,
+			Allocate:    float64(util.Allocate),
+			Unallocated: float64(util.Unallocated),
+			Total:       float64(util.Allocate + util.Unallocated),
+		})
+		for _, block := range util.Blocks {
+			result.Subnets = append(result.Subnets, IPAMCount{
+				Name:        block.Name,
+				Allocate:    float64(block.Allocate),
+				Unallocated: float64(block.Unallocated),
+				Total:       float64(block.Allocate + block.Unallocated),
+			})
+
+			ns := getNamespaceByBlock(block.Name, datas)
+			count, ok := namespaces[ns]
+			if !ok {
+				count = &IPAMCount{Name: ns}
+				namespaces[ns] = count
+				order = append(order, ns)
+			}
+			count.Allocate += float64(block.Allocate)
+			count.Unallocated += float64(block.Unallocated)
+			count.Total += float64(block.Allocate + block.Unallocated)
+		}
+	}
+	for _, ns := range order {
+		result.Namespaces = append(result.Namespaces, *namespaces[ns])
+	}
+
+	return result
+}
+
+func (c *IPAMMetricsManager) enqueue() {
+	select {
+	case c.dirty <- struct{}{}:
+	default:
+	}
+}
+
+func (c *IPAMMetricsManager) update() {
+	metrics := c.GenerateMetrics()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.metrics = metrics
+}
+
+// Run rebuilds the metrics on changes, until stopCh is closed
+func (c *IPAMMetricsManager) Run(stopCh <-chan struct{}) error {
+	if err := c.ipamclient.Sync(stopCh); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(c.refresh)
+	defer ticker.Stop()
+
+	c.update()
+	for {
+		select {
+		case <-stopCh:
+			return nil
+		case <-c.dirty:
+			// wait for the rest of a burst of block updates
+			select {
+			case <-stopCh:
+				return nil
+			case <-time.After(ipamMetricsBatchPeriod):
+			}
+		case <-ticker.C:
+		}
+		c.update()
+	}
+}
+
+func (c *IPAMMetricsManager) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.HostnicIpamVxnetAllocator
+	ch <- c.HostnicIpamVxnetUnallocator
+	ch <- c.HostnicIpamVxnetTotal
+	ch <- c.HostnicIpamSubnetAllocator
+	ch <- c.HostnicIpamSubnetUnallocator
+	ch <- c.HostnicIpamSubnetTotal
+	ch <- c.HostnicIpamNamespaceAllocator
+	ch <- c.HostnicIpamNamespaceUnallocator
+	ch <- c.HostnicIpamNamespaceTotal
+}
+
+func collectCounts(ch chan<- prometheus.Metric, counts []IPAMCount, allocate, unallocated, total *prometheus.Desc) {
+	for _, item := range counts {
+		ch <- prometheus.MustNewConstMetric(allocate, prometheus.GaugeValue, item.Allocate, item.Name)
+		ch <- prometheus.MustNewConstMetric(unallocated, prometheus.GaugeValue, item.Unallocated, item.Name)
+		ch <- prometheus.MustNewConstMetric(total, prometheus.GaugeValue, item.Total, item.Name)
+	}
+}
+
+func (c *IPAMMetricsManager) Collect(ch chan<- prometheus.Metric) {
+	c.lock.RLock()
+	ipamMetrics := c.metrics
+	c.lock.RUnlock()
+
+	collectCounts(ch, ipamMetrics.Vxnets, c.HostnicIpamVxnetAllocator, c.HostnicIpamVxnetUnallocator, c.HostnicIpamVxnetTotal)
+	collectCounts(ch, ipamMetrics.Subnets, c.HostnicIpamSubnetAllocator, c.HostnicIpamSubnetUnallocator, c.HostnicIpamSubnetTotal)
+	collectCounts(ch, ipamMetrics.Namespaces, c.HostnicIpamNamespaceAllocator, c.HostnicIpamNamespaceUnallocator, c.HostnicIpamNamespaceTotal)
+}
+
+func NewIPAMMetricsManager(ipamclient ipam.IPAMClient, informers informers.SharedInformerFactory, k8sInformers k8sinformers.SharedInformerFactory, refresh time.Duration) *IPAMMetricsManager {
+	configMapInformer := k8sInformers.Core().V1().ConfigMaps()
+	c := &IPAMMetricsManager{
+		ipamclient:      ipamclient,
+		configMapLister: configMapInformer.Lister(),
+		refresh:         refresh,
+		dirty:           make(chan struct{}, 1),
+		HostnicIpamVxnetAllocator: prometheus.NewDesc(
+			"hostnic_ipam_vxnet_allocator",
+			"describe vxnet ipam allocator in cluster with hostnic cni",
+			[]string{"vxnet_name"},
+			prometheus.Labels{},
+		),
+		HostnicIpamVxnetUnallocator: prometheus.NewDesc(
+			"hostnic_ipam_vxnet_unallocator",
+			"describe vxnet ipam unallocator in cluster with hostnic cni",
+			[]string{"vxnet_name"},
+			prometheus.Labels{},
+		),
+		HostnicIpamVxnetTotal: prometheus.NewDesc(
+			"hostnic_ipam_vxnet_total",
+			"describe vxnet ipam total in cluster with hostnic cni",
+			[]string{"vxnet_name"},
+			prometheus.Labels{},
+		),
+		HostnicIpamSubnetAllocator: prometheus.NewDesc(
+			"hostnic_ipam_subnet_allocator",
+			"describe subnet ipam allocator in cluster with hostnic cni",
+			[]string{"subnet_name"},
+			prometheus.Labels{},
+		),
+		HostnicIpamSubnetUnallocator: prometheus.NewDesc(
+			"hostnic_ipam_subnet_unallocator",
+			"describe subnet ipam unallocator in cluster with hostnic cni",
+			[]string{"subnet_name"},
+			prometheus.Labels{},
+		),
+		HostnicIpamSubnetTotal: prometheus.NewDesc(
+			"hostnic_ipam_subnet_total",
+			"describe subnet ipam total in cluster with hostnic cni",
+			[]string{"subnet_name"},
+			prometheus.Labels{},
+		),
+		HostnicIpamNamespaceAllocator: prometheus.NewDesc(
+			"hostnic_ipam_namespace_allocator",
+			"describe namespace ipam allocator in cluster with hostnic cni",
+			[]string{"ns_name"},
+			prometheus.Labels{},
+		),
+		HostnicIpamNamespaceUnallocator: prometheus.NewDesc(
+			"hostnic_ipam_namespace_unallocator",
+			"describe namespace ipam unallocator in cluster with hostnic cni",
+			[]string{"ns_name"},
+			prometheus.Labels{},
+		),
+		HostnicIpamNamespaceTotal: prometheus.NewDesc(
+			"hostnic_ipam_namespace_total",
+			"describe namespace ipam total in cluster with hostnic cni",
+			[]string{"ns_name"},
+			prometheus.Labels{},
+		),
+	}
+
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.enqueue()
+		},
+		UpdateFunc: func(old, new interface{}) {
+			c.enqueue()
+		},
+		DeleteFunc: func(obj interface{}) {
+			c.enqueue()
+		},
+	}
+	informers.Network().V1alpha1().IPPools().Informer().AddEventHandler(handler)
+	informers.Network().V1alpha1().IPAMBlocks().Informer().AddEventHandler(handler)
+	configMapInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			cm, ok := obj.(*corev1.ConfigMap)
+			return ok && cm.Namespace == constants.IPAMConfigNamespace && cm.Name == constants.IPAMConfigName
+		},
+		Handler: handler,
+	})
+
+	return c
+}
+
+func getNamespaceByBlock(block string, datas map[string][]string) string {
+	for ns, subnets := range datas {
+		for _, subnet := range subnets {
+			if block == subnet {
+				return ns
+			}
+		}
+	}
+
+	// return a dummy name when no mapping rule found for this subnet
+	return constants.MetricsDummyNamespaceForSubnet
+}
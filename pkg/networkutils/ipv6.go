@@ -0,0 +1,167 @@
+package networkutils
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/vishvananda/netlink"
+	"k8s.io/klog/v2"
+
+	"github.com/yunify/hostnic-cni/pkg/constants"
+	"github.com/yunify/hostnic-cni/pkg/dhcp"
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+)
+
+var (
+	// vlan vxnets with ipv6, their bridges get dhcpv6 leases along with dhcpv4 ones
+	ipv6Lock   sync.Mutex
+	ipv6VxNets = map[string]bool{}
+)
+
+// SetupIPv6VxNets sets the vlan vxnets with ipv6
+func SetupIPv6VxNets(vxnets []string) {
+	ipv6Lock.Lock()
+	defer ipv6Lock.Unlock()
+
+	ipv6VxNets = make(map[string]bool)
+	for _, vxnet := range vxnets {
+		ipv6VxNets[vxnet] = true
+	}
+}
+
+// ipv6Enabled returns true if vxnet is configured to have ipv6
+func ipv6Enabled(vxnet string) bool {
+	ipv6Lock.Lock()
+	defer ipv6Lock.Unlock()
+
+	return ipv6VxNets[vxnet]
+}
+
+// setupIPv6RouteTable routes the traffic from the dhcpv6 address of br by the route table of nic,
+// the bridges of vxnets without ipv6 have no dhcpv6 lease and are skipped. So is every bridge
+// without the lease manager, e.g. in db-client, the leases are only known to the node daemon.
+func setupIPv6RouteTable(nic *rpc.HostNic, br netlink.Link) error {
+	if dhcp.Leases == nil {
+		return nil
+	}
+	lease, ok := dhcp.Leases.Lease(br.Attrs().Name, dhcp.FamilyIPv6)
+	if !ok {
+		return nil
+	}
+	return updateIPv6RouteTable(int(nic.RouteTableNum), br, lease)
+}
+
+// UpdateIPv6RouteTable follows the dhcpv6 lease of a bridge after it is renewed, rebound or lost.
+// It is called by the lease manager, so it must not wait for Acquire or Release.
+func UpdateIPv6RouteTable(lease dhcp.Lease) error {
+	if lease.Family != dhcp.FamilyIPv6 {
+		return nil
+	}
+	table, err := strconv.Atoi(strings.TrimPrefix(lease.Bridge, constants.BridgePrefix))
+	if err != nil {
+		return fmt.Errorf("link %s is not a bridge of nic", lease.Bridge)
+	}
+	br, err := netlink.LinkByName(lease.Bridge)
+	if err != nil {
+		if _, ok := err.(netlink.LinkNotFoundError); ok {
+			// the bridge is being cleaned up, the kernel removed its routes
+			return updateIPv6Rule(table, nil)
+		}
+		return fmt.Errorf("failed to get link %s: %v", lease.Bridge, err)
+	}
+	return updateIPv6RouteTable(table, br, lease)
+}
+
+// updateIPv6RouteTable copies the default routes which routers on br advertise to table, and points
+// the rule from br at the address of lease. The rule is removed while the lease is lost. The routes
+// are kept if no router replies, routers may rate limit their advertisements.
+func updateIPv6RouteTable(table int, br netlink.Link, lease dhcp.Lease) error {
+	advertisements, err := solicitRouters(br.Attrs().Name, br.Attrs().Index)
+	if err != nil {
+		return err
+	}
+	if len(advertisements) == 0 {
+		klog.Warningf("no router advertisement on link %s, keep the ipv6 routes of table %d", br.Attrs().Name, table)
+	} else if err := updateIPv6Routes(table, br, advertisements); err != nil {
+		return err
+	}
+
+	if lease.State == dhcp.StateInit {
+		return updateIPv6Rule(table, nil)
+	}
+	return updateIPv6Rule(table, lease.IP())
+}
+
+// updateIPv6Routes keeps a default route in table for each default router of advertisements
+func updateIPv6Routes(table int, br netlink.Link, advertisements []routerAdvertisement) error {
+	gateways := make(map[string]bool)
+	for _, ra := range advertisements {
+		if ra.lifetime == 0 {
+			continue
+		}
+		route := netlink.Route{
+			LinkIndex: br.Attrs().Index,
+			Gw:        ra.router,
+			Table:     table,
+		}
+		if err := netlink.RouteReplace(&route); err != nil {
+			return fmt.Errorf("failed to replace route %v: %v", route, err)
+		}
+		gateways[ra.router.String()] = true
+	}
+
+	// routers which are no longer default routers
+	filter := &netlink.Route{Table: table}
+	routes, err := netlink.RouteListFiltered(netlink.FAMILY_V6, filter, netlink.RT_FILTER_TABLE)
+	if err != nil {
+		return fmt.Errorf("failed to list ipv6 routes of table %d: %v", table, err)
+	}
+	for _, r := range routes {
+		if r.Dst != nil || r.Gw == nil || gateways[r.Gw.String()] {
+			continue
+		}
+		if err := netlink.RouteDel(&r); err != nil && !strings.Contains(err.Error(), constants.RouteNotExistsError) {
+			return fmt.Errorf("failed to del route %v: %v", r, err)
+		}
+	}
+	return nil
+}
+
+// updateIPv6Rule keeps the ipv6 rule of table from src only, a nil src removes the rule
+func updateIPv6Rule(table int, src net.IP) error {
+	rules, err := netlink.RuleList(netlink.FAMILY_V6)
+	if err != nil {
+		return fmt.Errorf("failed to list ipv6 rules: %v", err)
+	}
+	found := false
+	for _, rule := range rules {
+		if rule.Table != table || rule.Priority != constants.FromContainerRulePriority {
+			continue
+		}
+		if src != nil && rule.Src != nil && rule.Src.IP.Equal(src) {
+			found = true
+			continue
+		}
+		rule.Family = netlink.FAMILY_V6
+		if err := netlink.RuleDel(&rule); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to del rule %s: %v", rule, err)
+		}
+	}
+	if src == nil || found {
+		return nil
+	}
+
+	fromBridgeRule := netlink.NewRule()
+	fromBridgeRule.Family = netlink.FAMILY_V6
+	fromBridgeRule.Priority = constants.FromContainerRulePriority
+	fromBridgeRule.Table = table
+	fromBridgeRule.Src = &net.IPNet{IP: src, Mask: net.CIDRMask(128, 128)}
+	if err := netlink.RuleAdd(fromBridgeRule); err != nil && !os.IsExist(err) {
+		return fmt.Errorf("failed to add rule %s: %v", fromBridgeRule, err)
+	}
+	return nil
+}
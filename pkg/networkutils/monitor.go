@@ -0,0 +1,201 @@
+package networkutils
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+type NetChangeKind int
+
+const (
+	NetChangeLink NetChangeKind = iota
+	NetChangeAddr
+	NetChangeRoute
+	NetChangeRule
+)
+
+// NetChange is a link, address, route or rule update from the kernel
+type NetChange struct {
+	Kind NetChangeKind
+	// when the update was received, it is sent by the kernel after the change
+	Received time.Time
+	Deleted  bool
+
+	// name of the link of links and addresses, hardware address of links only
+	LinkName     string
+	HardwareAddr string
+	// the link is admin up and has a carrier
+	Up bool
+
+	// table of routes and rules, priority and source of rules only
+	Table    int
+	Priority int
+	Src      *net.IPNet
+}
+
+// WatchNetChanges sends the changes of links, ipv4 addresses, routes and rules
+// to ch, until stopCh is closed or a subscription fails. It only returns nil
+// if stopCh is closed.
+func WatchNetChanges(stopCh <-chan struct{}, ch chan<- NetChange) error {
+	done := make(chan struct{})
+	defer close(done)
+	errCh := make(chan error, 4)
+	onError := func(err error) {
+		select {
+		case errCh <- err:
+		default:
+		}
+	}
+
+	links := make(chan netlink.LinkUpdate, 64)
+	if err := netlink.LinkSubscribeWithOptions(links, done, netlink.LinkSubscribeOptions{ErrorCallback: onError}); err != nil {
+		return fmt.Errorf("subscribe links error: %v", err)
+	}
+	addrs := make(chan netlink.AddrUpdate, 64)
+	if err := netlink.AddrSubscribeWithOptions(addrs, done, netlink.AddrSubscribeOptions{ErrorCallback: onError}); err != nil {
+		return fmt.Errorf("subscribe addrs error: %v", err)
+	}
+	routes := make(chan netlink.RouteUpdate, 64)
+	if err := netlink.RouteSubscribeWithOptions(routes, done, netlink.RouteSubscribeOptions{ErrorCallback: onError}); err != nil {
+		return fmt.Errorf("subscribe routes error: %v", err)
+	}
+	rules := make(chan NetChange, 64)
+	if err := ruleSubscribe(rules, done, onError); err != nil {
+		return fmt.Errorf("subscribe rules error: %v", err)
+	}
+
+	for {
+		var change NetChange
+		select {
+		case <-stopCh:
+			return nil
+		case err := <-errCh:
+			return err
+		case update, ok := <-links:
+			if !ok {
+				return fmt.Errorf("link subscription closed")
+			}
+			attrs := update.Link.Attrs()
+			change = NetChange{
+				Kind:         NetChangeLink,
+				Deleted:      update.Header.Type == unix.RTM_DELLINK,
+				LinkName:     attrs.Name,
+				HardwareAddr: attrs.HardwareAddr.String(),
+				Up:           attrs.Flags&net.FlagUp != 0 && attrs.RawFlags&unix.IFF_LOWER_UP != 0,
+			}
+		case update, ok := <-addrs:
+			if !ok {
+				return fmt.Errorf("addr subscription closed")
+			}
+			if update.LinkAddress.IP.To4() == nil {
+				continue
+			}
+			change = NetChange{
+				Kind:    NetChangeAddr,
+				Deleted: !update.NewAddr,
+			}
+			// the link is gone if its addresses are removed on deletion
+			if link, err := netlink.LinkByIndex(update.LinkIndex); err == nil {
+				change.LinkName = link.Attrs().Name
+			}
+		case update, ok := <-routes:
+			if !ok {
+				return fmt.Errorf("route subscription closed")
+			}
+			change = NetChange{
+				Kind:    NetChangeRoute,
+				Deleted: update.Type == unix.RTM_DELROUTE,
+				Table:   update.Table,
+			}
+		case rule, ok := <-rules:
+			if !ok {
+				return fmt.Errorf("rule subscription closed")
+			}
+			change = rule
+		}
+
+		change.Received = time.Now()
+		select {
+		case ch <- change:
+		case <-stopCh:
+			return nil
+		}
+	}
+}
+
+// ruleSubscribe is missing in netlink v1.1.0, it follows the other subscriptions of the library
+func ruleSubscribe(ch chan<- NetChange, done <-chan struct{}, cberr func(error)) error {
+	s, err := nl.Subscribe(unix.NETLINK_ROUTE, unix.RTNLGRP_IPV4_RULE)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-done
+		s.Close()
+	}()
+
+	go func() {
+		defer close(ch)
+		for {
+			msgs, from, err := s.Receive()
+			if err != nil {
+				cberr(err)
+				return
+			}
+			if from.Pid != nl.PidKernel {
+				continue
+			}
+			for _, m := range msgs {
+				if m.Header.Type != unix.RTM_NEWRULE && m.Header.Type != unix.RTM_DELRULE {
+					continue
+				}
+				change, err := deserializeRule(m.Data)
+				if err != nil {
+					cberr(err)
+					return
+				}
+				change.Deleted = m.Header.Type == unix.RTM_DELRULE
+				select {
+				case ch <- change:
+				case <-done:
+					return
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// deserializeRule parses a fib_rule_hdr, which has the layout of a rtmsg, and its attributes
+func deserializeRule(b []byte) (NetChange, error) {
+	msg := nl.DeserializeRtMsg(b)
+	change := NetChange{
+		Kind:  NetChangeRule,
+		Table: int(msg.Table),
+	}
+
+	attrs, err := nl.ParseRouteAttr(b[msg.Len():])
+	if err != nil {
+		return change, err
+	}
+	native := nl.NativeEndian()
+	for _, attr := range attrs {
+		switch attr.Attr.Type {
+		case nl.FRA_TABLE:
+			change.Table = int(native.Uint32(attr.Value[0:4]))
+		case nl.FRA_PRIORITY:
+			change.Priority = int(native.Uint32(attr.Value[0:4]))
+		case nl.FRA_SRC:
+			change.Src = &net.IPNet{
+				IP:   attr.Value,
+				Mask: net.CIDRMask(int(msg.Src_len), 8*len(attr.Value)),
+			}
+		}
+	}
+	return change, nil
+}
@@ -0,0 +1,118 @@
+package networkutils
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// routers reply to a solicitation within MAX_RA_DELAY_TIME (500ms) of rfc 4861
+	routerSolicitationTimeout = time.Second
+
+	icmpv6RouterSolicitation  = 133
+	icmpv6RouterAdvertisement = 134
+	// neighbor discovery packets are only accepted with the maximum hop limit
+	ndiscHopLimit = 255
+)
+
+// routerAdvertisement is the part of a router advertisement needed by the route table of a nic
+type routerAdvertisement struct {
+	router net.IP
+	// zero if the router is not a default router
+	lifetime time.Duration
+}
+
+// solicitRouters sends a router solicitation on link and returns the advertisements received in
+// routerSolicitationTimeout, the last one of each router. The kernel does not need to accept them,
+// so the default routes they advertise do not end up in the main table.
+func solicitRouters(link string, index int) ([]routerAdvertisement, error) {
+	fd, err := unix.Socket(unix.AF_INET6, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.IPPROTO_ICMPV6)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open icmpv6 socket: %v", err)
+	}
+	defer unix.Close(fd)
+
+	if err := unix.BindToDevice(fd, link); err != nil {
+		return nil, fmt.Errorf("failed to bind icmpv6 socket to link %s: %v", link, err)
+	}
+	var filter unix.ICMPv6Filter
+	for i := range filter.Data {
+		filter.Data[i] = 0xffffffff
+	}
+	filter.Data[icmpv6RouterAdvertisement>>5] &^= 1 << (icmpv6RouterAdvertisement & 31)
+	if err := unix.SetsockoptICMPv6Filter(fd, unix.SOL_ICMPV6, unix.ICMPV6_FILTER, &filter); err != nil {
+		return nil, fmt.Errorf("failed to filter icmpv6 socket: %v", err)
+	}
+	if err := unix.SetsockoptInt(fd, unix.IPPROTO_IPV6, unix.IPV6_MULTICAST_HOPS, ndiscHopLimit); err != nil {
+		return nil, fmt.Errorf("failed to set hop limit of icmpv6 socket: %v", err)
+	}
+	// drops advertisements which are not from the link
+	if err := unix.SetsockoptInt(fd, unix.IPPROTO_IPV6, unix.IPV6_MINHOPCOUNT, ndiscHopLimit); err != nil {
+		return nil, fmt.Errorf("failed to set min hop count of icmpv6 socket: %v", err)
+	}
+
+	// the kernel fills in the checksum of icmpv6 packets
+	solicitation := []byte{icmpv6RouterSolicitation, 0, 0, 0, 0, 0, 0, 0}
+	allRouters := &unix.SockaddrInet6{ZoneId: uint32(index)}
+	copy(allRouters.Addr[:], net.IPv6linklocalallrouters)
+	if err := unix.Sendto(fd, solicitation, 0, allRouters); err != nil {
+		return nil, fmt.Errorf("failed to solicit routers on link %s: %v", link, err)
+	}
+
+	routers := make(map[string]routerAdvertisement)
+	buf := make([]byte, 1500)
+	deadline := time.Now().Add(routerSolicitationTimeout)
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			break
+		}
+		tv := unix.NsecToTimeval(remaining.Nanoseconds())
+		if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv); err != nil {
+			return nil, fmt.Errorf("failed to set timeout of icmpv6 socket: %v", err)
+		}
+		n, from, err := unix.Recvfrom(fd, buf, 0)
+		if err == unix.EINTR {
+			continue
+		}
+		if err == unix.EAGAIN || err == unix.EWOULDBLOCK {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to receive router advertisements on link %s: %v", link, err)
+		}
+		sa, ok := from.(*unix.SockaddrInet6)
+		if !ok {
+			continue
+		}
+		ra, ok := parseRouterAdvertisement(net.IP(sa.Addr[:]), buf[:n])
+		if ok {
+			routers[ra.router.String()] = ra
+		}
+	}
+
+	result := make([]routerAdvertisement, 0, len(routers))
+	for _, ra := range routers {
+		result = append(result, ra)
+	}
+	return result, nil
+}
+
+// parseRouterAdvertisement parses the icmpv6 message msg sent by src, see rfc 4861 4.2
+func parseRouterAdvertisement(src net.IP, msg []byte) (routerAdvertisement, bool) {
+	if len(msg) < 16 || msg[0] != icmpv6RouterAdvertisement || msg[1] != 0 {
+		return routerAdvertisement{}, false
+	}
+	// routers advertise from their link-local addresses
+	if !src.IsLinkLocalUnicast() {
+		return routerAdvertisement{}, false
+	}
+	return routerAdvertisement{
+		router:   append(net.IP(nil), src...),
+		lifetime: time.Duration(binary.BigEndian.Uint16(msg[6:8])) * time.Second,
+	}, true
+}
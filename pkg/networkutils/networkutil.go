@@ -7,17 +7,15 @@ import (
 	"os"
 	"os/exec"
 	"strings"
-	"time"
 
 	"github.com/containernetworking/plugins/pkg/ns"
 	"github.com/containernetworking/plugins/pkg/utils/sysctl"
-	"github.com/insomniacslk/dhcp/dhcpv4/client4"
-	"github.com/insomniacslk/dhcp/netboot"
 	"github.com/vishvananda/netlink"
 	"golang.org/x/sys/unix"
 	"k8s.io/klog/v2"
 
 	"github.com/yunify/hostnic-cni/pkg/constants"
+	"github.com/yunify/hostnic-cni/pkg/dhcp"
 	"github.com/yunify/hostnic-cni/pkg/qcclient"
 	"github.com/yunify/hostnic-cni/pkg/rpc"
 )
@@ -45,7 +43,19 @@ func (n NetworkUtils) SetupPodNetwork(nic *rpc.HostNic, ip string) error {
 		return fmt.Errorf("failed to add rule %s : %v", toPodRule, err)
 	}
 
-	return setArpReply(constants.GetHostNicBridgeName(int(nic.RouteTableNum)), ip, nic.HardwareAddr, "-I")
+	// SetupPodNetwork is replayed after node reboot, ebtables would insert a duplicated entry
+	brName := constants.GetHostNicBridgeName(int(nic.RouteTableNum))
+	entries, err := GetArpReplyByIP(ip)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if strings.Contains(entry, "--logical-in "+brName+" ") {
+			return nil
+		}
+	}
+
+	return setArpReply(brName, ip, nic.HardwareAddr, "-I")
 }
 
 // After the Response is uninstalled, the relevant routes are cleared, so you only need to delete the rule.
@@ -88,7 +98,7 @@ func (n NetworkUtils) SetupNetwork(nic *rpc.HostNic) (rpc.Phase, error) {
 		if err := n.setupNicNetwork(devName, master); err != nil {
 			return rpc.Phase_CreateAndAttach, err
 		}
-		if err := n.setupBridgeNetwork(master, brName, nic.VxNet.TunnelType); err != nil {
+		if err := n.setupBridgeNetwork(master, brName, nic.VxNet); err != nil {
 			return rpc.Phase_JoinBridge, err
 		}
 		if err := n.setupRouteTable(nic); err != nil {
@@ -149,7 +159,7 @@ func (n NetworkUtils) CheckAndRepairNetwork(nic *rpc.HostNic) (rpc.Phase, error)
 		if err := n.setupNicNetwork(devName, master); err != nil {
 			return rpc.Phase_CreateAndAttach, err
 		}
-		if err := n.setupBridgeNetwork(master, brName, nic.VxNet.TunnelType); err != nil {
+		if err := n.setupBridgeNetwork(master, brName, nic.VxNet); err != nil {
 			return rpc.Phase_JoinBridge, err
 		}
 		if err := n.setupRouteTable(nic); err != nil {
@@ -160,7 +170,7 @@ func (n NetworkUtils) CheckAndRepairNetwork(nic *rpc.HostNic) (rpc.Phase, error)
 		if err := n.setupNicNetwork(devName, slave); err != nil {
 			return rpc.Phase_CreateAndAttach, err
 		}
-		if err := n.setupBridgeNetwork(slave, brName, nic.VxNet.TunnelType); err != nil {
+		if err := n.setupBridgeNetwork(slave, brName, nic.VxNet); err != nil {
 			return rpc.Phase_JoinBridge, err
 		}
 		if err := n.setupRouteTable(nic); err != nil {
@@ -214,7 +224,7 @@ func (n NetworkUtils) setupNicNetwork(name string, link netlink.Link) error {
 }
 
 // create br and add hostnic to br
-func (n NetworkUtils) setupBridgeNetwork(link netlink.Link, brName, tunnelType string) error {
+func (n NetworkUtils) setupBridgeNetwork(link netlink.Link, brName string, vxnet *rpc.VxNet) error {
 	la := netlink.NewLinkAttrs()
 	la.Name = brName
 	br := &netlink.Bridge{LinkAttrs: la}
@@ -226,23 +236,30 @@ func (n NetworkUtils) setupBridgeNetwork(link netlink.Link, brName, tunnelType s
 	if err != nil {
 		return fmt.Errorf("faild to set link %s: %v", la.Name, err)
 	}
+	if vxnet.TunnelType == constants.TunnelTypeVlan && ipv6Enabled(vxnet.ID) {
+		// router advertisements are ignored by default once forwarding is on. Their default routes
+		// belong to the route table of the nic, not the main table, see updateIPv6RouteTable.
+		_, _ = sysctl.Sysctl(fmt.Sprintf("net/ipv6/conf/%s/accept_ra", la.Name), "2")
+		_, _ = sysctl.Sysctl(fmt.Sprintf("net/ipv6/conf/%s/accept_ra_defrtr", la.Name), "0")
+	}
 	err = netlink.LinkSetUp(br)
 	if err != nil {
 		return fmt.Errorf("failed to set link %s up: %v", la.Name, err)
 	}
-	if tunnelType == constants.TunnelTypeVlan {
-		// get an ip addr and add to br
-		// 1.get an ip addr from dhcp server
-		bootConf, err := getIPAddrFromDHCPServer(brName)
+	if vxnet.TunnelType == constants.TunnelTypeVlan {
+		// get an ip addr from dhcp server and add to br, the lease is renewed until the br is cleared
+		lease, err := dhcp.Leases.Acquire(brName)
 		if err != nil {
-			return fmt.Errorf("failed to get ip address for link %s: %v", brName, err)
+			return err
 		}
-		klog.Infof("get ip addr %+v success from dhcp server for link %s", bootConf.Addresses, brName)
+		klog.Infof("get ip addr %s for link %s, lease expires at %v", lease.Address, brName, lease.Expiry)
 
-		// 2. replace addr to br
-		err = replaceLinkIPAddr(br, bootConf.Addresses)
-		if err != nil {
-			return fmt.Errorf("failed to replace ip address for link %s: %v", brName, err)
+		if ipv6Enabled(vxnet.ID) {
+			lease, err = dhcp.Leases.AcquireIPv6(brName)
+			if err != nil {
+				return err
+			}
+			klog.Infof("get ipv6 addr %s for link %s, lease expires at %v", lease.Address, brName, lease.Expiry)
 		}
 	}
 
@@ -251,6 +268,11 @@ func (n NetworkUtils) setupBridgeNetwork(link netlink.Link, brName, tunnelType s
 
 func (n NetworkUtils) clearBridgeNetwork(nic *rpc.HostNic) error {
 	brName := constants.GetHostNicBridgeName(int(nic.RouteTableNum))
+	if nic.VxNet.TunnelType == constants.TunnelTypeVlan {
+		if err := dhcp.Leases.Release(brName); err != nil {
+			return err
+		}
+	}
 	br, err := netlink.LinkByName(brName)
 	if err != nil {
 		if _, ok := err.(netlink.LinkNotFoundError); ok {
@@ -319,6 +341,9 @@ func (n NetworkUtils) setupRouteTable(nic *rpc.HostNic) error {
 		return fmt.Errorf("failed to add rule %s: %v", fromPodRule, err)
 	}
 
+	if nic.VxNet.TunnelType == constants.TunnelTypeVlan {
+		return setupIPv6RouteTable(nic, master)
+	}
 	return nil
 }
 
@@ -333,6 +358,10 @@ func (n NetworkUtils) clearRouteTable(nic *rpc.HostNic) error {
 	if err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to del rule %s: %v", fromPodRule, err)
 	}
+
+	if nic.VxNet.TunnelType == constants.TunnelTypeVlan {
+		return updateIPv6Rule(int(nic.RouteTableNum), nil)
+	}
 	return nil
 }
 
@@ -393,6 +422,63 @@ func ListArpReply() (string, error) {
 	return out, err
 }
 
+// GetArpReplyByIP returns the ebtables arpreply entries for ip
+func GetArpReplyByIP(ip string) ([]string, error) {
+	out, err := ListArpReply()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		for i := 0; i < len(fields)-1; i++ {
+			if fields[i] == "--arp-ip-dst" && fields[i+1] == ip {
+				result = append(result, strings.TrimSpace(line))
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// GetRulesByDst returns the rules which route traffic to the pod ip
+func GetRulesByDst(ip string) ([]string, error) {
+	rules, err := getRuleListByDst(net.ParseIP(ip))
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, rule := range rules {
+		result = append(result, rule.String())
+	}
+	return result, nil
+}
+
+// DumpRouteTable returns the routes in route table and the rules which lookup it
+func DumpRouteTable(table int) ([]string, []string, error) {
+	routeList, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: table}, netlink.RT_FILTER_TABLE)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list routes of table %d: %v", table, err)
+	}
+	ruleList, err := netlink.RuleList(unix.AF_INET)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list rules: %v", err)
+	}
+
+	var routes, rules []string
+	for _, route := range routeList {
+		routes = append(routes, route.String())
+	}
+	for _, rule := range ruleList {
+		if rule.Table == table {
+			rules = append(rules, rule.String())
+		}
+	}
+	return routes, rules, nil
+}
+
 func getRuleListByDst(dst net.IP) ([]netlink.Rule, error) {
 	var dstRuleList []netlink.Rule
 	ruleList, err := netlink.RuleList(unix.AF_INET)
@@ -439,76 +525,3 @@ func ExecuteCommand(command string) (string, error) {
 
 	return out.String(), nil
 }
-
-func getIPAddrFromDHCPServer(ifname string) (*netboot.BootConf, error) {
-	// client := client4.NewClient()
-	client := &client4.Client{
-		ReadTimeout:  client4.DefaultReadTimeout * 5,
-		WriteTimeout: client4.DefaultWriteTimeout * 5,
-	}
-	conv, err := client.Exchange(ifname)
-	if err != nil {
-		return nil, fmt.Errorf("dhcp client exchange error: %v", err)
-	}
-	// extract the network configuration
-	netconf, err := netboot.ConversationToNetconfv4(conv)
-	if err != nil {
-		return nil, fmt.Errorf("dhcp client ConversationToNetconfv4 error: %v", err)
-	}
-	return netconf, nil
-}
-
-func replaceLinkIPAddr(link netlink.Link, addres []netboot.AddrConf) error {
-	var shortestLeaseTime time.Duration
-	ifName := link.Attrs().Name
-	if len(addres) < 1 {
-		return fmt.Errorf("there is no avaliable addr for link %s", ifName)
-	}
-	for _, addrConf := range addres {
-		if shortestLeaseTime.Seconds() == 0 {
-			shortestLeaseTime = addrConf.ValidLifetime
-		}
-		if addrConf.ValidLifetime.Seconds() < shortestLeaseTime.Seconds() {
-			shortestLeaseTime = addrConf.ValidLifetime
-		}
-
-		addr := &netlink.Addr{
-			IPNet:       &addrConf.IPNet,
-			ValidLft:    int(addrConf.ValidLifetime.Seconds()),
-			PreferedLft: int(addrConf.ValidLifetime.Seconds()),
-		}
-		err := netlink.AddrReplace(link, addr)
-		if err != nil {
-			return fmt.Errorf("replace addr %+v to link %s error: %v", addr, ifName, err)
-		}
-	}
-
-	if shortestLeaseTime.Seconds()/2 != constants.LastIPAddrRenewPeriod.Seconds() && shortestLeaseTime != 0 {
-		klog.Infof("update LastIPAddrRenewPeriod from %v to %v", constants.LastIPAddrRenewPeriod, shortestLeaseTime/2)
-		constants.LastIPAddrRenewPeriod = shortestLeaseTime / 2
-		constants.IpAddrReNewTicker.Reset(shortestLeaseTime / 2)
-	}
-
-	return nil
-}
-
-func UpdateLinkIPAddrAndLease(nic *rpc.HostNic) error {
-	brName := constants.GetHostNicBridgeName(int(nic.RouteTableNum))
-	la := netlink.NewLinkAttrs()
-	la.Name = brName
-	br := &netlink.Bridge{LinkAttrs: la}
-
-	// 1. get an ip form dhcp server
-	bootConf, err := getIPAddrFromDHCPServer(brName)
-	if err != nil || bootConf.Addresses == nil {
-		return fmt.Errorf("failed to get ip address for link %s: %v", brName, err)
-	}
-	klog.Infof("get ip addr %+v success from dhcp server for link %s", bootConf.Addresses, brName)
-
-	// 2. replace addr to br
-	err = replaceLinkIPAddr(br, bootConf.Addresses)
-	if err != nil {
-		return fmt.Errorf("failed to replace ip address for link %s: %v", brName, err)
-	}
-	return nil
-}
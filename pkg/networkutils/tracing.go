@@ -0,0 +1,85 @@
+package networkutils
+
+import (
+	"context"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+	"github.com/yunify/hostnic-cni/pkg/tracing"
+)
+
+// tracedNetworkUtils traces every step of NetworkHelper as a child of the span in ctx
+type tracedNetworkUtils struct {
+	helper NetworkUtilsWrap
+	ctx    context.Context
+}
+
+// WithContext returns NetworkHelper with its steps traced in ctx
+func WithContext(ctx context.Context) NetworkUtilsWrap {
+	return &tracedNetworkUtils{
+		helper: NetworkHelper,
+		ctx:    ctx,
+	}
+}
+
+func nicAttributes(nic *rpc.HostNic) []tracing.Attribute {
+	return []tracing.Attribute{
+		tracing.String("hostnic.nic", nic.GetID()),
+		tracing.String("hostnic.vxnet", nic.GetVxNet().GetID()),
+		tracing.Int("hostnic.route_table", int(nic.GetRouteTableNum())),
+	}
+}
+
+func (t *tracedNetworkUtils) SetupNetwork(nic *rpc.HostNic) (rpc.Phase, error) {
+	_, span := tracing.Start(t.ctx, "networkutils.SetupNetwork", nicAttributes(nic)...)
+	phase, err := t.helper.SetupNetwork(nic)
+	span.SetAttributes(tracing.String("hostnic.phase", phase.String()))
+	span.End(err)
+	return phase, err
+}
+
+func (t *tracedNetworkUtils) CleanupNetwork(nic *rpc.HostNic) error {
+	_, span := tracing.Start(t.ctx, "networkutils.CleanupNetwork", nicAttributes(nic)...)
+	err := t.helper.CleanupNetwork(nic)
+	span.End(err)
+	return err
+}
+
+func (t *tracedNetworkUtils) CheckAndRepairNetwork(nic *rpc.HostNic) (rpc.Phase, error) {
+	_, span := tracing.Start(t.ctx, "networkutils.CheckAndRepairNetwork", nicAttributes(nic)...)
+	phase, err := t.helper.CheckAndRepairNetwork(nic)
+	span.SetAttributes(tracing.String("hostnic.phase", phase.String()))
+	span.End(err)
+	return phase, err
+}
+
+func (t *tracedNetworkUtils) SetupPodNetwork(nic *rpc.HostNic, ip string) error {
+	_, span := tracing.Start(t.ctx, "networkutils.SetupPodNetwork",
+		append(nicAttributes(nic), tracing.String("k8s.pod.ip", ip))...)
+	err := t.helper.SetupPodNetwork(nic, ip)
+	span.End(err)
+	return err
+}
+
+func (t *tracedNetworkUtils) CleanupPodNetwork(nic *rpc.HostNic, ip string) error {
+	_, span := tracing.Start(t.ctx, "networkutils.CleanupPodNetwork",
+		append(nicAttributes(nic), tracing.String("k8s.pod.ip", ip))...)
+	err := t.helper.CleanupPodNetwork(nic, ip)
+	span.End(err)
+	return err
+}
+
+func (t *tracedNetworkUtils) LinkByMacAddr(macAddr string) (netlink.Link, error) {
+	_, span := tracing.Start(t.ctx, "networkutils.LinkByMacAddr", tracing.String("hostnic.mac", macAddr))
+	link, err := t.helper.LinkByMacAddr(macAddr)
+	span.End(err)
+	return link, err
+}
+
+func (t *tracedNetworkUtils) IsNSorErr(nspath string) error {
+	_, span := tracing.Start(t.ctx, "networkutils.IsNSorErr")
+	err := t.helper.IsNSorErr(nspath)
+	span.End(err)
+	return err
+}
@@ -0,0 +1,234 @@
+package networkutils
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"github.com/yunify/hostnic-cni/pkg/constants"
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+)
+
+// Diff is a difference between the network of a nic recorded in db and the kernel. Fix makes the
+// kernel match the record, it is nil if only the node daemon can repair it, e.g. a nic which has
+// to be attached again or a bridge which needs a dhcp lease.
+type Diff struct {
+	// Missing is set if the record has something the kernel has not, otherwise the kernel has
+	// something which is not recorded
+	Missing bool
+	What    string
+	Fix     func() error
+}
+
+func (d Diff) String() string {
+	if d.Missing {
+		return "- " + d.What
+	}
+	return "+ " + d.What
+}
+
+// VerifyNetwork compares the links, route table, rules and arp replies set up for nic and the
+// ips of its pods with the kernel
+func VerifyNetwork(nic *rpc.HostNic, podIPs []string) ([]Diff, error) {
+	diffs, err := verifyNic(nic)
+	if err != nil {
+		return nil, err
+	}
+	podDiffs, err := verifyPods(nic, podIPs)
+	if err != nil {
+		return nil, err
+	}
+	return append(diffs, podDiffs...), nil
+}
+
+// verifyNic compares the links and route table set up for nic with the kernel
+func verifyNic(nic *rpc.HostNic) ([]Diff, error) {
+	var diffs []Diff
+	devName := constants.GetHostNicName(nic.VxNet.ID)
+	brName := constants.GetHostNicBridgeName(int(nic.RouteTableNum))
+
+	n := NetworkUtils{}
+	master, slave, err := n.getLinksByMacAddr(nic.HardwareAddr)
+	if err != nil && err != constants.ErrNicNotFound {
+		return nil, fmt.Errorf("failed to get link %s: %v", nic.HardwareAddr, err)
+	}
+	if slave == nil {
+		slave, master = master, nil
+	}
+	if slave == nil {
+		diffs = append(diffs, Diff{Missing: true, What: fmt.Sprintf("link %s with addr %s", devName, nic.HardwareAddr)})
+	} else if slave.Attrs().Name != devName {
+		diffs = append(diffs, Diff{Missing: true, What: fmt.Sprintf("link %s with addr %s, got %s", devName, nic.HardwareAddr, slave.Attrs().Name)})
+	}
+
+	br, err := netlink.LinkByName(brName)
+	if err != nil {
+		if _, ok := err.(netlink.LinkNotFoundError); !ok {
+			return nil, fmt.Errorf("failed to lookup br %s: %v", brName, err)
+		}
+		diffs = append(diffs, Diff{Missing: true, What: "bridge " + brName})
+	} else if slave != nil && slave.Attrs().MasterIndex != br.Attrs().Index {
+		diffs = append(diffs, Diff{Missing: true, What: fmt.Sprintf("link %s in bridge %s", slave.Attrs().Name, brName)})
+	}
+	// the route table can only be set up on the links of nic
+	linksOK := len(diffs) == 0 && master != nil
+
+	tableDiffs, err := verifyRouteTable(nic, master)
+	if err != nil {
+		return nil, err
+	}
+	for _, diff := range tableDiffs {
+		if linksOK {
+			diff.Fix = func() error { return n.setupRouteTable(nic) }
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs, nil
+}
+
+func verifyRouteTable(nic *rpc.HostNic, br netlink.Link) ([]Diff, error) {
+	var diffs []Diff
+	table := int(nic.RouteTableNum)
+	_, dst, err := net.ParseCIDR(nic.VxNet.Network)
+	if err != nil {
+		return nil, fmt.Errorf("invalid network %q of vxnet %s: %v", nic.VxNet.Network, nic.VxNet.ID, err)
+	}
+
+	// the routes of vlan nics are cleared, the traffic goes through the address leased to the bridge
+	if nic.VxNet.TunnelType != constants.TunnelTypeVlan {
+		routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: table}, netlink.RT_FILTER_TABLE)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list routes of table %d: %v", table, err)
+		}
+		var link, gw bool
+		for _, r := range routes {
+			if br != nil && r.LinkIndex != br.Attrs().Index {
+				continue
+			}
+			if r.Dst != nil && r.Dst.String() == dst.String() {
+				link = true
+			}
+			if (r.Dst == nil || r.Dst.String() == "0.0.0.0/0") && r.Gw.Equal(net.ParseIP(nic.VxNet.Gateway)) {
+				gw = true
+			}
+		}
+		if !link {
+			diffs = append(diffs, Diff{Missing: true, What: fmt.Sprintf("route %s table %d", dst, table)})
+		}
+		if !gw {
+			diffs = append(diffs, Diff{Missing: true, What: fmt.Sprintf("route default via %s table %d", nic.VxNet.Gateway, table)})
+		}
+	}
+
+	srcRules, err := getRuleListBySrc(dst.IP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rules: %v", err)
+	}
+	for _, rule := range srcRules {
+		if rule.Table == table && rule.Src.String() == dst.String() {
+			return diffs, nil
+		}
+	}
+	return append(diffs, Diff{Missing: true, What: fmt.Sprintf("rule from %s lookup %d", dst, table)}), nil
+}
+
+func verifyPods(nic *rpc.HostNic, podIPs []string) ([]Diff, error) {
+	var diffs []Diff
+	brName := constants.GetHostNicBridgeName(int(nic.RouteTableNum))
+
+	rules, err := netlink.RuleList(unix.AF_INET)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rules: %v", err)
+	}
+	replies, err := arpReplies(brName)
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := make(map[string]bool)
+	for _, ip := range podIPs {
+		ip := ip
+		recorded[ip] = true
+		fix := func() error { return NetworkUtils{}.SetupPodNetwork(nic, ip) }
+
+		rule := false
+		for _, r := range rules {
+			if r.Dst != nil && r.Dst.IP.Equal(net.ParseIP(ip)) && r.Table == constants.MainTable {
+				rule = true
+			}
+		}
+		if !rule {
+			diffs = append(diffs, Diff{Missing: true, What: fmt.Sprintf("rule to %s lookup main", ip), Fix: fix})
+		}
+		if mac, ok := replies[ip]; !ok {
+			diffs = append(diffs, Diff{Missing: true, What: fmt.Sprintf("arp reply %s on %s", ip, brName), Fix: fix})
+		} else if !sameMAC(mac, nic.HardwareAddr) {
+			diffs = append(diffs, Diff{Missing: true, What: fmt.Sprintf("arp reply %s on %s with %s, got %s", ip, brName, nic.HardwareAddr, mac)})
+		}
+	}
+
+	var stale []string
+	for ip := range replies {
+		if !recorded[ip] {
+			stale = append(stale, ip)
+		}
+	}
+	sort.Strings(stale)
+	for _, ip := range stale {
+		ip, mac := ip, replies[ip]
+		diffs = append(diffs, Diff{
+			What: fmt.Sprintf("arp reply %s on %s", ip, brName),
+			Fix:  func() error { return setArpReply(brName, ip, mac, "-D") },
+		})
+	}
+	return diffs, nil
+}
+
+// arpReplies returns the macs of the ebtables arpreply entries on br by ip
+func arpReplies(br string) (map[string]string, error) {
+	out, err := ListArpReply()
+	if err != nil {
+		return nil, err
+	}
+
+	replies := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		var in, ip, mac string
+		for i := 0; i < len(fields)-1; i++ {
+			switch fields[i] {
+			case "--logical-in":
+				in = fields[i+1]
+			case "--arp-ip-dst":
+				ip = fields[i+1]
+			case "--arpreply-mac":
+				mac = fields[i+1]
+			}
+		}
+		if in == br && ip != "" {
+			replies[ip] = mac
+		}
+	}
+	return replies, nil
+}
+
+// sameMAC compares macs ignoring case and leading zeros, which ebtables omits
+func sameMAC(a, b string) bool {
+	as, bs := strings.Split(a, ":"), strings.Split(b, ":")
+	if len(as) != len(bs) {
+		return false
+	}
+	for i := range as {
+		x, errx := strconv.ParseUint(as[i], 16, 8)
+		y, erry := strconv.ParseUint(bs[i], 16, 8)
+		if errx != nil || erry != nil || x != y {
+			return false
+		}
+	}
+	return true
+}
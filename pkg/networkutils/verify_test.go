@@ -0,0 +1,106 @@
+package networkutils
+
+import (
+	"net"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+
+	"github.com/yunify/hostnic-cni/pkg/constants"
+	"github.com/yunify/hostnic-cni/pkg/dhcp"
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+)
+
+// setupTestNetns switches the test to a new netns until it ends, the test must not start goroutines
+// doing netlink calls
+func setupTestNetns(t *testing.T) {
+	runtime.LockOSThread()
+	origNS, err := netns.Get()
+	if err != nil {
+		t.Fatalf("failed to get netns: %v", err)
+	}
+	testNS, err := netns.New()
+	if err != nil {
+		t.Fatalf("failed to create netns: %v", err)
+	}
+	t.Cleanup(func() {
+		netns.Set(origNS)
+		origNS.Close()
+		testNS.Close()
+		runtime.UnlockOSThread()
+	})
+}
+
+// setupTestNic adds the links of nic the way the node daemon does: a bridge with the
+// address of the nic and the nic in it
+func setupTestNic(t *testing.T, nic *rpc.HostNic) {
+	mac, _ := net.ParseMAC(nic.HardwareAddr)
+	br := &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{
+		Name:         constants.GetHostNicBridgeName(int(nic.RouteTableNum)),
+		HardwareAddr: mac,
+	}}
+	if err := netlink.LinkAdd(br); err != nil {
+		t.Fatalf("failed to add bridge: %v", err)
+	}
+	link := &netlink.Veth{
+		LinkAttrs: netlink.LinkAttrs{
+			Name:         constants.GetHostNicName(nic.VxNet.ID),
+			HardwareAddr: mac,
+			MasterIndex:  br.Attrs().Index,
+		},
+		PeerName: "peer",
+	}
+	if err := netlink.LinkAdd(link); err != nil {
+		t.Fatalf("failed to add nic: %v", err)
+	}
+	for _, l := range []netlink.Link{br, link} {
+		if err := netlink.LinkSetUp(l); err != nil {
+			t.Fatalf("failed to set %s up: %v", l.Attrs().Name, err)
+		}
+	}
+}
+
+func TestVerifyFixVlanRouteTable(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("requires root to setup links and netns")
+	}
+	setupTestNetns(t)
+	// like db-client, which runs without the lease manager
+	saved := dhcp.Leases
+	dhcp.Leases = nil
+	defer func() { dhcp.Leases = saved }()
+
+	nic := &rpc.HostNic{
+		HardwareAddr:  "52:54:22:aa:bb:cc",
+		RouteTableNum: 260,
+		VxNet: &rpc.VxNet{
+			ID:         "vxnet-test",
+			TunnelType: constants.TunnelTypeVlan,
+			Network:    "172.16.0.0/24",
+			Gateway:    "172.16.0.1",
+		},
+	}
+	setupTestNic(t, nic)
+
+	diffs, err := verifyNic(nic)
+	if err != nil {
+		t.Fatalf("failed to verify nic: %v", err)
+	}
+	if len(diffs) != 1 || diffs[0].What != "rule from 172.16.0.0/24 lookup 260" || diffs[0].Fix == nil {
+		t.Fatalf("expect a fixable missing rule, got %v", diffs)
+	}
+	if err := diffs[0].Fix(); err != nil {
+		t.Fatalf("failed to fix %s: %v", diffs[0], err)
+	}
+
+	diffs, err = verifyNic(nic)
+	if err != nil {
+		t.Fatalf("failed to verify nic: %v", err)
+	}
+	if len(diffs) != 0 {
+		t.Errorf("expect no differences after the fix, got %v", diffs)
+	}
+}
@@ -0,0 +1,277 @@
+package qcclient
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/yunify/qingcloud-sdk-go/config"
+	log "k8s.io/klog/v2"
+
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+)
+
+const (
+	// kubelet updates a mounted secret by several renames in its directory
+	credentialsDebounce = time.Second
+	// reload even if no event is seen, e.g. after the directory was recreated
+	credentialsResync = 5 * time.Minute
+)
+
+// CredentialStatus is the result of the last reload of the qingcloud credentials
+type CredentialStatus struct {
+	AccessKeyID string    `json:"accessKeyID"`
+	LoadedAt    time.Time `json:"loadedAt"`
+	LastReload  time.Time `json:"lastReload,omitempty"`
+	LastError   string    `json:"lastError,omitempty"`
+}
+
+// credentials is set up by SetupProvider if the credentials are reloaded
+var credentials *reloadableClient
+
+var _ Provider = &reloadableClient{}
+
+type apiHolder struct {
+	api Provider
+}
+
+// reloadableClient forwards the calls to the client of the current credentials. A client
+// for new credentials replaces it only after build validated them, the calls made in the
+// meantime keep using the old credentials.
+type reloadableClient struct {
+	current atomic.Value
+	build   func(qsdkconfig *config.Config) (Provider, error)
+
+	lock     sync.Mutex
+	checksum [sha256.Size]byte
+	status   CredentialStatus
+}
+
+func newReloadableClient(api Provider, accessKeyID string, build func(qsdkconfig *config.Config) (Provider, error)) *reloadableClient {
+	r := &reloadableClient{
+		build: build,
+		status: CredentialStatus{
+			AccessKeyID: accessKeyID,
+			LoadedAt:    time.Now(),
+		},
+	}
+	r.current.Store(&apiHolder{api: api})
+	credentialsLoaded.SetToCurrentTime()
+	return r
+}
+
+func (r *reloadableClient) api() Provider {
+	return r.current.Load().(*apiHolder).api
+}
+
+// reload switches to the credentials in path if they changed and are valid
+func (r *reloadableClient) reload(path string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return r.reloadFailed(fmt.Errorf("read config %s error: %v", path, err))
+	}
+	checksum := sha256.Sum256(content)
+	if checksum == r.checksum {
+		return nil
+	}
+
+	qsdkconfig, err := config.NewDefault()
+	if err != nil {
+		return r.reloadFailed(fmt.Errorf("new sdk default config error: %v", err))
+	}
+	if err := qsdkconfig.LoadConfigFromContent(content); err != nil {
+		return r.reloadFailed(fmt.Errorf("parse config %s error: %v", path, err))
+	}
+	api, err := r.build(qsdkconfig)
+	if err != nil {
+		return r.reloadFailed(err)
+	}
+
+	r.current.Store(&apiHolder{api: api})
+	r.checksum = checksum
+	now := time.Now()
+	if r.status.AccessKeyID != qsdkconfig.AccessKeyID || r.status.LastError != "" {
+		log.Infof("reloaded qingcloud credentials of access key %s", qsdkconfig.AccessKeyID)
+	}
+	r.status = CredentialStatus{
+		AccessKeyID: qsdkconfig.AccessKeyID,
+		LoadedAt:    now,
+		LastReload:  now,
+	}
+	credentialReloads.WithLabelValues("success").Inc()
+	credentialsLoaded.SetToCurrentTime()
+	return nil
+}
+
+// reloadFailed records err, the current credentials are kept
+func (r *reloadableClient) reloadFailed(err error) error {
+	log.Errorf("failed to reload qingcloud credentials, keep access key %s: %v", r.status.AccessKeyID, err)
+	r.status.LastReload = time.Now()
+	r.status.LastError = err.Error()
+	credentialReloads.WithLabelValues("error").Inc()
+	return err
+}
+
+func (r *reloadableClient) Status() CredentialStatus {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.status
+}
+
+// watch reloads the credentials whenever the directory of path changes until stopCh is closed
+func (r *reloadableClient) watch(path string, stopCh <-chan struct{}) {
+	// the credentials in use were loaded from path
+	if content, err := os.ReadFile(path); err == nil {
+		r.lock.Lock()
+		r.checksum = sha256.Sum256(content)
+		r.lock.Unlock()
+	}
+
+	var events chan fsnotify.Event
+	watcher, err := fsnotify.NewWatcher()
+	if err == nil {
+		err = watcher.Add(filepath.Dir(path))
+	}
+	if err != nil {
+		log.Errorf("failed to watch %s, reload qingcloud credentials every %v: %v", path, credentialsResync, err)
+	} else {
+		defer watcher.Close()
+		events = watcher.Events
+		go func() {
+			for err := range watcher.Errors {
+				log.Errorf("watch %s error: %v", path, err)
+			}
+		}()
+	}
+
+	resync := time.NewTicker(credentialsResync)
+	defer resync.Stop()
+	debounce := time.NewTimer(credentialsDebounce)
+	debounce.Stop()
+	for {
+		select {
+		case <-events:
+			debounce.Reset(credentialsDebounce)
+		case <-debounce.C:
+			r.reload(path)
+		case <-resync.C:
+			r.reload(path)
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// CredentialReloadStatus returns the status of the credentials if they are reloaded
+func CredentialReloadStatus() (CredentialStatus, bool) {
+	if credentials == nil {
+		return CredentialStatus{}, false
+	}
+	return credentials.Status(), true
+}
+
+// InstallHandlers registers /debug/qingcloud/credentials on mux, it serves the status of the
+// credentials without the secret key.
+func InstallHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/qingcloud/credentials", func(w http.ResponseWriter, r *http.Request) {
+		status, ok := CredentialReloadStatus()
+		if !ok {
+			http.Error(w, "qingcloud credentials are not reloaded", http.StatusNotFound)
+			return
+		}
+		var buf bytes.Buffer
+		if err := json.NewEncoder(&buf).Encode(status); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(buf.Bytes())
+	})
+}
+
+func (r *reloadableClient) GetInstanceID() string {
+	return r.api().GetInstanceID()
+}
+
+func (r *reloadableClient) GetCreatedNicsByName(name string) ([]*rpc.HostNic, error) {
+	return r.api().GetCreatedNicsByName(name)
+}
+
+func (r *reloadableClient) GetVxNets(ids []string, customReservedIPCount int64) (map[string]*rpc.VxNet, error) {
+	return r.api().GetVxNets(ids, customReservedIPCount)
+}
+
+func (r *reloadableClient) DescribeNicJobs(ids []string) ([]string, map[string]bool, error) {
+	return r.api().DescribeNicJobs(ids)
+}
+
+func (r *reloadableClient) CreateNicsAndAttach(vxnet *rpc.VxNet, num int, ips []string, disableIP int) ([]*rpc.HostNic, string, error) {
+	return r.api().CreateNicsAndAttach(vxnet, num, ips, disableIP)
+}
+
+func (r *reloadableClient) GetNics(nics []string) (map[string]*rpc.HostNic, error) {
+	return r.api().GetNics(nics)
+}
+
+func (r *reloadableClient) DeleteNics(nicIDs []string) error {
+	return r.api().DeleteNics(nicIDs)
+}
+
+func (r *reloadableClient) DeattachNics(nicIDs []string, sync bool) (string, error) {
+	return r.api().DeattachNics(nicIDs, sync)
+}
+
+func (r *reloadableClient) AttachNics(nicIDs []string, sync bool) (string, error) {
+	return r.api().AttachNics(nicIDs, sync)
+}
+
+func (r *reloadableClient) GetAttachedNics() ([]*rpc.HostNic, error) {
+	return r.api().GetAttachedNics()
+}
+
+func (r *reloadableClient) GetCreatedNicsByVxNet(vxnet string) ([]*rpc.HostNic, error) {
+	return r.api().GetCreatedNicsByVxNet(vxnet)
+}
+
+func (r *reloadableClient) CreateVIPs(vxnet *rpc.VxNet) (string, error) {
+	return r.api().CreateVIPs(vxnet)
+}
+
+func (r *reloadableClient) DescribeVIPs(vxnet *rpc.VxNet) ([]*rpc.VIP, error) {
+	return r.api().DescribeVIPs(vxnet)
+}
+
+func (r *reloadableClient) DeleteVIPs(vips []string) (string, error) {
+	return r.api().DeleteVIPs(vips)
+}
+
+func (r *reloadableClient) CreateSecurityGroupRuleForVxNet(sg string, vxnet *rpc.VxNet) (string, error) {
+	return r.api().CreateSecurityGroupRuleForVxNet(sg, vxnet)
+}
+
+func (r *reloadableClient) GetSecurityGroupRuleForVxNet(sg string, vxnet *rpc.VxNet) (*rpc.SecurityGroupRule, error) {
+	return r.api().GetSecurityGroupRuleForVxNet(sg, vxnet)
+}
+
+func (r *reloadableClient) DeleteSecurityGroupRuleForVxNet(sgr string) error {
+	return r.api().DeleteSecurityGroupRuleForVxNet(sgr)
+}
+
+func (r *reloadableClient) DescribeClusterSecurityGroup(clusterID string) (string, error) {
+	return r.api().DescribeClusterSecurityGroup(clusterID)
+}
+
+func (r *reloadableClient) DescribeClusterNodes(clusterID string) ([]*rpc.Node, error) {
+	return r.api().DescribeClusterNodes(clusterID)
+}
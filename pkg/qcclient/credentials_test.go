@@ -0,0 +1,107 @@
+package qcclient
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yunify/qingcloud-sdk-go/config"
+)
+
+// keyAPI reports the access key it was built with as instance id
+type keyAPI struct {
+	Provider
+	key string
+}
+
+func (k *keyAPI) GetInstanceID() string {
+	return k.key
+}
+
+type testBuilder struct {
+	builds int
+}
+
+func (b *testBuilder) build(qsdkconfig *config.Config) (Provider, error) {
+	b.builds++
+	if qsdkconfig.AccessKeyID == "bad" {
+		return nil, fmt.Errorf("failed to DescribeAccessKeys: access key %s is invalid", qsdkconfig.AccessKeyID)
+	}
+	return &keyAPI{key: qsdkconfig.AccessKeyID}, nil
+}
+
+func writeCredentials(t *testing.T, path, key string) {
+	content := fmt.Sprintf("qy_access_key_id: %s\nqy_secret_access_key: secret\nzone: test\n", key)
+	// replace the file at once like kubelet does for a mounted secret
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write credentials: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatalf("failed to write credentials: %v", err)
+	}
+}
+
+func TestReloadCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	b := &testBuilder{}
+	r := newReloadableClient(&keyAPI{key: "old"}, "old", b.build)
+
+	writeCredentials(t, path, "new")
+	if err := r.reload(path); err != nil {
+		t.Fatalf("reload error: %v", err)
+	}
+	if key := r.GetInstanceID(); key != "new" {
+		t.Fatalf("expect client of the new credentials, got %s", key)
+	}
+	if status := r.Status(); status.AccessKeyID != "new" || status.LastError != "" {
+		t.Fatalf("unexpected status %+v", status)
+	}
+
+	// nothing is built if the file did not change
+	if err := r.reload(path); err != nil || b.builds != 1 {
+		t.Fatalf("expect 1 build for unchanged credentials, got %d %v", b.builds, err)
+	}
+
+	writeCredentials(t, path, "bad")
+	if err := r.reload(path); err == nil {
+		t.Fatalf("expect invalid credentials rejected")
+	}
+	if key := r.GetInstanceID(); key != "new" {
+		t.Fatalf("expect client of the valid credentials kept, got %s", key)
+	}
+	if status := r.Status(); status.AccessKeyID != "new" || status.LastError == "" {
+		t.Fatalf("expect the error recorded, got %+v", status)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("failed to remove credentials: %v", err)
+	}
+	if err := r.reload(path); err == nil || r.GetInstanceID() != "new" {
+		t.Fatalf("expect missing credentials rejected, got %v", err)
+	}
+}
+
+func TestWatchCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeCredentials(t, path, "old")
+	b := &testBuilder{}
+	r := newReloadableClient(&keyAPI{key: "old"}, "old", b.build)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go r.watch(path, stopCh)
+	// let the watcher start before the change
+	time.Sleep(100 * time.Millisecond)
+
+	writeCredentials(t, path, "new")
+	deadline := time.Now().Add(5 * time.Second)
+	for r.GetInstanceID() != "new" {
+		if time.Now().After(deadline) {
+			t.Fatalf("credentials not reloaded, status %+v", r.Status())
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
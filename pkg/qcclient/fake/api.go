@@ -0,0 +1,414 @@
+package fake
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sort"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/yunify/hostnic-cni/pkg/constants"
+	"github.com/yunify/hostnic-cni/pkg/qcclient"
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+)
+
+func (c *Cloud) GetInstanceID() string {
+	return c.instanceID
+}
+
+func (c *Cloud) GetCreatedNicsByName(name string) ([]*rpc.HostNic, error) {
+	if err := c.begin("GetCreatedNicsByName"); err != nil {
+		return nil, err
+	}
+	defer c.lock.Unlock()
+
+	var result []*rpc.HostNic
+	for _, n := range c.sortedNics(func(n *nic) bool { return n.name == name && !n.primary }) {
+		result = append(result, c.hostNic(n, true))
+	}
+	return result, nil
+}
+
+func (c *Cloud) GetCreatedNicsByVxNet(vxnet string) ([]*rpc.HostNic, error) {
+	if err := c.begin("GetCreatedNicsByVxNet"); err != nil {
+		return nil, err
+	}
+	defer c.lock.Unlock()
+
+	var result []*rpc.HostNic
+	for _, n := range c.sortedNics(func(n *nic) bool { return n.vxnet == vxnet && !n.primary }) {
+		result = append(result, c.hostNic(n, true))
+	}
+	return result, nil
+}
+
+func (c *Cloud) GetVxNets(ids []string, customReservedIPCount int64) (map[string]*rpc.VxNet, error) {
+	if err := c.begin("GetVxNets"); err != nil {
+		return nil, err
+	}
+	defer c.lock.Unlock()
+
+	if len(ids) <= 0 {
+		return nil, fmt.Errorf("GetVxNets should not have empty input")
+	}
+	result := make(map[string]*rpc.VxNet)
+	for _, id := range ids {
+		if vxnet, ok := c.vxnets[id]; ok {
+			// like the api, the end of the range is reserved for the nics and the rest is
+			// left to the vips of the pods
+			r := copyVxNet(vxnet)
+			reserved := int64(reservedIPsForVxlan)
+			if r.TunnelType == constants.TunnelTypeVlan {
+				reserved = qcclient.IPRangeCount(r.IPStart, r.IPEnd)/reservedIPsForVlan + customReservedIPCount
+			}
+			end := net.ParseIP(r.IPEnd).To4()
+			n := binary.BigEndian.Uint32(end) - uint32(reserved)
+			binary.BigEndian.PutUint32(end, n)
+			r.IPEnd = end.String()
+			result[id] = r
+		}
+	}
+	return result, nil
+}
+
+func (c *Cloud) DescribeNicJobs(ids []string) ([]string, map[string]bool, error) {
+	if err := c.begin("DescribeNicJobs"); err != nil {
+		return nil, nil, err
+	}
+	defer c.lock.Unlock()
+
+	var left []string
+	working := make(map[string]bool)
+	for _, id := range ids {
+		j, ok := c.jobs[id]
+		if !ok || j.status != jobStatusWorking || (j.action != "AttachNics" && j.action != "DetachNics") {
+			continue
+		}
+		left = append(left, id)
+		for _, nic := range j.nics {
+			working[nic] = true
+		}
+	}
+	return left, working, nil
+}
+
+func (c *Cloud) CreateNicsAndAttach(vxnet *rpc.VxNet, num int, ips []string, disableIP int) ([]*rpc.HostNic, string, error) {
+	if err := c.begin("CreateNicsAndAttach"); err != nil {
+		return nil, "", err
+	}
+	defer c.lock.Unlock()
+
+	v, ok := c.vxnets[vxnet.ID]
+	if !ok {
+		return nil, "", NotFound(vxnet.ID)
+	}
+	if ips != nil {
+		num = len(ips)
+	}
+	taken := c.takenIPs(vxnet.ID)
+	for _, ip := range ips {
+		if taken[ip] {
+			return nil, "", fmt.Errorf("ip %s of vxnet %s is in use", ip, vxnet.ID)
+		}
+	}
+
+	var created []*nic
+	for i := 0; i < num; i++ {
+		n := &nic{
+			id:     c.newMAC(),
+			name:   constants.NicPrefix + c.instanceID,
+			vxnet:  vxnet.ID,
+			status: nicStatusAvailable,
+		}
+		if ips != nil {
+			n.ip = ips[i]
+		} else {
+			ip, err := c.freeIP(v, taken)
+			if err != nil {
+				return nil, "", err
+			}
+			n.ip = ip
+		}
+		created = append(created, n)
+	}
+
+	var result []*rpc.HostNic
+	var ids []string
+	for _, n := range created {
+		c.nics[n.id] = n
+		r := c.hostNic(n, false)
+		r.VxNet = vxnet
+		if disableIP != 0 {
+			r.PrimaryAddress = ""
+		}
+		result = append(result, r)
+		ids = append(ids, n.id)
+	}
+	j := c.attach(ids)
+	return result, j.id, nil
+}
+
+func (c *Cloud) GetNics(ids []string) (map[string]*rpc.HostNic, error) {
+	if err := c.begin("GetNics"); err != nil {
+		return nil, err
+	}
+	defer c.lock.Unlock()
+
+	result := make(map[string]*rpc.HostNic)
+	for _, id := range ids {
+		if n, ok := c.nics[id]; ok {
+			result[id] = c.hostNic(n, false)
+		}
+	}
+	return result, nil
+}
+
+func (c *Cloud) DeleteNics(ids []string) error {
+	if err := c.begin("DeleteNics"); err != nil {
+		return err
+	}
+	defer c.lock.Unlock()
+
+	for _, id := range ids {
+		n, ok := c.nics[id]
+		if !ok {
+			return NotFound(id)
+		}
+		if n.status != nicStatusAvailable {
+			return fmt.Errorf("nic %s is %s", id, n.status)
+		}
+	}
+	for _, id := range ids {
+		delete(c.nics, id)
+	}
+	return nil
+}
+
+func (c *Cloud) DeattachNics(ids []string, sync bool) (string, error) {
+	if err := c.begin("DeattachNics"); err != nil {
+		return "", err
+	}
+	defer c.lock.Unlock()
+
+	if len(ids) <= 0 {
+		return "", nil
+	}
+	for _, id := range ids {
+		n, ok := c.nics[id]
+		if !ok {
+			return "", NotFound(id)
+		}
+		if n.status != nicStatusInUse || n.instance != c.instanceID {
+			return "", fmt.Errorf("nic %s is not attached to instance %s", id, c.instanceID)
+		}
+	}
+
+	j := c.newJob("DetachNics", ids, func() {
+		for _, id := range ids {
+			if n, ok := c.nics[id]; ok {
+				n.status = nicStatusAvailable
+				n.instance = ""
+			}
+		}
+	})
+	if sync {
+		c.wait(j)
+		return "", nil
+	}
+	return j.id, nil
+}
+
+func (c *Cloud) AttachNics(ids []string, sync bool) (string, error) {
+	if err := c.begin("AttachNics"); err != nil {
+		return "", err
+	}
+	defer c.lock.Unlock()
+
+	for _, id := range ids {
+		n, ok := c.nics[id]
+		if !ok {
+			return "", NotFound(id)
+		}
+		if n.status != nicStatusAvailable {
+			return "", fmt.Errorf("nic %s is %s", id, n.status)
+		}
+	}
+
+	j := c.attach(ids)
+	if sync {
+		c.wait(j)
+		return "", nil
+	}
+	return j.id, nil
+}
+
+func (c *Cloud) attach(ids []string) *job {
+	return c.newJob("AttachNics", ids, func() {
+		for _, id := range ids {
+			if n, ok := c.nics[id]; ok {
+				n.status = nicStatusInUse
+				n.instance = c.instanceID
+			}
+		}
+	})
+}
+
+func (c *Cloud) GetAttachedNics() ([]*rpc.HostNic, error) {
+	if err := c.begin("GetAttachedNics"); err != nil {
+		return nil, err
+	}
+	defer c.lock.Unlock()
+
+	var result []*rpc.HostNic
+	for _, n := range c.sortedNics(func(n *nic) bool { return n.instance == c.instanceID && n.status == nicStatusInUse }) {
+		result = append(result, c.hostNic(n, false))
+	}
+	return result, nil
+}
+
+func (c *Cloud) CreateVIPs(vxnet *rpc.VxNet) (string, error) {
+	if err := c.begin("CreateVIPs"); err != nil {
+		return "", err
+	}
+	defer c.lock.Unlock()
+
+	v, ok := c.vxnets[vxnet.ID]
+	if !ok {
+		return "", NotFound(vxnet.ID)
+	}
+	// the range is given by vxnet, which has the reserved ips cut off
+	r := copyVxNet(v)
+	r.IPStart, r.IPEnd = vxnet.IPStart, vxnet.IPEnd
+	count := int(qcclient.IPRangeCount(r.IPStart, r.IPEnd))
+	taken := c.takenIPs(vxnet.ID)
+	var addrs []string
+	for i := 0; i < count; i++ {
+		ip, err := c.freeIP(r, taken)
+		if err != nil {
+			return "", err
+		}
+		addrs = append(addrs, ip)
+	}
+
+	j := c.newJob("CreateVIPs", nil, func() {
+		for _, addr := range addrs {
+			id := c.newID("vip")
+			c.vips[id] = &rpc.VIP{ID: id, Name: constants.NicPrefix + vxnet.ID, Addr: addr, VxNetID: vxnet.ID}
+		}
+	})
+	return j.id, nil
+}
+
+func (c *Cloud) DescribeVIPs(vxnet *rpc.VxNet) ([]*rpc.VIP, error) {
+	if err := c.begin("DescribeVIPs"); err != nil {
+		return nil, err
+	}
+	defer c.lock.Unlock()
+
+	var result []*rpc.VIP
+	for _, vip := range c.vips {
+		if vip.VxNetID == vxnet.ID && vip.Name == constants.NicPrefix+vxnet.ID {
+			result = append(result, &rpc.VIP{ID: vip.ID, Name: vip.Name, Addr: vip.Addr, VxNetID: vip.VxNetID})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result, nil
+}
+
+func (c *Cloud) DeleteVIPs(vips []string) (string, error) {
+	if err := c.begin("DeleteVIPs"); err != nil {
+		return "", err
+	}
+	defer c.lock.Unlock()
+
+	if len(vips) <= 0 {
+		return "", nil
+	}
+	for _, id := range vips {
+		if _, ok := c.vips[id]; !ok {
+			return "", NotFound(id)
+		}
+	}
+	j := c.newJob("DeleteVIPs", nil, func() {
+		for _, id := range vips {
+			delete(c.vips, id)
+		}
+	})
+	return j.id, nil
+}
+
+func (c *Cloud) CreateSecurityGroupRuleForVxNet(sg string, vxnet *rpc.VxNet) (string, error) {
+	if err := c.begin("CreateSecurityGroupRuleForVxNet"); err != nil {
+		return "", err
+	}
+	defer c.lock.Unlock()
+
+	id := c.newID("sgr")
+	c.rules[id] = &rpc.SecurityGroupRule{
+		ID:              id,
+		Name:            constants.NicPrefix + vxnet.ID,
+		SecurityGroupID: sg,
+		Action:          "accept",
+		Protocol:        "all",
+		Val3:            vxnet.Network,
+	}
+	// the rule takes effect once the security group is applied
+	j := c.newJob("ApplySecurityGroup", nil, func() {})
+	return j.id, nil
+}
+
+func (c *Cloud) GetSecurityGroupRuleForVxNet(sg string, vxnet *rpc.VxNet) (*rpc.SecurityGroupRule, error) {
+	if err := c.begin("GetSecurityGroupRuleForVxNet"); err != nil {
+		return nil, err
+	}
+	defer c.lock.Unlock()
+
+	var ids []string
+	for id := range c.rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		rule := c.rules[id]
+		if rule.SecurityGroupID == sg && rule.Val3 == vxnet.Network && rule.Action == "accept" && rule.Protocol == "all" {
+			return proto.Clone(rule).(*rpc.SecurityGroupRule), nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *Cloud) DeleteSecurityGroupRuleForVxNet(sgr string) error {
+	if err := c.begin("DeleteSecurityGroupRuleForVxNet"); err != nil {
+		return err
+	}
+	defer c.lock.Unlock()
+
+	if _, ok := c.rules[sgr]; !ok {
+		return NotFound(sgr)
+	}
+	delete(c.rules, sgr)
+	return nil
+}
+
+func (c *Cloud) DescribeClusterSecurityGroup(clusterID string) (string, error) {
+	if err := c.begin("DescribeClusterSecurityGroup"); err != nil {
+		return "", err
+	}
+	defer c.lock.Unlock()
+
+	return c.clusters[clusterID], nil
+}
+
+func (c *Cloud) DescribeClusterNodes(clusterID string) ([]*rpc.Node, error) {
+	if err := c.begin("DescribeClusterNodes"); err != nil {
+		return nil, err
+	}
+	defer c.lock.Unlock()
+
+	var result []*rpc.Node
+	for _, node := range c.nodes[clusterID] {
+		result = append(result, proto.Clone(node).(*rpc.Node))
+	}
+	return result, nil
+}
@@ -0,0 +1,337 @@
+// Package fake simulates the qingcloud api in memory, so the allocator, the vxnetpool
+// controller and the tools can be tested without credentials.
+package fake
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	qcerrors "github.com/yunify/qingcloud-sdk-go/request/errors"
+
+	"github.com/yunify/hostnic-cni/pkg/constants"
+	"github.com/yunify/hostnic-cni/pkg/qcclient"
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+)
+
+const (
+	nicStatusAvailable = "available"
+	nicStatusInUse     = "in-use"
+
+	jobStatusWorking    = "working"
+	jobStatusSuccessful = "successful"
+
+	// ret code of the api for missing resources
+	retCodeNotFound = 2100
+
+	// ips at the end of the range of a vxnet which are left to the nics, 1/7 of the range for vlan
+	reservedIPsForVxlan = 12
+	reservedIPsForVlan  = 7
+)
+
+var _ qcclient.Provider = &Cloud{}
+
+// Fault is injected into the calls of a method
+type Fault struct {
+	// returned instead of calling the method if set
+	Err error
+	// added before the call
+	Latency time.Duration
+	// number of calls the fault applies to, all calls if 0
+	Times int
+}
+
+type nic struct {
+	id       string
+	name     string
+	vxnet    string
+	ip       string
+	instance string
+	status   string
+	primary  bool
+}
+
+type job struct {
+	id     string
+	action string
+	nics   []string
+	status string
+	done   time.Time
+	apply  func()
+}
+
+// Cloud is a qingcloud account with one zone. Async operations create jobs which are
+// applied after the job latency, when the next call is made or on CompleteJobs.
+type Cloud struct {
+	lock sync.Mutex
+
+	instanceID string
+	vxnets     map[string]*rpc.VxNet
+	nics       map[string]*nic
+	vips       map[string]*rpc.VIP
+	rules      map[string]*rpc.SecurityGroupRule
+	clusters   map[string]string
+	nodes      map[string][]*rpc.Node
+	jobs       map[string]*job
+
+	jobLatency time.Duration
+	faults     map[string]*Fault
+	calls      map[string]int
+	lastID     int
+}
+
+// NewCloud returns an empty account, the calls are made from the instance instanceID
+func NewCloud(instanceID string) *Cloud {
+	return &Cloud{
+		instanceID: instanceID,
+		vxnets:     make(map[string]*rpc.VxNet),
+		nics:       make(map[string]*nic),
+		vips:       make(map[string]*rpc.VIP),
+		rules:      make(map[string]*rpc.SecurityGroupRule),
+		clusters:   make(map[string]string),
+		nodes:      make(map[string][]*rpc.Node),
+		jobs:       make(map[string]*job),
+		faults:     make(map[string]*Fault),
+		calls:      make(map[string]int),
+	}
+}
+
+// Setup makes c the qingcloud client of the daemons
+func Setup(c *Cloud) {
+	qcclient.QClient = c
+}
+
+// AddVxNet adds a vxnet, its network, gateway and ip range must be set
+func (c *Cloud) AddVxNet(vxnet *rpc.VxNet) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.vxnets[vxnet.ID] = copyVxNet(vxnet)
+}
+
+// AddPrimaryNic attaches the primary nic of instance with ip in vxnet
+func (c *Cloud) AddPrimaryNic(instance, vxnet, ip string) string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	id := c.newMAC()
+	c.nics[id] = &nic{id: id, vxnet: vxnet, ip: ip, instance: instance, status: nicStatusInUse, primary: true}
+	return id
+}
+
+// AddCluster adds a cluster with its security group and nodes
+func (c *Cloud) AddCluster(clusterID, securityGroup string, nodes ...*rpc.Node) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.clusters[clusterID] = securityGroup
+	c.nodes[clusterID] = nodes
+}
+
+// SetJobLatency sets how long jobs created later take
+func (c *Cloud) SetJobLatency(latency time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.jobLatency = latency
+}
+
+// CompleteJobs applies all working jobs at once
+func (c *Cloud) CompleteJobs() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, j := range c.jobs {
+		c.complete(j)
+	}
+}
+
+// Inject makes the calls of method fail or slow down, see Fault
+func (c *Cloud) Inject(method string, f Fault) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.faults[method] = &f
+}
+
+// Calls returns the number of calls of method, including failed ones
+func (c *Cloud) Calls(method string) int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.calls[method]
+}
+
+// Nics returns the nics created for instance by id, whether they are attached or not
+func (c *Cloud) Nics(instance string) map[string]*rpc.HostNic {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.advance()
+	result := make(map[string]*rpc.HostNic)
+	for _, n := range c.nics {
+		if n.name == constants.NicPrefix+instance {
+			result[n.id] = c.hostNic(n, true)
+		}
+	}
+	return result
+}
+
+// NotFound returns the error of the api for a missing resource
+func NotFound(id string) error {
+	return &qcerrors.QingCloudError{
+		RetCode: retCodeNotFound,
+		Message: fmt.Sprintf("%s, resource [%s] not found", constants.ResourceNotFound, id),
+	}
+}
+
+// call counts a call of method and applies its fault, it must be called without the lock
+func (c *Cloud) call(method string) error {
+	c.lock.Lock()
+	c.calls[method]++
+	var f Fault
+	if fault, ok := c.faults[method]; ok {
+		f = *fault
+		if fault.Times > 0 {
+			fault.Times--
+			if fault.Times == 0 {
+				delete(c.faults, method)
+			}
+		}
+	}
+	c.lock.Unlock()
+
+	time.Sleep(f.Latency)
+	return f.Err
+}
+
+// begin locks c for a call of method and applies the jobs which are done
+func (c *Cloud) begin(method string) error {
+	if err := c.call(method); err != nil {
+		return err
+	}
+	c.lock.Lock()
+	c.advance()
+	return nil
+}
+
+// advance applies the jobs which are done
+func (c *Cloud) advance() {
+	now := time.Now()
+	for _, j := range c.jobs {
+		if j.status == jobStatusWorking && !now.Before(j.done) {
+			c.complete(j)
+		}
+	}
+}
+
+func (c *Cloud) complete(j *job) {
+	if j.status == jobStatusWorking {
+		j.status = jobStatusSuccessful
+		j.apply()
+	}
+}
+
+func (c *Cloud) newID(prefix string) string {
+	c.lastID++
+	return fmt.Sprintf("%s-%08d", prefix, c.lastID)
+}
+
+func (c *Cloud) newMAC() string {
+	c.lastID++
+	return fmt.Sprintf("52:54:%02x:%02x:%02x:%02x", byte(c.lastID>>24), byte(c.lastID>>16), byte(c.lastID>>8), byte(c.lastID))
+}
+
+func (c *Cloud) newJob(action string, nics []string, apply func()) *job {
+	j := &job{
+		id:     c.newID("j"),
+		action: action,
+		nics:   nics,
+		status: jobStatusWorking,
+		done:   time.Now().Add(c.jobLatency),
+		apply:  apply,
+	}
+	c.jobs[j.id] = j
+	return j
+}
+
+// wait completes j after the job latency for the sync calls, it must be called with the lock
+func (c *Cloud) wait(j *job) {
+	c.lock.Unlock()
+	time.Sleep(time.Until(j.done))
+	c.lock.Lock()
+	c.complete(j)
+}
+
+func (c *Cloud) hostNic(n *nic, withVxNet bool) *rpc.HostNic {
+	vxnet := &rpc.VxNet{ID: n.vxnet}
+	if withVxNet && c.vxnets[n.vxnet] != nil {
+		vxnet = copyVxNet(c.vxnets[n.vxnet])
+	}
+	return &rpc.HostNic{
+		ID:             n.id,
+		VxNet:          vxnet,
+		HardwareAddr:   n.id,
+		PrimaryAddress: n.ip,
+		IsPrimary:      n.primary,
+		Using:          n.status == nicStatusInUse,
+	}
+}
+
+// sortedNics returns the nics matching fn ordered by id
+func (c *Cloud) sortedNics(fn func(n *nic) bool) []*nic {
+	var result []*nic
+	for _, n := range c.nics {
+		if fn(n) {
+			result = append(result, n)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].id < result[j].id })
+	return result
+}
+
+func copyVxNet(vxnet *rpc.VxNet) *rpc.VxNet {
+	return &rpc.VxNet{
+		ID:         vxnet.ID,
+		Gateway:    vxnet.Gateway,
+		Network:    vxnet.Network,
+		IPStart:    vxnet.IPStart,
+		IPEnd:      vxnet.IPEnd,
+		TunnelType: vxnet.TunnelType,
+	}
+}
+
+// freeIP returns the first ip of the range of vxnet which is neither used by a nic nor a vip
+func (c *Cloud) freeIP(vxnet *rpc.VxNet, taken map[string]bool) (string, error) {
+	for ip := net.ParseIP(vxnet.IPStart); ip != nil; ip = nextIP(ip) {
+		if !taken[ip.String()] {
+			taken[ip.String()] = true
+			return ip.String(), nil
+		}
+		if ip.Equal(net.ParseIP(vxnet.IPEnd)) {
+			break
+		}
+	}
+	return "", fmt.Errorf("no free ip in vxnet %s", vxnet.ID)
+}
+
+func (c *Cloud) takenIPs(vxnet string) map[string]bool {
+	taken := make(map[string]bool)
+	for _, n := range c.nics {
+		if n.vxnet == vxnet {
+			taken[n.ip] = true
+		}
+	}
+	for _, vip := range c.vips {
+		if vip.VxNetID == vxnet {
+			taken[vip.Addr] = true
+		}
+	}
+	return taken
+}
+
+// nextIP returns the ipv4 address after ip, or nil after 255.255.255.255
+func nextIP(ip net.IP) net.IP {
+	next := append(net.IP(nil), ip.To4()...)
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			return next
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package fake
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yunify/hostnic-cni/pkg/constants"
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+)
+
+func newTestCloud() *Cloud {
+	c := NewCloud("i-test")
+	c.AddVxNet(&rpc.VxNet{
+		ID:      "vxnet-a",
+		Gateway: "192.168.0.1",
+		Network: "192.168.0.0/24",
+		IPStart: "192.168.0.2",
+		IPEnd:   "192.168.0.4",
+	})
+	return c
+}
+
+func TestNicJobs(t *testing.T) {
+	c := newTestCloud()
+	c.SetJobLatency(time.Hour)
+
+	nics, jobID, err := c.CreateNicsAndAttach(&rpc.VxNet{ID: "vxnet-a"}, 2, nil, 0)
+	if err != nil {
+		t.Fatalf("CreateNicsAndAttach error: %v", err)
+	}
+	if len(nics) != 2 || nics[0].PrimaryAddress != "192.168.0.2" || nics[1].PrimaryAddress != "192.168.0.3" {
+		t.Fatalf("unexpected nics %v", nics)
+	}
+
+	left, working, err := c.DescribeNicJobs([]string{jobID})
+	if err != nil {
+		t.Fatalf("DescribeNicJobs error: %v", err)
+	}
+	if len(left) != 1 || !working[nics[0].ID] || !working[nics[1].ID] {
+		t.Fatalf("job %s should be working on %v, got %v %v", jobID, nics, left, working)
+	}
+	if attached, _ := c.GetAttachedNics(); len(attached) != 0 {
+		t.Fatalf("nics attached before the job is done: %v", attached)
+	}
+	if err := c.DeleteNics([]string{nics[0].ID}); err != nil {
+		t.Fatalf("DeleteNics error: %v", err)
+	}
+
+	c.CompleteJobs()
+	left, _, _ = c.DescribeNicJobs([]string{jobID})
+	if len(left) != 0 {
+		t.Fatalf("job %s should be done", jobID)
+	}
+	attached, err := c.GetAttachedNics()
+	if err != nil {
+		t.Fatalf("GetAttachedNics error: %v", err)
+	}
+	if len(attached) != 1 || attached[0].ID != nics[1].ID || !attached[0].Using {
+		t.Fatalf("expect %s attached, got %v", nics[1].ID, attached)
+	}
+
+	if err := c.DeleteNics([]string{nics[1].ID}); err == nil {
+		t.Fatalf("deleting an in-use nic should fail")
+	}
+	c.SetJobLatency(0)
+	if _, err := c.DeattachNics([]string{nics[1].ID}, true); err != nil {
+		t.Fatalf("DeattachNics error: %v", err)
+	}
+	if err := c.DeleteNics([]string{nics[1].ID}); err != nil {
+		t.Fatalf("DeleteNics error: %v", err)
+	}
+	if len(c.Nics("i-test")) != 0 {
+		t.Fatalf("nics left after deleting them: %v", c.Nics("i-test"))
+	}
+}
+
+func TestInject(t *testing.T) {
+	c := newTestCloud()
+	injected := errors.New("injected")
+	c.Inject("GetVxNets", Fault{Err: injected, Times: 2})
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetVxNets([]string{"vxnet-a"}, 0); err != injected {
+			t.Fatalf("call %d: expect injected error, got %v", i, err)
+		}
+	}
+	vxnets, err := c.GetVxNets([]string{"vxnet-a"}, 0)
+	if err != nil || vxnets["vxnet-a"] == nil {
+		t.Fatalf("expect vxnet-a after the fault, got %v %v", vxnets, err)
+	}
+	if c.Calls("GetVxNets") != 3 {
+		t.Fatalf("expect 3 calls, got %d", c.Calls("GetVxNets"))
+	}
+
+	c.Inject("GetAttachedNics", Fault{Latency: 50 * time.Millisecond})
+	start := time.Now()
+	if _, err := c.GetAttachedNics(); err != nil {
+		t.Fatalf("GetAttachedNics error: %v", err)
+	}
+	if time.Since(start) < 50*time.Millisecond {
+		t.Fatalf("latency was not applied")
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	c := newTestCloud()
+	err := c.DeleteNics([]string{"52:54:00:00:00:ff"})
+	if err == nil || !strings.Contains(err.Error(), constants.ResourceNotFound) {
+		t.Fatalf("expect %s, got %v", constants.ResourceNotFound, err)
+	}
+	if _, _, err := c.CreateNicsAndAttach(&rpc.VxNet{ID: "vxnet-b"}, 1, nil, 0); err == nil {
+		t.Fatalf("creating nics in an unknown vxnet should fail")
+	}
+}
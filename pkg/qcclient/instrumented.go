@@ -0,0 +1,214 @@
+package qcclient
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/yunify/hostnic-cni/pkg/health"
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+	"github.com/yunify/hostnic-cni/pkg/tracing"
+)
+
+var _ Provider = &instrumentedClient{}
+
+// APIError is returned for every failed qingcloud api call, its message is the
+// message of the underlying error so callers matching on it keep working.
+type APIError struct {
+	Method string
+	Err    error
+}
+
+func (e *APIError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// IsAPIError returns true if err was caused by a qingcloud api call
+func IsAPIError(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr)
+}
+
+// instrumentedClient reports the result and latency of every qingcloud api call
+// to the health checker, prometheus and the trace in ctx
+type instrumentedClient struct {
+	api Provider
+	ctx context.Context
+}
+
+func newInstrumentedClient(api Provider) Provider {
+	return &instrumentedClient{api: api, ctx: context.Background()}
+}
+
+// WithContext returns QClient with its calls traced as children of the span in ctx
+func WithContext(ctx context.Context) Provider {
+	if c, ok := QClient.(*instrumentedClient); ok {
+		return &instrumentedClient{api: c.api, ctx: ctx}
+	}
+	return QClient
+}
+
+type apiCall struct {
+	method string
+	start  time.Time
+	span   *tracing.Span
+}
+
+func (h *instrumentedClient) begin(method string) *apiCall {
+	_, span := tracing.Start(h.ctx, "qingcloud."+method)
+	return &apiCall{
+		method: method,
+		start:  time.Now(),
+		span:   span,
+	}
+}
+
+// end records the call and wraps a failure into an APIError
+func (c *apiCall) end(err error) error {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	apiDuration.WithLabelValues(c.method, result).Observe(time.Since(c.start).Seconds())
+	health.ReportQingCloudResult(c.method, err)
+	c.span.End(err)
+
+	if err != nil {
+		return &APIError{Method: c.method, Err: err}
+	}
+	return nil
+}
+
+func (h *instrumentedClient) GetInstanceID() string {
+	return h.api.GetInstanceID()
+}
+
+func (h *instrumentedClient) GetCreatedNicsByName(name string) ([]*rpc.HostNic, error) {
+	call := h.begin("GetCreatedNicsByName")
+	nics, err := h.api.GetCreatedNicsByName(name)
+	err = call.end(err)
+	return nics, err
+}
+
+func (h *instrumentedClient) GetVxNets(ids []string, customReservedIPCount int64) (map[string]*rpc.VxNet, error) {
+	call := h.begin("GetVxNets")
+	vxnets, err := h.api.GetVxNets(ids, customReservedIPCount)
+	err = call.end(err)
+	return vxnets, err
+}
+
+func (h *instrumentedClient) DescribeNicJobs(ids []string) ([]string, map[string]bool, error) {
+	call := h.begin("DescribeNicJobs")
+	working, nics, err := h.api.DescribeNicJobs(ids)
+	err = call.end(err)
+	return working, nics, err
+}
+
+func (h *instrumentedClient) CreateNicsAndAttach(vxnet *rpc.VxNet, num int, ips []string, disableIP int) ([]*rpc.HostNic, string, error) {
+	call := h.begin("CreateNicsAndAttach")
+	nics, job, err := h.api.CreateNicsAndAttach(vxnet, num, ips, disableIP)
+	err = call.end(err)
+	return nics, job, err
+}
+
+func (h *instrumentedClient) GetNics(nics []string) (map[string]*rpc.HostNic, error) {
+	call := h.begin("GetNics")
+	result, err := h.api.GetNics(nics)
+	err = call.end(err)
+	return result, err
+}
+
+func (h *instrumentedClient) DeleteNics(nicIDs []string) error {
+	call := h.begin("DeleteNics")
+	err := h.api.DeleteNics(nicIDs)
+	err = call.end(err)
+	return err
+}
+
+func (h *instrumentedClient) DeattachNics(nicIDs []string, sync bool) (string, error) {
+	call := h.begin("DeattachNics")
+	job, err := h.api.DeattachNics(nicIDs, sync)
+	err = call.end(err)
+	return job, err
+}
+
+func (h *instrumentedClient) AttachNics(nicIDs []string, sync bool) (string, error) {
+	call := h.begin("AttachNics")
+	job, err := h.api.AttachNics(nicIDs, sync)
+	err = call.end(err)
+	return job, err
+}
+
+func (h *instrumentedClient) GetAttachedNics() ([]*rpc.HostNic, error) {
+	call := h.begin("GetAttachedNics")
+	nics, err := h.api.GetAttachedNics()
+	err = call.end(err)
+	return nics, err
+}
+
+func (h *instrumentedClient) GetCreatedNicsByVxNet(vxnet string) ([]*rpc.HostNic, error) {
+	call := h.begin("GetCreatedNicsByVxNet")
+	nics, err := h.api.GetCreatedNicsByVxNet(vxnet)
+	err = call.end(err)
+	return nics, err
+}
+
+func (h *instrumentedClient) CreateVIPs(vxnet *rpc.VxNet) (string, error) {
+	call := h.begin("CreateVIPs")
+	job, err := h.api.CreateVIPs(vxnet)
+	err = call.end(err)
+	return job, err
+}
+
+func (h *instrumentedClient) DescribeVIPs(vxnet *rpc.VxNet) ([]*rpc.VIP, error) {
+	call := h.begin("DescribeVIPs")
+	vips, err := h.api.DescribeVIPs(vxnet)
+	err = call.end(err)
+	return vips, err
+}
+
+func (h *instrumentedClient) DeleteVIPs(vips []string) (string, error) {
+	call := h.begin("DeleteVIPs")
+	job, err := h.api.DeleteVIPs(vips)
+	err = call.end(err)
+	return job, err
+}
+
+func (h *instrumentedClient) CreateSecurityGroupRuleForVxNet(sg string, vxnet *rpc.VxNet) (string, error) {
+	call := h.begin("CreateSecurityGroupRuleForVxNet")
+	rule, err := h.api.CreateSecurityGroupRuleForVxNet(sg, vxnet)
+	err = call.end(err)
+	return rule, err
+}
+
+func (h *instrumentedClient) GetSecurityGroupRuleForVxNet(sg string, vxnet *rpc.VxNet) (*rpc.SecurityGroupRule, error) {
+	call := h.begin("GetSecurityGroupRuleForVxNet")
+	rule, err := h.api.GetSecurityGroupRuleForVxNet(sg, vxnet)
+	err = call.end(err)
+	return rule, err
+}
+
+func (h *instrumentedClient) DeleteSecurityGroupRuleForVxNet(sgr string) error {
+	call := h.begin("DeleteSecurityGroupRuleForVxNet")
+	err := h.api.DeleteSecurityGroupRuleForVxNet(sgr)
+	err = call.end(err)
+	return err
+}
+
+func (h *instrumentedClient) DescribeClusterSecurityGroup(clusterID string) (string, error) {
+	call := h.begin("DescribeClusterSecurityGroup")
+	sg, err := h.api.DescribeClusterSecurityGroup(clusterID)
+	err = call.end(err)
+	return sg, err
+}
+
+func (h *instrumentedClient) DescribeClusterNodes(clusterID string) ([]*rpc.Node, error) {
+	call := h.begin("DescribeClusterNodes")
+	nodes, err := h.api.DescribeClusterNodes(clusterID)
+	err = call.end(err)
+	return nodes, err
+}
@@ -4,8 +4,21 @@ import (
 	"github.com/yunify/hostnic-cni/pkg/rpc"
 )
 
-// QingCloudAPI is a wrapper interface of qingcloud api
-type QingCloudAPI interface {
+// providers behind QClient
+const (
+	ProviderQingCloud = "qingcloud"
+	// nics attached in advance, listed in a node-local file, and vxnets listed in a file
+	// shared by the cluster, see StaticConfig
+	ProviderStatic = "static"
+
+	DefaultStaticConfig = "/etc/hostnic-static/static-provider.yaml"
+	DefaultStaticVxNets = "/etc/hostnic-vxnets/vxnets.yaml"
+)
+
+// Provider is the source of the nics and vxnets of hostnic, the qingcloud api or a static
+// config selected by Options.Provider. Nic, vip and vxnet are named after qingcloud, a provider
+// maps its own resources to them and returns an error for what it does not support.
+type Provider interface {
 	//node info
 	GetInstanceID() string
 
@@ -40,5 +53,5 @@ type QingCloudAPI interface {
 }
 
 var (
-	QClient QingCloudAPI
+	QClient Provider
 )
@@ -0,0 +1,61 @@
+package qcclient
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// calls waiting for jobs to finish may take minutes
+	apiDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "hostnic_qingcloud_api_duration_seconds",
+			Help:    "latency of qingcloud api calls with hostnic cni",
+			Buckets: prometheus.ExponentialBuckets(0.05, 2, 14),
+		},
+		[]string{"method", "result"},
+	)
+
+	apiAttempts = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hostnic_qingcloud_api_attempts_total",
+			Help: "qingcloud api calls including retries by error class",
+		},
+		[]string{"method", "class"},
+	)
+
+	apiRetries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hostnic_qingcloud_api_retries_total",
+			Help: "retried qingcloud api calls by the class of the error retried",
+		},
+		[]string{"method", "class"},
+	)
+
+	apiLimiterWait = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "hostnic_qingcloud_api_rate_limiter_wait_seconds",
+			Help:    "time qingcloud api calls waited for the client rate limiter",
+			Buckets: prometheus.ExponentialBuckets(0.001, 4, 10),
+		},
+		[]string{"method"},
+	)
+
+	credentialReloads = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hostnic_qingcloud_credential_reloads_total",
+			Help: "reloads of changed qingcloud credentials by result",
+		},
+		[]string{"result"},
+	)
+
+	credentialsLoaded = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hostnic_qingcloud_credentials_loaded_timestamp_seconds",
+			Help: "time the qingcloud credentials in use were loaded",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(apiDuration, apiAttempts, apiRetries, apiLimiterWait, credentialReloads, credentialsLoaded)
+}
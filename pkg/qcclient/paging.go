@@ -0,0 +1,30 @@
+package qcclient
+
+import (
+	"fmt"
+)
+
+const (
+	// the api returns at most 100 items per page whatever the limit is
+	describePageLimit = 100
+	// stop paging if the total count keeps growing, e.g. while resources are created
+	describeMaxPages = 100
+)
+
+// describeAll calls describe with the offset and limit of each page until the total count
+// of items is fetched. describe returns the number of items in the page and the total count,
+// which is nil if the api does not report it, then only one page is fetched.
+func describeAll(method string, describe func(offset, limit int) (int, *int, error)) error {
+	offset := 0
+	for page := 0; page < describeMaxPages; page++ {
+		n, total, err := describe(offset, describePageLimit)
+		if err != nil {
+			return err
+		}
+		offset += n
+		if total == nil || n == 0 || offset >= *total {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s returned more than %d pages", method, describeMaxPages)
+}
@@ -0,0 +1,224 @@
+package qcclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/yunify/qingcloud-sdk-go/config"
+	"github.com/yunify/qingcloud-sdk-go/service"
+
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+)
+
+// pagedAPI serves the describe actions of the api from fixed item lists, at most
+// maxLimit items per page like the api does
+type pagedAPI struct {
+	lock     sync.Mutex
+	sets     map[string]string
+	items    map[string][]map[string]interface{}
+	maxLimit int
+	offsets  map[string][]int
+}
+
+func newPagedAPI() *pagedAPI {
+	return &pagedAPI{
+		sets: map[string]string{
+			"DescribeNics":               "nic_set",
+			"DescribeVxnets":             "vxnet_set",
+			"DescribeVips":               "vip_set",
+			"DescribeSecurityGroupRules": "security_group_rule_set",
+			"DescribeClusterNodes":       "node_set",
+		},
+		items:    make(map[string][]map[string]interface{}),
+		maxLimit: 100,
+		offsets:  make(map[string][]int),
+	}
+}
+
+func (p *pagedAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	action := r.URL.Query().Get("action")
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > p.maxLimit {
+		limit = p.maxLimit
+	}
+	p.offsets[action] = append(p.offsets[action], offset)
+
+	items := p.items[action]
+	page := []map[string]interface{}{}
+	if offset < len(items) {
+		end := offset + limit
+		if end > len(items) {
+			end = len(items)
+		}
+		page = items[offset:end]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"action":       action + "Response",
+		"ret_code":     0,
+		"total_count":  len(items),
+		p.sets[action]: page,
+	})
+}
+
+func newPagedClient(t *testing.T, api *pagedAPI) *qingcloudAPIWrapper {
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+
+	conf, err := config.NewWithEndpoint("key", "secret", server.URL+"/iaas/")
+	if err != nil {
+		t.Fatalf("failed to create sdk config: %v", err)
+	}
+	qcService, _ := service.Init(conf)
+	nicService, _ := qcService.Nic("test")
+	vxNetService, _ := qcService.VxNet("test")
+	vipService, _ := qcService.VIP("test")
+	sgService, _ := qcService.SecurityGroup("test")
+	clusterService, _ := qcService.Cluster("test")
+	return &qingcloudAPIWrapper{
+		nicService:     nicService,
+		vxNetService:   vxNetService,
+		vipService:     vipService,
+		sgService:      sgService,
+		clusterService: clusterService,
+		instanceID:     "i-test",
+	}
+}
+
+func TestDescribeAll(t *testing.T) {
+	api := newPagedAPI()
+	for i := 0; i < 250; i++ {
+		api.items["DescribeNics"] = append(api.items["DescribeNics"], map[string]interface{}{
+			"nic_id":     fmt.Sprintf("52:54:00:00:%02x:%02x", i/256, i%256),
+			"nic_name":   "hostnic_i-test",
+			"vxnet_id":   fmt.Sprintf("vxnet-%d", i%2),
+			"private_ip": fmt.Sprintf("192.168.%d.%d", i%2, i/2+2),
+			"role":       0,
+			"status":     "in-use",
+		})
+	}
+	for i := 0; i < 2; i++ {
+		api.items["DescribeVxnets"] = append(api.items["DescribeVxnets"], map[string]interface{}{
+			"vxnet_id": fmt.Sprintf("vxnet-%d", i),
+			"router": map[string]interface{}{
+				"manager_ip":   fmt.Sprintf("192.168.%d.1", i),
+				"ip_network":   fmt.Sprintf("192.168.%d.0/24", i),
+				"dyn_ip_start": fmt.Sprintf("192.168.%d.2", i),
+				"dyn_ip_end":   fmt.Sprintf("192.168.%d.254", i),
+			},
+		})
+	}
+	for i := 0; i < 230; i++ {
+		api.items["DescribeVips"] = append(api.items["DescribeVips"], map[string]interface{}{
+			"vip_id":   fmt.Sprintf("vip-%d", i),
+			"vip_name": "hostnic_vxnet-0",
+			"vip_addr": fmt.Sprintf("192.168.0.%d", i+2),
+			"vxnet_id": "vxnet-0",
+		})
+	}
+	for i := 0; i < 150; i++ {
+		api.items["DescribeSecurityGroupRules"] = append(api.items["DescribeSecurityGroupRules"], map[string]interface{}{
+			"security_group_rule_id":   fmt.Sprintf("sgr-%d", i),
+			"security_group_rule_name": fmt.Sprintf("rule-%d", i),
+			"security_group_id":        "sg-test",
+			"action":                   "accept",
+			"protocol":                 "all",
+			"val3":                     fmt.Sprintf("10.%d.0.0/16", i),
+			"direction":                0,
+			"priority":                 0,
+		})
+	}
+	for i := 0; i < 120; i++ {
+		api.items["DescribeClusterNodes"] = append(api.items["DescribeClusterNodes"], map[string]interface{}{
+			"instance_id":  fmt.Sprintf("i-%d", i),
+			"node_id":      fmt.Sprintf("cln-%d", i),
+			"host_machine": "host",
+			"private_ip":   fmt.Sprintf("172.16.0.%d", i),
+			"cluster_id":   "cl-test",
+			"status":       "active",
+		})
+	}
+	q := newPagedClient(t, api)
+
+	nics, err := q.GetCreatedNicsByName("hostnic_i-test")
+	if err != nil {
+		t.Fatalf("GetCreatedNicsByName error: %v", err)
+	}
+	if len(nics) != 250 || nics[249].VxNet.Gateway != "192.168.1.1" {
+		t.Fatalf("expect 250 nics with their vxnets, got %d", len(nics))
+	}
+	if offsets := fmt.Sprint(api.offsets["DescribeNics"]); offsets != "[0 100 200]" {
+		t.Fatalf("expect nics described from offsets 0, 100 and 200, got %s", offsets)
+	}
+	if attached, err := q.GetAttachedNics(); err != nil || len(attached) != 250 {
+		t.Fatalf("expect 250 attached nics, got %d %v", len(attached), err)
+	}
+
+	vips, err := q.DescribeVIPs(&rpc.VxNet{ID: "vxnet-0"})
+	if err != nil || len(vips) != 230 || vips[229].ID != "vip-229" {
+		t.Fatalf("expect 230 vips, got %d %v", len(vips), err)
+	}
+
+	// the rule of the vxnet is on the second page
+	rule, err := q.GetSecurityGroupRuleForVxNet("sg-test", &rpc.VxNet{ID: "vxnet-a", Network: "10.120.0.0/16"})
+	if err != nil || rule == nil || rule.ID != "sgr-120" {
+		t.Fatalf("expect rule sgr-120, got %v %v", rule, err)
+	}
+
+	nodes, err := q.DescribeClusterNodes("cl-test")
+	if err != nil || len(nodes) != 120 {
+		t.Fatalf("expect 120 cluster nodes, got %d %v", len(nodes), err)
+	}
+}
+
+func TestDescribeAllPages(t *testing.T) {
+	total := func(n int) *int { return &n }
+
+	var offsets []int
+	err := describeAll("Describe", func(offset, limit int) (int, *int, error) {
+		offsets = append(offsets, offset)
+		return 40, total(100), nil
+	})
+	if err != nil || fmt.Sprint(offsets) != "[0 40 80]" {
+		t.Fatalf("expect pages from offsets 0, 40 and 80, got %v %v", offsets, err)
+	}
+
+	// the api does not report the total count
+	calls := 0
+	describeAll("Describe", func(offset, limit int) (int, *int, error) {
+		calls++
+		return limit, nil, nil
+	})
+	if calls != 1 {
+		t.Fatalf("expect one page without total count, got %d", calls)
+	}
+
+	// an empty page ends paging even if the total count was not reached
+	calls = 0
+	describeAll("Describe", func(offset, limit int) (int, *int, error) {
+		calls++
+		if offset > 0 {
+			return 0, total(1000), nil
+		}
+		return 10, total(1000), nil
+	})
+	if calls != 2 {
+		t.Fatalf("expect paging to stop at an empty page, got %d calls", calls)
+	}
+
+	if err := describeAll("Describe", func(offset, limit int) (int, *int, error) {
+		return 1, total(offset + 2), nil
+	}); err == nil {
+		t.Fatalf("expect an error if the total count keeps growing")
+	}
+}
@@ -0,0 +1,315 @@
+package qcclient
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	qcerrors "github.com/yunify/qingcloud-sdk-go/request/errors"
+	"golang.org/x/time/rate"
+	log "k8s.io/klog/v2"
+
+	"github.com/yunify/hostnic-cni/pkg/constants"
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+)
+
+// ErrorClass tells whether a failed api call may be retried
+type ErrorClass string
+
+const (
+	ClassSuccess ErrorClass = "success"
+	// transient failures, e.g. internal errors of the api or the network
+	ClassRetryable ErrorClass = "retryable"
+	// the request was rejected before it was handled
+	ClassThrottled ErrorClass = "throttled"
+	ClassNotFound  ErrorClass = "not_found"
+	ClassFatal     ErrorClass = "fatal"
+)
+
+// ret codes of the api
+const (
+	retCodeInternalError = 5000
+	retCodeServerBusy    = 5100
+	retCodeServerUpdate  = 5300
+)
+
+const (
+	DefaultAPIQPS        = 5
+	DefaultAPIBurst      = 10
+	DefaultAPIMaxRetries = 5
+	defaultBaseDelay     = 500 * time.Millisecond
+	defaultMaxDelay      = 30 * time.Second
+)
+
+// the sdk only returns the status code of failed http responses in the message
+var statusCodeRe = regexp.MustCompile(`Response StatusCode: (\d+)`)
+
+// Classify returns the class of an error returned by the api
+func Classify(err error) ErrorClass {
+	if err == nil {
+		return ClassSuccess
+	}
+	if strings.Contains(err.Error(), constants.ResourceNotFound) {
+		return ClassNotFound
+	}
+
+	var qcErr *qcerrors.QingCloudError
+	if errors.As(err, &qcErr) {
+		return classifyRetCode(qcErr.RetCode)
+	}
+	var qcErrValue qcerrors.QingCloudError
+	if errors.As(err, &qcErrValue) {
+		return classifyRetCode(qcErrValue.RetCode)
+	}
+
+	if m := statusCodeRe.FindStringSubmatch(err.Error()); m != nil {
+		code, _ := strconv.Atoi(m[1])
+		switch {
+		case code == 429:
+			return ClassThrottled
+		case code >= 500:
+			return ClassRetryable
+		}
+		return ClassFatal
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return ClassRetryable
+	}
+	return ClassFatal
+}
+
+func classifyRetCode(code int) ErrorClass {
+	switch code {
+	case retCodeServerBusy:
+		return ClassThrottled
+	case retCodeInternalError, retCodeServerUpdate:
+		return ClassRetryable
+	}
+	return ClassFatal
+}
+
+// Backoff is an exponential backoff with full jitter
+type Backoff struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+// Delay returns the time to wait before the retry, counted from 0
+func (b Backoff) Delay(retry int) time.Duration {
+	delay := b.BaseDelay << uint(retry)
+	if delay <= 0 || delay > b.MaxDelay {
+		delay = b.MaxDelay
+	}
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
+
+var _ Provider = &retryClient{}
+
+// retryClient rate limits the calls to the api and retries the failed ones.
+// Reads are retried on retryable and throttled errors, the calls changing
+// resources only on throttled errors, as they may have been applied otherwise.
+type retryClient struct {
+	api     Provider
+	limiter *rate.Limiter
+	backoff Backoff
+	sleep   func(time.Duration)
+}
+
+func newRetryClient(api Provider, opts Options) *retryClient {
+	qps, burst := opts.QPS, opts.Burst
+	if qps <= 0 {
+		qps = DefaultAPIQPS
+	}
+	if burst <= 0 {
+		burst = DefaultAPIBurst
+	}
+	retries := opts.MaxRetries
+	if retries == 0 {
+		retries = DefaultAPIMaxRetries
+	} else if retries < 0 {
+		retries = 0
+	}
+
+	return &retryClient{
+		api:     api,
+		limiter: rate.NewLimiter(rate.Limit(qps), burst),
+		backoff: Backoff{MaxRetries: retries, BaseDelay: defaultBaseDelay, MaxDelay: defaultMaxDelay},
+		sleep:   time.Sleep,
+	}
+}
+
+func (r *retryClient) do(method string, mutation bool, fn func() error) error {
+	for retry := 0; ; retry++ {
+		start := time.Now()
+		if err := r.limiter.Wait(context.Background()); err != nil {
+			return err
+		}
+		apiLimiterWait.WithLabelValues(method).Observe(time.Since(start).Seconds())
+
+		err := fn()
+		class := Classify(err)
+		apiAttempts.WithLabelValues(method, string(class)).Inc()
+
+		retryable := class == ClassThrottled || (class == ClassRetryable && !mutation)
+		if !retryable || retry >= r.backoff.MaxRetries {
+			return err
+		}
+
+		delay := r.backoff.Delay(retry)
+		log.Warningf("%s failed with %s error, retry %d/%d in %v: %v", method, class, retry+1, r.backoff.MaxRetries, delay, err)
+		apiRetries.WithLabelValues(method, string(class)).Inc()
+		r.sleep(delay)
+	}
+}
+
+func (r *retryClient) GetInstanceID() string {
+	return r.api.GetInstanceID()
+}
+
+func (r *retryClient) GetCreatedNicsByName(name string) (nics []*rpc.HostNic, err error) {
+	err = r.do("GetCreatedNicsByName", false, func() error {
+		nics, err = r.api.GetCreatedNicsByName(name)
+		return err
+	})
+	return nics, err
+}
+
+func (r *retryClient) GetVxNets(ids []string, customReservedIPCount int64) (vxnets map[string]*rpc.VxNet, err error) {
+	err = r.do("GetVxNets", false, func() error {
+		vxnets, err = r.api.GetVxNets(ids, customReservedIPCount)
+		return err
+	})
+	return vxnets, err
+}
+
+func (r *retryClient) DescribeNicJobs(ids []string) (working []string, nics map[string]bool, err error) {
+	err = r.do("DescribeNicJobs", false, func() error {
+		working, nics, err = r.api.DescribeNicJobs(ids)
+		return err
+	})
+	return working, nics, err
+}
+
+func (r *retryClient) CreateNicsAndAttach(vxnet *rpc.VxNet, num int, ips []string, disableIP int) (nics []*rpc.HostNic, job string, err error) {
+	err = r.do("CreateNicsAndAttach", true, func() error {
+		nics, job, err = r.api.CreateNicsAndAttach(vxnet, num, ips, disableIP)
+		return err
+	})
+	return nics, job, err
+}
+
+func (r *retryClient) GetNics(ids []string) (nics map[string]*rpc.HostNic, err error) {
+	err = r.do("GetNics", false, func() error {
+		nics, err = r.api.GetNics(ids)
+		return err
+	})
+	return nics, err
+}
+
+func (r *retryClient) DeleteNics(nicIDs []string) error {
+	return r.do("DeleteNics", true, func() error {
+		return r.api.DeleteNics(nicIDs)
+	})
+}
+
+func (r *retryClient) DeattachNics(nicIDs []string, sync bool) (job string, err error) {
+	err = r.do("DeattachNics", true, func() error {
+		job, err = r.api.DeattachNics(nicIDs, sync)
+		return err
+	})
+	return job, err
+}
+
+func (r *retryClient) AttachNics(nicIDs []string, sync bool) (job string, err error) {
+	err = r.do("AttachNics", true, func() error {
+		job, err = r.api.AttachNics(nicIDs, sync)
+		return err
+	})
+	return job, err
+}
+
+func (r *retryClient) GetAttachedNics() (nics []*rpc.HostNic, err error) {
+	err = r.do("GetAttachedNics", false, func() error {
+		nics, err = r.api.GetAttachedNics()
+		return err
+	})
+	return nics, err
+}
+
+func (r *retryClient) GetCreatedNicsByVxNet(vxnet string) (nics []*rpc.HostNic, err error) {
+	err = r.do("GetCreatedNicsByVxNet", false, func() error {
+		nics, err = r.api.GetCreatedNicsByVxNet(vxnet)
+		return err
+	})
+	return nics, err
+}
+
+func (r *retryClient) CreateVIPs(vxnet *rpc.VxNet) (job string, err error) {
+	err = r.do("CreateVIPs", true, func() error {
+		job, err = r.api.CreateVIPs(vxnet)
+		return err
+	})
+	return job, err
+}
+
+func (r *retryClient) DescribeVIPs(vxnet *rpc.VxNet) (vips []*rpc.VIP, err error) {
+	err = r.do("DescribeVIPs", false, func() error {
+		vips, err = r.api.DescribeVIPs(vxnet)
+		return err
+	})
+	return vips, err
+}
+
+func (r *retryClient) DeleteVIPs(vips []string) (job string, err error) {
+	err = r.do("DeleteVIPs", true, func() error {
+		job, err = r.api.DeleteVIPs(vips)
+		return err
+	})
+	return job, err
+}
+
+func (r *retryClient) CreateSecurityGroupRuleForVxNet(sg string, vxnet *rpc.VxNet) (job string, err error) {
+	err = r.do("CreateSecurityGroupRuleForVxNet", true, func() error {
+		job, err = r.api.CreateSecurityGroupRuleForVxNet(sg, vxnet)
+		return err
+	})
+	return job, err
+}
+
+func (r *retryClient) GetSecurityGroupRuleForVxNet(sg string, vxnet *rpc.VxNet) (rule *rpc.SecurityGroupRule, err error) {
+	err = r.do("GetSecurityGroupRuleForVxNet", false, func() error {
+		rule, err = r.api.GetSecurityGroupRuleForVxNet(sg, vxnet)
+		return err
+	})
+	return rule, err
+}
+
+func (r *retryClient) DeleteSecurityGroupRuleForVxNet(sgr string) error {
+	return r.do("DeleteSecurityGroupRuleForVxNet", true, func() error {
+		return r.api.DeleteSecurityGroupRuleForVxNet(sgr)
+	})
+}
+
+func (r *retryClient) DescribeClusterSecurityGroup(clusterID string) (sg string, err error) {
+	err = r.do("DescribeClusterSecurityGroup", false, func() error {
+		sg, err = r.api.DescribeClusterSecurityGroup(clusterID)
+		return err
+	})
+	return sg, err
+}
+
+func (r *retryClient) DescribeClusterNodes(clusterID string) (nodes []*rpc.Node, err error) {
+	err = r.do("DescribeClusterNodes", false, func() error {
+		nodes, err = r.api.DescribeClusterNodes(clusterID)
+		return err
+	})
+	return nodes, err
+}
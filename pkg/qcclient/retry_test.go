@@ -0,0 +1,148 @@
+package qcclient
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	qcerrors "github.com/yunify/qingcloud-sdk-go/request/errors"
+
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+)
+
+func TestClassify(t *testing.T) {
+	for _, c := range []struct {
+		err   error
+		class ErrorClass
+	}{
+		{nil, ClassSuccess},
+		{&qcerrors.QingCloudError{RetCode: 2100, Message: "ResourceNotFound, resource [nic] not found"}, ClassNotFound},
+		{fmt.Errorf("DeleteNics error: %w", qcerrors.QingCloudError{RetCode: 5100, Message: "ServerBusy"}), ClassThrottled},
+		{&qcerrors.QingCloudError{RetCode: 5000, Message: "InternalError"}, ClassRetryable},
+		{&qcerrors.QingCloudError{RetCode: 1400, Message: "PermissionDenied"}, ClassFatal},
+		{errors.New("Response StatusCode: 503"), ClassRetryable},
+		{errors.New("Response StatusCode: 429"), ClassThrottled},
+		{errors.New("Response StatusCode: 400"), ClassFatal},
+		{&net.OpError{Op: "dial", Err: errors.New("connection refused")}, ClassRetryable},
+		{errors.New("vxnet vxnet-a should open DHCP"), ClassFatal},
+	} {
+		if class := Classify(c.err); class != c.class {
+			t.Errorf("Classify(%v) = %s, expect %s", c.err, class, c.class)
+		}
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	b := Backoff{BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+	for retry, max := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second} {
+		for i := 0; i < 100; i++ {
+			if delay := b.Delay(retry); delay < 0 || delay > max {
+				t.Fatalf("delay of retry %d out of [0, %v]: %v", retry, max, delay)
+			}
+		}
+	}
+	if delay := b.Delay(100); delay < 0 || delay > b.MaxDelay {
+		t.Fatalf("delay of retry 100 out of [0, %v]: %v", b.MaxDelay, delay)
+	}
+}
+
+// flakyAPI fails the calls with errs before succeeding
+type flakyAPI struct {
+	Provider
+	errs  []error
+	calls int
+}
+
+func (f *flakyAPI) next() error {
+	f.calls++
+	if len(f.errs) == 0 {
+		return nil
+	}
+	err := f.errs[0]
+	f.errs = f.errs[1:]
+	return err
+}
+
+func (f *flakyAPI) GetVxNets(ids []string, customReservedIPCount int64) (map[string]*rpc.VxNet, error) {
+	if err := f.next(); err != nil {
+		return nil, err
+	}
+	return map[string]*rpc.VxNet{ids[0]: {ID: ids[0]}}, nil
+}
+
+func (f *flakyAPI) DeleteNics(nicIDs []string) error {
+	return f.next()
+}
+
+func newTestRetryClient(api Provider, opts Options) (*retryClient, *[]time.Duration) {
+	r := newRetryClient(api, opts)
+	var delays []time.Duration
+	r.sleep = func(d time.Duration) { delays = append(delays, d) }
+	return r, &delays
+}
+
+func TestRetryClient(t *testing.T) {
+	busy := &qcerrors.QingCloudError{RetCode: retCodeServerBusy, Message: "ServerBusy"}
+	internal := &qcerrors.QingCloudError{RetCode: retCodeInternalError, Message: "InternalError"}
+
+	api := &flakyAPI{errs: []error{busy, internal, internal}}
+	r, delays := newTestRetryClient(api, Options{})
+	vxnets, err := r.GetVxNets([]string{"vxnet-a"}, 0)
+	if err != nil || vxnets["vxnet-a"] == nil {
+		t.Fatalf("expect vxnet-a after retries, got %v %v", vxnets, err)
+	}
+	if api.calls != 4 || len(*delays) != 3 {
+		t.Fatalf("expect 4 calls with 3 delays, got %d %v", api.calls, *delays)
+	}
+
+	// reads give up after MaxRetries
+	api = &flakyAPI{errs: []error{internal, internal, internal}}
+	r, _ = newTestRetryClient(api, Options{MaxRetries: 2})
+	if _, err := r.GetVxNets([]string{"vxnet-a"}, 0); err != internal {
+		t.Fatalf("expect %v after 2 retries, got %v", internal, err)
+	}
+	if api.calls != 3 {
+		t.Fatalf("expect 3 calls, got %d", api.calls)
+	}
+
+	// mutations are retried only if throttled
+	api = &flakyAPI{errs: []error{busy, internal}}
+	r, _ = newTestRetryClient(api, Options{})
+	if err := r.DeleteNics([]string{"nic"}); err != internal {
+		t.Fatalf("expect %v of DeleteNics, got %v", internal, err)
+	}
+	if api.calls != 2 {
+		t.Fatalf("expect DeleteNics called twice, got %d", api.calls)
+	}
+
+	// not found and fatal errors are returned at once
+	notFound := &qcerrors.QingCloudError{RetCode: 2100, Message: "ResourceNotFound"}
+	api = &flakyAPI{errs: []error{notFound}}
+	r, _ = newTestRetryClient(api, Options{})
+	if _, err := r.GetVxNets([]string{"vxnet-a"}, 0); err != notFound || api.calls != 1 {
+		t.Fatalf("expect %v without retries, got %v after %d calls", notFound, err, api.calls)
+	}
+
+	api = &flakyAPI{errs: []error{busy}}
+	r, _ = newTestRetryClient(api, Options{MaxRetries: -1})
+	if _, err := r.GetVxNets([]string{"vxnet-a"}, 0); err != busy || api.calls != 1 {
+		t.Fatalf("expect %v without retries, got %v after %d calls", busy, err, api.calls)
+	}
+}
+
+func TestRetryClientRateLimit(t *testing.T) {
+	api := &flakyAPI{}
+	r, _ := newTestRetryClient(api, Options{QPS: 20, Burst: 1})
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if _, err := r.GetVxNets([]string{"vxnet-a"}, 0); err != nil {
+			t.Fatalf("GetVxNets error: %v", err)
+		}
+	}
+	// the first call takes the burst, the others wait 50ms each
+	if elapsed := time.Since(start); elapsed < 180*time.Millisecond {
+		t.Fatalf("expect 5 calls limited to 20 qps, took %v", elapsed)
+	}
+}
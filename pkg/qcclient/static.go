@@ -0,0 +1,452 @@
+package qcclient
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	log "k8s.io/klog/v2"
+
+	"github.com/yunify/hostnic-cni/pkg/constants"
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+)
+
+// StaticConfig describes the nics attached to a node in advance and the vxnets they are in,
+// for the nodes without a cloud api. The vxnets are shared by the cluster, they are read from
+// a file of their own which every node and the controller see, e.g. mounted from a ConfigMap.
+type StaticConfig struct {
+	// defaults to the hostname
+	InstanceID string        `json:"instanceID,omitempty"`
+	VxNets     []StaticVxNet `json:"vxnets"`
+	Nics       []StaticNic   `json:"nics,omitempty"`
+}
+
+// StaticVxNet is a vlan, pod ips are allocated from IPStart to IPEnd which should not be
+// used by others in the network
+type StaticVxNet struct {
+	ID      string `json:"id"`
+	VLAN    int    `json:"vlan,omitempty"`
+	Network string `json:"network"`
+	Gateway string `json:"gateway"`
+	IPStart string `json:"ipStart"`
+	IPEnd   string `json:"ipEnd"`
+	// the bridges of vlan vxnets get their addresses by dhcp
+	TunnelType string `json:"tunnelType,omitempty"`
+}
+
+// StaticNic is found by its mac, its vxnet is given by id or by vlan
+type StaticNic struct {
+	MAC            string `json:"mac"`
+	VxNet          string `json:"vxnet,omitempty"`
+	VLAN           int    `json:"vlan,omitempty"`
+	PrimaryAddress string `json:"primaryAddress,omitempty"`
+}
+
+var _ Provider = &staticProvider{}
+
+// static is set up by SetupProvider with the static provider
+var static *staticProvider
+
+// staticProvider serves the nics and vxnets of a StaticConfig. Nics can neither be created
+// nor deleted, creating a nic takes a free nic of the vxnet and deleting it gives it back.
+// The vips are the ip range of the vxnet, security groups are not supported.
+type staticProvider struct {
+	instanceID string
+	vxnets     map[string]*rpc.VxNet
+	nics       []*rpc.HostNic
+
+	lock sync.Mutex
+	// nics taken by hostnic
+	using map[string]bool
+	// vxnets whose vips were deleted
+	vipsDeleted map[string]bool
+}
+
+// LoadStaticConfig reads the nics of the node in path and the vxnets of the cluster in vxnetsPath.
+// The controller has no nics and passes no path. A node falls back to the vxnets in path if
+// vxnetsPath does not exist.
+func LoadStaticConfig(path, vxnetsPath string) (*StaticConfig, error) {
+	var conf StaticConfig
+	if path != "" {
+		if err := readStaticConfig(path, &conf); err != nil {
+			return nil, err
+		}
+		if conf.InstanceID == "" {
+			hostname, err := os.Hostname()
+			if err != nil {
+				return nil, fmt.Errorf("get hostname error: %v", err)
+			}
+			conf.InstanceID = hostname
+		}
+	}
+
+	var cluster StaticConfig
+	err := readStaticConfig(vxnetsPath, &cluster)
+	switch {
+	case err == nil:
+		if len(conf.VxNets) > 0 {
+			return nil, fmt.Errorf("vxnets are set for the cluster in %s, remove them from %s", vxnetsPath, path)
+		}
+		conf.VxNets = cluster.VxNets
+	case path == "" || !errors.Is(err, os.ErrNotExist):
+		return nil, err
+	}
+	return &conf, nil
+}
+
+func readStaticConfig(path string, conf *StaticConfig) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read static provider config %s error: %w", path, err)
+	}
+	if err := yaml.Unmarshal(content, conf); err != nil {
+		return fmt.Errorf("parse static provider config %s error: %v", path, err)
+	}
+	return nil
+}
+
+func newStaticProvider(conf *StaticConfig) (*staticProvider, error) {
+	s := &staticProvider{
+		instanceID:  conf.InstanceID,
+		vxnets:      make(map[string]*rpc.VxNet),
+		using:       make(map[string]bool),
+		vipsDeleted: make(map[string]bool),
+	}
+
+	vlans := make(map[int]string)
+	for _, v := range conf.VxNets {
+		if err := validateStaticVxNet(v); err != nil {
+			return nil, err
+		}
+		if s.vxnets[v.ID] != nil {
+			return nil, fmt.Errorf("duplicated vxnet %s", v.ID)
+		}
+		if v.VLAN != 0 {
+			if vxnet, ok := vlans[v.VLAN]; ok {
+				return nil, fmt.Errorf("vxnet %s and %s have the same vlan %d", vxnet, v.ID, v.VLAN)
+			}
+			vlans[v.VLAN] = v.ID
+		}
+		s.vxnets[v.ID] = &rpc.VxNet{
+			ID:         v.ID,
+			Gateway:    v.Gateway,
+			Network:    v.Network,
+			IPStart:    v.IPStart,
+			IPEnd:      v.IPEnd,
+			TunnelType: v.TunnelType,
+		}
+	}
+
+	macs := make(map[string]bool)
+	for _, n := range conf.Nics {
+		mac, err := net.ParseMAC(n.MAC)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mac of nic %s: %v", n.MAC, err)
+		}
+		if macs[mac.String()] {
+			return nil, fmt.Errorf("duplicated nic %s", mac)
+		}
+		macs[mac.String()] = true
+
+		vxnet := n.VxNet
+		if vxnet == "" {
+			if vxnet = vlans[n.VLAN]; vxnet == "" {
+				return nil, fmt.Errorf("no vxnet of vlan %d for nic %s", n.VLAN, mac)
+			}
+		}
+		if s.vxnets[vxnet] == nil {
+			return nil, fmt.Errorf("unknown vxnet %s of nic %s", vxnet, mac)
+		}
+		s.nics = append(s.nics, &rpc.HostNic{
+			ID:             mac.String(),
+			HardwareAddr:   mac.String(),
+			VxNet:          s.vxnets[vxnet],
+			PrimaryAddress: n.PrimaryAddress,
+		})
+	}
+	sort.Slice(s.nics, func(i, j int) bool { return s.nics[i].ID < s.nics[j].ID })
+
+	return s, nil
+}
+
+func validateStaticVxNet(v StaticVxNet) error {
+	if v.ID == "" {
+		return fmt.Errorf("vxnet without id")
+	}
+	_, network, err := net.ParseCIDR(v.Network)
+	if err != nil {
+		return fmt.Errorf("invalid network of vxnet %s: %v", v.ID, err)
+	}
+	for _, ip := range []string{v.Gateway, v.IPStart, v.IPEnd} {
+		if addr := net.ParseIP(ip); addr == nil || !network.Contains(addr) {
+			return fmt.Errorf("address %q of vxnet %s is not in %s", ip, v.ID, v.Network)
+		}
+	}
+	if IPRangeCount(v.IPStart, v.IPEnd) <= 0 {
+		return fmt.Errorf("invalid ip range %s-%s of vxnet %s", v.IPStart, v.IPEnd, v.ID)
+	}
+	return nil
+}
+
+// hostNic returns a copy of nic, the caller holds the lock
+func (s *staticProvider) hostNic(nic *rpc.HostNic) *rpc.HostNic {
+	result := proto.Clone(nic).(*rpc.HostNic)
+	result.Using = s.using[nic.ID]
+	return result
+}
+
+func (s *staticProvider) findNic(id string) *rpc.HostNic {
+	for _, nic := range s.nics {
+		if nic.ID == id {
+			return nic
+		}
+	}
+	return nil
+}
+
+// RestoreNicsInUse marks the nics of ids taken, they are the nics recorded by the allocator. The
+// static provider keeps no state, without it the nics in use would be handed out again after
+// a restart. It does nothing for other providers, which know the nics they created.
+func RestoreNicsInUse(ids []string) {
+	if static == nil {
+		return
+	}
+	static.lock.Lock()
+	defer static.lock.Unlock()
+
+	for _, id := range ids {
+		if static.findNic(id) == nil {
+			log.Warningf("recorded nic %s is not in static provider config", id)
+			continue
+		}
+		static.using[id] = true
+	}
+}
+
+// setUsing marks the nics of ids taken or given back, all of them should be in the config
+func (s *staticProvider) setUsing(ids []string, using bool) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, id := range ids {
+		if s.findNic(id) == nil {
+			return fmt.Errorf("%s, nic %s is not in static provider config", constants.ResourceNotFound, id)
+		}
+	}
+	for _, id := range ids {
+		if using {
+			s.using[id] = true
+		} else {
+			delete(s.using, id)
+		}
+	}
+	return nil
+}
+
+func unsupportedByStatic(method string) error {
+	return fmt.Errorf("%s is not supported by the static provider", method)
+}
+
+func (s *staticProvider) GetInstanceID() string {
+	return s.instanceID
+}
+
+func (s *staticProvider) GetCreatedNicsByName(name string) ([]*rpc.HostNic, error) {
+	if name != constants.NicPrefix+s.instanceID {
+		return nil, nil
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var result []*rpc.HostNic
+	for _, nic := range s.nics {
+		if s.using[nic.ID] {
+			result = append(result, s.hostNic(nic))
+		}
+	}
+	return result, nil
+}
+
+func (s *staticProvider) GetCreatedNicsByVxNet(vxnet string) ([]*rpc.HostNic, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var result []*rpc.HostNic
+	for _, nic := range s.nics {
+		if s.using[nic.ID] && nic.VxNet.ID == vxnet {
+			result = append(result, s.hostNic(nic))
+		}
+	}
+	return result, nil
+}
+
+// GetVxNets returns the vxnets as configured, no ips are reserved for the nics since they
+// have their own addresses
+func (s *staticProvider) GetVxNets(ids []string, customReservedIPCount int64) (map[string]*rpc.VxNet, error) {
+	if len(ids) <= 0 {
+		return nil, fmt.Errorf("GetVxNets should not have empty input")
+	}
+
+	result := make(map[string]*rpc.VxNet)
+	for _, id := range ids {
+		if vxnet, ok := s.vxnets[id]; ok {
+			result[id] = proto.Clone(vxnet).(*rpc.VxNet)
+		}
+	}
+	return result, nil
+}
+
+// DescribeNicJobs reports no working jobs, nics are attached in advance
+func (s *staticProvider) DescribeNicJobs(ids []string) ([]string, map[string]bool, error) {
+	return nil, make(map[string]bool), nil
+}
+
+func (s *staticProvider) CreateNicsAndAttach(vxnet *rpc.VxNet, num int, ips []string, disableIP int) ([]*rpc.HostNic, string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var result []*rpc.HostNic
+	for _, nic := range s.nics {
+		if len(result) == num {
+			break
+		}
+		if nic.VxNet.ID == vxnet.ID && !s.using[nic.ID] {
+			result = append(result, nic)
+		}
+	}
+	if len(result) < num {
+		return nil, "", fmt.Errorf("only %d of %d nics of vxnet %s are free in static provider config", len(result), num, vxnet.ID)
+	}
+	for i, nic := range result {
+		s.using[nic.ID] = true
+		result[i] = s.hostNic(nic)
+	}
+	return result, "", nil
+}
+
+func (s *staticProvider) GetNics(nics []string) (map[string]*rpc.HostNic, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	result := make(map[string]*rpc.HostNic)
+	for _, id := range nics {
+		if nic := s.findNic(id); nic != nil {
+			result[id] = s.hostNic(nic)
+		}
+	}
+	return result, nil
+}
+
+func (s *staticProvider) DeleteNics(nicIDs []string) error {
+	return s.setUsing(nicIDs, false)
+}
+
+func (s *staticProvider) DeattachNics(nicIDs []string, sync bool) (string, error) {
+	return "", s.setUsing(nicIDs, false)
+}
+
+func (s *staticProvider) AttachNics(nicIDs []string, sync bool) (string, error) {
+	return "", s.setUsing(nicIDs, true)
+}
+
+// GetAttachedNics returns all the nics in the config, taken or not
+func (s *staticProvider) GetAttachedNics() ([]*rpc.HostNic, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var result []*rpc.HostNic
+	for _, nic := range s.nics {
+		result = append(result, s.hostNic(nic))
+	}
+	return result, nil
+}
+
+func (s *staticProvider) CreateVIPs(vxnet *rpc.VxNet) (string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.vipsDeleted, vxnet.ID)
+	return "", nil
+}
+
+// DescribeVIPs returns a vip for each address in the ip range of vxnet, whose id is
+// <vxnet>/<address>
+func (s *staticProvider) DescribeVIPs(vxnet *rpc.VxNet) ([]*rpc.VIP, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	v, ok := s.vxnets[vxnet.ID]
+	if !ok {
+		return nil, fmt.Errorf("%s, vxnet %s is not in static provider config", constants.ResourceNotFound, vxnet.ID)
+	}
+	if s.vipsDeleted[vxnet.ID] {
+		return nil, nil
+	}
+
+	var vips []*rpc.VIP
+	end := net.ParseIP(v.IPEnd)
+	for ip := net.ParseIP(v.IPStart); ; ip = nextIP(ip) {
+		vips = append(vips, &rpc.VIP{
+			ID:      v.ID + "/" + ip.String(),
+			Name:    constants.NicPrefix + v.ID,
+			Addr:    ip.String(),
+			VxNetID: v.ID,
+		})
+		if ip.Equal(end) {
+			break
+		}
+	}
+	return vips, nil
+}
+
+func (s *staticProvider) DeleteVIPs(vips []string) (string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, vip := range vips {
+		if i := strings.LastIndex(vip, "/"); i > 0 {
+			s.vipsDeleted[vip[:i]] = true
+		}
+	}
+	return "", nil
+}
+
+func (s *staticProvider) CreateSecurityGroupRuleForVxNet(sg string, vxnet *rpc.VxNet) (string, error) {
+	return "", unsupportedByStatic("CreateSecurityGroupRuleForVxNet")
+}
+
+func (s *staticProvider) GetSecurityGroupRuleForVxNet(sg string, vxnet *rpc.VxNet) (*rpc.SecurityGroupRule, error) {
+	return nil, unsupportedByStatic("GetSecurityGroupRuleForVxNet")
+}
+
+func (s *staticProvider) DeleteSecurityGroupRuleForVxNet(sgr string) error {
+	return unsupportedByStatic("DeleteSecurityGroupRuleForVxNet")
+}
+
+// DescribeClusterSecurityGroup reports no security group, so that none is set up for the vxnets
+func (s *staticProvider) DescribeClusterSecurityGroup(clusterID string) (string, error) {
+	return "", nil
+}
+
+func (s *staticProvider) DescribeClusterNodes(clusterID string) ([]*rpc.Node, error) {
+	return nil, unsupportedByStatic("DescribeClusterNodes")
+}
+
+func nextIP(ip net.IP) net.IP {
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			break
+		}
+	}
+	return next
+}
@@ -0,0 +1,224 @@
+package qcclient
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yunify/hostnic-cni/pkg/constants"
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+)
+
+const testStaticConfig = `
+instanceID: node-1
+vxnets:
+- id: vlan-100
+  vlan: 100
+  network: 10.10.0.0/24
+  gateway: 10.10.0.1
+  ipStart: 10.10.0.100
+  ipEnd: 10.10.0.109
+  tunnelType: vlan
+- id: vlan-200
+  vlan: 200
+  network: 10.20.0.0/24
+  gateway: 10.20.0.1
+  ipStart: 10.20.0.2
+  ipEnd: 10.20.0.254
+nics:
+- mac: 52:54:00:00:01:00
+  vlan: 100
+  primaryAddress: 10.10.0.11
+- mac: 52:54:00:00:02:00
+  vlan: 200
+- mac: 52:54:00:00:02:01
+  vxnet: vlan-200
+`
+
+func newTestStaticProvider(t *testing.T, content string) (*staticProvider, error) {
+	path := filepath.Join(t.TempDir(), "static-provider.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write static provider config: %v", err)
+	}
+	conf, err := LoadStaticConfig(path, filepath.Join(t.TempDir(), "vxnets.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	return newStaticProvider(conf)
+}
+
+func TestStaticProviderNics(t *testing.T) {
+	s, err := newTestStaticProvider(t, testStaticConfig)
+	if err != nil {
+		t.Fatalf("newStaticProvider error: %v", err)
+	}
+
+	vxnets, err := s.GetVxNets([]string{"vlan-100", "vxnet-unknown"}, 0)
+	if err != nil || len(vxnets) != 1 {
+		t.Fatalf("expect only vxnet vlan-100, got %v %v", vxnets, err)
+	}
+	vxnet := vxnets["vlan-100"]
+	if vxnet.Gateway != "10.10.0.1" || vxnet.IPEnd != "10.10.0.109" || vxnet.TunnelType != constants.TunnelTypeVlan {
+		t.Fatalf("unexpected vxnet %v", vxnet)
+	}
+
+	nics, _, err := s.CreateNicsAndAttach(vxnet, 1, nil, 1)
+	if err != nil || len(nics) != 1 {
+		t.Fatalf("CreateNicsAndAttach error: %v", err)
+	}
+	if nic := nics[0]; nic.ID != "52:54:00:00:01:00" || nic.VxNet.ID != "vlan-100" || nic.PrimaryAddress != "10.10.0.11" || !nic.Using {
+		t.Fatalf("unexpected nic %v", nic)
+	}
+	if _, _, err := s.CreateNicsAndAttach(vxnet, 1, nil, 1); err == nil {
+		t.Fatalf("expect no free nic of vlan-100")
+	}
+
+	created, _ := s.GetCreatedNicsByName(constants.NicPrefix + s.GetInstanceID())
+	attached, _ := s.GetAttachedNics()
+	if len(created) != 1 || len(attached) != 3 {
+		t.Fatalf("expect 1 created of 3 attached nics, got %d %d", len(created), len(attached))
+	}
+
+	if _, err := s.DeattachNics([]string{"52:54:00:00:01:00"}, true); err != nil {
+		t.Fatalf("DeattachNics error: %v", err)
+	}
+	if err := s.DeleteNics([]string{"52:54:00:00:01:00"}); err != nil {
+		t.Fatalf("DeleteNics error: %v", err)
+	}
+	if created, _ := s.GetCreatedNicsByVxNet("vlan-100"); len(created) != 0 {
+		t.Fatalf("expect the nic given back, got %v", created)
+	}
+	if err := s.DeleteNics([]string{"52:54:00:00:ff:ff"}); err == nil || Classify(err) != ClassNotFound {
+		t.Fatalf("expect not found error of an unknown nic, got %v", err)
+	}
+
+	// the nics of vlan-200 are taken together
+	v200 := &rpc.VxNet{ID: "vlan-200"}
+	if nics, _, err := s.CreateNicsAndAttach(v200, 2, nil, 1); err != nil || len(nics) != 2 {
+		t.Fatalf("expect both nics of vlan-200, got %v %v", nics, err)
+	}
+}
+
+func TestStaticProviderVIPs(t *testing.T) {
+	s, err := newTestStaticProvider(t, testStaticConfig)
+	if err != nil {
+		t.Fatalf("newStaticProvider error: %v", err)
+	}
+
+	vxnet := &rpc.VxNet{ID: "vlan-100"}
+	vips, err := s.DescribeVIPs(vxnet)
+	if err != nil || len(vips) != 10 {
+		t.Fatalf("expect 10 vips, got %d %v", len(vips), err)
+	}
+	if vips[9].Addr != "10.10.0.109" || vips[9].ID != "vlan-100/10.10.0.109" {
+		t.Fatalf("unexpected vip %v", vips[9])
+	}
+
+	// the controller waits until the vips are gone when a vxnet is removed
+	if _, err := s.DeleteVIPs([]string{vips[0].ID}); err != nil {
+		t.Fatalf("DeleteVIPs error: %v", err)
+	}
+	if vips, _ := s.DescribeVIPs(vxnet); len(vips) != 0 {
+		t.Fatalf("expect no vips after DeleteVIPs, got %d", len(vips))
+	}
+	s.CreateVIPs(vxnet)
+	if vips, _ := s.DescribeVIPs(vxnet); len(vips) != 10 {
+		t.Fatalf("expect 10 vips after CreateVIPs, got %d", len(vips))
+	}
+
+	if sg, err := s.DescribeClusterSecurityGroup("cl-test"); err != nil || sg != "" {
+		t.Fatalf("expect no security group, got %s %v", sg, err)
+	}
+}
+
+func TestStaticConfigInvalid(t *testing.T) {
+	vxnet := `
+vxnets:
+- id: vlan-100
+  vlan: 100
+  network: 10.10.0.0/24
+  gateway: 10.10.0.1
+  ipStart: 10.10.0.100
+  ipEnd: 10.10.0.109
+`
+	for _, c := range []struct {
+		content string
+		err     string
+	}{
+		{vxnet + "- id: vlan-100\n  network: 10.20.0.0/24\n  gateway: 10.20.0.1\n  ipStart: 10.20.0.2\n  ipEnd: 10.20.0.9\n", "duplicated vxnet"},
+		{vxnet + "- id: vlan-101\n  vlan: 100\n  network: 10.20.0.0/24\n  gateway: 10.20.0.1\n  ipStart: 10.20.0.2\n  ipEnd: 10.20.0.9\n", "same vlan"},
+		{vxnet + "- id: vlan-200\n  network: 10.20.0.0/24\n  gateway: 10.20.0.1\n  ipStart: 10.20.0.2\n  ipEnd: 10.30.0.9\n", "not in"},
+		{vxnet + "- id: vlan-200\n  network: 10.20.0.0/24\n  gateway: 10.20.0.1\n  ipStart: 10.20.0.9\n  ipEnd: 10.20.0.2\n", "invalid ip range"},
+		{vxnet + "nics:\n- mac: 52:54:00:00:01\n  vlan: 100\n", "invalid mac"},
+		{vxnet + "nics:\n- mac: 52:54:00:00:01:00\n  vlan: 200\n", "no vxnet of vlan 200"},
+		{vxnet + "nics:\n- mac: 52:54:00:00:01:00\n  vxnet: vlan-200\n", "unknown vxnet"},
+		{vxnet + "nics:\n- mac: 52:54:00:00:01:00\n  vlan: 100\n- mac: 52:54:00:00:01:00\n  vlan: 100\n", "duplicated nic"},
+	} {
+		if _, err := newTestStaticProvider(t, c.content); err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("expect error %q, got %v", c.err, err)
+		}
+	}
+}
+
+func TestStaticProviderRestart(t *testing.T) {
+	s, err := newTestStaticProvider(t, testStaticConfig)
+	if err != nil {
+		t.Fatalf("newStaticProvider error: %v", err)
+	}
+	old := static
+	static = s
+	defer func() { static = old }()
+
+	// the nic of vlan-100 was taken before the restart
+	RestoreNicsInUse([]string{"52:54:00:00:01:00", "52:54:00:00:09:00"})
+	created, _ := s.GetCreatedNicsByName(constants.NicPrefix + s.GetInstanceID())
+	if len(created) != 1 || created[0].ID != "52:54:00:00:01:00" {
+		t.Fatalf("expect the restored nic created, got %v", created)
+	}
+	if _, _, err := s.CreateNicsAndAttach(&rpc.VxNet{ID: "vlan-100"}, 1, nil, 1); err == nil {
+		t.Fatalf("expect the restored nic not handed out again")
+	}
+}
+
+func TestStaticClusterVxNets(t *testing.T) {
+	dir := t.TempDir()
+	nics := filepath.Join(dir, "static-provider.yaml")
+	vxnets := filepath.Join(dir, "vxnets.yaml")
+	write := func(path, content string) {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+	cluster := testStaticConfig[:strings.Index(testStaticConfig, "nics:")]
+	write(vxnets, cluster[strings.Index(cluster, "vxnets:"):])
+
+	// the controller reads the vxnets of the cluster only
+	conf, err := LoadStaticConfig("", vxnets)
+	if err != nil || len(conf.VxNets) != 2 || len(conf.Nics) != 0 {
+		t.Fatalf("expect 2 vxnets without nics, got %v %v", conf, err)
+	}
+	if _, err := LoadStaticConfig("", filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Fatalf("expect error without the vxnets of the cluster")
+	}
+
+	// nodes list their nics, the vxnets come from the cluster
+	write(nics, "instanceID: node-1\nnics:\n- mac: 52:54:00:00:01:00\n  vlan: 100\n")
+	conf, err = LoadStaticConfig(nics, vxnets)
+	if err != nil || len(conf.VxNets) != 2 || len(conf.Nics) != 1 {
+		t.Fatalf("expect 2 vxnets and 1 nic, got %v %v", conf, err)
+	}
+	if _, err := newStaticProvider(conf); err != nil {
+		t.Fatalf("newStaticProvider error: %v", err)
+	}
+
+	// vxnets of a node would differ from the cluster
+	write(nics, testStaticConfig)
+	if _, err := LoadStaticConfig(nics, vxnets); err == nil {
+		t.Fatalf("expect error with vxnets in both files")
+	}
+	// unless the cluster has none
+	if conf, err := LoadStaticConfig(nics, filepath.Join(dir, "missing.yaml")); err != nil || len(conf.VxNets) != 2 {
+		t.Fatalf("expect the vxnets of the node, got %v %v", conf, err)
+	}
+}
@@ -35,9 +35,26 @@ const (
 
 type Options struct {
 	Tag string
+
+	// ProviderQingCloud if unset. The static provider reads the nics of the node in StaticConfig
+	// and the vxnets of the cluster in StaticVxNets, DefaultStaticConfig and DefaultStaticVxNets
+	// if unset. The controller sets NoStaticNics, it reads the vxnets only.
+	Provider     string
+	StaticConfig string
+	StaticVxNets string
+	NoStaticNics bool
+
+	// rate limit and retries of the api calls, the defaults are used if unset, no
+	// retries are made if MaxRetries is negative
+	QPS        float64
+	Burst      int
+	MaxRetries int
+
+	// watch the config file and switch to its credentials once they are validated
+	ReloadCredentials bool
 }
 
-var _ QingCloudAPI = &qingcloudAPIWrapper{}
+var _ Provider = &qingcloudAPIWrapper{}
 
 type qingcloudAPIWrapper struct {
 	nicService      *service.NicService
@@ -55,7 +72,16 @@ type qingcloudAPIWrapper struct {
 }
 
 // NewQingCloudClient create a qingcloud client to manipulate cloud resources
-func SetupQingCloudClient(opts Options) {
+func SetupProvider(opts Options) {
+	switch opts.Provider {
+	case "", ProviderQingCloud:
+	case ProviderStatic:
+		setupStaticClient(opts)
+		return
+	default:
+		log.Fatalf("unknown provider %s", opts.Provider)
+	}
+
 	instanceID, err := os.ReadFile(instanceIDFile)
 	if err != nil {
 		log.Fatalf("failed to load instance-id: %v", err)
@@ -71,49 +97,96 @@ func SetupQingCloudClient(opts Options) {
 
 	log.Infof("qsdkconfig inited: %v", qsdkconfig)
 
+	build := func(qsdkconfig *config.Config) (Provider, error) {
+		return newQingCloudAPIWrapper(qsdkconfig, string(instanceID), opts)
+	}
+	api, err := build(qsdkconfig)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	var client Provider = api
+	if opts.ReloadCredentials {
+		reloadable := newReloadableClient(api, qsdkconfig.AccessKeyID, build)
+		// the daemons never stop watching
+		go reloadable.watch(config.GetUserConfigFilePath(), nil)
+		credentials = reloadable
+		client = reloadable
+	}
+	QClient = newInstrumentedClient(newRetryClient(client, opts))
+}
+
+func setupStaticClient(opts Options) {
+	path, vxnetsPath := opts.StaticConfig, opts.StaticVxNets
+	if path == "" {
+		path = DefaultStaticConfig
+	}
+	if opts.NoStaticNics {
+		path = ""
+	}
+	if vxnetsPath == "" {
+		vxnetsPath = DefaultStaticVxNets
+	}
+	conf, err := LoadStaticConfig(path, vxnetsPath)
+	if err != nil {
+		log.Fatalf("failed to load static provider config: %v", err)
+	}
+	api, err := newStaticProvider(conf)
+	if err != nil {
+		log.Fatalf("invalid static provider config %s %s: %v", path, vxnetsPath, err)
+	}
+	log.Infof("static provider inited with %d vxnets and %d nics from %s %s", len(api.vxnets), len(api.nics), path, vxnetsPath)
+
+	static = api
+	QClient = newInstrumentedClient(api)
+}
+
+// newQingCloudAPIWrapper creates the sdk services with the credentials of qsdkconfig, which are
+// validated by DescribeAccessKeys
+func newQingCloudAPIWrapper(qsdkconfig *config.Config, instanceID string, opts Options) (*qingcloudAPIWrapper, error) {
 	qcService, err := service.Init(qsdkconfig)
 	if err != nil {
-		log.Fatalf("failed to init qingcloud sdk service: %v", err)
+		return nil, fmt.Errorf("failed to init qingcloud sdk service: %v", err)
 	}
 
 	nicService, err := qcService.Nic(qsdkconfig.Zone)
 	if err != nil {
-		log.Fatalf("failed to init qingcloud sdk nic service: %v", err)
+		return nil, fmt.Errorf("failed to init qingcloud sdk nic service: %v", err)
 	}
 
 	vxNetService, err := qcService.VxNet(qsdkconfig.Zone)
 	if err != nil {
-		log.Fatalf("failed to init qingcloud sdk vxnet service: %v", err)
+		return nil, fmt.Errorf("failed to init qingcloud sdk vxnet service: %v", err)
 	}
 
 	jobService, err := qcService.Job(qsdkconfig.Zone)
 	if err != nil {
-		log.Fatalf("failed to init qingcloud sdk job service: %v", err)
+		return nil, fmt.Errorf("failed to init qingcloud sdk job service: %v", err)
 	}
 
 	instanceService, err := qcService.Instance(qsdkconfig.Zone)
 	if err != nil {
-		log.Fatalf("failed to init qingcloud sdk instance service: %v", err)
+		return nil, fmt.Errorf("failed to init qingcloud sdk instance service: %v", err)
 	}
 
 	tagService, err := qcService.Tag(qsdkconfig.Zone)
 	if err != nil {
-		log.Fatalf("failed to init qingcloud sdk tag service: %v", err)
+		return nil, fmt.Errorf("failed to init qingcloud sdk tag service: %v", err)
 	}
 
 	vipService, err := qcService.VIP(qsdkconfig.Zone)
 	if err != nil {
-		log.Fatalf("failed to init qingcloud sdk vip service: %v", err)
+		return nil, fmt.Errorf("failed to init qingcloud sdk vip service: %v", err)
 	}
 
 	sgService, err := qcService.SecurityGroup(qsdkconfig.Zone)
 	if err != nil {
-		log.Fatalf("failed to init qingcloud sdk securityGroup service: %v", err)
+		return nil, fmt.Errorf("failed to init qingcloud sdk securityGroup service: %v", err)
 	}
 
 	clusterService, err := qcService.Cluster(qsdkconfig.Zone)
 	if err != nil {
-		log.Fatalf("failed to init qingcloud sdk cluster service: %v", err)
+		return nil, fmt.Errorf("failed to init qingcloud sdk cluster service: %v", err)
 	}
 
 	//useid
@@ -122,14 +195,14 @@ func SetupQingCloudClient(opts Options) {
 		AccessKeys: []*string{&qsdkconfig.AccessKeyID},
 	})
 	if err != nil {
-		log.Fatalf("failed to DescribeAccessKeys: %v", err)
+		return nil, fmt.Errorf("failed to DescribeAccessKeys: %v", err)
 	}
 	if len(output.AccessKeySet) == 0 {
-		log.Fatalf("DescribeAccessKeys is empty: %s", spew.Sdump(output))
+		return nil, fmt.Errorf("DescribeAccessKeys is empty: %s", spew.Sdump(output))
 	}
 	userId := *output.AccessKeySet[0].Owner
 
-	QClient = &qingcloudAPIWrapper{
+	return &qingcloudAPIWrapper{
 		nicService:      nicService,
 		vxNetService:    vxNetService,
 		instanceService: instanceService,
@@ -140,63 +213,46 @@ func SetupQingCloudClient(opts Options) {
 		clusterService:  clusterService,
 
 		userID:     userId,
-		instanceID: string(instanceID),
+		instanceID: instanceID,
 		opts:       opts,
-	}
+	}, nil
 }
 
 func (q *qingcloudAPIWrapper) GetInstanceID() string {
 	return q.instanceID
 }
 
-func (q *qingcloudAPIWrapper) GetCreatedNicsByName(name string) ([]*rpc.HostNic, error) {
-	input := &service.DescribeNicsInput{
-		Limit:   service.Int(constants.NicNumLimit),
-		NICName: service.String(name),
-	}
-	output, err := q.nicService.DescribeNics(input)
-	if err != nil {
-		log.Errorf("failed to GetCreatedNics: input (%s) output (%s) %v", spew.Sdump(input), spew.Sdump(output), err)
-		return nil, err
-	}
-
-	var (
-		nics   []*rpc.HostNic
-		netIDs []string
-	)
-	for _, nic := range output.NICSet {
-		if *nic.Role != 0 {
-			continue
-		}
-		nics = append(nics, constructHostnic(&rpc.VxNet{
-			ID: *nic.VxNetID,
-		}, nic))
-		netIDs = append(netIDs, *nic.VxNetID)
-	}
-
-	if len(netIDs) > 0 {
-		tmp := removeDupByMap(netIDs)
-		vxnets, err := q.GetVxNets(tmp, 0)
+// describeNics returns the nics matching input from all pages
+func (q *qingcloudAPIWrapper) describeNics(method string, input *service.DescribeNicsInput) ([]*service.NIC, error) {
+	var result []*service.NIC
+	err := describeAll(method, func(offset, limit int) (int, *int, error) {
+		input.Offset, input.Limit = service.Int(offset), service.Int(limit)
+		output, err := q.nicService.DescribeNics(input)
 		if err != nil {
-			return nil, err
+			log.Errorf("failed to %s: input (%s) output (%s) %v", method, spew.Sdump(input), spew.Sdump(output), err)
+			return 0, nil, err
 		}
+		result = append(result, output.NICSet...)
+		return len(output.NICSet), output.TotalCount, nil
+	})
+	return result, err
+}
 
-		for _, nic := range nics {
-			nic.VxNet = vxnets[nic.VxNet.ID]
-		}
-	}
-
-	return nics, nil
+func (q *qingcloudAPIWrapper) GetCreatedNicsByName(name string) ([]*rpc.HostNic, error) {
+	return q.getCreatedNics("GetCreatedNics", &service.DescribeNicsInput{
+		NICName: service.String(name),
+	})
 }
 
 func (q *qingcloudAPIWrapper) GetCreatedNicsByVxNet(vxnet string) ([]*rpc.HostNic, error) {
-	input := &service.DescribeNicsInput{
-		Limit:  service.Int(constants.VxnetNicNumLimit),
+	return q.getCreatedNics("GetCreatedNics", &service.DescribeNicsInput{
 		VxNets: []*string{service.String(vxnet)},
-	}
-	output, err := q.nicService.DescribeNics(input)
+	})
+}
+
+func (q *qingcloudAPIWrapper) getCreatedNics(method string, input *service.DescribeNicsInput) ([]*rpc.HostNic, error) {
+	nicSet, err := q.describeNics(method, input)
 	if err != nil {
-		log.Errorf("failed to GetCreatedNics: input (%s) output (%s) %v", spew.Sdump(input), spew.Sdump(output), err)
 		return nil, err
 	}
 
@@ -204,7 +260,7 @@ func (q *qingcloudAPIWrapper) GetCreatedNicsByVxNet(vxnet string) ([]*rpc.HostNi
 		nics   []*rpc.HostNic
 		netIDs []string
 	)
-	for _, nic := range output.NICSet {
+	for _, nic := range nicSet {
 		if *nic.Role != 0 {
 			continue
 		}
@@ -230,20 +286,16 @@ func (q *qingcloudAPIWrapper) GetCreatedNicsByVxNet(vxnet string) ([]*rpc.HostNi
 }
 
 func (q *qingcloudAPIWrapper) GetAttachedNics() ([]*rpc.HostNic, error) {
-	input := &service.DescribeNicsInput{
+	nicSet, err := q.describeNics("GetAttachedNics", &service.DescribeNicsInput{
 		Instances: []*string{&q.instanceID},
 		Status:    service.String("in-use"),
-		Limit:     service.Int(constants.NicNumLimit + 1),
-	}
-
-	output, err := q.nicService.DescribeNics(input)
+	})
 	if err != nil {
-		log.Errorf("failed to GetPrimaryNIC: input (%s) output (%s) %v", spew.Sdump(input), spew.Sdump(output), err)
 		return nil, err
 	}
 
 	var result []*rpc.HostNic
-	for _, nic := range output.NICSet {
+	for _, nic := range nicSet {
 		result = append(result, constructHostnic(nil, nic))
 	}
 
@@ -301,19 +353,15 @@ func constructHostnic(vxnet *rpc.VxNet, nic *service.NIC) *rpc.HostNic {
 }
 
 func (q *qingcloudAPIWrapper) GetNics(nics []string) (map[string]*rpc.HostNic, error) {
-	input := &service.DescribeNicsInput{
-		Nics:  service.StringSlice(nics),
-		Limit: service.Int(constants.NicNumLimit),
-	}
-
-	output, err := q.nicService.DescribeNics(input)
+	nicSet, err := q.describeNics("GetNics", &service.DescribeNicsInput{
+		Nics: service.StringSlice(nics),
+	})
 	if err != nil {
-		log.Errorf("failed to GetNics: input (%s) output (%s) %v", spew.Sdump(input), spew.Sdump(output), err)
 		return nil, err
 	}
 
 	result := make(map[string]*rpc.HostNic)
-	for _, nic := range output.NICSet {
+	for _, nic := range nicSet {
 		result[*nic.NICID] = constructHostnic(nil, nic)
 	}
 
@@ -417,19 +465,26 @@ type nics struct {
 
 func (q *qingcloudAPIWrapper) DescribeNicJobs(ids []string) ([]string, map[string]bool, error) {
 	input := &service.DescribeJobsInput{
-		Jobs:  service.StringSlice(ids),
-		Limit: service.Int(constants.NicNumLimit),
+		Jobs: service.StringSlice(ids),
 	}
-
-	output, err := q.jobService.DescribeJobs(input)
+	var jobs []*service.Job
+	err := describeAll("DescribeJobs", func(offset, limit int) (int, *int, error) {
+		input.Offset, input.Limit = service.Int(offset), service.Int(limit)
+		output, err := q.jobService.DescribeJobs(input)
+		if err != nil {
+			log.Errorf("failed to GetJobs: input (%s) output (%s) %v", spew.Sdump(input), spew.Sdump(output), err)
+			return 0, nil, err
+		}
+		jobs = append(jobs, output.JobSet...)
+		return len(output.JobSet), output.TotalCount, nil
+	})
 	if err != nil {
-		log.Errorf("failed to GetJobs: input (%s) output (%s) %v", spew.Sdump(input), spew.Sdump(output), err)
 		return nil, nil, err
 	}
 
 	working := make(map[string]bool)
 	var left []string
-	for _, j := range output.JobSet {
+	for _, j := range jobs {
 		if *j.JobAction == "DetachNics" || *j.JobAction == "AttachNics" {
 			if *j.Status == "working" || *j.Status == "pending" {
 				left = append(left, *j.JobID)
@@ -448,20 +503,27 @@ func (q *qingcloudAPIWrapper) DescribeNicJobs(ids []string) ([]string, map[strin
 func (q *qingcloudAPIWrapper) getVxNets(ids []string, public bool, customReservedIPCount int64) ([]*rpc.VxNet, error) {
 	input := &service.DescribeVxNetsInput{
 		VxNets: service.StringSlice(ids),
-		Limit:  service.Int(constants.NicNumLimit),
 	}
 	if public {
 		input.VxNetType = service.Int(2)
 	}
-
-	output, err := q.vxNetService.DescribeVxNets(input)
+	var vxnetSet []*service.VxNet
+	err := describeAll("DescribeVxNets", func(offset, limit int) (int, *int, error) {
+		input.Offset, input.Limit = service.Int(offset), service.Int(limit)
+		output, err := q.vxNetService.DescribeVxNets(input)
+		if err != nil {
+			log.Errorf("failed to GetVxNets: input (%s) output (%s) %v", spew.Sdump(input), spew.Sdump(output), err)
+			return 0, nil, err
+		}
+		vxnetSet = append(vxnetSet, output.VxNetSet...)
+		return len(output.VxNetSet), output.TotalCount, nil
+	})
 	if err != nil {
-		log.Errorf("failed to GetVxNets: input (%s) output (%s) %v", spew.Sdump(input), spew.Sdump(output), err)
 		return nil, err
 	}
 
 	var vxNets []*rpc.VxNet
-	for _, qcVxNet := range output.VxNetSet {
+	for _, qcVxNet := range vxnetSet {
 		vxnetItem := &rpc.VxNet{
 			ID: *qcVxNet.VxNetID,
 		}
@@ -594,18 +656,24 @@ func (q *qingcloudAPIWrapper) DescribeVIPs(vxnet *rpc.VxNet) ([]*rpc.VIP, error)
 	input := &service.DescribeVxNetsVIPsInput{
 		VIPName: &vipName,
 		VxNets:  []*string{&vxnet.ID},
-		// TODO: Limit not work, max item is 100
-		Limit: service.Int(constants.VIPNumLimit),
 	}
-
-	output, err := q.vipService.DescribeVxNetsVIPs(input)
+	var vipSet []*service.VIP
+	err := describeAll("DescribeVIPs", func(offset, limit int) (int, *int, error) {
+		input.Offset, input.Limit = service.Int(offset), service.Int(limit)
+		output, err := q.vipService.DescribeVxNetsVIPs(input)
+		if err != nil {
+			log.Errorf("failed to DescribeVIPs: input (%s) output (%s) %v", spew.Sdump(input), spew.Sdump(output), err)
+			return 0, nil, err
+		}
+		vipSet = append(vipSet, output.VIPSet...)
+		return len(output.VIPSet), output.TotalCount, nil
+	})
 	if err != nil {
-		log.Errorf("failed to DescribeVIPs: input (%s) output (%s) %v", spew.Sdump(input), spew.Sdump(output), err)
 		return nil, err
 	}
 
 	var vips []*rpc.VIP
-	for _, vip := range output.VIPSet {
+	for _, vip := range vipSet {
 		vipItem := &rpc.VIP{
 			ID:      *vip.VIPID,
 			Name:    *vip.VIPName,
@@ -673,14 +741,22 @@ func (q *qingcloudAPIWrapper) GetSecurityGroupRuleForVxNet(sg string, vxnet *rpc
 		SecurityGroup: service.String(sg),
 		Direction:     service.Int(0),
 	}
-
-	output, err := q.sgService.DescribeSecurityGroupRules(input)
-	if err != nil || *output.RetCode != 0 {
-		log.Errorf("failed to DescribeSecurityGroupRules: input (%s) output (%s) %v", spew.Sdump(input), spew.Sdump(output), err)
+	var rules []*service.SecurityGroupRule
+	err := describeAll("DescribeSecurityGroupRules", func(offset, limit int) (int, *int, error) {
+		input.Offset, input.Limit = service.Int(offset), service.Int(limit)
+		output, err := q.sgService.DescribeSecurityGroupRules(input)
+		if err != nil || *output.RetCode != 0 {
+			log.Errorf("failed to DescribeSecurityGroupRules: input (%s) output (%s) %v", spew.Sdump(input), spew.Sdump(output), err)
+			return 0, nil, err
+		}
+		rules = append(rules, output.SecurityGroupRuleSet...)
+		return len(output.SecurityGroupRuleSet), output.TotalCount, nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	for _, rule := range output.SecurityGroupRuleSet {
+	for _, rule := range rules {
 		if *rule.Val3 == vxnet.Network && *rule.Action == "accept" && *rule.Protocol == "all" {
 			return &rpc.SecurityGroupRule{
 				ID:              *rule.SecurityGroupRuleID,
@@ -716,14 +792,22 @@ func (q *qingcloudAPIWrapper) DescribeClusterSecurityGroup(clusterID string) (st
 	input := &service.DescribeClustersInput{
 		Clusters: []*string{service.String(clusterID)},
 	}
-
-	output, err := q.clusterService.DescribeClusters(input)
-	if err != nil || *output.RetCode != 0 {
-		log.Errorf("failed to DescribeClusters: input (%s) output (%s) %v", spew.Sdump(input), spew.Sdump(output), err)
+	var clusters []*service.Cluster
+	err := describeAll("DescribeClusters", func(offset, limit int) (int, *int, error) {
+		input.Offset, input.Limit = service.Int(offset), service.Int(limit)
+		output, err := q.clusterService.DescribeClusters(input)
+		if err != nil || *output.RetCode != 0 {
+			log.Errorf("failed to DescribeClusters: input (%s) output (%s) %v", spew.Sdump(input), spew.Sdump(output), err)
+			return 0, nil, err
+		}
+		clusters = append(clusters, output.ClusterSet...)
+		return len(output.ClusterSet), output.TotalCount, nil
+	})
+	if err != nil {
 		return "", err
 	}
 
-	for _, cluster := range output.ClusterSet {
+	for _, cluster := range clusters {
 		if *cluster.ClusterID == clusterID {
 			return *cluster.SecurityGroupID, nil
 		}
@@ -736,15 +820,23 @@ func (q *qingcloudAPIWrapper) DescribeClusterNodes(clusterID string) ([]*rpc.Nod
 	input := &service.DescribeClusterNodesInput{
 		Cluster: service.String(clusterID),
 	}
-
-	output, err := q.clusterService.DescribeClusterNodes(input)
+	var nodeSet []*service.ClusterNode
+	err := describeAll("DescribeClusterNodes", func(offset, limit int) (int, *int, error) {
+		input.Offset, input.Limit = service.Int(offset), service.Int(limit)
+		output, err := q.clusterService.DescribeClusterNodes(input)
+		if err != nil {
+			log.Errorf("failed to DescribeClusterNodes: input (%s) output (%s) %v", spew.Sdump(input), spew.Sdump(output), err)
+			return 0, nil, err
+		}
+		nodeSet = append(nodeSet, output.NodeSet...)
+		return len(output.NodeSet), output.TotalCount, nil
+	})
 	if err != nil {
-		log.Errorf("failed to DescribeClusterNodes: input (%s) output (%s) %v", spew.Sdump(input), spew.Sdump(output), err)
 		return nil, err
 	}
 
 	var nodes []*rpc.Node
-	for _, node := range output.NodeSet {
+	for _, node := range nodeSet {
 		item := &rpc.Node{
 			InstanceID:  *node.InstanceID,
 			NodeID:      *node.NodeID,
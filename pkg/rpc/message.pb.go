@@ -130,6 +130,101 @@ func (Phase) EnumDescriptor() ([]byte, []int) {
 	return file_pkg_rpc_message_proto_rawDescGZIP(), []int{1}
 }
 
+type NicEventType int32
+
+const (
+	NicEventType_NicPhase  NicEventType = 0
+	NicEventType_NicRepair NicEventType = 1
+	NicEventType_NicFree   NicEventType = 2
+)
+
+// Enum value maps for NicEventType.
+var (
+	NicEventType_name = map[int32]string{
+		0: "NicPhase",
+		1: "NicRepair",
+		2: "NicFree",
+	}
+	NicEventType_value = map[string]int32{
+		"NicPhase":  0,
+		"NicRepair": 1,
+		"NicFree":   2,
+	}
+)
+
+func (x NicEventType) Enum() *NicEventType {
+	p := new(NicEventType)
+	*p = x
+	return p
+}
+
+func (x NicEventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (NicEventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_pkg_rpc_message_proto_enumTypes[2].Descriptor()
+}
+
+func (NicEventType) Type() protoreflect.EnumType {
+	return &file_pkg_rpc_message_proto_enumTypes[2]
+}
+
+func (x NicEventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use NicEventType.Descriptor instead.
+func (NicEventType) EnumDescriptor() ([]byte, []int) {
+	return file_pkg_rpc_message_proto_rawDescGZIP(), []int{2}
+}
+
+type PodEventType int32
+
+const (
+	PodEventType_PodAttach PodEventType = 0
+	PodEventType_PodDetach PodEventType = 1
+)
+
+// Enum value maps for PodEventType.
+var (
+	PodEventType_name = map[int32]string{
+		0: "PodAttach",
+		1: "PodDetach",
+	}
+	PodEventType_value = map[string]int32{
+		"PodAttach": 0,
+		"PodDetach": 1,
+	}
+)
+
+func (x PodEventType) Enum() *PodEventType {
+	p := new(PodEventType)
+	*p = x
+	return p
+}
+
+func (x PodEventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PodEventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_pkg_rpc_message_proto_enumTypes[3].Descriptor()
+}
+
+func (PodEventType) Type() protoreflect.EnumType {
+	return &file_pkg_rpc_message_proto_enumTypes[3]
+}
+
+func (x PodEventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PodEventType.Descriptor instead.
+func (PodEventType) EnumDescriptor() ([]byte, []int) {
+	return file_pkg_rpc_message_proto_rawDescGZIP(), []int{3}
+}
+
 type VxNet struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -468,11 +563,15 @@ type IPAMMessage struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Args   *PodInfo `protobuf:"bytes,1,opt,name=Args,proto3" json:"Args,omitempty"`
-	Nic    *HostNic `protobuf:"bytes,2,opt,name=Nic,proto3" json:"Nic,omitempty"`
-	Peek   bool     `protobuf:"varint,3,opt,name=Peek,proto3" json:"Peek,omitempty"`
-	Delete bool     `protobuf:"varint,4,opt,name=Delete,proto3" json:"Delete,omitempty"`
-	IP     string   `protobuf:"bytes,5,opt,name=IP,proto3" json:"IP,omitempty"`
+	Args *PodInfo `protobuf:"bytes,1,opt,name=Args,proto3" json:"Args,omitempty"`
+	Nic  *HostNic `protobuf:"bytes,2,opt,name=Nic,proto3" json:"Nic,omitempty"`
+	Peek bool     `protobuf:"varint,3,opt,name=Peek,proto3" json:"Peek,omitempty"`
+	// Deprecated: never read by the daemon, use Peek for two phase delete
+	Delete bool   `protobuf:"varint,4,opt,name=Delete,proto3" json:"Delete,omitempty"`
+	IP     string `protobuf:"bytes,5,opt,name=IP,proto3" json:"IP,omitempty"`
+	// protocol version and features of the sender, unset by legacy peers
+	Protocol uint32   `protobuf:"varint,6,opt,name=Protocol,proto3" json:"Protocol,omitempty"`
+	Features []string `protobuf:"bytes,7,rep,name=Features,proto3" json:"Features,omitempty"`
 }
 
 func (x *IPAMMessage) Reset() {
@@ -542,6 +641,75 @@ func (x *IPAMMessage) GetIP() string {
 	return ""
 }
 
+func (x *IPAMMessage) GetProtocol() uint32 {
+	if x != nil {
+		return x.Protocol
+	}
+	return 0
+}
+
+func (x *IPAMMessage) GetFeatures() []string {
+	if x != nil {
+		return x.Features
+	}
+	return nil
+}
+
+type VersionInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Protocol uint32   `protobuf:"varint,1,opt,name=Protocol,proto3" json:"Protocol,omitempty"`
+	Features []string `protobuf:"bytes,2,rep,name=Features,proto3" json:"Features,omitempty"`
+}
+
+func (x *VersionInfo) Reset() {
+	*x = VersionInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_rpc_message_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *VersionInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VersionInfo) ProtoMessage() {}
+
+func (x *VersionInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_rpc_message_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VersionInfo.ProtoReflect.Descriptor instead.
+func (*VersionInfo) Descriptor() ([]byte, []int) {
+	return file_pkg_rpc_message_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *VersionInfo) GetProtocol() uint32 {
+	if x != nil {
+		return x.Protocol
+	}
+	return 0
+}
+
+func (x *VersionInfo) GetFeatures() []string {
+	if x != nil {
+		return x.Features
+	}
+	return nil
+}
+
 type VIP struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -556,7 +724,7 @@ type VIP struct {
 func (x *VIP) Reset() {
 	*x = VIP{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pkg_rpc_message_proto_msgTypes[4]
+		mi := &file_pkg_rpc_message_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -569,7 +737,7 @@ func (x *VIP) String() string {
 func (*VIP) ProtoMessage() {}
 
 func (x *VIP) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_rpc_message_proto_msgTypes[4]
+	mi := &file_pkg_rpc_message_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -582,7 +750,7 @@ func (x *VIP) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VIP.ProtoReflect.Descriptor instead.
 func (*VIP) Descriptor() ([]byte, []int) {
-	return file_pkg_rpc_message_proto_rawDescGZIP(), []int{4}
+	return file_pkg_rpc_message_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *VIP) GetID() string {
@@ -631,7 +799,7 @@ type SecurityGroupRule struct {
 func (x *SecurityGroupRule) Reset() {
 	*x = SecurityGroupRule{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pkg_rpc_message_proto_msgTypes[5]
+		mi := &file_pkg_rpc_message_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -644,7 +812,7 @@ func (x *SecurityGroupRule) String() string {
 func (*SecurityGroupRule) ProtoMessage() {}
 
 func (x *SecurityGroupRule) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_rpc_message_proto_msgTypes[5]
+	mi := &file_pkg_rpc_message_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -657,7 +825,7 @@ func (x *SecurityGroupRule) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SecurityGroupRule.ProtoReflect.Descriptor instead.
 func (*SecurityGroupRule) Descriptor() ([]byte, []int) {
-	return file_pkg_rpc_message_proto_rawDescGZIP(), []int{5}
+	return file_pkg_rpc_message_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *SecurityGroupRule) GetID() string {
@@ -732,7 +900,7 @@ type Node struct {
 func (x *Node) Reset() {
 	*x = Node{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pkg_rpc_message_proto_msgTypes[6]
+		mi := &file_pkg_rpc_message_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -745,7 +913,7 @@ func (x *Node) String() string {
 func (*Node) ProtoMessage() {}
 
 func (x *Node) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_rpc_message_proto_msgTypes[6]
+	mi := &file_pkg_rpc_message_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -758,7 +926,7 @@ func (x *Node) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Node.ProtoReflect.Descriptor instead.
 func (*Node) Descriptor() ([]byte, []int) {
-	return file_pkg_rpc_message_proto_rawDescGZIP(), []int{6}
+	return file_pkg_rpc_message_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *Node) GetInstanceID() string {
@@ -813,12 +981,14 @@ type NicInfo struct {
 	Phase  string `protobuf:"bytes,3,opt,name=Phase,proto3" json:"Phase,omitempty"`
 	Status string `protobuf:"bytes,4,opt,name=Status,proto3" json:"Status,omitempty"`
 	Pods   int32  `protobuf:"varint,5,opt,name=Pods,proto3" json:"Pods,omitempty"`
+	// the error of the request, e.g. a failed repair, along with the resulting phase
+	Error string `protobuf:"bytes,6,opt,name=Error,proto3" json:"Error,omitempty"`
 }
 
 func (x *NicInfo) Reset() {
 	*x = NicInfo{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pkg_rpc_message_proto_msgTypes[7]
+		mi := &file_pkg_rpc_message_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -831,7 +1001,7 @@ func (x *NicInfo) String() string {
 func (*NicInfo) ProtoMessage() {}
 
 func (x *NicInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_rpc_message_proto_msgTypes[7]
+	mi := &file_pkg_rpc_message_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -844,7 +1014,7 @@ func (x *NicInfo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use NicInfo.ProtoReflect.Descriptor instead.
 func (*NicInfo) Descriptor() ([]byte, []int) {
-	return file_pkg_rpc_message_proto_rawDescGZIP(), []int{7}
+	return file_pkg_rpc_message_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *NicInfo) GetId() string {
@@ -882,6 +1052,13 @@ func (x *NicInfo) GetPods() int32 {
 	return 0
 }
 
+func (x *NicInfo) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
 type NicInfoList struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -893,7 +1070,7 @@ type NicInfoList struct {
 func (x *NicInfoList) Reset() {
 	*x = NicInfoList{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pkg_rpc_message_proto_msgTypes[8]
+		mi := &file_pkg_rpc_message_proto_msgTypes[9]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -906,7 +1083,7 @@ func (x *NicInfoList) String() string {
 func (*NicInfoList) ProtoMessage() {}
 
 func (x *NicInfoList) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_rpc_message_proto_msgTypes[8]
+	mi := &file_pkg_rpc_message_proto_msgTypes[9]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -919,7 +1096,7 @@ func (x *NicInfoList) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use NicInfoList.ProtoReflect.Descriptor instead.
 func (*NicInfoList) Descriptor() ([]byte, []int) {
-	return file_pkg_rpc_message_proto_rawDescGZIP(), []int{8}
+	return file_pkg_rpc_message_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *NicInfoList) GetItems() []*NicInfo {
@@ -938,7 +1115,7 @@ type Nothing struct {
 func (x *Nothing) Reset() {
 	*x = Nothing{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pkg_rpc_message_proto_msgTypes[9]
+		mi := &file_pkg_rpc_message_proto_msgTypes[10]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -951,7 +1128,7 @@ func (x *Nothing) String() string {
 func (*Nothing) ProtoMessage() {}
 
 func (x *Nothing) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_rpc_message_proto_msgTypes[9]
+	mi := &file_pkg_rpc_message_proto_msgTypes[10]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -964,200 +1141,1115 @@ func (x *Nothing) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Nothing.ProtoReflect.Descriptor instead.
 func (*Nothing) Descriptor() ([]byte, []int) {
-	return file_pkg_rpc_message_proto_rawDescGZIP(), []int{9}
+	return file_pkg_rpc_message_proto_rawDescGZIP(), []int{10}
 }
 
-var File_pkg_rpc_message_proto protoreflect.FileDescriptor
+type PodNetInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
 
-var file_pkg_rpc_message_proto_rawDesc = []byte{
-	0x0a, 0x15, 0x70, 0x6b, 0x67, 0x2f, 0x72, 0x70, 0x63, 0x2f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
-	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x03, 0x72, 0x70, 0x63, 0x22, 0xb7, 0x01, 0x0a,
-	0x05, 0x56, 0x78, 0x4e, 0x65, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x02, 0x49, 0x44, 0x12, 0x18, 0x0a, 0x07, 0x47, 0x61, 0x74, 0x65, 0x77, 0x61,
-	0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x47, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79,
-	0x12, 0x18, 0x0a, 0x07, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x18, 0x03, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x07, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x12, 0x1a, 0x0a, 0x08, 0x52, 0x6f,
-	0x75, 0x74, 0x65, 0x72, 0x49, 0x44, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x52, 0x6f,
-	0x75, 0x74, 0x65, 0x72, 0x49, 0x44, 0x12, 0x18, 0x0a, 0x07, 0x49, 0x50, 0x53, 0x74, 0x61, 0x72,
-	0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x49, 0x50, 0x53, 0x74, 0x61, 0x72, 0x74,
-	0x12, 0x14, 0x0a, 0x05, 0x49, 0x50, 0x45, 0x6e, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x05, 0x49, 0x50, 0x45, 0x6e, 0x64, 0x12, 0x1e, 0x0a, 0x0a, 0x54, 0x75, 0x6e, 0x6e, 0x65, 0x6c,
-	0x54, 0x79, 0x70, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x54, 0x75, 0x6e, 0x6e,
-	0x65, 0x6c, 0x54, 0x79, 0x70, 0x65, 0x22, 0xc4, 0x02, 0x0a, 0x07, 0x48, 0x6f, 0x73, 0x74, 0x4e,
-	0x69, 0x63, 0x12, 0x20, 0x0a, 0x05, 0x56, 0x78, 0x4e, 0x65, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x0b, 0x32, 0x0a, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x56, 0x78, 0x4e, 0x65, 0x74, 0x52, 0x05, 0x56,
-	0x78, 0x4e, 0x65, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x44, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x02, 0x49, 0x44, 0x12, 0x22, 0x0a, 0x0c, 0x48, 0x61, 0x72, 0x64, 0x77, 0x61, 0x72, 0x65,
-	0x41, 0x64, 0x64, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x48, 0x61, 0x72, 0x64,
-	0x77, 0x61, 0x72, 0x65, 0x41, 0x64, 0x64, 0x72, 0x12, 0x26, 0x0a, 0x0e, 0x50, 0x72, 0x69, 0x6d,
-	0x61, 0x72, 0x79, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x0e, 0x50, 0x72, 0x69, 0x6d, 0x61, 0x72, 0x79, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73,
-	0x12, 0x1c, 0x0a, 0x09, 0x49, 0x73, 0x50, 0x72, 0x69, 0x6d, 0x61, 0x72, 0x79, 0x18, 0x05, 0x20,
-	0x01, 0x28, 0x08, 0x52, 0x09, 0x49, 0x73, 0x50, 0x72, 0x69, 0x6d, 0x61, 0x72, 0x79, 0x12, 0x14,
-	0x0a, 0x05, 0x55, 0x73, 0x69, 0x6e, 0x67, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x55,
-	0x73, 0x69, 0x6e, 0x67, 0x12, 0x1a, 0x0a, 0x08, 0x52, 0x65, 0x73, 0x65, 0x72, 0x76, 0x65, 0x64,
-	0x18, 0x07, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x52, 0x65, 0x73, 0x65, 0x72, 0x76, 0x65, 0x64,
-	0x12, 0x24, 0x0a, 0x0d, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x54, 0x61, 0x62, 0x6c, 0x65, 0x4e, 0x75,
-	0x6d, 0x18, 0x08, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0d, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x54, 0x61,
-	0x62, 0x6c, 0x65, 0x4e, 0x75, 0x6d, 0x12, 0x23, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73,
-	0x18, 0x09, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0b, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x53, 0x74, 0x61,
-	0x74, 0x75, 0x73, 0x52, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x20, 0x0a, 0x05, 0x50,
-	0x68, 0x61, 0x73, 0x65, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0a, 0x2e, 0x72, 0x70, 0x63,
-	0x2e, 0x50, 0x68, 0x61, 0x73, 0x65, 0x52, 0x05, 0x50, 0x68, 0x61, 0x73, 0x65, 0x22, 0x85, 0x02,
-	0x0a, 0x07, 0x50, 0x6f, 0x64, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x12, 0x0a, 0x04, 0x4e, 0x61, 0x6d,
-	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1c, 0x0a,
-	0x09, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x09, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x43,
-	0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x0a, 0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x4e,
-	0x65, 0x74, 0x6e, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x4e, 0x65, 0x74, 0x6e,
-	0x73, 0x12, 0x16, 0x0a, 0x06, 0x49, 0x66, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x06, 0x49, 0x66, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x4e, 0x69, 0x63,
-	0x54, 0x79, 0x70, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x4e, 0x69, 0x63, 0x54,
-	0x79, 0x70, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x50, 0x6f, 0x64, 0x49, 0x50, 0x18, 0x07, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x05, 0x50, 0x6f, 0x64, 0x49, 0x50, 0x12, 0x18, 0x0a, 0x07, 0x48, 0x6f, 0x73,
-	0x74, 0x4e, 0x69, 0x63, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x48, 0x6f, 0x73, 0x74,
-	0x4e, 0x69, 0x63, 0x12, 0x14, 0x0a, 0x05, 0x56, 0x78, 0x4e, 0x65, 0x74, 0x18, 0x09, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x05, 0x56, 0x78, 0x4e, 0x65, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x6e, 0x6f, 0x64,
-	0x65, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x6e, 0x6f, 0x64,
-	0x65, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0x8b, 0x01, 0x0a, 0x0b, 0x49, 0x50, 0x41, 0x4d, 0x4d, 0x65,
-	0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x20, 0x0a, 0x04, 0x41, 0x72, 0x67, 0x73, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x50, 0x6f, 0x64, 0x49, 0x6e, 0x66,
-	0x6f, 0x52, 0x04, 0x41, 0x72, 0x67, 0x73, 0x12, 0x1e, 0x0a, 0x03, 0x4e, 0x69, 0x63, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x48, 0x6f, 0x73, 0x74, 0x4e,
-	0x69, 0x63, 0x52, 0x03, 0x4e, 0x69, 0x63, 0x12, 0x12, 0x0a, 0x04, 0x50, 0x65, 0x65, 0x6b, 0x18,
-	0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x50, 0x65, 0x65, 0x6b, 0x12, 0x16, 0x0a, 0x06, 0x44,
-	0x65, 0x6c, 0x65, 0x74, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x44, 0x65, 0x6c,
-	0x65, 0x74, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x50, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x02, 0x49, 0x50, 0x22, 0x57, 0x0a, 0x03, 0x56, 0x49, 0x50, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x44,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x49, 0x44, 0x12, 0x12, 0x0a, 0x04, 0x4e, 0x61,
-	0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x12,
-	0x0a, 0x04, 0x41, 0x64, 0x64, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x41, 0x64,
-	0x64, 0x72, 0x12, 0x18, 0x0a, 0x07, 0x56, 0x78, 0x4e, 0x65, 0x74, 0x49, 0x44, 0x18, 0x04, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x07, 0x56, 0x78, 0x4e, 0x65, 0x74, 0x49, 0x44, 0x22, 0xe3, 0x01, 0x0a,
-	0x11, 0x53, 0x65, 0x63, 0x75, 0x72, 0x69, 0x74, 0x79, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x52, 0x75,
-	0x6c, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02,
-	0x49, 0x44, 0x12, 0x12, 0x0a, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x28, 0x0a, 0x0f, 0x53, 0x65, 0x63, 0x75, 0x72, 0x69,
-	0x74, 0x79, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x49, 0x44, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x0f, 0x53, 0x65, 0x63, 0x75, 0x72, 0x69, 0x74, 0x79, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x49, 0x44,
-	0x12, 0x16, 0x0a, 0x06, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x06, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x50, 0x72, 0x6f, 0x74,
-	0x6f, 0x63, 0x6f, 0x6c, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x50, 0x72, 0x6f, 0x74,
-	0x6f, 0x63, 0x6f, 0x6c, 0x12, 0x12, 0x0a, 0x04, 0x56, 0x61, 0x6c, 0x33, 0x18, 0x08, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x04, 0x56, 0x61, 0x6c, 0x33, 0x12, 0x1c, 0x0a, 0x09, 0x44, 0x69, 0x72, 0x65,
-	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x44, 0x69, 0x72,
-	0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x50, 0x72, 0x69, 0x6f, 0x72, 0x69,
-	0x74, 0x79, 0x18, 0x06, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x50, 0x72, 0x69, 0x6f, 0x72, 0x69,
-	0x74, 0x79, 0x22, 0xb4, 0x01, 0x0a, 0x04, 0x4e, 0x6f, 0x64, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x49,
-	0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x0a, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x44, 0x12, 0x16, 0x0a, 0x06, 0x4e,
-	0x6f, 0x64, 0x65, 0x49, 0x44, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x4e, 0x6f, 0x64,
-	0x65, 0x49, 0x44, 0x12, 0x20, 0x0a, 0x0b, 0x48, 0x6f, 0x73, 0x74, 0x4d, 0x61, 0x63, 0x68, 0x69,
-	0x6e, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x48, 0x6f, 0x73, 0x74, 0x4d, 0x61,
-	0x63, 0x68, 0x69, 0x6e, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x50, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65,
-	0x49, 0x50, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x50, 0x72, 0x69, 0x76, 0x61, 0x74,
-	0x65, 0x49, 0x50, 0x12, 0x1c, 0x0a, 0x09, 0x43, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x49, 0x44,
-	0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x43, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x49,
-	0x44, 0x12, 0x16, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x71, 0x0a, 0x07, 0x4e, 0x69, 0x63,
-	0x49, 0x6e, 0x66, 0x6f, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x02, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x56, 0x78, 0x6e, 0x65, 0x74, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x05, 0x56, 0x78, 0x6e, 0x65, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x50, 0x68,
-	0x61, 0x73, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x50, 0x68, 0x61, 0x73, 0x65,
-	0x12, 0x16, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x50, 0x6f, 0x64, 0x73,
-	0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x50, 0x6f, 0x64, 0x73, 0x22, 0x31, 0x0a, 0x0b,
-	0x4e, 0x69, 0x63, 0x49, 0x6e, 0x66, 0x6f, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x22, 0x0a, 0x05, 0x69,
-	0x74, 0x65, 0x6d, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x72, 0x70, 0x63,
-	0x2e, 0x4e, 0x69, 0x63, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x05, 0x69, 0x74, 0x65, 0x6d, 0x73, 0x22,
-	0x09, 0x0a, 0x07, 0x4e, 0x6f, 0x74, 0x68, 0x69, 0x6e, 0x67, 0x2a, 0x43, 0x0a, 0x06, 0x53, 0x74,
-	0x61, 0x74, 0x75, 0x73, 0x12, 0x08, 0x0a, 0x04, 0x46, 0x52, 0x45, 0x45, 0x10, 0x00, 0x12, 0x09,
-	0x0a, 0x05, 0x55, 0x53, 0x49, 0x4e, 0x47, 0x10, 0x01, 0x12, 0x0c, 0x0a, 0x08, 0x44, 0x45, 0x4c,
-	0x45, 0x54, 0x49, 0x4e, 0x47, 0x10, 0x02, 0x12, 0x09, 0x0a, 0x05, 0x45, 0x52, 0x52, 0x4f, 0x52,
-	0x10, 0x03, 0x12, 0x0b, 0x0a, 0x07, 0x44, 0x45, 0x4c, 0x45, 0x54, 0x45, 0x44, 0x10, 0x04, 0x2a,
-	0x58, 0x0a, 0x05, 0x50, 0x68, 0x61, 0x73, 0x65, 0x12, 0x08, 0x0a, 0x04, 0x49, 0x6e, 0x69, 0x74,
-	0x10, 0x00, 0x12, 0x13, 0x0a, 0x0f, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x41, 0x6e, 0x64, 0x41,
-	0x74, 0x74, 0x61, 0x63, 0x68, 0x10, 0x01, 0x12, 0x0e, 0x0a, 0x0a, 0x4a, 0x6f, 0x69, 0x6e, 0x42,
-	0x72, 0x69, 0x64, 0x67, 0x65, 0x10, 0x02, 0x12, 0x11, 0x0a, 0x0d, 0x53, 0x65, 0x74, 0x52, 0x6f,
-	0x75, 0x74, 0x65, 0x54, 0x61, 0x62, 0x6c, 0x65, 0x10, 0x03, 0x12, 0x0d, 0x0a, 0x09, 0x53, 0x75,
-	0x63, 0x63, 0x65, 0x65, 0x64, 0x65, 0x64, 0x10, 0x04, 0x32, 0xcd, 0x01, 0x0a, 0x0a, 0x43, 0x4e,
-	0x49, 0x42, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x12, 0x32, 0x0a, 0x0a, 0x41, 0x64, 0x64, 0x4e,
-	0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x12, 0x10, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x49, 0x50, 0x41,
-	0x4d, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x10, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x49,
-	0x50, 0x41, 0x4d, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x00, 0x12, 0x32, 0x0a, 0x0a,
-	0x44, 0x65, 0x6c, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x12, 0x10, 0x2e, 0x72, 0x70, 0x63,
-	0x2e, 0x49, 0x50, 0x41, 0x4d, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x10, 0x2e, 0x72,
-	0x70, 0x63, 0x2e, 0x49, 0x50, 0x41, 0x4d, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x00,
-	0x12, 0x2c, 0x0a, 0x08, 0x53, 0x68, 0x6f, 0x77, 0x4e, 0x69, 0x63, 0x73, 0x12, 0x0c, 0x2e, 0x72,
-	0x70, 0x63, 0x2e, 0x4e, 0x6f, 0x74, 0x68, 0x69, 0x6e, 0x67, 0x1a, 0x10, 0x2e, 0x72, 0x70, 0x63,
-	0x2e, 0x4e, 0x69, 0x63, 0x49, 0x6e, 0x66, 0x6f, 0x4c, 0x69, 0x73, 0x74, 0x22, 0x00, 0x12, 0x29,
-	0x0a, 0x09, 0x43, 0x6c, 0x65, 0x61, 0x72, 0x4e, 0x69, 0x63, 0x73, 0x12, 0x0c, 0x2e, 0x72, 0x70,
-	0x63, 0x2e, 0x4e, 0x6f, 0x74, 0x68, 0x69, 0x6e, 0x67, 0x1a, 0x0c, 0x2e, 0x72, 0x70, 0x63, 0x2e,
-	0x4e, 0x6f, 0x74, 0x68, 0x69, 0x6e, 0x67, 0x22, 0x00, 0x42, 0x0b, 0x5a, 0x09, 0x2e, 0x2f, 0x70,
-	0x6b, 0x67, 0x2f, 0x72, 0x70, 0x63, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	Namespace     string `protobuf:"bytes,1,opt,name=Namespace,proto3" json:"Namespace,omitempty"`
+	Name          string `protobuf:"bytes,2,opt,name=Name,proto3" json:"Name,omitempty"`
+	Containter    string `protobuf:"bytes,3,opt,name=Containter,proto3" json:"Containter,omitempty"`
+	PodIP         string `protobuf:"bytes,4,opt,name=PodIP,proto3" json:"PodIP,omitempty"`
+	HostNic       string `protobuf:"bytes,5,opt,name=HostNic,proto3" json:"HostNic,omitempty"`
+	Vxnet         string `protobuf:"bytes,6,opt,name=Vxnet,proto3" json:"Vxnet,omitempty"`
+	RouteTableNum int32  `protobuf:"varint,7,opt,name=RouteTableNum,proto3" json:"RouteTableNum,omitempty"`
 }
 
-var (
-	file_pkg_rpc_message_proto_rawDescOnce sync.Once
-	file_pkg_rpc_message_proto_rawDescData = file_pkg_rpc_message_proto_rawDesc
-)
+func (x *PodNetInfo) Reset() {
+	*x = PodNetInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_rpc_message_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
 
-func file_pkg_rpc_message_proto_rawDescGZIP() []byte {
-	file_pkg_rpc_message_proto_rawDescOnce.Do(func() {
-		file_pkg_rpc_message_proto_rawDescData = protoimpl.X.CompressGZIP(file_pkg_rpc_message_proto_rawDescData)
-	})
-	return file_pkg_rpc_message_proto_rawDescData
+func (x *PodNetInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-var file_pkg_rpc_message_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_pkg_rpc_message_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
-var file_pkg_rpc_message_proto_goTypes = []interface{}{
-	(Status)(0),               // 0: rpc.Status
-	(Phase)(0),                // 1: rpc.Phase
-	(*VxNet)(nil),             // 2: rpc.VxNet
-	(*HostNic)(nil),           // 3: rpc.HostNic
-	(*PodInfo)(nil),           // 4: rpc.PodInfo
-	(*IPAMMessage)(nil),       // 5: rpc.IPAMMessage
-	(*VIP)(nil),               // 6: rpc.VIP
-	(*SecurityGroupRule)(nil), // 7: rpc.SecurityGroupRule
-	(*Node)(nil),              // 8: rpc.Node
-	(*NicInfo)(nil),           // 9: rpc.NicInfo
-	(*NicInfoList)(nil),       // 10: rpc.NicInfoList
-	(*Nothing)(nil),           // 11: rpc.Nothing
+func (*PodNetInfo) ProtoMessage() {}
+
+func (x *PodNetInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_rpc_message_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
-var file_pkg_rpc_message_proto_depIdxs = []int32{
-	2,  // 0: rpc.HostNic.VxNet:type_name -> rpc.VxNet
-	0,  // 1: rpc.HostNic.Status:type_name -> rpc.Status
-	1,  // 2: rpc.HostNic.Phase:type_name -> rpc.Phase
-	4,  // 3: rpc.IPAMMessage.Args:type_name -> rpc.PodInfo
-	3,  // 4: rpc.IPAMMessage.Nic:type_name -> rpc.HostNic
-	9,  // 5: rpc.NicInfoList.items:type_name -> rpc.NicInfo
-	5,  // 6: rpc.CNIBackend.AddNetwork:input_type -> rpc.IPAMMessage
-	5,  // 7: rpc.CNIBackend.DelNetwork:input_type -> rpc.IPAMMessage
-	11, // 8: rpc.CNIBackend.ShowNics:input_type -> rpc.Nothing
-	11, // 9: rpc.CNIBackend.ClearNics:input_type -> rpc.Nothing
-	5,  // 10: rpc.CNIBackend.AddNetwork:output_type -> rpc.IPAMMessage
-	5,  // 11: rpc.CNIBackend.DelNetwork:output_type -> rpc.IPAMMessage
-	10, // 12: rpc.CNIBackend.ShowNics:output_type -> rpc.NicInfoList
-	11, // 13: rpc.CNIBackend.ClearNics:output_type -> rpc.Nothing
-	10, // [10:14] is the sub-list for method output_type
-	6,  // [6:10] is the sub-list for method input_type
-	6,  // [6:6] is the sub-list for extension type_name
-	6,  // [6:6] is the sub-list for extension extendee
-	0,  // [0:6] is the sub-list for field type_name
+
+// Deprecated: Use PodNetInfo.ProtoReflect.Descriptor instead.
+func (*PodNetInfo) Descriptor() ([]byte, []int) {
+	return file_pkg_rpc_message_proto_rawDescGZIP(), []int{11}
 }
 
-func init() { file_pkg_rpc_message_proto_init() }
-func file_pkg_rpc_message_proto_init() {
-	if File_pkg_rpc_message_proto != nil {
-		return
+func (x *PodNetInfo) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
 	}
-	if !protoimpl.UnsafeEnabled {
-		file_pkg_rpc_message_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*VxNet); i {
-			case 0:
-				return &v.state
+	return ""
+}
+
+func (x *PodNetInfo) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *PodNetInfo) GetContainter() string {
+	if x != nil {
+		return x.Containter
+	}
+	return ""
+}
+
+func (x *PodNetInfo) GetPodIP() string {
+	if x != nil {
+		return x.PodIP
+	}
+	return ""
+}
+
+func (x *PodNetInfo) GetHostNic() string {
+	if x != nil {
+		return x.HostNic
+	}
+	return ""
+}
+
+func (x *PodNetInfo) GetVxnet() string {
+	if x != nil {
+		return x.Vxnet
+	}
+	return ""
+}
+
+func (x *PodNetInfo) GetRouteTableNum() int32 {
+	if x != nil {
+		return x.RouteTableNum
+	}
+	return 0
+}
+
+type PodNetInfoList struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Items []*PodNetInfo `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+}
+
+func (x *PodNetInfoList) Reset() {
+	*x = PodNetInfoList{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_rpc_message_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PodNetInfoList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PodNetInfoList) ProtoMessage() {}
+
+func (x *PodNetInfoList) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_rpc_message_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PodNetInfoList.ProtoReflect.Descriptor instead.
+func (*PodNetInfoList) Descriptor() ([]byte, []int) {
+	return file_pkg_rpc_message_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *PodNetInfoList) GetItems() []*PodNetInfo {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+// PodNetwork is the full network record of a pod, with the kernel state installed for it
+type PodNetwork struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Pod        *PodInfo `protobuf:"bytes,1,opt,name=Pod,proto3" json:"Pod,omitempty"`
+	Nic        *HostNic `protobuf:"bytes,2,opt,name=Nic,proto3" json:"Nic,omitempty"`
+	Rules      []string `protobuf:"bytes,3,rep,name=Rules,proto3" json:"Rules,omitempty"`
+	ArpReplies []string `protobuf:"bytes,4,rep,name=ArpReplies,proto3" json:"ArpReplies,omitempty"`
+}
+
+func (x *PodNetwork) Reset() {
+	*x = PodNetwork{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_rpc_message_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PodNetwork) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PodNetwork) ProtoMessage() {}
+
+func (x *PodNetwork) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_rpc_message_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PodNetwork.ProtoReflect.Descriptor instead.
+func (*PodNetwork) Descriptor() ([]byte, []int) {
+	return file_pkg_rpc_message_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *PodNetwork) GetPod() *PodInfo {
+	if x != nil {
+		return x.Pod
+	}
+	return nil
+}
+
+func (x *PodNetwork) GetNic() *HostNic {
+	if x != nil {
+		return x.Nic
+	}
+	return nil
+}
+
+func (x *PodNetwork) GetRules() []string {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+func (x *PodNetwork) GetArpReplies() []string {
+	if x != nil {
+		return x.ArpReplies
+	}
+	return nil
+}
+
+// NicRequest selects a nic by vxnet or nic id, an empty request selects all nics
+type NicRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Vxnet string `protobuf:"bytes,1,opt,name=Vxnet,proto3" json:"Vxnet,omitempty"`
+	Nic   string `protobuf:"bytes,2,opt,name=Nic,proto3" json:"Nic,omitempty"`
+	// setup nic network again even if it is healthy
+	Resetup bool `protobuf:"varint,3,opt,name=Resetup,proto3" json:"Resetup,omitempty"`
+}
+
+func (x *NicRequest) Reset() {
+	*x = NicRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_rpc_message_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NicRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NicRequest) ProtoMessage() {}
+
+func (x *NicRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_rpc_message_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NicRequest.ProtoReflect.Descriptor instead.
+func (*NicRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_rpc_message_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *NicRequest) GetVxnet() string {
+	if x != nil {
+		return x.Vxnet
+	}
+	return ""
+}
+
+func (x *NicRequest) GetNic() string {
+	if x != nil {
+		return x.Nic
+	}
+	return ""
+}
+
+func (x *NicRequest) GetResetup() bool {
+	if x != nil {
+		return x.Resetup
+	}
+	return false
+}
+
+type NicRoutes struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id            string   `protobuf:"bytes,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	Vxnet         string   `protobuf:"bytes,2,opt,name=Vxnet,proto3" json:"Vxnet,omitempty"`
+	RouteTableNum int32    `protobuf:"varint,3,opt,name=RouteTableNum,proto3" json:"RouteTableNum,omitempty"`
+	Bridge        string   `protobuf:"bytes,4,opt,name=Bridge,proto3" json:"Bridge,omitempty"`
+	Routes        []string `protobuf:"bytes,5,rep,name=Routes,proto3" json:"Routes,omitempty"`
+	Rules         []string `protobuf:"bytes,6,rep,name=Rules,proto3" json:"Rules,omitempty"`
+}
+
+func (x *NicRoutes) Reset() {
+	*x = NicRoutes{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_rpc_message_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NicRoutes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NicRoutes) ProtoMessage() {}
+
+func (x *NicRoutes) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_rpc_message_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NicRoutes.ProtoReflect.Descriptor instead.
+func (*NicRoutes) Descriptor() ([]byte, []int) {
+	return file_pkg_rpc_message_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *NicRoutes) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *NicRoutes) GetVxnet() string {
+	if x != nil {
+		return x.Vxnet
+	}
+	return ""
+}
+
+func (x *NicRoutes) GetRouteTableNum() int32 {
+	if x != nil {
+		return x.RouteTableNum
+	}
+	return 0
+}
+
+func (x *NicRoutes) GetBridge() string {
+	if x != nil {
+		return x.Bridge
+	}
+	return ""
+}
+
+func (x *NicRoutes) GetRoutes() []string {
+	if x != nil {
+		return x.Routes
+	}
+	return nil
+}
+
+func (x *NicRoutes) GetRules() []string {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+type NicRouteList struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Items []*NicRoutes `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+}
+
+func (x *NicRouteList) Reset() {
+	*x = NicRouteList{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_rpc_message_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NicRouteList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NicRouteList) ProtoMessage() {}
+
+func (x *NicRouteList) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_rpc_message_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NicRouteList.ProtoReflect.Descriptor instead.
+func (*NicRouteList) Descriptor() ([]byte, []int) {
+	return file_pkg_rpc_message_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *NicRouteList) GetItems() []*NicRoutes {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+// WatchRequest resumes a watch after Sequence, 0 means only new events are sent
+type WatchRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sequence uint64 `protobuf:"varint,1,opt,name=Sequence,proto3" json:"Sequence,omitempty"`
+	Epoch    string `protobuf:"bytes,2,opt,name=Epoch,proto3" json:"Epoch,omitempty"`
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_rpc_message_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_rpc_message_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_rpc_message_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *WatchRequest) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *WatchRequest) GetEpoch() string {
+	if x != nil {
+		return x.Epoch
+	}
+	return ""
+}
+
+type NicEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sequence  uint64       `protobuf:"varint,1,opt,name=Sequence,proto3" json:"Sequence,omitempty"`
+	Type      NicEventType `protobuf:"varint,2,opt,name=Type,proto3,enum=rpc.NicEventType" json:"Type,omitempty"`
+	Nic       *HostNic     `protobuf:"bytes,3,opt,name=Nic,proto3" json:"Nic,omitempty"`
+	PrevPhase Phase        `protobuf:"varint,4,opt,name=PrevPhase,proto3,enum=rpc.Phase" json:"PrevPhase,omitempty"`
+	Message   string       `protobuf:"bytes,5,opt,name=Message,proto3" json:"Message,omitempty"`
+	Epoch     string       `protobuf:"bytes,6,opt,name=Epoch,proto3" json:"Epoch,omitempty"`
+}
+
+func (x *NicEvent) Reset() {
+	*x = NicEvent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_rpc_message_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NicEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NicEvent) ProtoMessage() {}
+
+func (x *NicEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_rpc_message_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NicEvent.ProtoReflect.Descriptor instead.
+func (*NicEvent) Descriptor() ([]byte, []int) {
+	return file_pkg_rpc_message_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *NicEvent) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *NicEvent) GetType() NicEventType {
+	if x != nil {
+		return x.Type
+	}
+	return NicEventType_NicPhase
+}
+
+func (x *NicEvent) GetNic() *HostNic {
+	if x != nil {
+		return x.Nic
+	}
+	return nil
+}
+
+func (x *NicEvent) GetPrevPhase() Phase {
+	if x != nil {
+		return x.PrevPhase
+	}
+	return Phase_Init
+}
+
+func (x *NicEvent) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *NicEvent) GetEpoch() string {
+	if x != nil {
+		return x.Epoch
+	}
+	return ""
+}
+
+type PodEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sequence uint64       `protobuf:"varint,1,opt,name=Sequence,proto3" json:"Sequence,omitempty"`
+	Type     PodEventType `protobuf:"varint,2,opt,name=Type,proto3,enum=rpc.PodEventType" json:"Type,omitempty"`
+	Pod      *PodInfo     `protobuf:"bytes,3,opt,name=Pod,proto3" json:"Pod,omitempty"`
+	Nic      *HostNic     `protobuf:"bytes,4,opt,name=Nic,proto3" json:"Nic,omitempty"`
+	Epoch    string       `protobuf:"bytes,5,opt,name=Epoch,proto3" json:"Epoch,omitempty"`
+}
+
+func (x *PodEvent) Reset() {
+	*x = PodEvent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_rpc_message_proto_msgTypes[19]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PodEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PodEvent) ProtoMessage() {}
+
+func (x *PodEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_rpc_message_proto_msgTypes[19]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PodEvent.ProtoReflect.Descriptor instead.
+func (*PodEvent) Descriptor() ([]byte, []int) {
+	return file_pkg_rpc_message_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *PodEvent) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *PodEvent) GetType() PodEventType {
+	if x != nil {
+		return x.Type
+	}
+	return PodEventType_PodAttach
+}
+
+func (x *PodEvent) GetPod() *PodInfo {
+	if x != nil {
+		return x.Pod
+	}
+	return nil
+}
+
+func (x *PodEvent) GetNic() *HostNic {
+	if x != nil {
+		return x.Nic
+	}
+	return nil
+}
+
+func (x *PodEvent) GetEpoch() string {
+	if x != nil {
+		return x.Epoch
+	}
+	return ""
+}
+
+type Stage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name    string  `protobuf:"bytes,1,opt,name=Name,proto3" json:"Name,omitempty"`
+	Seconds float64 `protobuf:"fixed64,2,opt,name=Seconds,proto3" json:"Seconds,omitempty"`
+}
+
+func (x *Stage) Reset() {
+	*x = Stage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_rpc_message_proto_msgTypes[20]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Stage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Stage) ProtoMessage() {}
+
+func (x *Stage) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_rpc_message_proto_msgTypes[20]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Stage.ProtoReflect.Descriptor instead.
+func (*Stage) Descriptor() ([]byte, []int) {
+	return file_pkg_rpc_message_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *Stage) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Stage) GetSeconds() float64 {
+	if x != nil {
+		return x.Seconds
+	}
+	return 0
+}
+
+type StageReport struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// add or del
+	Op     string   `protobuf:"bytes,1,opt,name=Op,proto3" json:"Op,omitempty"`
+	Stages []*Stage `protobuf:"bytes,2,rep,name=Stages,proto3" json:"Stages,omitempty"`
+	Failed bool     `protobuf:"varint,3,opt,name=Failed,proto3" json:"Failed,omitempty"`
+}
+
+func (x *StageReport) Reset() {
+	*x = StageReport{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_rpc_message_proto_msgTypes[21]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StageReport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StageReport) ProtoMessage() {}
+
+func (x *StageReport) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_rpc_message_proto_msgTypes[21]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StageReport.ProtoReflect.Descriptor instead.
+func (*StageReport) Descriptor() ([]byte, []int) {
+	return file_pkg_rpc_message_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *StageReport) GetOp() string {
+	if x != nil {
+		return x.Op
+	}
+	return ""
+}
+
+func (x *StageReport) GetStages() []*Stage {
+	if x != nil {
+		return x.Stages
+	}
+	return nil
+}
+
+func (x *StageReport) GetFailed() bool {
+	if x != nil {
+		return x.Failed
+	}
+	return false
+}
+
+var File_pkg_rpc_message_proto protoreflect.FileDescriptor
+
+var file_pkg_rpc_message_proto_rawDesc = []byte{
+	0x0a, 0x15, 0x70, 0x6b, 0x67, 0x2f, 0x72, 0x70, 0x63, 0x2f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x03, 0x72, 0x70, 0x63, 0x22, 0xb7, 0x01, 0x0a,
+	0x05, 0x56, 0x78, 0x4e, 0x65, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x02, 0x49, 0x44, 0x12, 0x18, 0x0a, 0x07, 0x47, 0x61, 0x74, 0x65, 0x77, 0x61,
+	0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x47, 0x61, 0x74, 0x65, 0x77, 0x61, 0x79,
+	0x12, 0x18, 0x0a, 0x07, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x07, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x12, 0x1a, 0x0a, 0x08, 0x52, 0x6f,
+	0x75, 0x74, 0x65, 0x72, 0x49, 0x44, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x52, 0x6f,
+	0x75, 0x74, 0x65, 0x72, 0x49, 0x44, 0x12, 0x18, 0x0a, 0x07, 0x49, 0x50, 0x53, 0x74, 0x61, 0x72,
+	0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x49, 0x50, 0x53, 0x74, 0x61, 0x72, 0x74,
+	0x12, 0x14, 0x0a, 0x05, 0x49, 0x50, 0x45, 0x6e, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x05, 0x49, 0x50, 0x45, 0x6e, 0x64, 0x12, 0x1e, 0x0a, 0x0a, 0x54, 0x75, 0x6e, 0x6e, 0x65, 0x6c,
+	0x54, 0x79, 0x70, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x54, 0x75, 0x6e, 0x6e,
+	0x65, 0x6c, 0x54, 0x79, 0x70, 0x65, 0x22, 0xc4, 0x02, 0x0a, 0x07, 0x48, 0x6f, 0x73, 0x74, 0x4e,
+	0x69, 0x63, 0x12, 0x20, 0x0a, 0x05, 0x56, 0x78, 0x4e, 0x65, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x0a, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x56, 0x78, 0x4e, 0x65, 0x74, 0x52, 0x05, 0x56,
+	0x78, 0x4e, 0x65, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x44, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x02, 0x49, 0x44, 0x12, 0x22, 0x0a, 0x0c, 0x48, 0x61, 0x72, 0x64, 0x77, 0x61, 0x72, 0x65,
+	0x41, 0x64, 0x64, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x48, 0x61, 0x72, 0x64,
+	0x77, 0x61, 0x72, 0x65, 0x41, 0x64, 0x64, 0x72, 0x12, 0x26, 0x0a, 0x0e, 0x50, 0x72, 0x69, 0x6d,
+	0x61, 0x72, 0x79, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0e, 0x50, 0x72, 0x69, 0x6d, 0x61, 0x72, 0x79, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73,
+	0x12, 0x1c, 0x0a, 0x09, 0x49, 0x73, 0x50, 0x72, 0x69, 0x6d, 0x61, 0x72, 0x79, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x09, 0x49, 0x73, 0x50, 0x72, 0x69, 0x6d, 0x61, 0x72, 0x79, 0x12, 0x14,
+	0x0a, 0x05, 0x55, 0x73, 0x69, 0x6e, 0x67, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x55,
+	0x73, 0x69, 0x6e, 0x67, 0x12, 0x1a, 0x0a, 0x08, 0x52, 0x65, 0x73, 0x65, 0x72, 0x76, 0x65, 0x64,
+	0x18, 0x07, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x52, 0x65, 0x73, 0x65, 0x72, 0x76, 0x65, 0x64,
+	0x12, 0x24, 0x0a, 0x0d, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x54, 0x61, 0x62, 0x6c, 0x65, 0x4e, 0x75,
+	0x6d, 0x18, 0x08, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0d, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x54, 0x61,
+	0x62, 0x6c, 0x65, 0x4e, 0x75, 0x6d, 0x12, 0x23, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x18, 0x09, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0b, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x53, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x52, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x20, 0x0a, 0x05, 0x50,
+	0x68, 0x61, 0x73, 0x65, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0a, 0x2e, 0x72, 0x70, 0x63,
+	0x2e, 0x50, 0x68, 0x61, 0x73, 0x65, 0x52, 0x05, 0x50, 0x68, 0x61, 0x73, 0x65, 0x22, 0x85, 0x02,
+	0x0a, 0x07, 0x50, 0x6f, 0x64, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x12, 0x0a, 0x04, 0x4e, 0x61, 0x6d,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1c, 0x0a,
+	0x09, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x09, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x43,
+	0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0a, 0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x4e,
+	0x65, 0x74, 0x6e, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x4e, 0x65, 0x74, 0x6e,
+	0x73, 0x12, 0x16, 0x0a, 0x06, 0x49, 0x66, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x06, 0x49, 0x66, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x4e, 0x69, 0x63,
+	0x54, 0x79, 0x70, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x4e, 0x69, 0x63, 0x54,
+	0x79, 0x70, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x50, 0x6f, 0x64, 0x49, 0x50, 0x18, 0x07, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x05, 0x50, 0x6f, 0x64, 0x49, 0x50, 0x12, 0x18, 0x0a, 0x07, 0x48, 0x6f, 0x73,
+	0x74, 0x4e, 0x69, 0x63, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x48, 0x6f, 0x73, 0x74,
+	0x4e, 0x69, 0x63, 0x12, 0x14, 0x0a, 0x05, 0x56, 0x78, 0x4e, 0x65, 0x74, 0x18, 0x09, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x05, 0x56, 0x78, 0x4e, 0x65, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x6e, 0x6f, 0x64,
+	0x65, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x6e, 0x6f, 0x64,
+	0x65, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0xc3, 0x01, 0x0a, 0x0b, 0x49, 0x50, 0x41, 0x4d, 0x4d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x20, 0x0a, 0x04, 0x41, 0x72, 0x67, 0x73, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x50, 0x6f, 0x64, 0x49, 0x6e, 0x66,
+	0x6f, 0x52, 0x04, 0x41, 0x72, 0x67, 0x73, 0x12, 0x1e, 0x0a, 0x03, 0x4e, 0x69, 0x63, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x48, 0x6f, 0x73, 0x74, 0x4e,
+	0x69, 0x63, 0x52, 0x03, 0x4e, 0x69, 0x63, 0x12, 0x12, 0x0a, 0x04, 0x50, 0x65, 0x65, 0x6b, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x50, 0x65, 0x65, 0x6b, 0x12, 0x16, 0x0a, 0x06, 0x44,
+	0x65, 0x6c, 0x65, 0x74, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x44, 0x65, 0x6c,
+	0x65, 0x74, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x50, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x02, 0x49, 0x50, 0x12, 0x1a, 0x0a, 0x08, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x12,
+	0x1a, 0x0a, 0x08, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x08, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x73, 0x22, 0x45, 0x0a, 0x0b, 0x56,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x1a, 0x0a, 0x08, 0x50, 0x72,
+	0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x50, 0x72,
+	0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x12, 0x1a, 0x0a, 0x08, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72,
+	0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72,
+	0x65, 0x73, 0x22, 0x57, 0x0a, 0x03, 0x56, 0x49, 0x50, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x44, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x49, 0x44, 0x12, 0x12, 0x0a, 0x04, 0x4e, 0x61, 0x6d,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a,
+	0x04, 0x41, 0x64, 0x64, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x41, 0x64, 0x64,
+	0x72, 0x12, 0x18, 0x0a, 0x07, 0x56, 0x78, 0x4e, 0x65, 0x74, 0x49, 0x44, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x07, 0x56, 0x78, 0x4e, 0x65, 0x74, 0x49, 0x44, 0x22, 0xe3, 0x01, 0x0a, 0x11,
+	0x53, 0x65, 0x63, 0x75, 0x72, 0x69, 0x74, 0x79, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x52, 0x75, 0x6c,
+	0x65, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x49,
+	0x44, 0x12, 0x12, 0x0a, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x28, 0x0a, 0x0f, 0x53, 0x65, 0x63, 0x75, 0x72, 0x69, 0x74,
+	0x79, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x49, 0x44, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f,
+	0x53, 0x65, 0x63, 0x75, 0x72, 0x69, 0x74, 0x79, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x49, 0x44, 0x12,
+	0x16, 0x0a, 0x06, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x06, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x50, 0x72, 0x6f, 0x74, 0x6f,
+	0x63, 0x6f, 0x6c, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x50, 0x72, 0x6f, 0x74, 0x6f,
+	0x63, 0x6f, 0x6c, 0x12, 0x12, 0x0a, 0x04, 0x56, 0x61, 0x6c, 0x33, 0x18, 0x08, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x56, 0x61, 0x6c, 0x33, 0x12, 0x1c, 0x0a, 0x09, 0x44, 0x69, 0x72, 0x65, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x44, 0x69, 0x72, 0x65,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x50, 0x72, 0x69, 0x6f, 0x72, 0x69, 0x74,
+	0x79, 0x18, 0x06, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x50, 0x72, 0x69, 0x6f, 0x72, 0x69, 0x74,
+	0x79, 0x22, 0xb4, 0x01, 0x0a, 0x04, 0x4e, 0x6f, 0x64, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x49, 0x6e,
+	0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a,
+	0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x44, 0x12, 0x16, 0x0a, 0x06, 0x4e, 0x6f,
+	0x64, 0x65, 0x49, 0x44, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x4e, 0x6f, 0x64, 0x65,
+	0x49, 0x44, 0x12, 0x20, 0x0a, 0x0b, 0x48, 0x6f, 0x73, 0x74, 0x4d, 0x61, 0x63, 0x68, 0x69, 0x6e,
+	0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x48, 0x6f, 0x73, 0x74, 0x4d, 0x61, 0x63,
+	0x68, 0x69, 0x6e, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x50, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x49,
+	0x50, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x50, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65,
+	0x49, 0x50, 0x12, 0x1c, 0x0a, 0x09, 0x43, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x49, 0x44, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x43, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x49, 0x44,
+	0x12, 0x16, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x87, 0x01, 0x0a, 0x07, 0x4e, 0x69, 0x63,
+	0x49, 0x6e, 0x66, 0x6f, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x02, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x56, 0x78, 0x6e, 0x65, 0x74, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x05, 0x56, 0x78, 0x6e, 0x65, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x50, 0x68,
+	0x61, 0x73, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x50, 0x68, 0x61, 0x73, 0x65,
+	0x12, 0x16, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x50, 0x6f, 0x64, 0x73,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x50, 0x6f, 0x64, 0x73, 0x12, 0x14, 0x0a, 0x05,
+	0x45, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x45, 0x72, 0x72,
+	0x6f, 0x72, 0x22, 0x31, 0x0a, 0x0b, 0x4e, 0x69, 0x63, 0x49, 0x6e, 0x66, 0x6f, 0x4c, 0x69, 0x73,
+	0x74, 0x12, 0x22, 0x0a, 0x05, 0x69, 0x74, 0x65, 0x6d, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x0c, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x4e, 0x69, 0x63, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x05,
+	0x69, 0x74, 0x65, 0x6d, 0x73, 0x22, 0x09, 0x0a, 0x07, 0x4e, 0x6f, 0x74, 0x68, 0x69, 0x6e, 0x67,
+	0x22, 0xca, 0x01, 0x0a, 0x0a, 0x50, 0x6f, 0x64, 0x4e, 0x65, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x12,
+	0x1c, 0x0a, 0x09, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x09, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x12, 0x12, 0x0a,
+	0x04, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x4e, 0x61, 0x6d,
+	0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x74, 0x65,
+	0x72, 0x12, 0x14, 0x0a, 0x05, 0x50, 0x6f, 0x64, 0x49, 0x50, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x05, 0x50, 0x6f, 0x64, 0x49, 0x50, 0x12, 0x18, 0x0a, 0x07, 0x48, 0x6f, 0x73, 0x74, 0x4e,
+	0x69, 0x63, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x48, 0x6f, 0x73, 0x74, 0x4e, 0x69,
+	0x63, 0x12, 0x14, 0x0a, 0x05, 0x56, 0x78, 0x6e, 0x65, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x05, 0x56, 0x78, 0x6e, 0x65, 0x74, 0x12, 0x24, 0x0a, 0x0d, 0x52, 0x6f, 0x75, 0x74, 0x65,
+	0x54, 0x61, 0x62, 0x6c, 0x65, 0x4e, 0x75, 0x6d, 0x18, 0x07, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0d,
+	0x52, 0x6f, 0x75, 0x74, 0x65, 0x54, 0x61, 0x62, 0x6c, 0x65, 0x4e, 0x75, 0x6d, 0x22, 0x37, 0x0a,
+	0x0e, 0x50, 0x6f, 0x64, 0x4e, 0x65, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x4c, 0x69, 0x73, 0x74, 0x12,
+	0x25, 0x0a, 0x05, 0x69, 0x74, 0x65, 0x6d, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f,
+	0x2e, 0x72, 0x70, 0x63, 0x2e, 0x50, 0x6f, 0x64, 0x4e, 0x65, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x52,
+	0x05, 0x69, 0x74, 0x65, 0x6d, 0x73, 0x22, 0x82, 0x01, 0x0a, 0x0a, 0x50, 0x6f, 0x64, 0x4e, 0x65,
+	0x74, 0x77, 0x6f, 0x72, 0x6b, 0x12, 0x1e, 0x0a, 0x03, 0x50, 0x6f, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x50, 0x6f, 0x64, 0x49, 0x6e, 0x66, 0x6f,
+	0x52, 0x03, 0x50, 0x6f, 0x64, 0x12, 0x1e, 0x0a, 0x03, 0x4e, 0x69, 0x63, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x48, 0x6f, 0x73, 0x74, 0x4e, 0x69, 0x63,
+	0x52, 0x03, 0x4e, 0x69, 0x63, 0x12, 0x14, 0x0a, 0x05, 0x52, 0x75, 0x6c, 0x65, 0x73, 0x18, 0x03,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x52, 0x75, 0x6c, 0x65, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x41,
+	0x72, 0x70, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x65, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52,
+	0x0a, 0x41, 0x72, 0x70, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x65, 0x73, 0x22, 0x4e, 0x0a, 0x0a, 0x4e,
+	0x69, 0x63, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x56, 0x78, 0x6e,
+	0x65, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x56, 0x78, 0x6e, 0x65, 0x74, 0x12,
+	0x10, 0x0a, 0x03, 0x4e, 0x69, 0x63, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x4e, 0x69,
+	0x63, 0x12, 0x18, 0x0a, 0x07, 0x52, 0x65, 0x73, 0x65, 0x74, 0x75, 0x70, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x07, 0x52, 0x65, 0x73, 0x65, 0x74, 0x75, 0x70, 0x22, 0x9d, 0x01, 0x0a, 0x09,
+	0x4e, 0x69, 0x63, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x73, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x56, 0x78, 0x6e,
+	0x65, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x56, 0x78, 0x6e, 0x65, 0x74, 0x12,
+	0x24, 0x0a, 0x0d, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x54, 0x61, 0x62, 0x6c, 0x65, 0x4e, 0x75, 0x6d,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0d, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x54, 0x61, 0x62,
+	0x6c, 0x65, 0x4e, 0x75, 0x6d, 0x12, 0x16, 0x0a, 0x06, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65, 0x12, 0x16, 0x0a,
+	0x06, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x52,
+	0x6f, 0x75, 0x74, 0x65, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x52, 0x75, 0x6c, 0x65, 0x73, 0x18, 0x06,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x52, 0x75, 0x6c, 0x65, 0x73, 0x22, 0x34, 0x0a, 0x0c, 0x4e,
+	0x69, 0x63, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x24, 0x0a, 0x05, 0x69,
+	0x74, 0x65, 0x6d, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x72, 0x70, 0x63,
+	0x2e, 0x4e, 0x69, 0x63, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x73, 0x52, 0x05, 0x69, 0x74, 0x65, 0x6d,
+	0x73, 0x22, 0x40, 0x0a, 0x0c, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x1a, 0x0a, 0x08, 0x53, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x08, 0x53, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x14, 0x0a,
+	0x05, 0x45, 0x70, 0x6f, 0x63, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x45, 0x70,
+	0x6f, 0x63, 0x68, 0x22, 0xc7, 0x01, 0x0a, 0x08, 0x4e, 0x69, 0x63, 0x45, 0x76, 0x65, 0x6e, 0x74,
+	0x12, 0x1a, 0x0a, 0x08, 0x53, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x08, 0x53, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x25, 0x0a, 0x04,
+	0x54, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x72, 0x70, 0x63,
+	0x2e, 0x4e, 0x69, 0x63, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x54,
+	0x79, 0x70, 0x65, 0x12, 0x1e, 0x0a, 0x03, 0x4e, 0x69, 0x63, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x0c, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x48, 0x6f, 0x73, 0x74, 0x4e, 0x69, 0x63, 0x52, 0x03,
+	0x4e, 0x69, 0x63, 0x12, 0x28, 0x0a, 0x09, 0x50, 0x72, 0x65, 0x76, 0x50, 0x68, 0x61, 0x73, 0x65,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0a, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x50, 0x68, 0x61,
+	0x73, 0x65, 0x52, 0x09, 0x50, 0x72, 0x65, 0x76, 0x50, 0x68, 0x61, 0x73, 0x65, 0x12, 0x18, 0x0a,
+	0x07, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
+	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x45, 0x70, 0x6f, 0x63, 0x68,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x45, 0x70, 0x6f, 0x63, 0x68, 0x22, 0xa3, 0x01,
+	0x0a, 0x08, 0x50, 0x6f, 0x64, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x53, 0x65,
+	0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x53, 0x65,
+	0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x25, 0x0a, 0x04, 0x54, 0x79, 0x70, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x50, 0x6f, 0x64, 0x45, 0x76,
+	0x65, 0x6e, 0x74, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x54, 0x79, 0x70, 0x65, 0x12, 0x1e, 0x0a,
+	0x03, 0x50, 0x6f, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x72, 0x70, 0x63,
+	0x2e, 0x50, 0x6f, 0x64, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x03, 0x50, 0x6f, 0x64, 0x12, 0x1e, 0x0a,
+	0x03, 0x4e, 0x69, 0x63, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x72, 0x70, 0x63,
+	0x2e, 0x48, 0x6f, 0x73, 0x74, 0x4e, 0x69, 0x63, 0x52, 0x03, 0x4e, 0x69, 0x63, 0x12, 0x14, 0x0a,
+	0x05, 0x45, 0x70, 0x6f, 0x63, 0x68, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x45, 0x70,
+	0x6f, 0x63, 0x68, 0x22, 0x35, 0x0a, 0x05, 0x53, 0x74, 0x61, 0x67, 0x65, 0x12, 0x12, 0x0a, 0x04,
+	0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x4e, 0x61, 0x6d, 0x65,
+	0x12, 0x18, 0x0a, 0x07, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x01, 0x52, 0x07, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x22, 0x59, 0x0a, 0x0b, 0x53, 0x74,
+	0x61, 0x67, 0x65, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x4f, 0x70, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x4f, 0x70, 0x12, 0x22, 0x0a, 0x06, 0x53, 0x74, 0x61,
+	0x67, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x72, 0x70, 0x63, 0x2e,
+	0x53, 0x74, 0x61, 0x67, 0x65, 0x52, 0x06, 0x53, 0x74, 0x61, 0x67, 0x65, 0x73, 0x12, 0x16, 0x0a,
+	0x06, 0x46, 0x61, 0x69, 0x6c, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x46,
+	0x61, 0x69, 0x6c, 0x65, 0x64, 0x2a, 0x43, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12,
+	0x08, 0x0a, 0x04, 0x46, 0x52, 0x45, 0x45, 0x10, 0x00, 0x12, 0x09, 0x0a, 0x05, 0x55, 0x53, 0x49,
+	0x4e, 0x47, 0x10, 0x01, 0x12, 0x0c, 0x0a, 0x08, 0x44, 0x45, 0x4c, 0x45, 0x54, 0x49, 0x4e, 0x47,
+	0x10, 0x02, 0x12, 0x09, 0x0a, 0x05, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x03, 0x12, 0x0b, 0x0a,
+	0x07, 0x44, 0x45, 0x4c, 0x45, 0x54, 0x45, 0x44, 0x10, 0x04, 0x2a, 0x58, 0x0a, 0x05, 0x50, 0x68,
+	0x61, 0x73, 0x65, 0x12, 0x08, 0x0a, 0x04, 0x49, 0x6e, 0x69, 0x74, 0x10, 0x00, 0x12, 0x13, 0x0a,
+	0x0f, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x41, 0x6e, 0x64, 0x41, 0x74, 0x74, 0x61, 0x63, 0x68,
+	0x10, 0x01, 0x12, 0x0e, 0x0a, 0x0a, 0x4a, 0x6f, 0x69, 0x6e, 0x42, 0x72, 0x69, 0x64, 0x67, 0x65,
+	0x10, 0x02, 0x12, 0x11, 0x0a, 0x0d, 0x53, 0x65, 0x74, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x54, 0x61,
+	0x62, 0x6c, 0x65, 0x10, 0x03, 0x12, 0x0d, 0x0a, 0x09, 0x53, 0x75, 0x63, 0x63, 0x65, 0x65, 0x64,
+	0x65, 0x64, 0x10, 0x04, 0x2a, 0x38, 0x0a, 0x0c, 0x4e, 0x69, 0x63, 0x45, 0x76, 0x65, 0x6e, 0x74,
+	0x54, 0x79, 0x70, 0x65, 0x12, 0x0c, 0x0a, 0x08, 0x4e, 0x69, 0x63, 0x50, 0x68, 0x61, 0x73, 0x65,
+	0x10, 0x00, 0x12, 0x0d, 0x0a, 0x09, 0x4e, 0x69, 0x63, 0x52, 0x65, 0x70, 0x61, 0x69, 0x72, 0x10,
+	0x01, 0x12, 0x0b, 0x0a, 0x07, 0x4e, 0x69, 0x63, 0x46, 0x72, 0x65, 0x65, 0x10, 0x02, 0x2a, 0x2c,
+	0x0a, 0x0c, 0x50, 0x6f, 0x64, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x54, 0x79, 0x70, 0x65, 0x12, 0x0d,
+	0x0a, 0x09, 0x50, 0x6f, 0x64, 0x41, 0x74, 0x74, 0x61, 0x63, 0x68, 0x10, 0x00, 0x12, 0x0d, 0x0a,
+	0x09, 0x50, 0x6f, 0x64, 0x44, 0x65, 0x74, 0x61, 0x63, 0x68, 0x10, 0x01, 0x32, 0xd7, 0x04, 0x0a,
+	0x0a, 0x43, 0x4e, 0x49, 0x42, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x12, 0x2f, 0x0a, 0x07, 0x56,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x10, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x56, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x66, 0x6f, 0x1a, 0x10, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x56,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x66, 0x6f, 0x22, 0x00, 0x12, 0x32, 0x0a, 0x0a,
+	0x41, 0x64, 0x64, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x12, 0x10, 0x2e, 0x72, 0x70, 0x63,
+	0x2e, 0x49, 0x50, 0x41, 0x4d, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x10, 0x2e, 0x72,
+	0x70, 0x63, 0x2e, 0x49, 0x50, 0x41, 0x4d, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x00,
+	0x12, 0x32, 0x0a, 0x0a, 0x44, 0x65, 0x6c, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x12, 0x10,
+	0x2e, 0x72, 0x70, 0x63, 0x2e, 0x49, 0x50, 0x41, 0x4d, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x1a, 0x10, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x49, 0x50, 0x41, 0x4d, 0x4d, 0x65, 0x73, 0x73, 0x61,
+	0x67, 0x65, 0x22, 0x00, 0x12, 0x2c, 0x0a, 0x08, 0x53, 0x68, 0x6f, 0x77, 0x4e, 0x69, 0x63, 0x73,
+	0x12, 0x0c, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x4e, 0x6f, 0x74, 0x68, 0x69, 0x6e, 0x67, 0x1a, 0x10,
+	0x2e, 0x72, 0x70, 0x63, 0x2e, 0x4e, 0x69, 0x63, 0x49, 0x6e, 0x66, 0x6f, 0x4c, 0x69, 0x73, 0x74,
+	0x22, 0x00, 0x12, 0x29, 0x0a, 0x09, 0x43, 0x6c, 0x65, 0x61, 0x72, 0x4e, 0x69, 0x63, 0x73, 0x12,
+	0x0c, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x4e, 0x6f, 0x74, 0x68, 0x69, 0x6e, 0x67, 0x1a, 0x0c, 0x2e,
+	0x72, 0x70, 0x63, 0x2e, 0x4e, 0x6f, 0x74, 0x68, 0x69, 0x6e, 0x67, 0x22, 0x00, 0x12, 0x30, 0x0a,
+	0x0c, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x53, 0x74, 0x61, 0x67, 0x65, 0x73, 0x12, 0x10, 0x2e,
+	0x72, 0x70, 0x63, 0x2e, 0x53, 0x74, 0x61, 0x67, 0x65, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x1a,
+	0x0c, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x4e, 0x6f, 0x74, 0x68, 0x69, 0x6e, 0x67, 0x22, 0x00, 0x12,
+	0x2f, 0x0a, 0x08, 0x4c, 0x69, 0x73, 0x74, 0x50, 0x6f, 0x64, 0x73, 0x12, 0x0c, 0x2e, 0x72, 0x70,
+	0x63, 0x2e, 0x4e, 0x6f, 0x74, 0x68, 0x69, 0x6e, 0x67, 0x1a, 0x13, 0x2e, 0x72, 0x70, 0x63, 0x2e,
+	0x50, 0x6f, 0x64, 0x4e, 0x65, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x4c, 0x69, 0x73, 0x74, 0x22, 0x00,
+	0x12, 0x29, 0x0a, 0x06, 0x47, 0x65, 0x74, 0x50, 0x6f, 0x64, 0x12, 0x0c, 0x2e, 0x72, 0x70, 0x63,
+	0x2e, 0x50, 0x6f, 0x64, 0x49, 0x6e, 0x66, 0x6f, 0x1a, 0x0f, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x50,
+	0x6f, 0x64, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x22, 0x00, 0x12, 0x35, 0x0a, 0x0d, 0x44,
+	0x75, 0x6d, 0x70, 0x4e, 0x69, 0x63, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x73, 0x12, 0x0f, 0x2e, 0x72,
+	0x70, 0x63, 0x2e, 0x4e, 0x69, 0x63, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x11, 0x2e,
+	0x72, 0x70, 0x63, 0x2e, 0x4e, 0x69, 0x63, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x4c, 0x69, 0x73, 0x74,
+	0x22, 0x00, 0x12, 0x2c, 0x0a, 0x09, 0x52, 0x65, 0x70, 0x61, 0x69, 0x72, 0x4e, 0x69, 0x63, 0x12,
+	0x0f, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x4e, 0x69, 0x63, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x0c, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x4e, 0x69, 0x63, 0x49, 0x6e, 0x66, 0x6f, 0x22, 0x00,
+	0x12, 0x31, 0x0a, 0x09, 0x57, 0x61, 0x74, 0x63, 0x68, 0x4e, 0x69, 0x63, 0x73, 0x12, 0x11, 0x2e,
+	0x72, 0x70, 0x63, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x0d, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x4e, 0x69, 0x63, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x22,
+	0x00, 0x30, 0x01, 0x12, 0x31, 0x0a, 0x09, 0x57, 0x61, 0x74, 0x63, 0x68, 0x50, 0x6f, 0x64, 0x73,
+	0x12, 0x11, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x0d, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x50, 0x6f, 0x64, 0x45, 0x76, 0x65,
+	0x6e, 0x74, 0x22, 0x00, 0x30, 0x01, 0x42, 0x0b, 0x5a, 0x09, 0x2e, 0x2f, 0x70, 0x6b, 0x67, 0x2f,
+	0x72, 0x70, 0x63, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_pkg_rpc_message_proto_rawDescOnce sync.Once
+	file_pkg_rpc_message_proto_rawDescData = file_pkg_rpc_message_proto_rawDesc
+)
+
+func file_pkg_rpc_message_proto_rawDescGZIP() []byte {
+	file_pkg_rpc_message_proto_rawDescOnce.Do(func() {
+		file_pkg_rpc_message_proto_rawDescData = protoimpl.X.CompressGZIP(file_pkg_rpc_message_proto_rawDescData)
+	})
+	return file_pkg_rpc_message_proto_rawDescData
+}
+
+var file_pkg_rpc_message_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
+var file_pkg_rpc_message_proto_msgTypes = make([]protoimpl.MessageInfo, 22)
+var file_pkg_rpc_message_proto_goTypes = []interface{}{
+	(Status)(0),               // 0: rpc.Status
+	(Phase)(0),                // 1: rpc.Phase
+	(NicEventType)(0),         // 2: rpc.NicEventType
+	(PodEventType)(0),         // 3: rpc.PodEventType
+	(*VxNet)(nil),             // 4: rpc.VxNet
+	(*HostNic)(nil),           // 5: rpc.HostNic
+	(*PodInfo)(nil),           // 6: rpc.PodInfo
+	(*IPAMMessage)(nil),       // 7: rpc.IPAMMessage
+	(*VersionInfo)(nil),       // 8: rpc.VersionInfo
+	(*VIP)(nil),               // 9: rpc.VIP
+	(*SecurityGroupRule)(nil), // 10: rpc.SecurityGroupRule
+	(*Node)(nil),              // 11: rpc.Node
+	(*NicInfo)(nil),           // 12: rpc.NicInfo
+	(*NicInfoList)(nil),       // 13: rpc.NicInfoList
+	(*Nothing)(nil),           // 14: rpc.Nothing
+	(*PodNetInfo)(nil),        // 15: rpc.PodNetInfo
+	(*PodNetInfoList)(nil),    // 16: rpc.PodNetInfoList
+	(*PodNetwork)(nil),        // 17: rpc.PodNetwork
+	(*NicRequest)(nil),        // 18: rpc.NicRequest
+	(*NicRoutes)(nil),         // 19: rpc.NicRoutes
+	(*NicRouteList)(nil),      // 20: rpc.NicRouteList
+	(*WatchRequest)(nil),      // 21: rpc.WatchRequest
+	(*NicEvent)(nil),          // 22: rpc.NicEvent
+	(*PodEvent)(nil),          // 23: rpc.PodEvent
+	(*Stage)(nil),             // 24: rpc.Stage
+	(*StageReport)(nil),       // 25: rpc.StageReport
+}
+var file_pkg_rpc_message_proto_depIdxs = []int32{
+	4,  // 0: rpc.HostNic.VxNet:type_name -> rpc.VxNet
+	0,  // 1: rpc.HostNic.Status:type_name -> rpc.Status
+	1,  // 2: rpc.HostNic.Phase:type_name -> rpc.Phase
+	6,  // 3: rpc.IPAMMessage.Args:type_name -> rpc.PodInfo
+	5,  // 4: rpc.IPAMMessage.Nic:type_name -> rpc.HostNic
+	12, // 5: rpc.NicInfoList.items:type_name -> rpc.NicInfo
+	15, // 6: rpc.PodNetInfoList.items:type_name -> rpc.PodNetInfo
+	6,  // 7: rpc.PodNetwork.Pod:type_name -> rpc.PodInfo
+	5,  // 8: rpc.PodNetwork.Nic:type_name -> rpc.HostNic
+	19, // 9: rpc.NicRouteList.items:type_name -> rpc.NicRoutes
+	2,  // 10: rpc.NicEvent.Type:type_name -> rpc.NicEventType
+	5,  // 11: rpc.NicEvent.Nic:type_name -> rpc.HostNic
+	1,  // 12: rpc.NicEvent.PrevPhase:type_name -> rpc.Phase
+	3,  // 13: rpc.PodEvent.Type:type_name -> rpc.PodEventType
+	6,  // 14: rpc.PodEvent.Pod:type_name -> rpc.PodInfo
+	5,  // 15: rpc.PodEvent.Nic:type_name -> rpc.HostNic
+	24, // 16: rpc.StageReport.Stages:type_name -> rpc.Stage
+	8,  // 17: rpc.CNIBackend.Version:input_type -> rpc.VersionInfo
+	7,  // 18: rpc.CNIBackend.AddNetwork:input_type -> rpc.IPAMMessage
+	7,  // 19: rpc.CNIBackend.DelNetwork:input_type -> rpc.IPAMMessage
+	14, // 20: rpc.CNIBackend.ShowNics:input_type -> rpc.Nothing
+	14, // 21: rpc.CNIBackend.ClearNics:input_type -> rpc.Nothing
+	25, // 22: rpc.CNIBackend.ReportStages:input_type -> rpc.StageReport
+	14, // 23: rpc.CNIBackend.ListPods:input_type -> rpc.Nothing
+	6,  // 24: rpc.CNIBackend.GetPod:input_type -> rpc.PodInfo
+	18, // 25: rpc.CNIBackend.DumpNicRoutes:input_type -> rpc.NicRequest
+	18, // 26: rpc.CNIBackend.RepairNic:input_type -> rpc.NicRequest
+	21, // 27: rpc.CNIBackend.WatchNics:input_type -> rpc.WatchRequest
+	21, // 28: rpc.CNIBackend.WatchPods:input_type -> rpc.WatchRequest
+	8,  // 29: rpc.CNIBackend.Version:output_type -> rpc.VersionInfo
+	7,  // 30: rpc.CNIBackend.AddNetwork:output_type -> rpc.IPAMMessage
+	7,  // 31: rpc.CNIBackend.DelNetwork:output_type -> rpc.IPAMMessage
+	13, // 32: rpc.CNIBackend.ShowNics:output_type -> rpc.NicInfoList
+	14, // 33: rpc.CNIBackend.ClearNics:output_type -> rpc.Nothing
+	14, // 34: rpc.CNIBackend.ReportStages:output_type -> rpc.Nothing
+	16, // 35: rpc.CNIBackend.ListPods:output_type -> rpc.PodNetInfoList
+	17, // 36: rpc.CNIBackend.GetPod:output_type -> rpc.PodNetwork
+	20, // 37: rpc.CNIBackend.DumpNicRoutes:output_type -> rpc.NicRouteList
+	12, // 38: rpc.CNIBackend.RepairNic:output_type -> rpc.NicInfo
+	22, // 39: rpc.CNIBackend.WatchNics:output_type -> rpc.NicEvent
+	23, // 40: rpc.CNIBackend.WatchPods:output_type -> rpc.PodEvent
+	29, // [29:41] is the sub-list for method output_type
+	17, // [17:29] is the sub-list for method input_type
+	17, // [17:17] is the sub-list for extension type_name
+	17, // [17:17] is the sub-list for extension extendee
+	0,  // [0:17] is the sub-list for field type_name
+}
+
+func init() { file_pkg_rpc_message_proto_init() }
+func file_pkg_rpc_message_proto_init() {
+	if File_pkg_rpc_message_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_pkg_rpc_message_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VxNet); i {
+			case 0:
+				return &v.state
 			case 1:
 				return &v.sizeCache
 			case 2:
@@ -1203,7 +2295,7 @@ func file_pkg_rpc_message_proto_init() {
 			}
 		}
 		file_pkg_rpc_message_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*VIP); i {
+			switch v := v.(*VersionInfo); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1215,7 +2307,7 @@ func file_pkg_rpc_message_proto_init() {
 			}
 		}
 		file_pkg_rpc_message_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SecurityGroupRule); i {
+			switch v := v.(*VIP); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1227,7 +2319,7 @@ func file_pkg_rpc_message_proto_init() {
 			}
 		}
 		file_pkg_rpc_message_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Node); i {
+			switch v := v.(*SecurityGroupRule); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1239,7 +2331,7 @@ func file_pkg_rpc_message_proto_init() {
 			}
 		}
 		file_pkg_rpc_message_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*NicInfo); i {
+			switch v := v.(*Node); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1251,7 +2343,7 @@ func file_pkg_rpc_message_proto_init() {
 			}
 		}
 		file_pkg_rpc_message_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*NicInfoList); i {
+			switch v := v.(*NicInfo); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1263,6 +2355,18 @@ func file_pkg_rpc_message_proto_init() {
 			}
 		}
 		file_pkg_rpc_message_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*NicInfoList); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_rpc_message_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Nothing); i {
 			case 0:
 				return &v.state
@@ -1274,14 +2378,146 @@ func file_pkg_rpc_message_proto_init() {
 				return nil
 			}
 		}
+		file_pkg_rpc_message_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PodNetInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_rpc_message_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PodNetInfoList); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_rpc_message_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PodNetwork); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_rpc_message_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*NicRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_rpc_message_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*NicRoutes); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_rpc_message_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*NicRouteList); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_rpc_message_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WatchRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_rpc_message_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*NicEvent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_rpc_message_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PodEvent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_rpc_message_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Stage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_rpc_message_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StageReport); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_pkg_rpc_message_proto_rawDesc,
-			NumEnums:      2,
-			NumMessages:   10,
+			NumEnums:      4,
+			NumMessages:   22,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
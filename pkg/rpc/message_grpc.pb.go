@@ -19,20 +19,41 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	CNIBackend_AddNetwork_FullMethodName = "/rpc.CNIBackend/AddNetwork"
-	CNIBackend_DelNetwork_FullMethodName = "/rpc.CNIBackend/DelNetwork"
-	CNIBackend_ShowNics_FullMethodName   = "/rpc.CNIBackend/ShowNics"
-	CNIBackend_ClearNics_FullMethodName  = "/rpc.CNIBackend/ClearNics"
+	CNIBackend_Version_FullMethodName       = "/rpc.CNIBackend/Version"
+	CNIBackend_AddNetwork_FullMethodName    = "/rpc.CNIBackend/AddNetwork"
+	CNIBackend_DelNetwork_FullMethodName    = "/rpc.CNIBackend/DelNetwork"
+	CNIBackend_ShowNics_FullMethodName      = "/rpc.CNIBackend/ShowNics"
+	CNIBackend_ClearNics_FullMethodName     = "/rpc.CNIBackend/ClearNics"
+	CNIBackend_ReportStages_FullMethodName  = "/rpc.CNIBackend/ReportStages"
+	CNIBackend_ListPods_FullMethodName      = "/rpc.CNIBackend/ListPods"
+	CNIBackend_GetPod_FullMethodName        = "/rpc.CNIBackend/GetPod"
+	CNIBackend_DumpNicRoutes_FullMethodName = "/rpc.CNIBackend/DumpNicRoutes"
+	CNIBackend_RepairNic_FullMethodName     = "/rpc.CNIBackend/RepairNic"
+	CNIBackend_WatchNics_FullMethodName     = "/rpc.CNIBackend/WatchNics"
+	CNIBackend_WatchPods_FullMethodName     = "/rpc.CNIBackend/WatchPods"
 )
 
 // CNIBackendClient is the client API for CNIBackend service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type CNIBackendClient interface {
+	// Version is used by peers to negotiate protocol version and features,
+	// daemons which do not implement it speak the legacy protocol.
+	Version(ctx context.Context, in *VersionInfo, opts ...grpc.CallOption) (*VersionInfo, error)
 	AddNetwork(ctx context.Context, in *IPAMMessage, opts ...grpc.CallOption) (*IPAMMessage, error)
 	DelNetwork(ctx context.Context, in *IPAMMessage, opts ...grpc.CallOption) (*IPAMMessage, error)
 	ShowNics(ctx context.Context, in *Nothing, opts ...grpc.CallOption) (*NicInfoList, error)
 	ClearNics(ctx context.Context, in *Nothing, opts ...grpc.CallOption) (*Nothing, error)
+	// ReportStages is called by the plugin to export the timings of its own stages
+	ReportStages(ctx context.Context, in *StageReport, opts ...grpc.CallOption) (*Nothing, error)
+	// debug and introspection
+	ListPods(ctx context.Context, in *Nothing, opts ...grpc.CallOption) (*PodNetInfoList, error)
+	GetPod(ctx context.Context, in *PodInfo, opts ...grpc.CallOption) (*PodNetwork, error)
+	DumpNicRoutes(ctx context.Context, in *NicRequest, opts ...grpc.CallOption) (*NicRouteList, error)
+	RepairNic(ctx context.Context, in *NicRequest, opts ...grpc.CallOption) (*NicInfo, error)
+	// watch state changes of the allocator
+	WatchNics(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (CNIBackend_WatchNicsClient, error)
+	WatchPods(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (CNIBackend_WatchPodsClient, error)
 }
 
 type cNIBackendClient struct {
@@ -43,6 +64,15 @@ func NewCNIBackendClient(cc grpc.ClientConnInterface) CNIBackendClient {
 	return &cNIBackendClient{cc}
 }
 
+func (c *cNIBackendClient) Version(ctx context.Context, in *VersionInfo, opts ...grpc.CallOption) (*VersionInfo, error) {
+	out := new(VersionInfo)
+	err := c.cc.Invoke(ctx, CNIBackend_Version_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *cNIBackendClient) AddNetwork(ctx context.Context, in *IPAMMessage, opts ...grpc.CallOption) (*IPAMMessage, error) {
 	out := new(IPAMMessage)
 	err := c.cc.Invoke(ctx, CNIBackend_AddNetwork_FullMethodName, in, out, opts...)
@@ -79,20 +109,145 @@ func (c *cNIBackendClient) ClearNics(ctx context.Context, in *Nothing, opts ...g
 	return out, nil
 }
 
+func (c *cNIBackendClient) ReportStages(ctx context.Context, in *StageReport, opts ...grpc.CallOption) (*Nothing, error) {
+	out := new(Nothing)
+	err := c.cc.Invoke(ctx, CNIBackend_ReportStages_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cNIBackendClient) ListPods(ctx context.Context, in *Nothing, opts ...grpc.CallOption) (*PodNetInfoList, error) {
+	out := new(PodNetInfoList)
+	err := c.cc.Invoke(ctx, CNIBackend_ListPods_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cNIBackendClient) GetPod(ctx context.Context, in *PodInfo, opts ...grpc.CallOption) (*PodNetwork, error) {
+	out := new(PodNetwork)
+	err := c.cc.Invoke(ctx, CNIBackend_GetPod_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cNIBackendClient) DumpNicRoutes(ctx context.Context, in *NicRequest, opts ...grpc.CallOption) (*NicRouteList, error) {
+	out := new(NicRouteList)
+	err := c.cc.Invoke(ctx, CNIBackend_DumpNicRoutes_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cNIBackendClient) RepairNic(ctx context.Context, in *NicRequest, opts ...grpc.CallOption) (*NicInfo, error) {
+	out := new(NicInfo)
+	err := c.cc.Invoke(ctx, CNIBackend_RepairNic_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cNIBackendClient) WatchNics(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (CNIBackend_WatchNicsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &CNIBackend_ServiceDesc.Streams[0], CNIBackend_WatchNics_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &cNIBackendWatchNicsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type CNIBackend_WatchNicsClient interface {
+	Recv() (*NicEvent, error)
+	grpc.ClientStream
+}
+
+type cNIBackendWatchNicsClient struct {
+	grpc.ClientStream
+}
+
+func (x *cNIBackendWatchNicsClient) Recv() (*NicEvent, error) {
+	m := new(NicEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *cNIBackendClient) WatchPods(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (CNIBackend_WatchPodsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &CNIBackend_ServiceDesc.Streams[1], CNIBackend_WatchPods_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &cNIBackendWatchPodsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type CNIBackend_WatchPodsClient interface {
+	Recv() (*PodEvent, error)
+	grpc.ClientStream
+}
+
+type cNIBackendWatchPodsClient struct {
+	grpc.ClientStream
+}
+
+func (x *cNIBackendWatchPodsClient) Recv() (*PodEvent, error) {
+	m := new(PodEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // CNIBackendServer is the server API for CNIBackend service.
 // All implementations should embed UnimplementedCNIBackendServer
 // for forward compatibility
 type CNIBackendServer interface {
+	// Version is used by peers to negotiate protocol version and features,
+	// daemons which do not implement it speak the legacy protocol.
+	Version(context.Context, *VersionInfo) (*VersionInfo, error)
 	AddNetwork(context.Context, *IPAMMessage) (*IPAMMessage, error)
 	DelNetwork(context.Context, *IPAMMessage) (*IPAMMessage, error)
 	ShowNics(context.Context, *Nothing) (*NicInfoList, error)
 	ClearNics(context.Context, *Nothing) (*Nothing, error)
+	// ReportStages is called by the plugin to export the timings of its own stages
+	ReportStages(context.Context, *StageReport) (*Nothing, error)
+	// debug and introspection
+	ListPods(context.Context, *Nothing) (*PodNetInfoList, error)
+	GetPod(context.Context, *PodInfo) (*PodNetwork, error)
+	DumpNicRoutes(context.Context, *NicRequest) (*NicRouteList, error)
+	RepairNic(context.Context, *NicRequest) (*NicInfo, error)
+	// watch state changes of the allocator
+	WatchNics(*WatchRequest, CNIBackend_WatchNicsServer) error
+	WatchPods(*WatchRequest, CNIBackend_WatchPodsServer) error
 }
 
 // UnimplementedCNIBackendServer should be embedded to have forward compatible implementations.
 type UnimplementedCNIBackendServer struct {
 }
 
+func (UnimplementedCNIBackendServer) Version(context.Context, *VersionInfo) (*VersionInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Version not implemented")
+}
 func (UnimplementedCNIBackendServer) AddNetwork(context.Context, *IPAMMessage) (*IPAMMessage, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AddNetwork not implemented")
 }
@@ -105,6 +260,27 @@ func (UnimplementedCNIBackendServer) ShowNics(context.Context, *Nothing) (*NicIn
 func (UnimplementedCNIBackendServer) ClearNics(context.Context, *Nothing) (*Nothing, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ClearNics not implemented")
 }
+func (UnimplementedCNIBackendServer) ReportStages(context.Context, *StageReport) (*Nothing, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportStages not implemented")
+}
+func (UnimplementedCNIBackendServer) ListPods(context.Context, *Nothing) (*PodNetInfoList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPods not implemented")
+}
+func (UnimplementedCNIBackendServer) GetPod(context.Context, *PodInfo) (*PodNetwork, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPod not implemented")
+}
+func (UnimplementedCNIBackendServer) DumpNicRoutes(context.Context, *NicRequest) (*NicRouteList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DumpNicRoutes not implemented")
+}
+func (UnimplementedCNIBackendServer) RepairNic(context.Context, *NicRequest) (*NicInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RepairNic not implemented")
+}
+func (UnimplementedCNIBackendServer) WatchNics(*WatchRequest, CNIBackend_WatchNicsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchNics not implemented")
+}
+func (UnimplementedCNIBackendServer) WatchPods(*WatchRequest, CNIBackend_WatchPodsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchPods not implemented")
+}
 
 // UnsafeCNIBackendServer may be embedded to opt out of forward compatibility for this service.
 // Use of this interface is not recommended, as added methods to CNIBackendServer will
@@ -117,6 +293,24 @@ func RegisterCNIBackendServer(s grpc.ServiceRegistrar, srv CNIBackendServer) {
 	s.RegisterService(&CNIBackend_ServiceDesc, srv)
 }
 
+func _CNIBackend_Version_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VersionInfo)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CNIBackendServer).Version(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CNIBackend_Version_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CNIBackendServer).Version(ctx, req.(*VersionInfo))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _CNIBackend_AddNetwork_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(IPAMMessage)
 	if err := dec(in); err != nil {
@@ -189,6 +383,138 @@ func _CNIBackend_ClearNics_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CNIBackend_ReportStages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StageReport)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CNIBackendServer).ReportStages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CNIBackend_ReportStages_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CNIBackendServer).ReportStages(ctx, req.(*StageReport))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CNIBackend_ListPods_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Nothing)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CNIBackendServer).ListPods(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CNIBackend_ListPods_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CNIBackendServer).ListPods(ctx, req.(*Nothing))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CNIBackend_GetPod_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PodInfo)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CNIBackendServer).GetPod(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CNIBackend_GetPod_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CNIBackendServer).GetPod(ctx, req.(*PodInfo))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CNIBackend_DumpNicRoutes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NicRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CNIBackendServer).DumpNicRoutes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CNIBackend_DumpNicRoutes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CNIBackendServer).DumpNicRoutes(ctx, req.(*NicRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CNIBackend_RepairNic_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NicRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CNIBackendServer).RepairNic(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CNIBackend_RepairNic_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CNIBackendServer).RepairNic(ctx, req.(*NicRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CNIBackend_WatchNics_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CNIBackendServer).WatchNics(m, &cNIBackendWatchNicsServer{stream})
+}
+
+type CNIBackend_WatchNicsServer interface {
+	Send(*NicEvent) error
+	grpc.ServerStream
+}
+
+type cNIBackendWatchNicsServer struct {
+	grpc.ServerStream
+}
+
+func (x *cNIBackendWatchNicsServer) Send(m *NicEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _CNIBackend_WatchPods_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CNIBackendServer).WatchPods(m, &cNIBackendWatchPodsServer{stream})
+}
+
+type CNIBackend_WatchPodsServer interface {
+	Send(*PodEvent) error
+	grpc.ServerStream
+}
+
+type cNIBackendWatchPodsServer struct {
+	grpc.ServerStream
+}
+
+func (x *cNIBackendWatchPodsServer) Send(m *PodEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // CNIBackend_ServiceDesc is the grpc.ServiceDesc for CNIBackend service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -196,6 +522,10 @@ var CNIBackend_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "rpc.CNIBackend",
 	HandlerType: (*CNIBackendServer)(nil),
 	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Version",
+			Handler:    _CNIBackend_Version_Handler,
+		},
 		{
 			MethodName: "AddNetwork",
 			Handler:    _CNIBackend_AddNetwork_Handler,
@@ -212,7 +542,38 @@ var CNIBackend_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ClearNics",
 			Handler:    _CNIBackend_ClearNics_Handler,
 		},
+		{
+			MethodName: "ReportStages",
+			Handler:    _CNIBackend_ReportStages_Handler,
+		},
+		{
+			MethodName: "ListPods",
+			Handler:    _CNIBackend_ListPods_Handler,
+		},
+		{
+			MethodName: "GetPod",
+			Handler:    _CNIBackend_GetPod_Handler,
+		},
+		{
+			MethodName: "DumpNicRoutes",
+			Handler:    _CNIBackend_DumpNicRoutes_Handler,
+		},
+		{
+			MethodName: "RepairNic",
+			Handler:    _CNIBackend_RepairNic_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchNics",
+			Handler:       _CNIBackend_WatchNics_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchPods",
+			Handler:       _CNIBackend_WatchPods_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "pkg/rpc/message.proto",
 }
@@ -0,0 +1,105 @@
+package rpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Protocol versions of the CNIBackend service. Peers which do not implement
+// the Version rpc, and plugins which do not set IPAMMessage.Protocol, speak
+// ProtocolLegacy.
+const (
+	ProtocolLegacy  uint32 = 1
+	ProtocolCurrent uint32 = 2
+)
+
+// Features which can be negotiated between the plugin and the daemon.
+const (
+	// the daemon waits for the nic link to show up before replying AddNetwork,
+	// so the plugin does not need to wait for it again
+	FeatureNicLinkReady = "nic-link-ready"
+	// the daemon implements ListPods, GetPod, DumpNicRoutes and RepairNic
+	FeatureDebug = "debug"
+	// the daemon implements WatchNics and WatchPods
+	FeatureWatch = "watch"
+	// the daemon exports stage timings reported by the plugin through ReportStages
+	FeatureStageReport = "stage-report"
+)
+
+// DaemonFeatures are the features supported by the daemon of this build
+var DaemonFeatures = []string{
+	FeatureNicLinkReady,
+	FeatureDebug,
+	FeatureWatch,
+	FeatureStageReport,
+}
+
+// PluginFeatures are the features supported by the plugin of this build
+var PluginFeatures = []string{
+	FeatureNicLinkReady,
+	FeatureStageReport,
+}
+
+func NewVersionInfo(features []string) *VersionInfo {
+	return &VersionInfo{
+		Protocol: ProtocolCurrent,
+		Features: features,
+	}
+}
+
+func LegacyVersionInfo() *VersionInfo {
+	return &VersionInfo{
+		Protocol: ProtocolLegacy,
+	}
+}
+
+func (v *VersionInfo) HasFeature(feature string) bool {
+	for _, f := range v.GetFeatures() {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// Negotiate returns the version both sides agree on: the lower protocol and the common features.
+func Negotiate(local, remote *VersionInfo) *VersionInfo {
+	result := &VersionInfo{
+		Protocol: local.GetProtocol(),
+	}
+	if remote.GetProtocol() < result.Protocol {
+		result.Protocol = remote.GetProtocol()
+	}
+	for _, f := range local.GetFeatures() {
+		if remote.HasFeature(f) {
+			result.Features = append(result.Features, f)
+		}
+	}
+	return result
+}
+
+// PeerVersion returns the version of the plugin which sent msg.
+func PeerVersion(msg *IPAMMessage) *VersionInfo {
+	if msg.GetProtocol() == 0 {
+		return LegacyVersionInfo()
+	}
+	return &VersionInfo{
+		Protocol: msg.GetProtocol(),
+		Features: msg.GetFeatures(),
+	}
+}
+
+// NegotiateWithDaemon calls the Version rpc of the daemon and returns the negotiated version,
+// a daemon which does not implement Version is treated as a legacy daemon.
+func NegotiateWithDaemon(ctx context.Context, c CNIBackendClient, local *VersionInfo) (*VersionInfo, error) {
+	remote, err := c.Version(ctx, local)
+	if err != nil {
+		if status.Code(err) != codes.Unimplemented {
+			return nil, err
+		}
+		remote = LegacyVersionInfo()
+	}
+	return Negotiate(local, remote), nil
+}
@@ -0,0 +1,176 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// legacyDaemon behaves like a daemon built before the Version rpc: it echoes the request
+type legacyDaemon struct {
+	UnimplementedCNIBackendServer
+}
+
+func (d *legacyDaemon) AddNetwork(ctx context.Context, in *IPAMMessage) (*IPAMMessage, error) {
+	in.IP = "192.168.0.10"
+	return in, nil
+}
+
+type currentDaemon struct {
+	legacyDaemon
+	peer *VersionInfo
+}
+
+func (d *currentDaemon) Version(ctx context.Context, in *VersionInfo) (*VersionInfo, error) {
+	return NewVersionInfo(DaemonFeatures), nil
+}
+
+func (d *currentDaemon) AddNetwork(ctx context.Context, in *IPAMMessage) (*IPAMMessage, error) {
+	d.peer = PeerVersion(in)
+	in.IP = "192.168.0.10"
+	in.Protocol, in.Features = ProtocolCurrent, DaemonFeatures
+	return in, nil
+}
+
+func startDaemon(t *testing.T, srv CNIBackendServer) CNIBackendClient {
+	socket := filepath.Join(t.TempDir(), "hostnic.socket")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("failed to listen %s: %v", socket, err)
+	}
+	s := grpc.NewServer()
+	RegisterCNIBackendServer(s, srv)
+	go s.Serve(listener)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.Dial("unix://"+socket, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to dial %s: %v", socket, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return NewCNIBackendClient(conn)
+}
+
+func TestNegotiate(t *testing.T) {
+	local := &VersionInfo{Protocol: 3, Features: []string{"a", "b"}}
+	remote := &VersionInfo{Protocol: 2, Features: []string{"b", "c"}}
+
+	result := Negotiate(local, remote)
+	if result.Protocol != 2 {
+		t.Errorf("expect protocol 2, got %d", result.Protocol)
+	}
+	if len(result.Features) != 1 || !result.HasFeature("b") {
+		t.Errorf("expect features [b], got %v", result.Features)
+	}
+
+	result = Negotiate(local, LegacyVersionInfo())
+	if result.Protocol != ProtocolLegacy || len(result.Features) != 0 {
+		t.Errorf("expect legacy version, got %v", result)
+	}
+}
+
+func TestCurrentPluginLegacyDaemon(t *testing.T) {
+	c := startDaemon(t, &legacyDaemon{})
+
+	version, err := NegotiateWithDaemon(context.Background(), c, NewVersionInfo(PluginFeatures))
+	if err != nil {
+		t.Fatalf("negotiate with legacy daemon failed: %v", err)
+	}
+	if version.Protocol != ProtocolLegacy {
+		t.Errorf("expect legacy protocol, got %d", version.Protocol)
+	}
+	if version.HasFeature(FeatureNicLinkReady) {
+		t.Errorf("legacy daemon should not have feature %s", FeatureNicLinkReady)
+	}
+
+	// the legacy daemon echoes the fields it does not know about, so they must
+	// not be used to detect the version of the daemon
+	reply, err := c.AddNetwork(context.Background(), &IPAMMessage{
+		Args:     &PodInfo{Name: "pod"},
+		Protocol: ProtocolCurrent,
+		Features: PluginFeatures,
+	})
+	if err != nil {
+		t.Fatalf("AddNetwork failed: %v", err)
+	}
+	if reply.IP != "192.168.0.10" || reply.Args.Name != "pod" {
+		t.Errorf("unexpected reply %v", reply)
+	}
+}
+
+func TestCurrentPluginCurrentDaemon(t *testing.T) {
+	d := &currentDaemon{}
+	c := startDaemon(t, d)
+
+	version, err := NegotiateWithDaemon(context.Background(), c, NewVersionInfo(PluginFeatures))
+	if err != nil {
+		t.Fatalf("negotiate failed: %v", err)
+	}
+	if version.Protocol != ProtocolCurrent {
+		t.Errorf("expect protocol %d, got %d", ProtocolCurrent, version.Protocol)
+	}
+	if !version.HasFeature(FeatureNicLinkReady) {
+		t.Errorf("expect feature %s, got %v", FeatureNicLinkReady, version.Features)
+	}
+	if version.HasFeature(FeatureWatch) {
+		t.Errorf("feature %s is not supported by plugin, got %v", FeatureWatch, version.Features)
+	}
+
+	_, err = c.AddNetwork(context.Background(), &IPAMMessage{
+		Args:     &PodInfo{Name: "pod"},
+		Protocol: ProtocolCurrent,
+		Features: PluginFeatures,
+	})
+	if err != nil {
+		t.Fatalf("AddNetwork failed: %v", err)
+	}
+	if d.peer.Protocol != ProtocolCurrent || !d.peer.HasFeature(FeatureNicLinkReady) {
+		t.Errorf("unexpected peer version %v", d.peer)
+	}
+}
+
+func TestLegacyPluginCurrentDaemon(t *testing.T) {
+	d := &currentDaemon{}
+	c := startDaemon(t, d)
+
+	// a legacy plugin never calls Version and does not set Protocol
+	reply, err := c.AddNetwork(context.Background(), &IPAMMessage{
+		Args: &PodInfo{Name: "pod"},
+	})
+	if err != nil {
+		t.Fatalf("AddNetwork failed: %v", err)
+	}
+	if d.peer.Protocol != ProtocolLegacy || len(d.peer.Features) != 0 {
+		t.Errorf("expect legacy peer, got %v", d.peer)
+	}
+	if reply.IP != "192.168.0.10" || reply.Args.Name != "pod" {
+		t.Errorf("unexpected reply %v", reply)
+	}
+}
+
+func TestLegacyMessageWireCompatible(t *testing.T) {
+	// a message encoded by a legacy peer only has fields 1 - 5
+	var legacy []byte
+	legacy = protowire.AppendTag(legacy, 3, protowire.VarintType)
+	legacy = protowire.AppendVarint(legacy, 1)
+	legacy = protowire.AppendTag(legacy, 5, protowire.BytesType)
+	legacy = protowire.AppendString(legacy, "192.168.0.10")
+
+	msg := &IPAMMessage{}
+	if err := proto.Unmarshal(legacy, msg); err != nil {
+		t.Fatalf("failed to decode legacy message: %v", err)
+	}
+	if !msg.Peek || msg.IP != "192.168.0.10" {
+		t.Errorf("unexpected message %v", msg)
+	}
+	if PeerVersion(msg).Protocol != ProtocolLegacy {
+		t.Errorf("expect legacy peer, got %v", PeerVersion(msg))
+	}
+}
@@ -0,0 +1,215 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	log "k8s.io/klog/v2"
+
+	"github.com/yunify/hostnic-cni/pkg/conf"
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+)
+
+// access levels of grpc methods on the hostnic socket
+const (
+	accessPublic = iota
+	accessReadOnly
+	accessMutating
+)
+
+// methods not listed here are mutating
+var methodAccess = map[string]int{
+	rpc.CNIBackend_Version_FullMethodName:       accessPublic,
+	healthpb.Health_Check_FullMethodName:        accessPublic,
+	healthpb.Health_Watch_FullMethodName:        accessPublic,
+	rpc.CNIBackend_ShowNics_FullMethodName:      accessReadOnly,
+	rpc.CNIBackend_ListPods_FullMethodName:      accessReadOnly,
+	rpc.CNIBackend_GetPod_FullMethodName:        accessReadOnly,
+	rpc.CNIBackend_DumpNicRoutes_FullMethodName: accessReadOnly,
+	rpc.CNIBackend_WatchNics_FullMethodName:     accessReadOnly,
+	rpc.CNIBackend_WatchPods_FullMethodName:     accessReadOnly,
+}
+
+// peerCredAuthInfo is the credential of the process on the other side of the unix socket
+type peerCredAuthInfo struct {
+	credentials.CommonAuthInfo
+	ucred *unix.Ucred
+}
+
+func (p peerCredAuthInfo) AuthType() string {
+	return "peercred"
+}
+
+// peerCredTransport reads SO_PEERCRED of every accepted connection
+type peerCredTransport struct {
+	credentials.TransportCredentials
+}
+
+func newPeerCredTransport() credentials.TransportCredentials {
+	return &peerCredTransport{
+		TransportCredentials: insecure.NewCredentials(),
+	}
+}
+
+func (t *peerCredTransport) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	uc, ok := conn.(*net.UnixConn)
+	if !ok {
+		return nil, nil, fmt.Errorf("connection from %s is not a unix socket", conn.RemoteAddr())
+	}
+	raw, err := uc.SyscallConn()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var ucred *unix.Ucred
+	var credErr error
+	err = raw.Control(func(fd uintptr) {
+		ucred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if credErr != nil {
+		return nil, nil, fmt.Errorf("failed to get peer credential: %v", credErr)
+	}
+
+	return conn, peerCredAuthInfo{
+		CommonAuthInfo: credentials.CommonAuthInfo{SecurityLevel: credentials.NoSecurity},
+		ucred:          ucred,
+	}, nil
+}
+
+func (t *peerCredTransport) Clone() credentials.TransportCredentials {
+	return &peerCredTransport{
+		TransportCredentials: t.TransportCredentials.Clone(),
+	}
+}
+
+// authorizer allows root and AllowedUIDs to call every method, and callers whose
+// primary group is ReadOnlyGroup to call read-only methods. Supplementary groups
+// are not considered, SO_PEERCRED only carries the primary group and the daemon
+// cannot read /proc of callers without hostPID.
+type authorizer struct {
+	uids map[uint32]bool
+	gid  int
+}
+
+func newAuthorizer(conf conf.ServerConf) (*authorizer, error) {
+	a := &authorizer{
+		uids: map[uint32]bool{0: true},
+		gid:  -1,
+	}
+	for _, uid := range conf.AllowedUIDs {
+		a.uids[uid] = true
+	}
+
+	if conf.ReadOnlyGroup != "" {
+		group, err := user.LookupGroup(conf.ReadOnlyGroup)
+		if err != nil {
+			group, err = user.LookupGroupId(conf.ReadOnlyGroup)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to lookup group %s: %v", conf.ReadOnlyGroup, err)
+		}
+		if a.gid, err = strconv.Atoi(group.Gid); err != nil {
+			return nil, fmt.Errorf("invalid gid %s of group %s", group.Gid, conf.ReadOnlyGroup)
+		}
+	}
+
+	return a, nil
+}
+
+// socketMode restricts who can connect to the socket, callers are still
+// authorized per method. Other users can only connect if AllowedUIDs is set.
+func (a *authorizer) socketMode() os.FileMode {
+	var mode os.FileMode = 0600
+	if a.gid >= 0 {
+		mode = 0660
+	}
+	if len(a.uids) > 1 {
+		mode = 0666
+	}
+	return mode
+}
+
+// listen creates the socket at path with socketMode, the socket is created under
+// a umask so that it is never accessible with a wider mode.
+func (a *authorizer) listen(path string) (net.Listener, error) {
+	mode := a.socketMode()
+	mask := unix.Umask(int(0777 &^ mode))
+	listener, err := net.Listen("unix", path)
+	unix.Umask(mask)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.gid >= 0 {
+		if err := os.Chown(path, 0, a.gid); err != nil {
+			listener.Close()
+			return nil, err
+		}
+	}
+	return listener, nil
+}
+
+func (a *authorizer) authorize(ctx context.Context, method string) error {
+	access, ok := methodAccess[method]
+	if !ok {
+		access = accessMutating
+	}
+	if access == accessPublic {
+		return nil
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return a.reject(method, nil, "no peer")
+	}
+	info, ok := p.AuthInfo.(peerCredAuthInfo)
+	if !ok {
+		return a.reject(method, nil, "no peer credential")
+	}
+
+	if a.uids[info.ucred.Uid] {
+		return nil
+	}
+	if access == accessReadOnly && a.gid >= 0 && int(info.ucred.Gid) == a.gid {
+		return nil
+	}
+	return a.reject(method, info.ucred, "permission denied")
+}
+
+func (a *authorizer) reject(method string, ucred *unix.Ucred, reason string) error {
+	if ucred != nil {
+		log.Warningf("audit: rejected %s from pid %d uid %d gid %d: %s", method, ucred.Pid, ucred.Uid, ucred.Gid, reason)
+	} else {
+		log.Warningf("audit: rejected %s: %s", method, reason)
+	}
+	return status.Errorf(codes.PermissionDenied, "%s: %s", method, reason)
+}
+
+func (a *authorizer) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := a.authorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *authorizer) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := a.authorize(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/sys/unix"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+)
+
+func TestMethodAccess(t *testing.T) {
+	// every method of the service is either listed or left mutating on purpose
+	expect := map[string]int{
+		rpc.CNIBackend_AddNetwork_FullMethodName:   accessMutating,
+		rpc.CNIBackend_DelNetwork_FullMethodName:   accessMutating,
+		rpc.CNIBackend_ClearNics_FullMethodName:    accessMutating,
+		rpc.CNIBackend_RepairNic_FullMethodName:    accessMutating,
+		rpc.CNIBackend_ReportStages_FullMethodName: accessMutating,
+	}
+	for method, access := range methodAccess {
+		expect[method] = access
+	}
+
+	desc := rpc.CNIBackend_ServiceDesc
+	var methods []string
+	for _, m := range desc.Methods {
+		methods = append(methods, "/"+desc.ServiceName+"/"+m.MethodName)
+	}
+	for _, m := range desc.Streams {
+		methods = append(methods, "/"+desc.ServiceName+"/"+m.StreamName)
+	}
+	for _, method := range methods {
+		if _, ok := expect[method]; !ok {
+			t.Errorf("access of method %s is not decided", method)
+		}
+	}
+
+	for _, method := range []string{rpc.CNIBackend_AddNetwork_FullMethodName, rpc.CNIBackend_RepairNic_FullMethodName} {
+		if _, ok := methodAccess[method]; ok {
+			t.Errorf("method %s should be mutating", method)
+		}
+	}
+	if methodAccess[healthpb.Health_Check_FullMethodName] != accessPublic {
+		t.Errorf("health check should be public")
+	}
+}
+
+func peerContext(uid, gid uint32) context.Context {
+	return peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: peerCredAuthInfo{ucred: &unix.Ucred{Pid: 1, Uid: uid, Gid: gid}},
+	})
+}
+
+func TestAuthorize(t *testing.T) {
+	a := &authorizer{
+		uids: map[uint32]bool{0: true, 1000: true},
+		gid:  2000,
+	}
+
+	for _, c := range []struct {
+		name   string
+		ctx    context.Context
+		method string
+		allow  bool
+	}{
+		{"root", peerContext(0, 0), rpc.CNIBackend_AddNetwork_FullMethodName, true},
+		{"allowed uid", peerContext(1000, 1000), rpc.CNIBackend_RepairNic_FullMethodName, true},
+		{"group reads", peerContext(1001, 2000), rpc.CNIBackend_ListPods_FullMethodName, true},
+		{"group watches", peerContext(1001, 2000), rpc.CNIBackend_WatchNics_FullMethodName, true},
+		{"group mutates", peerContext(1001, 2000), rpc.CNIBackend_ClearNics_FullMethodName, false},
+		{"other reads", peerContext(1001, 1001), rpc.CNIBackend_ShowNics_FullMethodName, false},
+		{"other version", peerContext(1001, 1001), rpc.CNIBackend_Version_FullMethodName, true},
+		{"unknown method", peerContext(1001, 2000), "/rpc.CNIBackend/Unknown", false},
+		{"no peer", context.Background(), rpc.CNIBackend_ShowNics_FullMethodName, false},
+		{"no credential", peer.NewContext(context.Background(), &peer.Peer{}), rpc.CNIBackend_ShowNics_FullMethodName, false},
+	} {
+		err := a.authorize(c.ctx, c.method)
+		if c.allow && err != nil {
+			t.Errorf("%s: expect allowed, got %v", c.name, err)
+		}
+		if !c.allow && status.Code(err) != codes.PermissionDenied {
+			t.Errorf("%s: expect permission denied, got %v", c.name, err)
+		}
+	}
+
+	// without ReadOnlyGroup only root is allowed
+	a = &authorizer{uids: map[uint32]bool{0: true}, gid: -1}
+	if err := a.authorize(peerContext(1001, 0), rpc.CNIBackend_ShowNics_FullMethodName); err == nil {
+		t.Errorf("expect permission denied without read-only group")
+	}
+}
+
+func TestListenSocketMode(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		a    *authorizer
+		mode os.FileMode
+	}{
+		{"root only", &authorizer{uids: map[uint32]bool{0: true}, gid: -1}, 0600},
+		{"allowed uids", &authorizer{uids: map[uint32]bool{0: true, 1000: true}, gid: -1}, 0666},
+	} {
+		path := filepath.Join(t.TempDir(), "hostnic.socket")
+		listener, err := c.a.listen(path)
+		if err != nil {
+			t.Fatalf("%s: listen error: %v", c.name, err)
+		}
+		info, err := os.Stat(path)
+		listener.Close()
+		if err != nil {
+			t.Fatalf("%s: stat error: %v", c.name, err)
+		}
+		if info.Mode().Perm() != c.mode {
+			t.Errorf("%s: expect mode %o, got %o", c.name, c.mode, info.Mode().Perm())
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"errors"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/yunify/hostnic-cni/pkg/constants"
+	"github.com/yunify/hostnic-cni/pkg/qcclient"
+	"github.com/yunify/hostnic-cni/pkg/simple/client/network/ippool/ipam"
+)
+
+// reasons of pod network failures
+const (
+	reasonGetPodFailed          = "get_pod_failed"
+	reasonNoPool                = "no_pool"
+	reasonBlockExhausted        = "block_exhausted"
+	reasonAssignConflict        = "assign_conflict"
+	reasonDatastoreError        = "datastore_error"
+	reasonFixedIPFailed         = "fixed_ip_failed"
+	reasonAnnotationPatchFailed = "annotation_patch_failed"
+	reasonQingCloudError        = "qingcloud_error"
+	reasonNicLimit              = "nic_limit"
+	reasonNicSetupFailed        = "nic_setup_failed"
+	reasonGetHandleFailed       = "get_handle_failed"
+	reasonReleaseFailed         = "release_failed"
+	reasonFreeHostNicFailed     = "free_hostnic_failed"
+)
+
+// results of releasing orphaned pod records
+const (
+	reconcileResultReleased = "released"
+	reconcileResultFailed   = "failed"
+	reconcileResultDryRun   = "dry_run"
+)
+
+var (
+	serverStageDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "hostnic_server_stage_duration_seconds",
+			Help:    "latency of each stage of AddNetwork and DelNetwork with hostnic cni",
+			Buckets: prometheus.ExponentialBuckets(0.005, 2, 16),
+		},
+		[]string{"op", "stage"},
+	)
+	pluginStageDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "hostnic_plugin_stage_duration_seconds",
+			Help:    "latency of each stage of cni ADD and DEL reported by the hostnic plugin",
+			Buckets: prometheus.ExponentialBuckets(0.005, 2, 16),
+		},
+		[]string{"op", "stage", "result"},
+	)
+	podNetworkFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:        "hostnic_pod_network_failures_total",
+			Help:        "failures of AddNetwork and DelNetwork with hostnic cni by reason",
+			ConstLabels: prometheus.Labels{"node_name": os.Getenv("MY_NODE_NAME")},
+		},
+		[]string{"op", "reason", "namespace", "vxnet"},
+	)
+	reconcileOrphans = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hostnic_reconcile_orphaned_records_total",
+			Help: "orphaned pod records found by the reconciler of hostnic cni by reason and result",
+		},
+		[]string{"reason", "result"},
+	)
+	reconcileSuspects = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hostnic_reconcile_suspected_records",
+			Help: "pod records found orphaned in the last reconcile of hostnic cni, released if still orphaned in the next",
+		},
+	)
+	reconcileCRIErrors = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hostnic_reconcile_cri_errors_total",
+			Help: "failures to list sandboxes from the cri runtime in reconcile of hostnic cni",
+		},
+	)
+	reconcileDuration = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "hostnic_reconcile_duration_seconds",
+			Help:    "latency of a reconcile of pod records with hostnic cni",
+			Buckets: prometheus.ExponentialBuckets(0.005, 2, 16),
+		},
+	)
+)
+
+var stageNameRegexp = regexp.MustCompile(`^[a-z_]{1,32}$`)
+
+func init() {
+	prometheus.MustRegister(serverStageDuration, pluginStageDuration, podNetworkFailures)
+	prometheus.MustRegister(reconcileOrphans, reconcileSuspects, reconcileCRIErrors, reconcileDuration)
+}
+
+// observeStage records the duration of stage since start, and returns the start of the next stage
+func observeStage(op, stage string, start time.Time) time.Time {
+	now := time.Now()
+	serverStageDuration.WithLabelValues(op, stage).Observe(now.Sub(start).Seconds())
+	return now
+}
+
+func countFailure(op, reason, namespace, vxnet string) {
+	podNetworkFailures.WithLabelValues(op, reason, namespace, vxnet).Inc()
+}
+
+// assignFailureReason tells a missing or unusable pool from a pool without free addresses, and both
+// from failures of the apiserver
+func assignFailureReason(err error) string {
+	switch {
+	case errors.Is(err, ipam.ErrNoQualifiedPool) || errors.Is(err, ipam.ErrUnknowIPPoolType):
+		return reasonNoPool
+	case errors.Is(err, ipam.ErrNoFreeBlocks):
+		return reasonBlockExhausted
+	case errors.Is(err, ipam.ErrMaxRetry):
+		return reasonAssignConflict
+	default:
+		return reasonDatastoreError
+	}
+}
+
+func allocHostNicFailureReason(err error) string {
+	switch {
+	case errors.Is(err, constants.ErrNoAvailableNIC):
+		return reasonNicLimit
+	case qcclient.IsAPIError(err):
+		return reasonQingCloudError
+	default:
+		return reasonNicSetupFailed
+	}
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/yunify/hostnic-cni/pkg/constants"
+	"github.com/yunify/hostnic-cni/pkg/qcclient"
+	"github.com/yunify/hostnic-cni/pkg/simple/client/network/ippool/ipam"
+)
+
+func TestAssignFailureReason(t *testing.T) {
+	for _, c := range []struct {
+		err    error
+		reason string
+	}{
+		{ipam.ErrNoQualifiedPool, reasonNoPool},
+		{fmt.Errorf("not found pool: %w", ipam.ErrNoQualifiedPool), reasonNoPool},
+		{ipam.ErrUnknowIPPoolType, reasonNoPool},
+		{fmt.Errorf("no appropriate ippool found: %w", ipam.ErrNoFreeBlocks), reasonBlockExhausted},
+		{ipam.ErrMaxRetry, reasonAssignConflict},
+		{fmt.Errorf("no appropriate ippool found: %w", ipam.ErrMaxRetry), reasonAssignConflict},
+		{fmt.Errorf("%w: get blocks: timeout", ipam.ErrDatastore), reasonDatastoreError},
+		{errors.New("unknown"), reasonDatastoreError},
+	} {
+		if reason := assignFailureReason(c.err); reason != c.reason {
+			t.Errorf("expect reason %s of %v, got %s", c.reason, c.err, reason)
+		}
+	}
+}
+
+func TestAllocHostNicFailureReason(t *testing.T) {
+	if reason := allocHostNicFailureReason(fmt.Errorf("alloc: %w", constants.ErrNoAvailableNIC)); reason != reasonNicLimit {
+		t.Errorf("expect reason %s, got %s", reasonNicLimit, reason)
+	}
+	if reason := allocHostNicFailureReason(errors.New("link not found")); reason != reasonNicSetupFailed {
+		t.Errorf("expect reason %s, got %s", reasonNicSetupFailed, reason)
+	}
+	apiErr := &qcclient.APIError{Method: "CreateNicsAndAttach", Err: errors.New("quota exceeded")}
+	if reason := allocHostNicFailureReason(fmt.Errorf("create nics: %w", apiErr)); reason != reasonQingCloudError {
+		t.Errorf("expect reason %s, got %s", reasonQingCloudError, reason)
+	}
+}
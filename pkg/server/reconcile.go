@@ -0,0 +1,267 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+	log "k8s.io/klog/v2"
+
+	"github.com/yunify/hostnic-cni/pkg/allocator"
+	"github.com/yunify/hostnic-cni/pkg/networkutils"
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+	"github.com/yunify/hostnic-cni/pkg/simple/client/network/ippool/ipam"
+)
+
+const criTimeout = 10 * time.Second
+
+// reasons a pod record is orphaned
+const (
+	orphanPodGone     = "pod_gone"
+	orphanSandboxGone = "sandbox_gone"
+)
+
+type ReconcilerOptions struct {
+	// interval between two runs, the reconciler is disabled if it is zero
+	Interval time.Duration
+	// only report orphaned records, without releasing them
+	DryRun bool
+	// endpoint of the CRI runtime service, e.g. unix:///run/containerd/containerd.sock,
+	// sandboxes are not checked if it is empty
+	CRIEndpoint string
+}
+
+// Reconciler releases pod records in db whose DEL was missed, e.g. when
+// the runtime crashed or the pod was force deleted. Such records keep their
+// nic from being freed by ClearFreeHostnic.
+//
+// With a CRI endpoint a record is orphaned if the runtime reports its sandbox
+// as not ready or gone, otherwise if its pod is no longer on this node in the
+// pod informer. Records must be orphaned in two runs in a row before they are
+// released, so a pod which is just being set up is never released.
+//
+// Each run also adopts or deletes the nics of the instance which are missing
+// from db, see Allocator.ReconcileNics.
+type Reconciler struct {
+	opts       ReconcilerOptions
+	nodeName   string
+	podLister  corelisters.PodLister
+	ipamclient ipam.IPAMClient
+
+	// orphans of the last run by container id
+	suspects map[string]string
+}
+
+func NewReconciler(opts ReconcilerOptions, nodeName string, podLister corelisters.PodLister, ipamclient ipam.IPAMClient) *Reconciler {
+	return &Reconciler{
+		opts:       opts,
+		nodeName:   nodeName,
+		podLister:  podLister,
+		ipamclient: ipamclient,
+		suspects:   make(map[string]string),
+	}
+}
+
+func (r *Reconciler) Start(stopCh <-chan struct{}) {
+	if r.opts.Interval <= 0 {
+		log.Info("pod record reconciler is disabled")
+		return
+	}
+	go r.run(stopCh)
+}
+
+func (r *Reconciler) run(stopCh <-chan struct{}) {
+	ticker := time.NewTicker(r.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			log.Info("stopped pod record reconciler")
+			return
+		case <-ticker.C:
+			r.Reconcile()
+			if err := r.ReconcileNics(); err != nil {
+				log.Errorf("reconcile nics failed: %v", err)
+			}
+		}
+	}
+}
+
+// Reconcile runs a single pass over all pod records of this node
+func (r *Reconciler) Reconcile() {
+	start := time.Now()
+	defer func() {
+		reconcileDuration.Observe(time.Since(start).Seconds())
+	}()
+
+	sandboxes, err := r.listSandboxes()
+	if err != nil {
+		// the pod informer alone is still able to find force deleted pods
+		log.Warningf("reconcile without sandboxes, list from %s failed: %v", r.opts.CRIEndpoint, err)
+		reconcileCRIErrors.Inc()
+	}
+
+	suspects := make(map[string]string)
+	for _, record := range allocator.Alloc.ListPods() {
+		reason := r.orphanReason(record, sandboxes)
+		if reason == "" {
+			continue
+		}
+		suspects[record.Containter] = reason
+		if _, ok := r.suspects[record.Containter]; !ok {
+			log.Infof("pod record %s/%s/%s ip %s looks orphaned: %s", record.Namespace, record.Name, record.Containter, record.PodIP, reason)
+			continue
+		}
+
+		if r.opts.DryRun {
+			log.Infof("dry run, skip releasing orphaned pod record %s/%s/%s ip %s: %s", record.Namespace, record.Name, record.Containter, record.PodIP, reason)
+			reconcileOrphans.WithLabelValues(reason, reconcileResultDryRun).Inc()
+			continue
+		}
+		if err := r.release(record); err != nil {
+			log.Errorf("release orphaned pod record %s/%s/%s ip %s failed: %v", record.Namespace, record.Name, record.Containter, record.PodIP, err)
+			reconcileOrphans.WithLabelValues(reason, reconcileResultFailed).Inc()
+			continue
+		}
+		log.Infof("released orphaned pod record %s/%s/%s ip %s: %s", record.Namespace, record.Name, record.Containter, record.PodIP, reason)
+		reconcileOrphans.WithLabelValues(reason, reconcileResultReleased).Inc()
+		delete(suspects, record.Containter)
+	}
+	r.suspects = suspects
+	reconcileSuspects.Set(float64(len(suspects)))
+}
+
+// ReconcileNics adopts or deletes the nics of the instance missing from db.
+// A vxnet is in use if a running pod of this node has an ip in it.
+func (r *Reconciler) ReconcileNics() error {
+	return allocator.Alloc.ReconcileNics(r.vxnetInUse, r.opts.DryRun)
+}
+
+func (r *Reconciler) vxnetInUse(vxnet *rpc.VxNet) bool {
+	_, ipnet, err := net.ParseCIDR(vxnet.Network)
+	if err != nil {
+		// keep the nic, it is checked again on the next run
+		log.Warningf("parse network %q of vxnet %s failed: %v", vxnet.Network, vxnet.ID, err)
+		return true
+	}
+
+	pods, err := r.podLister.List(labels.Everything())
+	if err != nil {
+		log.Warningf("list pods failed: %v", err)
+		return true
+	}
+	for _, pod := range pods {
+		if pod.Spec.NodeName != r.nodeName || pod.Spec.HostNetwork {
+			continue
+		}
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if ip := net.ParseIP(pod.Status.PodIP); ip != nil && ipnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// orphanReason returns why the record is orphaned, or an empty string if it is not.
+// sandboxes is nil if they are unknown.
+func (r *Reconciler) orphanReason(record *rpc.PodNetInfo, sandboxes map[string]runtimeapi.PodSandboxState) string {
+	if sandboxes != nil {
+		state, ok := sandboxes[record.Containter]
+		if !ok || state != runtimeapi.PodSandboxState_SANDBOX_READY {
+			return orphanSandboxGone
+		}
+		// kubelet calls DEL once it stops a running sandbox
+		return ""
+	}
+
+	pod, err := r.podLister.Pods(record.Namespace).Get(record.Name)
+	if errors.IsNotFound(err) {
+		return orphanPodGone
+	}
+	if err != nil {
+		log.Warningf("get pod %s/%s failed: %v", record.Namespace, record.Name, err)
+		return ""
+	}
+	if pod.Spec.NodeName != r.nodeName {
+		return orphanPodGone
+	}
+	// the pod was recreated with the same name on this node and got another ip. A new sandbox
+	// whose ip kubelet has not reported yet is only suspected, the next run sees its ip.
+	if pod.Status.PodIP != "" && record.PodIP != "" && pod.Status.PodIP != record.PodIP {
+		return orphanPodGone
+	}
+	return ""
+}
+
+// release cleans up the record the same way as a DEL of the cni plugin whose sandbox is gone
+func (r *Reconciler) release(record *rpc.PodNetInfo) error {
+	args := &rpc.PodInfo{
+		Namespace:  record.Namespace,
+		Name:       record.Name,
+		Containter: record.Containter,
+	}
+	nic, ip, err := allocator.Alloc.FreeHostNic(args, true)
+	if err != nil {
+		return err
+	}
+	if nic == nil {
+		// released by a DEL meanwhile
+		return nil
+	}
+
+	if err := networkutils.NetworkHelper.CleanupPodNetwork(nic, ip); err != nil {
+		return fmt.Errorf("clean network rule error: %v", err)
+	}
+	if err := r.ipamclient.ReleaseByHandle(PodHandleKey(args)); err != nil {
+		return fmt.Errorf("release ip by handleID %s error: %v", PodHandleKey(args), err)
+	}
+	if _, _, err := allocator.Alloc.FreeHostNic(args, false); err != nil {
+		return fmt.Errorf("clear pod db record error: %v", err)
+	}
+	return nil
+}
+
+// listSandboxes returns the state of the sandboxes by id, or nil if the CRI endpoint is not set
+func (r *Reconciler) listSandboxes() (map[string]runtimeapi.PodSandboxState, error) {
+	if r.opts.CRIEndpoint == "" {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), criTimeout)
+	defer cancel()
+
+	path := strings.TrimPrefix(r.opts.CRIEndpoint, "unix://")
+	conn, err := grpc.DialContext(ctx, path,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", addr)
+		}),
+		grpc.WithBlock())
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	resp, err := runtimeapi.NewRuntimeServiceClient(conn).ListPodSandbox(ctx, &runtimeapi.ListPodSandboxRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	sandboxes := make(map[string]runtimeapi.PodSandboxState)
+	for _, sandbox := range resp.Items {
+		sandboxes[sandbox.Id] = sandbox.State
+	}
+	return sandboxes, nil
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sinformers "k8s.io/client-go/informers"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"github.com/yunify/hostnic-cni/pkg/allocator"
+	"github.com/yunify/hostnic-cni/pkg/apis/network/v1alpha1"
+	"github.com/yunify/hostnic-cni/pkg/client/clientset/versioned/fake"
+	"github.com/yunify/hostnic-cni/pkg/client/informers/externalversions"
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+	"github.com/yunify/hostnic-cni/pkg/simple/client/network/ippool/ipam"
+)
+
+const testNode = "node-a"
+
+func testPod(name, node string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		Spec:       corev1.PodSpec{NodeName: node},
+	}
+}
+
+// newTestReconciler returns a reconciler of testNode seeing pods in its pod lister
+func newTestReconciler(opts ReconcilerOptions, pods ...*corev1.Pod) (*Reconciler, cache.Indexer, *fake.Clientset) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, pod := range pods {
+		indexer.Add(pod)
+	}
+	client := fake.NewSimpleClientset()
+	ipamclient := ipam.NewIPAMClient(client, v1alpha1.VLAN, externalversions.NewSharedInformerFactory(client, 0),
+		k8sinformers.NewSharedInformerFactory(nil, 0))
+	return NewReconciler(opts, testNode, corelisters.NewPodLister(indexer), ipamclient), indexer, client
+}
+
+func TestOrphanReason(t *testing.T) {
+	recreated := testPod("recreated", testNode)
+	recreated.Status.PodIP = "192.168.1.11"
+	r, _, _ := newTestReconciler(ReconcilerOptions{}, testPod("running", testNode), testPod("moved", "node-b"), recreated)
+	sandboxes := map[string]runtimeapi.PodSandboxState{
+		"ready":    runtimeapi.PodSandboxState_SANDBOX_READY,
+		"notready": runtimeapi.PodSandboxState_SANDBOX_NOTREADY,
+	}
+
+	for _, c := range []struct {
+		name      string
+		record    *rpc.PodNetInfo
+		sandboxes map[string]runtimeapi.PodSandboxState
+		reason    string
+	}{
+		{"running pod", &rpc.PodNetInfo{Namespace: "default", Name: "running", Containter: "c1"}, nil, ""},
+		{"deleted pod", &rpc.PodNetInfo{Namespace: "default", Name: "deleted", Containter: "c2"}, nil, orphanPodGone},
+		{"pod on another node", &rpc.PodNetInfo{Namespace: "default", Name: "moved", Containter: "c3"}, nil, orphanPodGone},
+		{"recreated pod", &rpc.PodNetInfo{Namespace: "default", Name: "recreated", Containter: "c4", PodIP: "192.168.1.10"}, nil, orphanPodGone},
+		{"recreated pod's record", &rpc.PodNetInfo{Namespace: "default", Name: "recreated", Containter: "c5", PodIP: "192.168.1.11"}, nil, ""},
+		{"pod without ip yet", &rpc.PodNetInfo{Namespace: "default", Name: "running", Containter: "c6", PodIP: "192.168.1.12"}, nil, ""},
+		{"ready sandbox", &rpc.PodNetInfo{Namespace: "default", Name: "deleted", Containter: "ready"}, sandboxes, ""},
+		{"sandbox not ready", &rpc.PodNetInfo{Namespace: "default", Name: "running", Containter: "notready"}, sandboxes, orphanSandboxGone},
+		{"sandbox gone", &rpc.PodNetInfo{Namespace: "default", Name: "running", Containter: "gone"}, sandboxes, orphanSandboxGone},
+		// an empty list of the runtime is still authoritative
+		{"no sandboxes", &rpc.PodNetInfo{Namespace: "default", Name: "running", Containter: "c1"}, map[string]runtimeapi.PodSandboxState{}, orphanSandboxGone},
+	} {
+		if reason := r.orphanReason(c.record, c.sandboxes); reason != c.reason {
+			t.Errorf("%s: expect reason %q, got %q", c.name, c.reason, reason)
+		}
+	}
+}
+
+func addTestPod(t *testing.T, container, name string) {
+	pod := &rpc.PodInfo{Namespace: "default", Name: name, Containter: container, VxNet: "vxnet-a"}
+	if _, err := allocator.Alloc.AllocHostNic(context.Background(), pod); err != nil {
+		t.Fatalf("failed to add pod %s: %v", container, err)
+	}
+}
+
+func recordedContainers() []string {
+	var result []string
+	for _, pod := range allocator.Alloc.ListPods() {
+		result = append(result, pod.Containter)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// releasedHandles returns the handles the ipam client was asked to release
+func releasedHandles(client *fake.Clientset) []string {
+	var result []string
+	for _, action := range client.Actions() {
+		if get, ok := action.(k8stesting.GetAction); ok && get.GetResource().Resource == "ipamhandles" {
+			result = append(result, get.GetName())
+		}
+	}
+	return result
+}
+
+func TestReconcileReleasesSuspects(t *testing.T) {
+	setupTestAllocator(t)
+	addTestPod(t, "c1", "running")
+	addTestPod(t, "c2", "deleted")
+	addTestPod(t, "c3", "starting")
+	r, pods, client := newTestReconciler(ReconcilerOptions{}, testPod("running", testNode))
+
+	// the first run only suspects the orphans
+	r.Reconcile()
+	if got := recordedContainers(); len(got) != 3 {
+		t.Fatalf("expect no record released in the first run, got %v", got)
+	}
+	if len(r.suspects) != 2 || r.suspects["c2"] != orphanPodGone || r.suspects["c3"] != orphanPodGone {
+		t.Fatalf("expect c2 and c3 suspected, got %v", r.suspects)
+	}
+
+	// c3 shows up in the informer before the second run
+	pods.Add(testPod("starting", testNode))
+	r.Reconcile()
+	if got := recordedContainers(); len(got) != 2 || got[0] != "c1" || got[1] != "c3" {
+		t.Fatalf("expect only c2 released, got %v", got)
+	}
+	if len(r.suspects) != 0 {
+		t.Fatalf("expect no suspects left, got %v", r.suspects)
+	}
+	handle := PodHandleKey(&rpc.PodInfo{Namespace: "default", Name: "deleted", Containter: "c2"})
+	if got := releasedHandles(client); len(got) != 1 || got[0] != handle {
+		t.Fatalf("expect ip of handle %s released, got %v", handle, got)
+	}
+}
+
+func TestReconcileDryRun(t *testing.T) {
+	setupTestAllocator(t)
+	addTestPod(t, "c1", "deleted")
+	r, _, client := newTestReconciler(ReconcilerOptions{DryRun: true})
+
+	for i := 0; i < 3; i++ {
+		r.Reconcile()
+	}
+	if got := recordedContainers(); len(got) != 1 {
+		t.Fatalf("expect no record released in dry run, got %v", got)
+	}
+	if r.suspects["c1"] != orphanPodGone {
+		t.Fatalf("expect c1 still suspected, got %v", r.suspects)
+	}
+	if got := releasedHandles(client); len(got) != 0 {
+		t.Fatalf("expect no ip released in dry run, got %v", got)
+	}
+}
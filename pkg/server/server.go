@@ -13,6 +13,11 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
@@ -22,9 +27,14 @@ import (
 	"github.com/yunify/hostnic-cni/pkg/conf"
 	"github.com/yunify/hostnic-cni/pkg/config"
 	"github.com/yunify/hostnic-cni/pkg/constants"
-	"github.com/yunify/hostnic-cni/pkg/metrics"
+	"github.com/yunify/hostnic-cni/pkg/dhcp"
+	"github.com/yunify/hostnic-cni/pkg/events"
+	"github.com/yunify/hostnic-cni/pkg/health"
+	"github.com/yunify/hostnic-cni/pkg/networkutils"
+	"github.com/yunify/hostnic-cni/pkg/qcclient"
 	"github.com/yunify/hostnic-cni/pkg/rpc"
 	"github.com/yunify/hostnic-cni/pkg/simple/client/network/ippool/ipam"
+	"github.com/yunify/hostnic-cni/pkg/tracing"
 )
 
 type IPAMServer struct {
@@ -32,29 +42,35 @@ type IPAMServer struct {
 	kubeclient    kubernetes.Interface
 	ipamclient    ipam.IPAMClient
 	clusterConfig *config.ClusterConfig
-	metricsPort   int
-	oddPodCount   *metrics.OddPodCount
+	// node local nic and pod metrics, served on /metrics
+	metrics prometheus.Collector
 }
 
-func NewIPAMServer(conf conf.ServerConf, clusterConfig *config.ClusterConfig, kubeclient kubernetes.Interface, ipamclient ipam.IPAMClient, metricsPort int) *IPAMServer {
-	count := metrics.OddPodCount{
-		BlockFailedCount:        0,
-		PoolFailedCount:         0,
-		NotFoundCount:           0,
-		AllocFailedCount:        0,
-		FreeFromPoolFailedCount: 0,
-		FreeFromHostFailedCount: 0,
-	}
+func NewIPAMServer(conf conf.ServerConf, clusterConfig *config.ClusterConfig, kubeclient kubernetes.Interface, ipamclient ipam.IPAMClient, metrics prometheus.Collector) *IPAMServer {
 	return &IPAMServer{
 		conf:          conf,
 		kubeclient:    kubeclient,
 		ipamclient:    ipamclient,
 		clusterConfig: clusterConfig,
-		metricsPort:   metricsPort,
-		oddPodCount:   &count,
+		metrics:       metrics,
 	}
 }
 
+// StartHTTPServer serves /healthz and /readyz on port, so that probes get
+// per-check detail while the daemon is still syncing. /metrics is added to the
+// same mux once the grpc server is running, /debug/dhcp/leases once the
+// allocator is set up.
+func StartHTTPServer(port int) {
+	health.InstallHandlers(http.DefaultServeMux)
+	dhcp.InstallHandlers(http.DefaultServeMux)
+	qcclient.InstallHandlers(http.DefaultServeMux)
+	go func() {
+		if err := http.ListenAndServe(fmt.Sprintf(":%d", port), nil); err != nil {
+			log.Fatalf("Failed to serve http on port %d: %v", port, err)
+		}
+	}()
+}
+
 func (s *IPAMServer) Start(stopCh <-chan struct{}) error {
 	go s.run(stopCh)
 	return nil
@@ -69,17 +85,21 @@ func (s *IPAMServer) run(stopCh <-chan struct{}) {
 		log.Warningf("cannot remove file %s: %v", socketFilePath, err)
 	}
 
-	listener, err := net.Listen("unix", socketFilePath)
+	auth, err := newAuthorizer(s.conf)
+	if err != nil {
+		log.Fatalf("Failed to setup authorizer: %v", err)
+	}
+	listener, err := auth.listen(socketFilePath)
 	if err != nil {
 		log.Fatalf("Failed to listen to %s: %v", socketFilePath, err)
 	}
 
 	//start up metrics server routine
-	hostnicMetricsManager := metrics.NewHostnicMetricsManager(s.kubeclient, s.ipamclient, s.oddPodCount)
 	reg := prometheus.NewPedanticRegistry()
-	reg.MustRegister(hostnicMetricsManager)
+	reg.MustRegister(s.metrics)
 	gatherers := prometheus.Gatherers{
 		reg,
+		prometheus.DefaultGatherer,
 	}
 	h := promhttp.HandlerFor(gatherers,
 		promhttp.HandlerOpts{
@@ -89,44 +109,66 @@ func (s *IPAMServer) run(stopCh <-chan struct{}) {
 	http.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
 		h.ServeHTTP(w, r)
 	})
-	go func() {
-		http.ListenAndServe(fmt.Sprintf(":%d", s.metricsPort), nil)
-	}()
 
 	//start up server rpc routine
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(
+		grpc.Creds(newPeerCredTransport()),
+		grpc.ChainUnaryInterceptor(tracing.UnaryServerInterceptor(), auth.unaryInterceptor),
+		grpc.StreamInterceptor(auth.streamInterceptor),
+	)
 	rpc.RegisterCNIBackendServer(grpcServer, s)
+	healthServer := grpchealth.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	health.Default.Watch(func(ready bool) {
+		status := healthpb.HealthCheckResponse_NOT_SERVING
+		if ready {
+			status = healthpb.HealthCheckResponse_SERVING
+		}
+		healthServer.SetServingStatus("", status)
+		healthServer.SetServingStatus(rpc.CNIBackend_ServiceDesc.ServiceName, status)
+	})
+	health.RegisterLiveness(health.LivenessGRPCServer, 0)
 	go func() {
-		grpcServer.Serve(listener)
+		if err := grpcServer.Serve(listener); err != nil {
+			log.Errorf("grpc server exited: %v", err)
+			health.SetDead(health.LivenessGRPCServer, fmt.Errorf("grpc server exited: %v", err))
+		}
 	}()
 
 	log.Info("server grpc server started")
 	<-stopCh
+	healthServer.Shutdown()
 	grpcServer.Stop()
 	log.Info("server grpc server stopped")
 }
 
-func (s *IPAMServer) getK8sPodInfo(podName, podNamespace string) (ipList []string, err error) {
-	pod, _ := s.kubeclient.CoreV1().Pods(podNamespace).Get(context.Background(), podName, metav1.GetOptions{})
+func (s *IPAMServer) getK8sPodInfo(podName, podNamespace string) (pod *corev1.Pod, ipList []string, err error) {
+	pod, _ = s.kubeclient.CoreV1().Pods(podNamespace).Get(context.Background(), podName, metav1.GetOptions{})
 	ipAddr, ok := pod.Annotations[constants.CalicoAnnotationIpAddr]
 	if ipAddr == "" || !ok {
-		return ipList, nil
+		return pod, ipList, nil
 	}
 	err = json.Unmarshal([]byte(ipAddr), &ipList)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse '%s' as JSON: %s", ipAddr, err)
+		return pod, nil, fmt.Errorf("failed to parse '%s' as JSON: %s", ipAddr, err)
 	}
 
 	for i := 0; i < len(ipList); i++ {
 		if net.ParseIP(ipList[i]) == nil {
-			return nil, fmt.Errorf("ip[%s] failed to parse err", ipList[i])
+			return pod, nil, fmt.Errorf("ip[%s] failed to parse err", ipList[i])
 		}
 	}
 	return
 }
 
+// Version returns the protocol version and features of the daemon
+func (s *IPAMServer) Version(context context.Context, in *rpc.VersionInfo) (*rpc.VersionInfo, error) {
+	log.Infof("Version request (%v)", in)
+	return rpc.NewVersionInfo(rpc.DaemonFeatures), nil
+}
+
 // AddNetwork handle add pod request
-func (s *IPAMServer) AddNetwork(context context.Context, in *rpc.IPAMMessage) (*rpc.IPAMMessage, error) {
+func (s *IPAMServer) AddNetwork(ctx context.Context, in *rpc.IPAMMessage) (*rpc.IPAMMessage, error) {
 	var (
 		err      error
 		info     ipam.PoolInfo
@@ -135,16 +177,24 @@ func (s *IPAMServer) AddNetwork(context context.Context, in *rpc.IPAMMessage) (*
 		handleID string
 	)
 
-	log.Infof("AddNetwork request (%v)", in.Args)
+	log.Infof("AddNetwork request (%v) from plugin (%v)", in.Args, rpc.PeerVersion(in))
+	in.Protocol, in.Features = rpc.ProtocolCurrent, rpc.DaemonFeatures
+	start := time.Now()
 	defer func() {
+		observeStage("add", "total", start)
 		log.Infof("AddNetwork reply (%s): from (%v) get (%s) nic (%s) %v", handleID, info, podIP, allocator.GetNicKey(in.Nic), err)
 	}()
 
-	handleID = podHandleKey(in.Args)
-	ipList, err := s.getK8sPodInfo(in.Args.Name, in.Args.Namespace)
+	handleID = PodHandleKey(in.Args)
+	tracing.SpanFromContext(ctx).SetAttributes(podAttributes(in.Args)...)
+	pod, ipList, err := s.getK8sPodInfo(in.Args.Name, in.Args.Namespace)
+	podRef := events.PodReference(in.Args.Namespace, in.Args.Name, pod.GetUID())
 	if err != nil {
+		countFailure("add", reasonGetPodFailed, in.Args.Namespace, "")
+		events.Eventf(podRef, corev1.EventTypeWarning, events.ReasonAllocateIPFailed, "Invalid fixed ip annotation: %v", err)
 		return nil, err
 	}
+	stageStart := observeStage("add", "get_pod", start)
 
 	attrs := map[string]string{
 		ipam.IPAMBlockAttributeNamespace: in.Args.Namespace,
@@ -153,92 +203,128 @@ func (s *IPAMServer) AddNetwork(context context.Context, in *rpc.IPAMMessage) (*
 		ipam.IPAMBlockAttributeTimestamp: time.Now().UTC().String(),
 	}
 
+	if rst, err = s.assignPodIP(ctx, in, handleID, ipList, attrs, &info); err != nil {
+		if len(ipList) > 0 {
+			events.Eventf(podRef, corev1.EventTypeWarning, events.ReasonFixedIPConflict, "Failed to assign fixed ips %v: %v", ipList, err)
+		} else {
+			events.Eventf(podRef, corev1.EventTypeWarning, events.ReasonAllocateIPFailed, "Failed to assign ip: %v", err)
+		}
+		return nil, err
+	}
+
+	podIP = rst.IPs[0].Address.IP.String()
+	stageStart = observeStage("add", "ipam_assign", stageStart)
+
+	if s.conf.NetworkPolicy == "calico" {
+		// patch pod's annotations for calico policy
+		if err := s.patchPodIPAnnotations(ctx, in.Args.Namespace, in.Args.Name, podIP); err != nil {
+			countFailure("add", reasonAnnotationPatchFailed, in.Args.Namespace, info.IPPool)
+			if err := s.ipamclient.ReleaseByHandle(handleID); err != nil {
+				log.Errorf("AddNetwork request (%v) ReleaseByHandle failed: %v", in.Args, err)
+			}
+			return nil, err
+		}
+		stageStart = observeStage("add", "annotation_patch", stageStart)
+	}
+
+	in.Args.VxNet = info.IPPool
+	in.Args.PodIP = podIP
+	in.IP = podIP
+	in.Nic, err = allocator.Alloc.AllocHostNic(ctx, in.Args)
+	observeStage("add", "alloc_hostnic", stageStart)
+	if err != nil {
+		countFailure("add", allocHostNicFailureReason(err), in.Args.Namespace, info.IPPool)
+		events.Eventf(podRef, corev1.EventTypeWarning, events.ReasonAllocateNicFailed, "Failed to allocate nic of vxnet %s for ip %s: %v", info.IPPool, podIP, err)
+	}
+	return in, err
+}
+
+// assignPodIP assigns an ip to the pod from the blocks of its namespace or the default pools
+func (s *IPAMServer) assignPodIP(ctx context.Context, in *rpc.IPAMMessage, handleID string, ipList []string, attrs map[string]string, info *ipam.PoolInfo) (rst *current.Result, err error) {
+	_, span := tracing.Start(ctx, "ipam.Assign", tracing.Int("ipam.fixed_ips", len(ipList)))
+	defer func() {
+		span.SetAttributes(tracing.String("ipam.pool", info.IPPool), tracing.String("ipam.block", info.Block))
+		span.End(err)
+	}()
+
 	if blocks := s.clusterConfig.GetBlocksForAPP(in.Args.Namespace); len(blocks) > 0 {
 		if len(ipList) > 0 {
-			rst, err = s.ipamclient.AssignFixIps(handleID, ipList, nil, blocks, &info, attrs)
+			rst, err = s.ipamclient.AssignFixIps(handleID, ipList, nil, blocks, info, attrs)
 			if err != nil {
+				countFailure("add", reasonFixedIPFailed, in.Args.Namespace, "")
 				return nil, err
 			}
 		} else if rst, err = s.ipamclient.AutoAssignFromBlocks(ipam.AutoAssignArgs{
 			HandleID: handleID,
 			Blocks:   blocks,
-			Info:     &info,
+			Info:     info,
 			Attrs:    attrs,
 		}); err != nil {
-			(*s.oddPodCount).BlockFailedCount = (*s.oddPodCount).BlockFailedCount + 1
+			countFailure("add", assignFailureReason(err), in.Args.Namespace, "")
 			return nil, err
 		}
 	} else if pools := s.clusterConfig.GetDefaultIPPools(); len(pools) > 0 {
 		if len(ipList) > 0 {
-			rst, err = s.ipamclient.AssignFixIps(handleID, ipList, pools, nil, &info, attrs)
+			rst, err = s.ipamclient.AssignFixIps(handleID, ipList, pools, nil, info, attrs)
 			if err != nil {
+				countFailure("add", reasonFixedIPFailed, in.Args.Namespace, "")
 				return nil, err
 			}
 		} else if rst, err = s.ipamclient.AutoAssignFromPools(ipam.AutoAssignArgs{
 			HandleID: handleID,
 			Pools:    pools,
-			Info:     &info,
+			Info:     info,
 			Attrs:    attrs,
 		}); err != nil {
-			(*s.oddPodCount).PoolFailedCount = (*s.oddPodCount).PoolFailedCount + 1
+			countFailure("add", assignFailureReason(err), in.Args.Namespace, "")
 			return nil, err
 		}
 	} else {
-		(*s.oddPodCount).NotFoundCount = (*s.oddPodCount).NotFoundCount + 1
+		countFailure("add", reasonNoPool, in.Args.Namespace, "")
 		return nil, fmt.Errorf("pool or block not found")
 	}
 
-	podIP = rst.IPs[0].Address.IP.String()
-
-	if s.conf.NetworkPolicy == "calico" {
-		// patch pod's annotations for calico policy
-		if err := s.patchPodIPAnnotations(in.Args.Namespace, in.Args.Name, podIP); err != nil {
-			if err := s.ipamclient.ReleaseByHandle(handleID); err != nil {
-				log.Errorf("AddNetwork request (%v) ReleaseByHandle failed: %v", in.Args, err)
-			}
-			return nil, err
-		}
-	}
-
-	in.Args.VxNet = info.IPPool
-	in.Args.PodIP = podIP
-	in.IP = podIP
-	in.Nic, err = allocator.Alloc.AllocHostNic(in.Args)
-	if err != nil {
-		(*s.oddPodCount).AllocFailedCount = (*s.oddPodCount).AllocFailedCount + 1
-	}
-	return in, err
+	return rst, nil
 }
 
 // DelNetwork handle del pod request
-func (s *IPAMServer) DelNetwork(context context.Context, in *rpc.IPAMMessage) (*rpc.IPAMMessage, error) {
+func (s *IPAMServer) DelNetwork(ctx context.Context, in *rpc.IPAMMessage) (*rpc.IPAMMessage, error) {
 	var (
 		err      error
 		handleID string
 	)
 
-	log.Infof("DelNetwork request (%v)", in.Args)
+	log.Infof("DelNetwork request (%v) from plugin (%v)", in.Args, rpc.PeerVersion(in))
+	in.Protocol, in.Features = rpc.ProtocolCurrent, rpc.DaemonFeatures
+	start := time.Now()
 	defer func() {
+		observeStage("del", "total", start)
 		log.Infof("DelNetwork reply (%s): ip (%v) nic (%s) %v", handleID, in.IP, allocator.GetNicKey(in.Nic), err)
 	}()
 
-	handleID = podHandleKey(in.Args)
+	handleID = PodHandleKey(in.Args)
+	tracing.SpanFromContext(ctx).SetAttributes(podAttributes(in.Args)...)
 
 	//get nic and pod ip info here
 	in.Nic, in.IP, _ = allocator.Alloc.FreeHostNic(in.Args, true)
+	stageStart := observeStage("del", "peek_hostnic", start)
 
 	// if no nic or pod record in db, get ip by handleID
 	// this ip only used for log
 	if in.IP == "" {
 		//get ip by handleID
+		_, span := tracing.Start(ctx, "ipam.GetIPByHandleID")
 		ips, err := s.ipamclient.GetIPByHandleID(handleID)
+		span.End(err)
 		if err != nil {
+			countFailure("del", reasonGetHandleFailed, in.Args.Namespace, podVxnet(in))
 			return in, fmt.Errorf("get ip by handleID %s error: %v", handleID, err)
 		}
 		if len(ips) > 0 {
 			in.IP = ips[0]
 			log.Infof("get ip %v by handleID %s success", ips, handleID)
 		}
+		stageStart = observeStage("del", "ipam_get_handle", stageStart)
 	}
 
 	if in.Peek {
@@ -247,21 +333,48 @@ func (s *IPAMServer) DelNetwork(context context.Context, in *rpc.IPAMMessage) (*
 
 	//release ip in ipamblock
 	log.Infof("going to release ip (%s) by handleID %s", in.IP, handleID)
-	if err = s.ipamclient.ReleaseByHandle(handleID); err != nil {
-		(*s.oddPodCount).FreeFromPoolFailedCount = (*s.oddPodCount).FreeFromPoolFailedCount + 1
+	_, span := tracing.Start(ctx, "ipam.ReleaseByHandle", tracing.String("k8s.pod.ip", in.IP))
+	err = s.ipamclient.ReleaseByHandle(handleID)
+	span.End(err)
+	if err != nil {
+		countFailure("del", reasonReleaseFailed, in.Args.Namespace, podVxnet(in))
 		return in, fmt.Errorf("release ip %s by handleID %s error: %v", in.IP, handleID, err)
 	}
+	stageStart = observeStage("del", "ipam_release", stageStart)
 
 	//clear pod db record
 	log.Infof("release ip (%s) by handleID %s success, going to clear db record for hostnic", in.IP, handleID)
 	_, _, err = allocator.Alloc.FreeHostNic(in.Args, in.Peek)
+	observeStage("del", "free_hostnic", stageStart)
 	if err != nil {
-		(*s.oddPodCount).FreeFromHostFailedCount = (*s.oddPodCount).FreeFromHostFailedCount + 1
+		countFailure("del", reasonFreeHostNicFailed, in.Args.Namespace, podVxnet(in))
 		return in, fmt.Errorf("clear pod db record error for %s: %v", handleID, err)
 	}
 	return in, nil
 }
 
+// ReportStages exports stage timings reported by the plugin
+func (s *IPAMServer) ReportStages(context context.Context, in *rpc.StageReport) (*rpc.Nothing, error) {
+	// stage names become label values, so only accept what the plugin is able to send
+	if in.Op != "add" && in.Op != "del" {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown op %s", in.Op)
+	}
+	for _, stage := range in.Stages {
+		if !stageNameRegexp.MatchString(stage.Name) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid stage name %s", stage.Name)
+		}
+	}
+
+	result := "success"
+	if in.Failed {
+		result = "failed"
+	}
+	for _, stage := range in.Stages {
+		pluginStageDuration.WithLabelValues(in.Op, stage.Name, result).Observe(stage.Seconds)
+	}
+	return &rpc.Nothing{}, nil
+}
+
 func (s *IPAMServer) ShowNics(context context.Context, in *rpc.Nothing) (*rpc.NicInfoList, error) {
 	log.Info("ShowNics request")
 	ret := &rpc.NicInfoList{}
@@ -289,13 +402,139 @@ func (s *IPAMServer) ClearNics(context context.Context, in *rpc.Nothing) (*rpc.N
 	return in, err
 }
 
-func (s *IPAMServer) patchPodIPAnnotations(ns, podName string, ip string) error {
+func (s *IPAMServer) ListPods(context context.Context, in *rpc.Nothing) (*rpc.PodNetInfoList, error) {
+	log.Info("ListPods request")
+	ret := &rpc.PodNetInfoList{
+		Items: allocator.Alloc.ListPods(),
+	}
+	log.Infof("ListPods reply: %d pods", len(ret.Items))
+	return ret, nil
+}
+
+func (s *IPAMServer) GetPod(context context.Context, in *rpc.PodInfo) (*rpc.PodNetwork, error) {
+	log.Infof("GetPod request (%v)", in)
+	nic, pod := allocator.Alloc.GetPod(in)
+	if pod == nil {
+		return nil, fmt.Errorf("no db record for pod %s/%s %s", in.Namespace, in.Name, in.Containter)
+	}
+
+	ret := &rpc.PodNetwork{
+		Pod: pod,
+		Nic: nic,
+	}
+	var err error
+	if ret.Rules, err = networkutils.GetRulesByDst(pod.PodIP); err != nil {
+		return nil, fmt.Errorf("failed to get rules for pod ip %s: %v", pod.PodIP, err)
+	}
+	if ret.ArpReplies, err = networkutils.GetArpReplyByIP(pod.PodIP); err != nil {
+		return nil, fmt.Errorf("failed to get arp reply for pod ip %s: %v", pod.PodIP, err)
+	}
+	return ret, nil
+}
+
+func (s *IPAMServer) DumpNicRoutes(context context.Context, in *rpc.NicRequest) (*rpc.NicRouteList, error) {
+	log.Infof("DumpNicRoutes request (%v)", in)
+	ret := &rpc.NicRouteList{}
+	for _, nic := range allocator.Alloc.GetHostNics(in.Vxnet, in.Nic) {
+		routes, rules, err := networkutils.DumpRouteTable(int(nic.RouteTableNum))
+		if err != nil {
+			return nil, err
+		}
+		ret.Items = append(ret.Items, &rpc.NicRoutes{
+			Id:            nic.ID,
+			Vxnet:         nic.VxNet.ID,
+			RouteTableNum: nic.RouteTableNum,
+			Bridge:        constants.GetHostNicBridgeName(int(nic.RouteTableNum)),
+			Routes:        routes,
+			Rules:         rules,
+		})
+	}
+	return ret, nil
+}
+
+func (s *IPAMServer) RepairNic(context context.Context, in *rpc.NicRequest) (*rpc.NicInfo, error) {
+	log.Infof("RepairNic request (%v)", in)
+	if in.Vxnet == "" && in.Nic == "" {
+		return nil, fmt.Errorf("vxnet or nic should be specified")
+	}
+
+	nic, err := allocator.Alloc.RepairHostNic(in.Vxnet, in.Nic, in.Resetup)
+	if nic == nil {
+		return nil, err
+	}
+	// grpc drops the reply along with an error, so a failed repair is reported in the reply
+	ret := &rpc.NicInfo{
+		Id:     nic.ID,
+		Vxnet:  nic.VxNet.ID,
+		Phase:  nic.Phase.String(),
+		Status: nic.Status.String(),
+	}
+	if err != nil {
+		ret.Error = err.Error()
+	}
+	log.Infof("RepairNic reply: %v", ret)
+	return ret, nil
+}
+
+func (s *IPAMServer) WatchNics(in *rpc.WatchRequest, stream rpc.CNIBackend_WatchNicsServer) error {
+	log.Infof("WatchNics request (%v)", in)
+	return watchEvents(stream.Context(), in, func(e *allocator.Event) error {
+		if e.Nic == nil {
+			return nil
+		}
+		return stream.Send(e.Nic)
+	})
+}
+
+func (s *IPAMServer) WatchPods(in *rpc.WatchRequest, stream rpc.CNIBackend_WatchPodsServer) error {
+	log.Infof("WatchPods request (%v)", in)
+	return watchEvents(stream.Context(), in, func(e *allocator.Event) error {
+		if e.Pod == nil {
+			return nil
+		}
+		return stream.Send(e.Pod)
+	})
+}
+
+// watchEvents sends allocator events after the sequence of req until ctx is done
+func watchEvents(ctx context.Context, req *rpc.WatchRequest, send func(e *allocator.Event) error) error {
+	epoch, seq := req.Epoch, req.Sequence
+	if seq == 0 {
+		epoch, seq = allocator.Alloc.Epoch(), allocator.Alloc.LatestSequence()
+	}
+
+	for {
+		events, next, err := allocator.Alloc.EventsSince(epoch, seq)
+		if err != nil {
+			return status.Error(codes.OutOfRange, err.Error())
+		}
+		for _, e := range events {
+			if err := send(e); err != nil {
+				return err
+			}
+			seq = e.Sequence
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-next:
+		}
+	}
+}
+
+func (s *IPAMServer) patchPodIPAnnotations(ctx context.Context, ns, podName string, ip string) (err error) {
+	ctx, span := tracing.Start(ctx, "PatchPodIPAnnotations")
+	defer func() {
+		span.End(err)
+	}()
+
 	patch, err := calculateAnnotationPatch(constants.CalicoAnnotationPodIP, ip, constants.CalicoAnnotationPodIPs, ip)
 	if err != nil {
 		return err
 	}
 
-	_, err = s.kubeclient.CoreV1().Pods(ns).Patch(context.TODO(), podName, types.StrategicMergePatchType, patch, metav1.PatchOptions{}, "status")
+	_, err = s.kubeclient.CoreV1().Pods(ns).Patch(ctx, podName, types.StrategicMergePatchType, patch, metav1.PatchOptions{}, "status")
 	if err != nil {
 		return err
 	}
@@ -303,10 +542,27 @@ func (s *IPAMServer) patchPodIPAnnotations(ns, podName string, ip string) error
 	return nil
 }
 
-func podHandleKey(pod *rpc.PodInfo) string {
+// PodHandleKey is the ipam handle which the ip of pod is assigned with
+func PodHandleKey(pod *rpc.PodInfo) string {
 	return pod.Namespace + "-" + pod.Name + "-" + pod.Containter
 }
 
+func podAttributes(pod *rpc.PodInfo) []tracing.Attribute {
+	return []tracing.Attribute{
+		tracing.String("k8s.namespace.name", pod.Namespace),
+		tracing.String("k8s.pod.name", pod.Name),
+		tracing.String("container.id", pod.Containter),
+	}
+}
+
+// podVxnet returns the vxnet of the pod being deleted, it is empty if the pod has no db record
+func podVxnet(in *rpc.IPAMMessage) string {
+	if in.Nic != nil && in.Nic.VxNet != nil {
+		return in.Nic.VxNet.ID
+	}
+	return in.Args.VxNet
+}
+
 func calculateAnnotationPatch(namesAndValues ...string) ([]byte, error) {
 	patch := map[string]interface{}{}
 	metadata := map[string]interface{}{}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/yunify/hostnic-cni/pkg/allocator"
+	"github.com/yunify/hostnic-cni/pkg/conf"
+	"github.com/yunify/hostnic-cni/pkg/constants"
+	"github.com/yunify/hostnic-cni/pkg/db"
+	"github.com/yunify/hostnic-cni/pkg/networkutils"
+	"github.com/yunify/hostnic-cni/pkg/qcclient/fake"
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+)
+
+// failingNetwork fails to setup the network of every nic
+type failingNetwork struct {
+	networkutils.NetworkUtilsFake
+}
+
+func (n failingNetwork) SetupNetwork(nic *rpc.HostNic) (rpc.Phase, error) {
+	return rpc.Phase_CreateAndAttach, errors.New("bridge not found")
+}
+
+// setupTestAllocator sets up allocator.Alloc with the nic of vxnet-a restored from db
+func setupTestAllocator(t *testing.T) {
+	fake.Setup(fake.NewCloud("i-test"))
+
+	helper := networkutils.NetworkHelper
+	networkutils.NetworkHelper = failingNetwork{}
+	defaultStore := db.DefaultStore
+	db.DefaultStore = db.NewMemoryStore()
+	alloc := allocator.Alloc
+	t.Cleanup(func() {
+		networkutils.NetworkHelper = helper
+		db.DefaultStore = defaultStore
+		allocator.Alloc = alloc
+	})
+
+	store := db.NewMemoryStore()
+	value, _ := json.Marshal(map[string]interface{}{
+		"Nic": &rpc.HostNic{
+			ID:            "52:54:00:00:01:00",
+			VxNet:         &rpc.VxNet{ID: "vxnet-a", Gateway: "192.168.0.1", Network: "192.168.0.0/24"},
+			RouteTableNum: constants.DefaultRouteTableBase,
+			Phase:         rpc.Phase_Succeeded,
+		},
+	})
+	if err := store.Put(db.NicKey("vxnet-a"), value); err != nil {
+		t.Fatalf("failed to put nic record: %v", err)
+	}
+	allocator.SetupAllocator(conf.PoolConf{MaxNic: 2, RouteTableBase: constants.DefaultRouteTableBase}, store, nil)
+}
+
+func TestRepairNic(t *testing.T) {
+	setupTestAllocator(t)
+	s := &IPAMServer{}
+
+	if _, err := s.RepairNic(context.Background(), &rpc.NicRequest{}); err == nil {
+		t.Fatalf("expect error without vxnet or nic")
+	}
+	if _, err := s.RepairNic(context.Background(), &rpc.NicRequest{Vxnet: "vxnet-b"}); err == nil {
+		t.Fatalf("expect error of an unknown nic")
+	}
+
+	// the failure is reported along with the resulting phase
+	info, err := s.RepairNic(context.Background(), &rpc.NicRequest{Vxnet: "vxnet-a", Resetup: true})
+	if err != nil {
+		t.Fatalf("expect the reply of a failed repair, got %v", err)
+	}
+	if info.Id != "52:54:00:00:01:00" || info.Phase != rpc.Phase_CreateAndAttach.String() || info.Error != "bridge not found" {
+		t.Fatalf("unexpected reply %v", info)
+	}
+}
+
+type nicEventStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	cancel context.CancelFunc
+	events []*rpc.NicEvent
+}
+
+func (s *nicEventStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *nicEventStream) Send(e *rpc.NicEvent) error {
+	s.events = append(s.events, e)
+	s.cancel()
+	return nil
+}
+
+func TestWatchNics(t *testing.T) {
+	setupTestAllocator(t)
+	s := &IPAMServer{}
+
+	req := &rpc.NicRequest{Nic: "52:54:00:00:01:00", Resetup: true}
+	s.RepairNic(context.Background(), req)
+	seq := allocator.Alloc.LatestSequence()
+	s.RepairNic(context.Background(), req)
+
+	// resumes after the first repair
+	stream := &nicEventStream{}
+	stream.ctx, stream.cancel = context.WithCancel(context.Background())
+	if err := s.WatchNics(&rpc.WatchRequest{Sequence: seq, Epoch: allocator.Alloc.Epoch()}, stream); err != nil {
+		t.Fatalf("WatchNics error: %v", err)
+	}
+	if len(stream.events) != 1 {
+		t.Fatalf("expect 1 event, got %v", stream.events)
+	}
+	if e := stream.events[0]; e.Sequence != seq+1 || e.Type != rpc.NicEventType_NicRepair || e.Nic.ID != "52:54:00:00:01:00" || e.Message != "bridge not found" {
+		t.Fatalf("unexpected event %v", e)
+	}
+
+	// the sequence of the daemon before restart
+	stream.ctx, stream.cancel = context.WithCancel(context.Background())
+	err := s.WatchNics(&rpc.WatchRequest{Sequence: seq, Epoch: "stale"}, stream)
+	if status.Code(err) != codes.OutOfRange {
+		t.Fatalf("expect OutOfRange of a stale epoch, got %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/yunify/hostnic-cni/pkg/rpc"
+)
+
+// startServer serves the handlers of s on a unix socket, the way the plugin connects to the daemon
+func startServer(t *testing.T, s *IPAMServer) rpc.CNIBackendClient {
+	socket := filepath.Join(t.TempDir(), "hostnic.socket")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("failed to listen %s: %v", socket, err)
+	}
+	grpcServer := grpc.NewServer(grpc.Creds(newPeerCredTransport()))
+	rpc.RegisterCNIBackendServer(grpcServer, s)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.Dial("unix://"+socket, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to dial %s: %v", socket, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return rpc.NewCNIBackendClient(conn)
+}
+
+func TestNegotiateWithIPAMServer(t *testing.T) {
+	setupTestAllocator(t)
+	c := startServer(t, &IPAMServer{})
+	ctx := context.Background()
+
+	version, err := rpc.NegotiateWithDaemon(ctx, c, rpc.NewVersionInfo(rpc.PluginFeatures))
+	if err != nil {
+		t.Fatalf("negotiate with the daemon failed: %v", err)
+	}
+	if version.Protocol != rpc.ProtocolCurrent {
+		t.Errorf("expect protocol %d, got %d", rpc.ProtocolCurrent, version.Protocol)
+	}
+	if len(version.Features) != len(rpc.PluginFeatures) {
+		t.Errorf("expect all plugin features %v, got %v", rpc.PluginFeatures, version.Features)
+	}
+
+	// a plugin of an older protocol gets its own protocol
+	version, err = rpc.NegotiateWithDaemon(ctx, c, rpc.LegacyVersionInfo())
+	if err != nil || version.Protocol != rpc.ProtocolLegacy || len(version.Features) != 0 {
+		t.Errorf("expect legacy version, got %v %v", version, err)
+	}
+
+	// the rpcs behind the features the daemon advertises are implemented
+	daemon, err := c.Version(ctx, rpc.NewVersionInfo(nil))
+	if err != nil {
+		t.Fatalf("Version failed: %v", err)
+	}
+	for feature, call := range map[string]func() error{
+		rpc.FeatureDebug: func() error {
+			_, err := c.ListPods(ctx, &rpc.Nothing{})
+			return err
+		},
+		rpc.FeatureWatch: func() error {
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			stream, err := c.WatchNics(ctx, &rpc.WatchRequest{})
+			if err != nil {
+				return err
+			}
+			cancel()
+			_, err = stream.Recv()
+			return err
+		},
+		rpc.FeatureStageReport: func() error {
+			_, err := c.ReportStages(ctx, &rpc.StageReport{Op: "add", Stages: []*rpc.Stage{{Name: "alloc", Seconds: 0.1}}})
+			return err
+		},
+	} {
+		if !daemon.HasFeature(feature) {
+			t.Errorf("expect daemon feature %s, got %v", feature, daemon.Features)
+			continue
+		}
+		if err := call(); status.Code(err) == codes.Unimplemented {
+			t.Errorf("rpc of feature %s is not implemented: %v", feature, err)
+		}
+	}
+}
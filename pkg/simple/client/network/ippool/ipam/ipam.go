@@ -69,6 +69,8 @@ var (
 	ErrNoFreeBlocks     = errors.New("no free blocks in ippool")
 	ErrMaxRetry         = errors.New("Max retries hit - excessive concurrent IPAM requests")
 	ErrUnknowIPPoolType = errors.New("unknow ippool type")
+	// wraps the errors of requests to the apiserver or listers
+	ErrDatastore = errors.New("ipam datastore request failed")
 )
 
 func (c IPAMClient) getAllPools() ([]v1alpha1.IPPool, error) {
@@ -524,7 +526,7 @@ func (c IPAMClient) GetUtilization(args GetUtilizationArgs) ([]*PoolUtilization,
 	}
 
 	if len(allPools) <= 0 {
-		return nil, fmt.Errorf("not found pool")
+		return nil, fmt.Errorf("not found pool: %w", ErrNoQualifiedPool)
 	}
 
 	// Identify the ones we want and create a PoolUtilization for each of those.
@@ -579,7 +581,7 @@ func (c IPAMClient) GetPoolBlocksUtilization(args GetUtilizationArgs) ([]*PoolBl
 	}
 
 	if len(allPools) <= 0 {
-		return nil, fmt.Errorf("not found pool")
+		return nil, fmt.Errorf("not found pool: %w", ErrNoQualifiedPool)
 	}
 
 	// Identify the ones we want and create a PoolUtilization for each of those.
@@ -644,7 +646,7 @@ func (c IPAMClient) GetAndFixBrokenBlocks(args GetUtilizationArgs, fix bool) ([]
 	}
 
 	if len(allPools) <= 0 {
-		return nil, fmt.Errorf("not found pool")
+		return nil, fmt.Errorf("not found pool: %w", ErrNoQualifiedPool)
 	}
 
 	// Identify the ones we want and create a PoolUtilization for each of those.
@@ -988,17 +990,28 @@ func (c IPAMClient) deleteHandle(h *v1alpha1.IPAMHandle) error {
 	return c.client.NetworkV1alpha1().IPAMHandles().Delete(context.Background(), h.Name, metav1.DeleteOptions{})
 }
 
+// AutoAssignFromPools assigns an ip from the first of args.Pools with free addresses. The error wraps
+// ErrNoQualifiedPool if none of the pools is found, ErrNoFreeBlocks if they are all exhausted, and
+// ErrDatastore if the utilization cannot be got.
 func (c IPAMClient) AutoAssignFromPools(args AutoAssignArgs) (*current.Result, error) {
 	utils, err := c.GetUtilization(GetUtilizationArgs{args.Pools})
 	if err != nil {
-		return nil, err
+		if errors.Is(err, ErrNoQualifiedPool) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: get utilization of %v: %v", ErrDatastore, args.Pools, err)
+	}
+	if len(utils) <= 0 {
+		return nil, fmt.Errorf("pools %v: %w", args.Pools, ErrNoQualifiedPool)
 	}
 
+	lastErr := ErrNoFreeBlocks
 	for _, util := range utils {
 		if util.Unallocated > 1 {
 			args.Pool = util.Name
 			if r, err := c.AutoAssign(args); err != nil {
 				klog.Warningf("AutoAssign from pool %s failed: %v", util.Name, err)
+				lastErr = err
 				continue
 			} else {
 				return r, nil
@@ -1006,21 +1019,36 @@ func (c IPAMClient) AutoAssignFromPools(args AutoAssignArgs) (*current.Result, e
 		}
 	}
 
-	return nil, fmt.Errorf("no appropriate ippool found")
+	return nil, fmt.Errorf("no appropriate ippool found: %w", lastErr)
 }
 
+// AutoAssignFromBlocks assigns an ip from the first of args.Blocks with free addresses. The error wraps
+// ErrNoQualifiedPool if none of the blocks is found, ErrDatastore if they cannot be got, ErrNoFreeBlocks
+// if they are all exhausted, and ErrMaxRetry if assigning from the free ones failed.
 func (c IPAMClient) AutoAssignFromBlocks(args AutoAssignArgs) (*current.Result, error) {
 	var blocks []*v1alpha1.IPAMBlock
+	var getErr error
 	for _, block := range args.Blocks {
 		if b, err := c.client.NetworkV1alpha1().IPAMBlocks().Get(context.Background(), block, metav1.GetOptions{}); err == nil {
 			blocks = append(blocks, b)
 		} else {
 			klog.Warningf("Get block %s failed: %v", block, err)
+			if !k8serrors.IsNotFound(err) {
+				getErr = err
+			}
 		}
 	}
+	if len(blocks) <= 0 {
+		if getErr != nil {
+			return nil, fmt.Errorf("%w: get blocks %v: %v", ErrDatastore, args.Blocks, getErr)
+		}
+		return nil, fmt.Errorf("blocks %v: %w", args.Blocks, ErrNoQualifiedPool)
+	}
 
+	exhausted := true
 	for _, block := range blocks {
 		if block.NumFreeAddresses() >= 1 {
+			exhausted = false
 			if ip, err := c.autoAssignFromBlock(args.HandleID, args.Attrs, block); err == nil {
 				poolName := block.Labels[networkv1alpha1.IPPoolNameLabel]
 				if pool, err := c.ippoolsLister.Get(poolName); err == nil {
@@ -1038,6 +1066,9 @@ func (c IPAMClient) AutoAssignFromBlocks(args AutoAssignArgs) (*current.Result,
 		}
 	}
 
+	if exhausted {
+		return nil, fmt.Errorf("blocks %v: %w", args.Blocks, ErrNoFreeBlocks)
+	}
 	return nil, ErrMaxRetry
 }
 
@@ -1580,6 +1611,30 @@ func (c IPAMClient) ReleaseByIP(ip string) error {
 	return nil
 }
 
+// GetNodeHandles returns the ips assigned with the node attribute of node, keyed by their handles
+func (c IPAMClient) GetNodeHandles(node string) (map[string][]string, error) {
+	blocks, err := c.ipamblocksLister.List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("list ipamblocks error: %v", err)
+	}
+
+	handles := make(map[string][]string)
+	for _, block := range blocks {
+		for ordinal, attrIndex := range block.Spec.Allocations {
+			if attrIndex == nil || *attrIndex >= len(block.Spec.Attributes) {
+				continue
+			}
+			attr := block.Spec.Attributes[*attrIndex]
+			if attr.AttrPrimary == "" || attr.AttrSecondary[IPAMBlockAttributeNode] != node {
+				continue
+			}
+			ip, _ := block.OrdinalToIP(ordinal)
+			handles[attr.AttrPrimary] = append(handles[attr.AttrPrimary], ip.String())
+		}
+	}
+	return handles, nil
+}
+
 func (c IPAMClient) GetIPByHandleID(handleID string) (ips []string, err error) {
 	handle, err := c.queryHandle(handleID)
 	if err != nil {
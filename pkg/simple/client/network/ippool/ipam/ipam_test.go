@@ -15,3 +15,83 @@ limitations under the License.
 */
 
 package ipam
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sinformers "k8s.io/client-go/informers"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/yunify/hostnic-cni/pkg/apis/network/v1alpha1"
+	"github.com/yunify/hostnic-cni/pkg/client/clientset/versioned/fake"
+	"github.com/yunify/hostnic-cni/pkg/client/informers/externalversions"
+)
+
+func TestAutoAssignFromBlocksErrors(t *testing.T) {
+	full := &v1alpha1.IPAMBlock{
+		ObjectMeta: metav1.ObjectMeta{Name: "4100-172-16-4-0-26"},
+		Spec:       v1alpha1.IPAMBlockSpec{CIDR: "172.16.4.0/26"},
+	}
+	client := fake.NewSimpleClientset(full)
+	c := NewIPAMClient(client, v1alpha1.VLAN, externalversions.NewSharedInformerFactory(client, 0),
+		k8sinformers.NewSharedInformerFactory(nil, 0))
+
+	args := AutoAssignArgs{HandleID: "default.pod", Info: &PoolInfo{}}
+	for _, tc := range []struct {
+		name   string
+		blocks []string
+		expect error
+	}{
+		{"no block", []string{"4100-172-16-5-0-26"}, ErrNoQualifiedPool},
+		{"exhausted", []string{"4100-172-16-4-0-26", "4100-172-16-5-0-26"}, ErrNoFreeBlocks},
+	} {
+		args.Blocks = tc.blocks
+		if _, err := c.AutoAssignFromBlocks(args); !errors.Is(err, tc.expect) {
+			t.Errorf("%s: expect %v, got %v", tc.name, tc.expect, err)
+		}
+	}
+
+	client.PrependReactor("get", "ipamblocks", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("apiserver unavailable")
+	})
+	args.Blocks = []string{"4100-172-16-4-0-26"}
+	if _, err := c.AutoAssignFromBlocks(args); !errors.Is(err, ErrDatastore) {
+		t.Errorf("expect %v, got %v", ErrDatastore, err)
+	}
+}
+
+func TestGetNodeHandles(t *testing.T) {
+	zero, one, two := 0, 1, 2
+	block := &v1alpha1.IPAMBlock{
+		ObjectMeta: metav1.ObjectMeta{Name: "4100-172-16-4-0-30"},
+		Spec: v1alpha1.IPAMBlockSpec{
+			CIDR:        "172.16.4.0/30",
+			Allocations: []*int{&zero, nil, &one, &two},
+			Attributes: []v1alpha1.AllocationAttribute{
+				{AttrPrimary: "default-a-c1", AttrSecondary: map[string]string{IPAMBlockAttributeNode: "node-a"}},
+				{AttrPrimary: "default-b-c2", AttrSecondary: map[string]string{IPAMBlockAttributeNode: "node-b"}},
+				{AttrPrimary: "default-a-c3", AttrSecondary: map[string]string{IPAMBlockAttributeNode: "node-a"}},
+			},
+		},
+	}
+	client := fake.NewSimpleClientset()
+	informers := externalversions.NewSharedInformerFactory(client, 0)
+	informers.Network().V1alpha1().IPAMBlocks().Informer().GetIndexer().Add(block)
+	c := NewIPAMClient(client, v1alpha1.VLAN, informers, k8sinformers.NewSharedInformerFactory(nil, 0))
+
+	handles, err := c.GetNodeHandles("node-a")
+	if err != nil {
+		t.Fatalf("failed to get handles: %v", err)
+	}
+	expect := map[string][]string{
+		"default-a-c1": {"172.16.4.0"},
+		"default-a-c3": {"172.16.4.3"},
+	}
+	if !reflect.DeepEqual(handles, expect) {
+		t.Fatalf("expect handles %v, got %v", expect, handles)
+	}
+}
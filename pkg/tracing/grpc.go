@@ -0,0 +1,66 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// trace context travels in the W3C traceparent header
+var propagator = propagation.TraceContext{}
+
+// metadataCarrier adapts grpc metadata to a propagation.TextMapCarrier
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// UnaryClientInterceptor starts a client span for each call and passes its context in the grpc metadata
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, span := startSpan(ctx, method, trace.SpanKindClient, nil)
+		if span != nil {
+			md, _ := metadata.FromOutgoingContext(ctx)
+			md = md.Copy()
+			propagator.Inject(ctx, metadataCarrier(md))
+			ctx = metadata.NewOutgoingContext(ctx, md)
+		}
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		span.End(err)
+		return err
+	}
+}
+
+// UnaryServerInterceptor continues the trace of the caller, if any, with a server span for each call
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			// an invalid traceparent is ignored, the call starts a new trace
+			ctx = propagator.Extract(ctx, metadataCarrier(md))
+		}
+
+		ctx, span := startSpan(ctx, info.FullMethod, trace.SpanKindServer, nil)
+		resp, err := handler(ctx, req)
+		span.End(err)
+		return resp, err
+	}
+}
@@ -0,0 +1,198 @@
+// Package tracing follows a pod network request from the cni plugin through
+// hostnic-node down to the qingcloud api.
+//
+// Spans are recorded, sampled and batched by the OpenTelemetry Go SDK, and
+// trace context is carried between processes in the W3C traceparent header.
+//
+// The SDK is pinned at v1.2.0, the last release that does not depend on
+// go-logr v1. klog v2.9 and controller-runtime v0.9 vendored here are built
+// against go-logr v0.4, whose Logger is an interface instead of a struct, so
+// upgrading the SDK means upgrading them first.
+//
+// The OTLP exporters of the SDK are separate modules which pull in the
+// generated OTLP protos and their own grpc stack, so spans are posted by the
+// small exporter in export.go instead. Its limits:
+//   - only OTLP/HTTP with the JSON encoding, the http protocol of the
+//     collector's otlp receiver must be enabled
+//   - no compression, no tls options other than an https endpoint, no headers
+//   - a batch that fails to export is logged and dropped, it is not retried
+//   - span events, links and dropped counts are not sent
+//   - attribute values other than string, bool, int64 and float64 are sent as
+//     their string form
+package tracing
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+	log "k8s.io/klog/v2"
+)
+
+const (
+	instrumentationName = "github.com/yunify/hostnic-cni"
+
+	// spans are dropped once this many are waiting to be exported
+	maxQueueSize    = 2048
+	maxBatchSize    = 512
+	batchTimeout    = 5 * time.Second
+	exportTimeout   = 10 * time.Second
+	shutdownTimeout = 5 * time.Second
+)
+
+// Config of the trace exporter, tracing is disabled if Endpoint is empty.
+type Config struct {
+	// base url of the OTLP/HTTP receiver, e.g. http://otel-collector.kube-system:4318
+	Endpoint string `json:"endpoint,omitempty" yaml:"endpoint,omitempty"`
+	// fraction of new traces to record, traces started by a remote parent follow its decision
+	SampleRatio float64 `json:"sampleRatio,omitempty" yaml:"sampleRatio,omitempty"`
+}
+
+type Attribute = attribute.KeyValue
+
+func String(key, value string) Attribute {
+	return attribute.String(key, value)
+}
+
+func Int(key string, value int) Attribute {
+	return attribute.Int(key, value)
+}
+
+func Bool(key string, value bool) Attribute {
+	return attribute.Bool(key, value)
+}
+
+// Span is a timed operation of a trace. A nil Span is valid and records nothing,
+// it is returned while tracing is disabled.
+type Span struct {
+	span trace.Span
+}
+
+func (s *Span) SpanContext() trace.SpanContext {
+	if s == nil {
+		return trace.SpanContext{}
+	}
+	return s.span.SpanContext()
+}
+
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.span.SetAttributes(attrs...)
+}
+
+// End ends the span, a non-nil err marks the span failed.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	if err != nil {
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
+
+// SpanFromContext returns the current span in ctx, or nil
+func SpanFromContext(ctx context.Context) *Span {
+	span := trace.SpanFromContext(ctx)
+	if !span.SpanContext().IsValid() || span.SpanContext().IsRemote() {
+		return nil
+	}
+	return &Span{span: span}
+}
+
+// Start starts a span as a child of the current span in ctx, the span must be ended by the caller.
+func Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	return startSpan(ctx, name, trace.SpanKindInternal, attrs)
+}
+
+func startSpan(ctx context.Context, name string, kind trace.SpanKind, attrs []Attribute) (context.Context, *Span) {
+	p := getProvider()
+	if p == nil {
+		return ctx, nil
+	}
+
+	ctx, span := p.Tracer(instrumentationName).Start(ctx, name, trace.WithSpanKind(kind), trace.WithAttributes(attrs...))
+	return ctx, &Span{span: span}
+}
+
+var (
+	providerLock sync.RWMutex
+	current      *sdktrace.TracerProvider
+)
+
+func getProvider() *sdktrace.TracerProvider {
+	providerLock.RLock()
+	defer providerLock.RUnlock()
+
+	return current
+}
+
+func init() {
+	// failed exports are reported by the sdk through the global handler
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		log.Warningf("tracing: %v", err)
+	}))
+}
+
+// Setup starts exporting spans of service to conf.Endpoint. The returned
+// function flushes pending spans and stops exporting, short lived processes
+// like the cni plugin must call it before exiting.
+func Setup(service string, conf Config) func() {
+	if conf.Endpoint == "" {
+		return func() {}
+	}
+	return SetupWithExporter(service, newOTLPExporter(conf.Endpoint), conf.SampleRatio)
+}
+
+// SetupWithExporter is Setup with a custom exporter, e.g. a tracetest.InMemoryExporter in tests.
+// A sampleRatio out of (0, 1) records every trace.
+func SetupWithExporter(service string, exporter sdktrace.SpanExporter, sampleRatio float64) func() {
+	if sampleRatio <= 0 || sampleRatio > 1 {
+		sampleRatio = 1
+	}
+	p := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter,
+			sdktrace.WithMaxQueueSize(maxQueueSize),
+			sdktrace.WithMaxExportBatchSize(maxBatchSize),
+			sdktrace.WithBatchTimeout(batchTimeout),
+			sdktrace.WithExportTimeout(exportTimeout)),
+		// the decision is made by the trace id, so every process makes the same decision for a trace
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(sdkresource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(service))),
+	)
+
+	providerLock.Lock()
+	old := current
+	current = p
+	providerLock.Unlock()
+	if old != nil {
+		shutdown(service, old)
+	}
+
+	return func() {
+		providerLock.Lock()
+		if current == p {
+			current = nil
+		}
+		providerLock.Unlock()
+		shutdown(service, p)
+	}
+}
+
+func shutdown(service string, p *sdktrace.TracerProvider) {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := p.Shutdown(ctx); err != nil {
+		log.Warningf("failed to flush spans of %s: %v", service, err)
+	}
+}
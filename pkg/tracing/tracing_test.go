@@ -0,0 +1,183 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+)
+
+// testExporter keeps the spans after shutdown, the InMemoryExporter drops them
+type testExporter struct {
+	*tracetest.InMemoryExporter
+}
+
+func newTestExporter() testExporter {
+	return testExporter{tracetest.NewInMemoryExporter()}
+}
+
+func (e testExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+func TestInvalidTraceparent(t *testing.T) {
+	exporter := newTestExporter()
+	shutdown := SetupWithExporter("test", exporter, 1)
+	defer shutdown()
+
+	for _, value := range []string{
+		"",
+		"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331",
+		"00-00000000000000000000000000000000-b7ad6b7169203331-01",
+		"00-0af7651916cd43dd8448eb211c80319c-0000000000000000-01",
+		"ff-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		"00-0af7651916cd43dd8448eb211c80319x-b7ad6b7169203331-01",
+	} {
+		ctx := propagator.Extract(context.Background(), metadataCarrier(metadata.Pairs("traceparent", value)))
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			t.Errorf("expect no trace context from %q, got %v", value, sc)
+		}
+		// the span starts a new trace
+		_, span := Start(ctx, "new")
+		if !span.SpanContext().IsValid() || !span.SpanContext().IsSampled() {
+			t.Errorf("expect a new sampled trace after %q", value)
+		}
+		span.End(nil)
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	ctx, span := Start(context.Background(), "noop")
+	if span != nil || SpanFromContext(ctx) != nil {
+		t.Fatalf("expect no span while tracing is disabled")
+	}
+	// a nil span must be safe to use
+	span.SetAttributes(String("key", "value"))
+	span.End(errors.New("failed"))
+}
+
+func TestPropagationOverGRPC(t *testing.T) {
+	exporter := newTestExporter()
+	shutdown := SetupWithExporter("test", exporter, 1)
+
+	socket := filepath.Join(t.TempDir(), "test.socket")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("failed to listen %s: %v", socket, err)
+	}
+	s := grpc.NewServer(grpc.UnaryInterceptor(UnaryServerInterceptor()))
+	healthpb.RegisterHealthServer(s, grpchealth.NewServer())
+	go s.Serve(listener)
+	defer s.Stop()
+
+	conn, err := grpc.Dial("unix://"+socket,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor()))
+	if err != nil {
+		t.Fatalf("failed to dial %s: %v", socket, err)
+	}
+	defer conn.Close()
+
+	ctx, root := Start(context.Background(), "cni.ADD", String("container.id", "abc"))
+	if _, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("health check failed: %v", err)
+	}
+	root.End(nil)
+	shutdown()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("expect 3 spans, got %d", len(spans))
+	}
+	byKind := make(map[trace.SpanKind]tracetest.SpanStub)
+	for _, span := range spans {
+		if span.SpanContext.TraceID() != root.SpanContext().TraceID() {
+			t.Errorf("span %s is not in trace %s", span.Name, root.SpanContext().TraceID())
+		}
+		byKind[span.SpanKind] = span
+	}
+
+	client, ok := byKind[trace.SpanKindClient]
+	server, ok2 := byKind[trace.SpanKindServer]
+	if !ok || !ok2 {
+		t.Fatalf("expect a client and a server span, got %v", spans)
+	}
+	if client.Parent.SpanID() != root.SpanContext().SpanID() {
+		t.Errorf("client span parent %s, expect %s", client.Parent.SpanID(), root.SpanContext().SpanID())
+	}
+	if server.Parent.SpanID() != client.SpanContext.SpanID() || !server.Parent.IsRemote() {
+		t.Errorf("server span parent %v, expect remote %s", server.Parent, client.SpanContext.SpanID())
+	}
+	if server.Name != healthpb.Health_Check_FullMethodName {
+		t.Errorf("unexpected server span name %s", server.Name)
+	}
+}
+
+func TestRemoteParentNotSampled(t *testing.T) {
+	exporter := newTestExporter()
+	shutdown := SetupWithExporter("test", exporter, 1)
+
+	// not sampled by the caller
+	md := metadata.Pairs("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-00")
+	ctx := propagator.Extract(context.Background(), metadataCarrier(md))
+	ctx, parent := Start(ctx, "parent")
+	_, child := Start(ctx, "child")
+	child.End(nil)
+	parent.End(nil)
+	shutdown()
+
+	if spans := exporter.GetSpans(); len(spans) != 0 {
+		t.Errorf("expect no spans of an unsampled trace, got %d", len(spans))
+	}
+}
+
+func TestOTLPExport(t *testing.T) {
+	var received otlpTraces
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected request %s %s", r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	shutdown := Setup("hostnic-node", Config{Endpoint: srv.URL})
+	_, span := Start(context.Background(), "AllocHostNic", Int("count", 1))
+	span.End(errors.New("no free nic"))
+	shutdown()
+
+	if len(received.ResourceSpans) != 1 || len(received.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("unexpected request %+v", received)
+	}
+	resource := received.ResourceSpans[0].Resource
+	if len(resource.Attributes) != 1 || resource.Attributes[0].Key != "service.name" || resource.Attributes[0].Value["stringValue"] != "hostnic-node" {
+		t.Errorf("unexpected resource %+v", resource)
+	}
+	spans := received.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 1 {
+		t.Fatalf("expect 1 span, got %d", len(spans))
+	}
+	if spans[0].TraceID != span.SpanContext().TraceID().String() || spans[0].Name != "AllocHostNic" {
+		t.Errorf("unexpected span %+v", spans[0])
+	}
+	if spans[0].Status.Code != 2 || spans[0].Status.Message != "no free nic" {
+		t.Errorf("unexpected status %+v", spans[0].Status)
+	}
+	if len(spans[0].Attributes) != 1 || spans[0].Attributes[0].Value["intValue"] != "1" {
+		t.Errorf("unexpected attributes %+v", spans[0].Attributes)
+	}
+}